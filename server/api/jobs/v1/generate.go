@@ -0,0 +1,4 @@
+// Package jobsv1 holds the generated code for the JobService gRPC API.
+package jobsv1
+
+//go:generate protoc -I ../../.. --go_out=../../.. --go_opt=paths=source_relative --go-grpc_out=../../.. --go-grpc_opt=paths=source_relative api/jobs/v1/jobs.proto
@@ -0,0 +1,895 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: api/jobs/v1/jobs.proto
+
+package jobsv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type StartJobRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	UserId           string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	JobApplicationId string                 `protobuf:"bytes,2,opt,name=job_application_id,json=jobApplicationId,proto3" json:"job_application_id,omitempty"`
+	JobDescription   string                 `protobuf:"bytes,3,opt,name=job_description,json=jobDescription,proto3" json:"job_description,omitempty"`
+	// Output language; the server default when empty.
+	Language string `protobuf:"bytes,4,opt,name=language,proto3" json:"language,omitempty"`
+	// "pdf" (default) or "docx".
+	Format string `protobuf:"bytes,5,opt,name=format,proto3" json:"format,omitempty"`
+	// "A4" (default), "Letter" or "Legal".
+	PaperSize string `protobuf:"bytes,6,opt,name=paper_size,json=paperSize,proto3" json:"paper_size,omitempty"`
+	// A retried request with the same key returns the original job.
+	IdempotencyKey string `protobuf:"bytes,7,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	// Optional profile overrides, same shape as the HTTP "profile" field.
+	Profile *structpb.Struct `protobuf:"bytes,8,opt,name=profile,proto3" json:"profile,omitempty"`
+	// Skip cached AI responses, e.g. after the user's data changed.
+	NoCache bool `protobuf:"varint,9,opt,name=no_cache,json=noCache,proto3" json:"no_cache,omitempty"`
+	// Stop after the HTML artifact, skipping PDF rendering.
+	DryRun bool `protobuf:"varint,10,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	// "split" or "single" AI flow; the server default when empty.
+	AiFlow string `protobuf:"bytes,11,opt,name=ai_flow,json=aiFlow,proto3" json:"ai_flow,omitempty"`
+	// Layout to render: "classic" (default), "compact" or "two-column".
+	Template string `protobuf:"bytes,12,opt,name=template,proto3" json:"template,omitempty"`
+	// Overrides the layout's accent color and font.
+	Theme *Theme `protobuf:"bytes,13,opt,name=theme,proto3" json:"theme,omitempty"`
+	// Render page 1 first and record it as preview_pdf while the full PDF is
+	// rendered.
+	Preview bool `protobuf:"varint,14,opt,name=preview,proto3" json:"preview,omitempty"`
+	// Also write a cover letter for the job application; skipped without
+	// job_application_id.
+	CoverLetter bool `protobuf:"varint,15,opt,name=cover_letter,json=coverLetter,proto3" json:"cover_letter,omitempty"`
+	// Only these project ids are rendered; ignored when none of them match.
+	IncludeProjectIds []string `protobuf:"bytes,16,rep,name=include_project_ids,json=includeProjectIds,proto3" json:"include_project_ids,omitempty"`
+	// Project ids left out of the resume.
+	ExcludeProjectIds []string `protobuf:"bytes,17,rep,name=exclude_project_ids,json=excludeProjectIds,proto3" json:"exclude_project_ids,omitempty"`
+	// "recent" or "relevance" order of experience and projects.
+	Order string `protobuf:"bytes,18,opt,name=order,proto3" json:"order,omitempty"`
+	// Extra outputs besides the PDF and HTML: "docx" and "txt".
+	Formats []string `protobuf:"bytes,19,rep,name=formats,proto3" json:"formats,omitempty"`
+	// Fold the .txt output to ASCII; accented text is kept by default.
+	AsciiText     bool `protobuf:"varint,20,opt,name=ascii_text,json=asciiText,proto3" json:"ascii_text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartJobRequest) Reset() {
+	*x = StartJobRequest{}
+	mi := &file_api_jobs_v1_jobs_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartJobRequest) ProtoMessage() {}
+
+func (x *StartJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_jobs_v1_jobs_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartJobRequest.ProtoReflect.Descriptor instead.
+func (*StartJobRequest) Descriptor() ([]byte, []int) {
+	return file_api_jobs_v1_jobs_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *StartJobRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *StartJobRequest) GetJobApplicationId() string {
+	if x != nil {
+		return x.JobApplicationId
+	}
+	return ""
+}
+
+func (x *StartJobRequest) GetJobDescription() string {
+	if x != nil {
+		return x.JobDescription
+	}
+	return ""
+}
+
+func (x *StartJobRequest) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *StartJobRequest) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *StartJobRequest) GetPaperSize() string {
+	if x != nil {
+		return x.PaperSize
+	}
+	return ""
+}
+
+func (x *StartJobRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+func (x *StartJobRequest) GetProfile() *structpb.Struct {
+	if x != nil {
+		return x.Profile
+	}
+	return nil
+}
+
+func (x *StartJobRequest) GetNoCache() bool {
+	if x != nil {
+		return x.NoCache
+	}
+	return false
+}
+
+func (x *StartJobRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+func (x *StartJobRequest) GetAiFlow() string {
+	if x != nil {
+		return x.AiFlow
+	}
+	return ""
+}
+
+func (x *StartJobRequest) GetTemplate() string {
+	if x != nil {
+		return x.Template
+	}
+	return ""
+}
+
+func (x *StartJobRequest) GetTheme() *Theme {
+	if x != nil {
+		return x.Theme
+	}
+	return nil
+}
+
+func (x *StartJobRequest) GetPreview() bool {
+	if x != nil {
+		return x.Preview
+	}
+	return false
+}
+
+func (x *StartJobRequest) GetCoverLetter() bool {
+	if x != nil {
+		return x.CoverLetter
+	}
+	return false
+}
+
+func (x *StartJobRequest) GetIncludeProjectIds() []string {
+	if x != nil {
+		return x.IncludeProjectIds
+	}
+	return nil
+}
+
+func (x *StartJobRequest) GetExcludeProjectIds() []string {
+	if x != nil {
+		return x.ExcludeProjectIds
+	}
+	return nil
+}
+
+func (x *StartJobRequest) GetOrder() string {
+	if x != nil {
+		return x.Order
+	}
+	return ""
+}
+
+func (x *StartJobRequest) GetFormats() []string {
+	if x != nil {
+		return x.Formats
+	}
+	return nil
+}
+
+func (x *StartJobRequest) GetAsciiText() bool {
+	if x != nil {
+		return x.AsciiText
+	}
+	return false
+}
+
+type Theme struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Hex color such as "#0a66c2".
+	AccentColor string `protobuf:"bytes,1,opt,name=accent_color,json=accentColor,proto3" json:"accent_color,omitempty"`
+	// One of the server's supported fonts, e.g. "Inter".
+	Font          string `protobuf:"bytes,2,opt,name=font,proto3" json:"font,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Theme) Reset() {
+	*x = Theme{}
+	mi := &file_api_jobs_v1_jobs_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Theme) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Theme) ProtoMessage() {}
+
+func (x *Theme) ProtoReflect() protoreflect.Message {
+	mi := &file_api_jobs_v1_jobs_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Theme.ProtoReflect.Descriptor instead.
+func (*Theme) Descriptor() ([]byte, []int) {
+	return file_api_jobs_v1_jobs_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Theme) GetAccentColor() string {
+	if x != nil {
+		return x.AccentColor
+	}
+	return ""
+}
+
+func (x *Theme) GetFont() string {
+	if x != nil {
+		return x.Font
+	}
+	return ""
+}
+
+type StartJobResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartJobResponse) Reset() {
+	*x = StartJobResponse{}
+	mi := &file_api_jobs_v1_jobs_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartJobResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartJobResponse) ProtoMessage() {}
+
+func (x *StartJobResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_jobs_v1_jobs_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartJobResponse.ProtoReflect.Descriptor instead.
+func (*StartJobResponse) Descriptor() ([]byte, []int) {
+	return file_api_jobs_v1_jobs_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *StartJobResponse) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *StartJobResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+type GetJobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetJobRequest) Reset() {
+	*x = GetJobRequest{}
+	mi := &file_api_jobs_v1_jobs_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetJobRequest) ProtoMessage() {}
+
+func (x *GetJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_jobs_v1_jobs_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetJobRequest.ProtoReflect.Descriptor instead.
+func (*GetJobRequest) Descriptor() ([]byte, []int) {
+	return file_api_jobs_v1_jobs_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetJobRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+type Job struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	JobId    string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	UserId   string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Status   string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	Language string                 `protobuf:"bytes,4,opt,name=language,proto3" json:"language,omitempty"`
+	// Artifact paths and processing details, as in GET /jobs/:id.
+	Metadata      *structpb.Struct       `protobuf:"bytes,5,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Job) Reset() {
+	*x = Job{}
+	mi := &file_api_jobs_v1_jobs_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Job) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Job) ProtoMessage() {}
+
+func (x *Job) ProtoReflect() protoreflect.Message {
+	mi := &file_api_jobs_v1_jobs_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Job.ProtoReflect.Descriptor instead.
+func (*Job) Descriptor() ([]byte, []int) {
+	return file_api_jobs_v1_jobs_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Job) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *Job) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *Job) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Job) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *Job) GetMetadata() *structpb.Struct {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *Job) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Job) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type ListJobsRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	UserId string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// Only jobs with this status when set.
+	Status        string `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	Limit         int32  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32  `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListJobsRequest) Reset() {
+	*x = ListJobsRequest{}
+	mi := &file_api_jobs_v1_jobs_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListJobsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListJobsRequest) ProtoMessage() {}
+
+func (x *ListJobsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_jobs_v1_jobs_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListJobsRequest.ProtoReflect.Descriptor instead.
+func (*ListJobsRequest) Descriptor() ([]byte, []int) {
+	return file_api_jobs_v1_jobs_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListJobsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ListJobsRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ListJobsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListJobsRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type ListJobsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Jobs          []*Job                 `protobuf:"bytes,1,rep,name=jobs,proto3" json:"jobs,omitempty"`
+	Limit         int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32                  `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListJobsResponse) Reset() {
+	*x = ListJobsResponse{}
+	mi := &file_api_jobs_v1_jobs_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListJobsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListJobsResponse) ProtoMessage() {}
+
+func (x *ListJobsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_jobs_v1_jobs_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListJobsResponse.ProtoReflect.Descriptor instead.
+func (*ListJobsResponse) Descriptor() ([]byte, []int) {
+	return file_api_jobs_v1_jobs_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListJobsResponse) GetJobs() []*Job {
+	if x != nil {
+		return x.Jobs
+	}
+	return nil
+}
+
+func (x *ListJobsResponse) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListJobsResponse) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type WatchJobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchJobRequest) Reset() {
+	*x = WatchJobRequest{}
+	mi := &file_api_jobs_v1_jobs_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchJobRequest) ProtoMessage() {}
+
+func (x *WatchJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_jobs_v1_jobs_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchJobRequest.ProtoReflect.Descriptor instead.
+func (*WatchJobRequest) Descriptor() ([]byte, []int) {
+	return file_api_jobs_v1_jobs_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *WatchJobRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+type JobEvent struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	JobId  string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	Stage  string                 `protobuf:"bytes,2,opt,name=stage,proto3" json:"stage,omitempty"`
+	Detail string                 `protobuf:"bytes,3,opt,name=detail,proto3" json:"detail,omitempty"`
+	Error  string                 `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	// Set on the last event of the stream (done, failed or cancelled).
+	Terminal bool                   `protobuf:"varint,5,opt,name=terminal,proto3" json:"terminal,omitempty"`
+	At       *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=at,proto3" json:"at,omitempty"`
+	// For stage_progress events: the new status of the stage named in detail.
+	Status        string `protobuf:"bytes,7,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobEvent) Reset() {
+	*x = JobEvent{}
+	mi := &file_api_jobs_v1_jobs_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobEvent) ProtoMessage() {}
+
+func (x *JobEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_api_jobs_v1_jobs_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobEvent.ProtoReflect.Descriptor instead.
+func (*JobEvent) Descriptor() ([]byte, []int) {
+	return file_api_jobs_v1_jobs_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *JobEvent) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *JobEvent) GetStage() string {
+	if x != nil {
+		return x.Stage
+	}
+	return ""
+}
+
+func (x *JobEvent) GetDetail() string {
+	if x != nil {
+		return x.Detail
+	}
+	return ""
+}
+
+func (x *JobEvent) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *JobEvent) GetTerminal() bool {
+	if x != nil {
+		return x.Terminal
+	}
+	return false
+}
+
+func (x *JobEvent) GetAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.At
+	}
+	return nil
+}
+
+func (x *JobEvent) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+var File_api_jobs_v1_jobs_proto protoreflect.FileDescriptor
+
+const file_api_jobs_v1_jobs_proto_rawDesc = "" +
+	"\n" +
+	"\x16api/jobs/v1/jobs.proto\x12\x0eresume.jobs.v1\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xb2\x05\n" +
+	"\x0fStartJobRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12,\n" +
+	"\x12job_application_id\x18\x02 \x01(\tR\x10jobApplicationId\x12'\n" +
+	"\x0fjob_description\x18\x03 \x01(\tR\x0ejobDescription\x12\x1a\n" +
+	"\blanguage\x18\x04 \x01(\tR\blanguage\x12\x16\n" +
+	"\x06format\x18\x05 \x01(\tR\x06format\x12\x1d\n" +
+	"\n" +
+	"paper_size\x18\x06 \x01(\tR\tpaperSize\x12'\n" +
+	"\x0fidempotency_key\x18\a \x01(\tR\x0eidempotencyKey\x121\n" +
+	"\aprofile\x18\b \x01(\v2\x17.google.protobuf.StructR\aprofile\x12\x19\n" +
+	"\bno_cache\x18\t \x01(\bR\anoCache\x12\x17\n" +
+	"\adry_run\x18\n" +
+	" \x01(\bR\x06dryRun\x12\x17\n" +
+	"\aai_flow\x18\v \x01(\tR\x06aiFlow\x12\x1a\n" +
+	"\btemplate\x18\f \x01(\tR\btemplate\x12+\n" +
+	"\x05theme\x18\r \x01(\v2\x15.resume.jobs.v1.ThemeR\x05theme\x12\x18\n" +
+	"\apreview\x18\x0e \x01(\bR\apreview\x12!\n" +
+	"\fcover_letter\x18\x0f \x01(\bR\vcoverLetter\x12.\n" +
+	"\x13include_project_ids\x18\x10 \x03(\tR\x11includeProjectIds\x12.\n" +
+	"\x13exclude_project_ids\x18\x11 \x03(\tR\x11excludeProjectIds\x12\x14\n" +
+	"\x05order\x18\x12 \x01(\tR\x05order\x12\x18\n" +
+	"\aformats\x18\x13 \x03(\tR\aformats\x12\x1d\n" +
+	"\n" +
+	"ascii_text\x18\x14 \x01(\bR\tasciiText\">\n" +
+	"\x05Theme\x12!\n" +
+	"\faccent_color\x18\x01 \x01(\tR\vaccentColor\x12\x12\n" +
+	"\x04font\x18\x02 \x01(\tR\x04font\"A\n" +
+	"\x10StartJobResponse\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\"&\n" +
+	"\rGetJobRequest\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\"\x94\x02\n" +
+	"\x03Job\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x1a\n" +
+	"\blanguage\x18\x04 \x01(\tR\blanguage\x123\n" +
+	"\bmetadata\x18\x05 \x01(\v2\x17.google.protobuf.StructR\bmetadata\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"p\n" +
+	"\x0fListJobsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x04 \x01(\x05R\x06offset\"i\n" +
+	"\x10ListJobsResponse\x12'\n" +
+	"\x04jobs\x18\x01 \x03(\v2\x13.resume.jobs.v1.JobR\x04jobs\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x05R\x06offset\"(\n" +
+	"\x0fWatchJobRequest\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\"\xc5\x01\n" +
+	"\bJobEvent\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\x12\x14\n" +
+	"\x05stage\x18\x02 \x01(\tR\x05stage\x12\x16\n" +
+	"\x06detail\x18\x03 \x01(\tR\x06detail\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\x12\x1a\n" +
+	"\bterminal\x18\x05 \x01(\bR\bterminal\x12*\n" +
+	"\x02at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\x02at\x12\x16\n" +
+	"\x06status\x18\a \x01(\tR\x06status2\xb1\x02\n" +
+	"\n" +
+	"JobService\x12M\n" +
+	"\bStartJob\x12\x1f.resume.jobs.v1.StartJobRequest\x1a .resume.jobs.v1.StartJobResponse\x12<\n" +
+	"\x06GetJob\x12\x1d.resume.jobs.v1.GetJobRequest\x1a\x13.resume.jobs.v1.Job\x12M\n" +
+	"\bListJobs\x12\x1f.resume.jobs.v1.ListJobsRequest\x1a .resume.jobs.v1.ListJobsResponse\x12G\n" +
+	"\bWatchJob\x12\x1f.resume.jobs.v1.WatchJobRequest\x1a\x18.resume.jobs.v1.JobEvent0\x01B%Z#resume-generator/api/jobs/v1;jobsv1b\x06proto3"
+
+var (
+	file_api_jobs_v1_jobs_proto_rawDescOnce sync.Once
+	file_api_jobs_v1_jobs_proto_rawDescData []byte
+)
+
+func file_api_jobs_v1_jobs_proto_rawDescGZIP() []byte {
+	file_api_jobs_v1_jobs_proto_rawDescOnce.Do(func() {
+		file_api_jobs_v1_jobs_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_api_jobs_v1_jobs_proto_rawDesc), len(file_api_jobs_v1_jobs_proto_rawDesc)))
+	})
+	return file_api_jobs_v1_jobs_proto_rawDescData
+}
+
+var file_api_jobs_v1_jobs_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_api_jobs_v1_jobs_proto_goTypes = []any{
+	(*StartJobRequest)(nil),       // 0: resume.jobs.v1.StartJobRequest
+	(*Theme)(nil),                 // 1: resume.jobs.v1.Theme
+	(*StartJobResponse)(nil),      // 2: resume.jobs.v1.StartJobResponse
+	(*GetJobRequest)(nil),         // 3: resume.jobs.v1.GetJobRequest
+	(*Job)(nil),                   // 4: resume.jobs.v1.Job
+	(*ListJobsRequest)(nil),       // 5: resume.jobs.v1.ListJobsRequest
+	(*ListJobsResponse)(nil),      // 6: resume.jobs.v1.ListJobsResponse
+	(*WatchJobRequest)(nil),       // 7: resume.jobs.v1.WatchJobRequest
+	(*JobEvent)(nil),              // 8: resume.jobs.v1.JobEvent
+	(*structpb.Struct)(nil),       // 9: google.protobuf.Struct
+	(*timestamppb.Timestamp)(nil), // 10: google.protobuf.Timestamp
+}
+var file_api_jobs_v1_jobs_proto_depIdxs = []int32{
+	9,  // 0: resume.jobs.v1.StartJobRequest.profile:type_name -> google.protobuf.Struct
+	1,  // 1: resume.jobs.v1.StartJobRequest.theme:type_name -> resume.jobs.v1.Theme
+	9,  // 2: resume.jobs.v1.Job.metadata:type_name -> google.protobuf.Struct
+	10, // 3: resume.jobs.v1.Job.created_at:type_name -> google.protobuf.Timestamp
+	10, // 4: resume.jobs.v1.Job.updated_at:type_name -> google.protobuf.Timestamp
+	4,  // 5: resume.jobs.v1.ListJobsResponse.jobs:type_name -> resume.jobs.v1.Job
+	10, // 6: resume.jobs.v1.JobEvent.at:type_name -> google.protobuf.Timestamp
+	0,  // 7: resume.jobs.v1.JobService.StartJob:input_type -> resume.jobs.v1.StartJobRequest
+	3,  // 8: resume.jobs.v1.JobService.GetJob:input_type -> resume.jobs.v1.GetJobRequest
+	5,  // 9: resume.jobs.v1.JobService.ListJobs:input_type -> resume.jobs.v1.ListJobsRequest
+	7,  // 10: resume.jobs.v1.JobService.WatchJob:input_type -> resume.jobs.v1.WatchJobRequest
+	2,  // 11: resume.jobs.v1.JobService.StartJob:output_type -> resume.jobs.v1.StartJobResponse
+	4,  // 12: resume.jobs.v1.JobService.GetJob:output_type -> resume.jobs.v1.Job
+	6,  // 13: resume.jobs.v1.JobService.ListJobs:output_type -> resume.jobs.v1.ListJobsResponse
+	8,  // 14: resume.jobs.v1.JobService.WatchJob:output_type -> resume.jobs.v1.JobEvent
+	11, // [11:15] is the sub-list for method output_type
+	7,  // [7:11] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_api_jobs_v1_jobs_proto_init() }
+func file_api_jobs_v1_jobs_proto_init() {
+	if File_api_jobs_v1_jobs_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_jobs_v1_jobs_proto_rawDesc), len(file_api_jobs_v1_jobs_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_api_jobs_v1_jobs_proto_goTypes,
+		DependencyIndexes: file_api_jobs_v1_jobs_proto_depIdxs,
+		MessageInfos:      file_api_jobs_v1_jobs_proto_msgTypes,
+	}.Build()
+	File_api_jobs_v1_jobs_proto = out.File
+	file_api_jobs_v1_jobs_proto_goTypes = nil
+	file_api_jobs_v1_jobs_proto_depIdxs = nil
+}
@@ -3,20 +3,44 @@ package main
 import (
 	"context"
 	"log"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
 	"time"
 
+	grpcadapter "resume-generator/internal/adapter/grpc"
 	httpadapter "resume-generator/internal/adapter/http"
 	repo "resume-generator/internal/adapter/repository"
 	"resume-generator/internal/infrastructure/migration"
 	"resume-generator/internal/usecase"
+	ai "resume-generator/pkg/ai"
+	"resume-generator/pkg/auth"
 	infra "resume-generator/pkg/infrastructure"
+	"resume-generator/pkg/logctx"
+	"resume-generator/pkg/ratelimit"
+	"resume-generator/pkg/retry"
+	"resume-generator/pkg/storage"
+	"resume-generator/templates"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// defaultShutdownTimeout bounds how long shutdown waits for in-flight jobs
+// when SHUTDOWN_TIMEOUT is not set.
+const defaultShutdownTimeout = 60 * time.Second
+
 func main() {
-	ctx := context.Background()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// LOG_LEVEL (debug, info, warn, error) and LOG_FORMAT=json configure
+	// the slog default; log.Printf output goes through it as well.
+	slog.SetDefault(logctx.NewLogger(os.Stderr, os.Getenv("LOG_LEVEL"), os.Getenv("LOG_FORMAT")))
 
 	// Load and validate required env vars
 	defaultLanguage := os.Getenv("DEFAULT_LANGUAGE")
@@ -24,6 +48,13 @@ func main() {
 		log.Fatalf("ERROR: DEFAULT_LANGUAGE env var is required")
 	}
 
+	// TEMPLATES_DIR serves template.html, style.css and the schemas from
+	// disk for customization; files it lacks fall back to the copies built
+	// into the binary
+	if dir := os.Getenv("TEMPLATES_DIR"); dir != "" {
+		templates.SetDir(dir)
+	}
+
 	// infra setup
 	jobsPool, err := infra.NewJobsPool(ctx)
 	if err != nil {
@@ -37,15 +68,222 @@ func main() {
 		}
 	}
 
-	renderer := infra.NewChromedpRenderer()
+	// DRY_RUN=true stops every job after the HTML artifact, for template
+	// and prompt work without Chrome
+	dryRun, _ := strconv.ParseBool(os.Getenv("DRY_RUN"))
 
+	// CHROME_POOL_SIZE > 0 keeps that many warm Chrome instances instead of
+	// launching a fresh browser per render; dry runs never render, so no
+	// pool is started.
+	var renderer usecase.Renderer = infra.NewChromedpRenderer()
+	var pooled *infra.PooledChromedpRenderer
+	if n, err := strconv.Atoi(os.Getenv("CHROME_POOL_SIZE")); err == nil && n > 0 && !dryRun {
+		pooled = infra.NewPooledChromedpRenderer(n)
+		renderer = pooled
+	}
+
+	// aggregation reuses the jobs pool instead of opening its own
+	if dsn := os.Getenv("JOBS_DATABASE_URL"); dsn != "" && jobsPool != nil {
+		repo.DefaultPools.Set(dsn, jobsPool)
+	}
 	jobsRepo := repo.NewJobsRepo(jobsPool)
-	processor := usecase.NewProcessor(renderer, jobsRepo, "templates", defaultLanguage)
+	// Translated labels are cached per language for LABEL_CACHE_TTL and
+	// persisted in label_translations.
+	labelTTL := ai.DefaultLabelCacheTTL
+	if d, err := time.ParseDuration(os.Getenv("LABEL_CACHE_TTL")); err == nil && d > 0 {
+		labelTTL = d
+	}
+	labelCache := ai.NewLabelCache(labelTTL, repo.NewLabelsRepo(jobsPool))
+
+	// AI_CACHE=true (or a positive CACHE_TTL) enables an in-memory LRU
+	// cache of the section formatter responses, keyed by formatter,
+	// payload, language and schema. Entries live for CACHE_TTL, an hour by
+	// default, and AI_CACHE_SIZE bounds their number; jobs started with
+	// noCache bypass it
+	var responseCache *ai.ResponseCache
+	cacheTTL, _ := time.ParseDuration(os.Getenv("CACHE_TTL"))
+	if aiCache, _ := strconv.ParseBool(os.Getenv("AI_CACHE")); aiCache || cacheTTL > 0 {
+		if cacheTTL <= 0 {
+			cacheTTL = ai.DefaultResponseCacheTTL
+		}
+		cacheSize, _ := strconv.Atoi(os.Getenv("AI_CACHE_SIZE"))
+		responseCache = ai.NewResponseCache(cacheTTL, ai.NewMemoryResponseStore(cacheSize))
+	}
+
+	// PREVIEW_WIDTH sets the pixel width of preview.png
+	previewWidth, _ := strconv.Atoi(os.Getenv("PREVIEW_WIDTH"))
+
+	// TEXT_WIDTH sets the column resume.txt wraps at (80 by default); a
+	// negative value turns wrapping off
+	textWidth, _ := strconv.Atoi(os.Getenv("TEXT_WIDTH"))
+
+	// MAX_AI_PAYLOAD_BYTES caps the aggregated data sent to the AI (256 KiB
+	// by default), trimming the least important sections first; -1 sends
+	// it whole
+	maxAIPayload, _ := strconv.Atoi(os.Getenv("MAX_AI_PAYLOAD_BYTES"))
+
+	// OUTPUT_DIR is the base directory of generated files (resume-data by
+	// default), e.g. a mounted persistent volume
+	outputDir := os.Getenv("OUTPUT_DIR")
+
+	// JOB_TIMEOUT bounds each job, AI calls and rendering included
+	jobTimeout, _ := time.ParseDuration(os.Getenv("JOB_TIMEOUT"))
+
+	// RENDER_RETRY_ATTEMPTS, _INITIAL_BACKOFF, _MAX_BACKOFF and _JITTER tune
+	// the PDF render retries (3 attempts, 1s doubling, by default); the AI
+	// client reads the same settings prefixed AI_RETRY
+	renderRetry := retry.FromEnv("RENDER_RETRY", retry.DefaultPolicy())
+
+	// STAGE_MAX_ATTEMPTS bounds the AI repair calls per invalid stage
+	stageAttempts, _ := strconv.Atoi(os.Getenv("STAGE_MAX_ATTEMPTS"))
+
+	// EMBED_PHOTO=true inlines meta.photo_url into the HTML as a data: URI so
+	// the PDF does not fetch it while printing
+	var photoFetcher *infra.ImageFetcher
+	if embed, _ := strconv.ParseBool(os.Getenv("EMBED_PHOTO")); embed {
+		photoFetcher = &infra.ImageFetcher{Client: &http.Client{Timeout: 10 * time.Second}}
+	}
+
+	// FONTS_DIR holds the font families templates embed into the PDF, one
+	// directory per family (fonts under TEMPLATES_DIR, templates/fonts by
+	// default)
+	var fonts *infra.FontSet
+	if dir := os.Getenv("FONTS_DIR"); dir != "" {
+		fonts = infra.NewFontSet(dir)
+	}
+
+	// jobs asking for the "tex" format also get a PDF compiled by pdflatex
+	// when it is on PATH; without it only the .tex source is written
+	var latexCompiler usecase.LaTeXCompiler
+	if c := infra.NewLaTeXCompiler(); c != nil {
+		latexCompiler = c
+	} else {
+		log.Printf("pdflatex not found, .tex exports will not be compiled")
+	}
+
+	// AI_MODE=off builds resumes from the aggregated data alone, without the
+	// AI service; the default, auto, falls back to that when the AI fails.
+	// AI_DISABLED=true is the same as AI_MODE=off, for switching the AI off
+	// during an outage.
+	aiMode := os.Getenv("AI_MODE")
+	if disabled, _ := strconv.ParseBool(os.Getenv("AI_DISABLED")); disabled {
+		aiMode = usecase.AIModeOff
+	}
+
+	// AI_SPLIT_FLOW=false formats resumes with a single AI call instead of
+	// one per section stage; jobs can still pick either with aiFlow
+	splitFlow := os.Getenv("AI_SPLIT_FLOW") != "false"
+
+	// S3_BUCKET stores the per-user copies in S3-compatible object storage
+	// (S3_ENDPOINT may point at MinIO) instead of local disk.
+	var artifactStore storage.Storage
+	if os.Getenv("S3_BUCKET") != "" {
+		s3, err := storage.NewS3Storage(storage.S3ConfigFromEnv())
+		if err != nil {
+			log.Fatalf("object storage: %v", err)
+		}
+		artifactStore = s3
+	}
+
+	processor := usecase.NewProcessor(renderer, jobsRepo, defaultLanguage,
+		usecase.WithDocxRenderer(infra.NewDocxRenderer()),
+		usecase.WithLaTeXCompiler(latexCompiler),
+		usecase.WithLabelCache(labelCache),
+		usecase.WithResponseCache(responseCache),
+		usecase.WithPreviewWidth(previewWidth),
+		usecase.WithTextWidth(textWidth),
+		usecase.WithMaxAIPayloadBytes(maxAIPayload),
+		usecase.WithRenderRetry(renderRetry),
+		usecase.WithJobTimeout(jobTimeout),
+		usecase.WithStageAttempts(stageAttempts),
+		usecase.WithOutputDir(outputDir),
+		usecase.WithPhotoEmbedding(photoFetcher),
+		usecase.WithFonts(fonts),
+		usecase.WithDryRun(dryRun),
+		usecase.WithAIMode(aiMode),
+		usecase.WithSplitFlow(splitFlow),
+		usecase.WithStorage(artifactStore))
+
+	// AUTH_ENABLED=true requires a bearer JWT on every route but /metrics
+	// and /readyz, and on every gRPC call, and limits callers to their own
+	// jobs and resumes
+	authConfig, err := auth.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("auth: %v", err)
+	}
+
+	// JOB_START_RATE (job starts per minute) and JOB_START_BURST limit how
+	// often each user, or each IP without auth, may start jobs. Buckets are
+	// kept in memory, or in Redis when RATE_LIMIT_REDIS_URL is set so every
+	// replica shares them.
+	var startLimiter ratelimit.Limiter
+	if perMinute, _ := strconv.ParseFloat(os.Getenv("JOB_START_RATE"), 64); perMinute > 0 {
+		cfg := ratelimit.Config{Rate: perMinute / 60}
+		if cfg.Burst, _ = strconv.Atoi(os.Getenv("JOB_START_BURST")); cfg.Burst <= 0 {
+			cfg.Burst = int(math.Max(1, math.Ceil(perMinute)))
+		}
+		if redisURL := os.Getenv("RATE_LIMIT_REDIS_URL"); redisURL != "" {
+			rl, err := ratelimit.NewRedisLimiter(cfg, redisURL, "resume-generator:job-start:")
+			if err != nil {
+				log.Fatalf("rate limit: %v", err)
+			}
+			defer rl.Close()
+			startLimiter = rl
+		} else {
+			startLimiter = ratelimit.NewMemoryLimiter(cfg)
+		}
+	}
 
 	app := fiber.New()
+	app.Use(httpadapter.RequestID())
+	app.Get("/metrics", httpadapter.Metrics)
 
 	h := httpadapter.NewHandler(processor, jobsRepo, defaultLanguage)
-	app.Post("/jobs/start", h.StartJob)
+	app.Get("/readyz", h.Ready)
+	// routes registered before Auth stay public
+	app.Use(httpadapter.Auth(authConfig))
+	startLimit := httpadapter.RateLimit(startLimiter)
+	app.Post("/jobs/start", startLimit, h.StartJob)
+	app.Post("/jobs/start-batch", httpadapter.RateLimitBatch(startLimiter), h.StartBatch)
+	app.Get("/jobs/:id", h.GetJob)
+	app.Post("/jobs/:id/retry", h.RetryJob)
+	app.Delete("/jobs/:id", h.CancelJob)
+	app.Get("/jobs/:id/html", h.GetJobHTML)
+	app.Get("/jobs/:id/pdf", h.GetJobPDF)
+	app.Get("/jobs/:id/preview.png", h.GetJobPreview)
+	app.Get("/jobs/:id/preview.pdf", h.GetJobPreviewPDF)
+	app.Get("/jobs/:id/cover-letter.pdf", h.GetJobCoverLetter)
+	app.Get("/jobs/:id/docx", h.GetJobDOCX)
+	app.Get("/jobs/:id/txt", h.GetJobText)
+	app.Get("/jobs/:id/tex", h.GetJobTeX)
+	app.Get("/jobs/:id/tex.pdf", h.GetJobTeXPDF)
+	app.Get("/jobs/:id/events", h.JobEvents)
+	app.Get("/users/:userId/jobs", h.ListUserJobs)
+	app.Delete("/labels/cache", httpadapter.AdminOnly(), h.InvalidateLabels)
+	app.Post("/admin/jobs/requeue-stale", httpadapter.AdminOnly(), h.RequeueStaleJobs)
+
+	// pick up jobs left pending by a previous crash
+	if res, err := h.SweepStaleJobs(ctx); err != nil {
+		log.Printf("warning: stale job sweep failed: %v", err)
+	} else if len(res.Requeued) > 0 {
+		log.Printf("requeued %d stale jobs", len(res.Requeued))
+	}
+
+	resumesRepo := repo.NewResumesRepo(jobsPool)
+	rh := httpadapter.NewResumesHandler(processor, resumesRepo)
+	app.Get("/users/:userId/resumes", rh.ListUserResumes)
+	app.Post("/resumes/generate", startLimit, h.GenerateResume)
+	app.Get("/resumes/:id", rh.GetResume)
+	app.Get("/resumes/:id/download", rh.DownloadResume)
+	app.Get("/resumes/:id/json", rh.GetResumeJSON)
+	app.Put("/resumes/:id/json", rh.UpdateResumeJSON)
+	app.Get("/resumes/:id/export", rh.ExportResume)
+
+	bh := httpadapter.NewBundleHandler(processor, jobsRepo, resumesRepo)
+	app.Get("/jobs/:id/bundle", bh.GetJobBundle)
+
+	uh := httpadapter.NewUserDataHandler(processor, jobsRepo, resumesRepo, h.StaleAfter())
+	app.Delete("/users/:userId/data", uh.PurgeUserData)
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -58,7 +296,70 @@ func main() {
 		}
 	}()
 
-	// keep process alive
-	<-time.After(100 * time.Hour)
-	_ = ctx
+	// gRPC API on GRPC_PORT, sharing the processor, repo and worker pool
+	grpcPort := os.Getenv("GRPC_PORT")
+	if grpcPort == "" {
+		grpcPort = "9090"
+	}
+	grpcServer := grpcadapter.NewServer(processor, jobsRepo, h.Jobs(), defaultLanguage,
+		grpcadapter.WithAuth(authConfig),
+		grpcadapter.WithRateLimit(startLimiter),
+		grpcadapter.WithLimits(grpcadapter.Limits{
+			MaxJobDescriptionBytes: h.Limits().MaxJobDescriptionBytes,
+			MaxProfileBytes:        h.Limits().MaxProfileBytes,
+			MaxItems:               h.Limits().MaxItems,
+		}),
+		grpcadapter.WithLanguages(h.Languages()),
+	).Register()
+	lis, err := net.Listen("tcp", ":"+grpcPort)
+	if err != nil {
+		log.Fatalf("grpc listen failed: %v", err)
+	}
+	go func() {
+		if err := grpcServer.Serve(lis); err != nil {
+			log.Printf("grpc server stopped: %v", err)
+		}
+	}()
+
+	// block until SIGINT/SIGTERM, then stop accepting requests and drain
+	// in-flight jobs before releasing resources
+	<-ctx.Done()
+	stop()
+	log.Printf("shutting down")
+
+	shutdownTimeout := defaultShutdownTimeout
+	if v := os.Getenv("SHUTDOWN_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			shutdownTimeout = d
+		}
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := app.ShutdownWithTimeout(shutdownTimeout); err != nil {
+		log.Printf("warning: http shutdown: %v", err)
+	}
+	// GracefulStop waits for open WatchJob streams; force them closed once
+	// the shutdown budget is spent
+	grpcStopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(grpcStopped)
+	}()
+	select {
+	case <-grpcStopped:
+	case <-shutdownCtx.Done():
+		grpcServer.Stop()
+	}
+	if err := h.WaitForJobs(shutdownCtx); err != nil {
+		log.Printf("warning: gave up waiting for in-flight jobs: %v", err)
+	}
+	if pooled != nil {
+		pooled.Close()
+	}
+	repo.CloseAllPools()
+	if jobsPool != nil {
+		jobsPool.Close()
+	}
+	log.Printf("shutdown complete")
 }
@@ -0,0 +1,81 @@
+package grpcadapter
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"resume-generator/pkg/auth"
+	"resume-generator/pkg/logctx"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// authenticate verifies the "authorization: Bearer <jwt>" metadata of an
+// incoming call and returns ctx carrying the caller. With a nil cfg, auth
+// is off and ctx is returned unchanged.
+func authenticate(ctx context.Context, cfg *auth.Config) (context.Context, error) {
+	if cfg == nil {
+		return ctx, nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	var token string
+	for _, v := range md.Get("authorization") {
+		if t, ok := strings.CutPrefix(v, "Bearer "); ok && strings.TrimSpace(t) != "" {
+			token = strings.TrimSpace(t)
+			break
+		}
+	}
+	if token == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+	p, err := cfg.Verify(token, time.Now())
+	if err != nil {
+		logctx.Debugf(ctx, "auth: rejected token: %v", err)
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+	return auth.NewContext(logctx.With(ctx, "caller", p.UserID), p), nil
+}
+
+// unaryAuth is the unary counterpart of the HTTP Auth middleware.
+func unaryAuth(cfg *auth.Config) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticate(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// streamAuth is the streaming counterpart of the HTTP Auth middleware.
+func streamAuth(cfg *auth.Config) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), cfg)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authStream overrides the stream context with the authenticated one.
+type authStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authStream) Context() context.Context { return s.ctx }
+
+// allowUser reports whether the caller in ctx may act for userID; see
+// auth.Principal.Allows.
+func allowUser(ctx context.Context, userID string) bool {
+	return auth.FromContext(ctx).Allows(userID)
+}
+
+func permissionDenied() error {
+	return status.Error(codes.PermissionDenied, "forbidden")
+}
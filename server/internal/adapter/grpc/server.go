@@ -0,0 +1,485 @@
+// Package grpcadapter serves the JobService gRPC API next to the HTTP
+// handler, sharing its processor, job store and worker pool.
+package grpcadapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	jobsv1 "resume-generator/api/jobs/v1"
+	"resume-generator/internal/domain"
+	"resume-generator/internal/usecase"
+	"resume-generator/pkg/auth"
+	"resume-generator/pkg/logctx"
+	"resume-generator/pkg/ratelimit"
+	"resume-generator/templates"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// Server implements jobsv1.JobServiceServer.
+type Server struct {
+	jobsv1.UnimplementedJobServiceServer
+
+	processor       *usecase.Processor
+	repo            usecase.JobsRepo
+	jobs            *usecase.WorkerPool
+	defaultLanguage string
+	auth            *auth.Config
+	limiter         ratelimit.Limiter
+	limits          Limits
+	languages       []string
+}
+
+// Limits bound the size of StartJob requests, mirroring the HTTP API's.
+// A zero field is not enforced.
+type Limits struct {
+	MaxJobDescriptionBytes int
+	MaxProfileBytes        int
+	MaxItems               int
+}
+
+// Option configures optional Server behaviour.
+type Option func(*Server)
+
+// WithAuth requires every call to carry a bearer JWT accepted by cfg and
+// restricts callers to their own jobs, as the HTTP Auth middleware does.
+// A nil cfg leaves auth off.
+func WithAuth(cfg *auth.Config) Option {
+	return func(s *Server) { s.auth = cfg }
+}
+
+// WithRateLimit takes a token from the caller's bucket in l for every
+// StartJob, keyed like the HTTP start routes so both APIs share a budget.
+func WithRateLimit(l ratelimit.Limiter) Option {
+	return func(s *Server) { s.limiter = l }
+}
+
+// WithLimits rejects StartJob requests exceeding l.
+func WithLimits(l Limits) Option {
+	return func(s *Server) { s.limits = l }
+}
+
+// WithLanguages restricts StartJob to the given languages, compared
+// ignoring case. Without it every language is accepted.
+func WithLanguages(languages []string) Option {
+	return func(s *Server) { s.languages = languages }
+}
+
+// NewServer builds the gRPC job service. jobs should be the HTTP handler's
+// pool so both APIs share the concurrency limit.
+func NewServer(p *usecase.Processor, r usecase.JobsRepo, jobs *usecase.WorkerPool, defaultLanguage string, opts ...Option) *Server {
+	s := &Server{processor: p, repo: r, jobs: jobs, defaultLanguage: defaultLanguage}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Register creates a grpc.Server serving s.
+func (s *Server) Register() *grpc.Server {
+	gs := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unaryAuth(s.auth)),
+		grpc.ChainStreamInterceptor(streamAuth(s.auth)),
+	)
+	jobsv1.RegisterJobServiceServer(gs, s)
+	return gs
+}
+
+// StartJob queues a job. A repeated idempotency_key returns the original
+// job unless it failed.
+func (s *Server) StartJob(ctx context.Context, req *jobsv1.StartJobRequest) (*jobsv1.StartJobResponse, error) {
+	uid, err := uuid.Parse(req.GetUserId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "user_id must be a UUID")
+	}
+	if !allowUser(ctx, uid.String()) {
+		return nil, permissionDenied()
+	}
+	if id := req.GetJobApplicationId(); id != "" {
+		if _, err := uuid.Parse(id); err != nil {
+			return nil, status.Error(codes.InvalidArgument, "job_application_id must be a UUID")
+		}
+	}
+
+	switch req.GetAiFlow() {
+	case "", usecase.FlowSplit, usecase.FlowSingle:
+	default:
+		return nil, status.Error(codes.InvalidArgument, `ai_flow must be "split" or "single"`)
+	}
+	if _, ok := templates.Lookup(req.GetTemplate()); !ok {
+		return nil, status.Error(codes.InvalidArgument, "template must be one of "+strings.Join(templates.Names(), ", "))
+	}
+	for _, f := range append([]string{req.GetFormat()}, req.GetFormats()...) {
+		if f != "" && !usecase.ValidFormat(f) {
+			return nil, status.Error(codes.InvalidArgument, "formats must be among "+strings.Join(usecase.Formats, ", "))
+		}
+	}
+	if !usecase.ValidOrder(req.GetOrder()) {
+		return nil, status.Error(codes.InvalidArgument, `order must be "recent" or "relevance"`)
+	}
+	theme, err := usecase.Theme{AccentColor: req.GetTheme().GetAccentColor(), Font: req.GetTheme().GetFont()}.Normalize()
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "theme: "+err.Error())
+	}
+	if err := s.checkLimits(req); err != nil {
+		return nil, err
+	}
+	if lang := req.GetLanguage(); lang != "" && !s.languageSupported(lang) {
+		return nil, status.Errorf(codes.InvalidArgument, "language %q is not supported", lang)
+	}
+	if err := s.allowStart(ctx); err != nil {
+		return nil, err
+	}
+
+	if key := req.GetIdempotencyKey(); key != "" {
+		existing, err := s.repo.FindByIdempotencyKey(ctx, uid, key)
+		if err == nil && existing.Status != "failed" && existing.Status != domain.StatusTimeout {
+			return &jobsv1.StartJobResponse{JobId: existing.ID.String(), Status: existing.Status}, nil
+		}
+		if err == nil {
+			if err := s.repo.ReleaseIdempotencyKey(ctx, existing.ID); err != nil {
+				log.Printf("warning: failed to release idempotency key for job %s: %v", existing.ID.String(), err)
+			}
+		}
+	}
+
+	language := req.GetLanguage()
+	if language == "" {
+		language = s.defaultLanguage
+	}
+	now := time.Now()
+	job := &domain.ResumeJob{
+		ID:             uuid.New(),
+		UserID:         uid,
+		JobDescription: req.GetJobDescription(),
+		Status:         "pending",
+		Metadata:       map[string]interface{}{},
+		Language:       language,
+		Template:       req.GetTemplate(),
+		IdempotencyKey: req.GetIdempotencyKey(),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if id := req.GetJobApplicationId(); id != "" {
+		job.Metadata["job_application_id"] = id
+	}
+	if f := req.GetFormat(); f != "" {
+		job.Metadata["format"] = f
+	}
+	if ps := req.GetPaperSize(); ps != "" {
+		job.Metadata["paper_size"] = ps
+	}
+	if req.GetNoCache() {
+		job.Metadata["no_cache"] = true
+	}
+	if req.GetDryRun() {
+		job.Metadata["dry_run"] = true
+	}
+	if req.GetPreview() {
+		job.Metadata["preview"] = true
+	}
+	if req.GetCoverLetter() {
+		job.Metadata["cover_letter"] = true
+	}
+	if f := req.GetAiFlow(); f != "" {
+		job.Metadata["ai_flow"] = f
+	}
+	usecase.SetTheme(job, theme)
+	usecase.SetTailoring(job, req.GetIncludeProjectIds(), req.GetExcludeProjectIds(), req.GetOrder())
+	usecase.SetFormats(job, req.GetFormats())
+	if req.GetAsciiText() {
+		job.Metadata["ascii_text"] = true
+	}
+	if p := req.GetProfile(); p != nil && len(p.GetFields()) > 0 {
+		job.Profile = p.AsMap()
+		job.Metadata["profile_overrides"] = p.AsMap()
+	}
+
+	if err := s.repo.Save(ctx, job); err != nil {
+		log.Printf("warning: failed to save job: %v", err)
+	}
+	if err := s.jobs.Submit(job); err != nil {
+		log.Printf("job %s rejected: %v", job.ID.String(), err)
+		job.Status = "failed"
+		job.Metadata["error"] = err.Error()
+		job.Metadata["failed_stage"] = usecase.StageSubmit
+		job.Metadata["error_code"] = usecase.ErrorCode(err)
+		job.Metadata["retriable"] = true
+		job.UpdatedAt = time.Now()
+		if err := s.repo.Save(context.Background(), job); err != nil {
+			log.Printf("warning: failed to save rejected job: %v", err)
+		}
+		if job.IdempotencyKey != "" {
+			if err := s.repo.ReleaseIdempotencyKey(context.Background(), job.ID); err != nil {
+				log.Printf("warning: failed to release idempotency key for job %s: %v", job.ID.String(), err)
+			}
+		}
+		if errors.Is(err, usecase.ErrPoolClosed) {
+			return nil, status.Error(codes.Unavailable, "server is shutting down")
+		}
+		return nil, status.Error(codes.ResourceExhausted, "too many jobs in progress, retry later")
+	}
+	return &jobsv1.StartJobResponse{JobId: job.ID.String(), Status: "started"}, nil
+}
+
+// checkLimits rejects a StartJob request exceeding s.limits with
+// InvalidArgument.
+func (s *Server) checkLimits(req *jobsv1.StartJobRequest) error {
+	l := s.limits
+	if l.MaxJobDescriptionBytes > 0 && len(req.GetJobDescription()) > l.MaxJobDescriptionBytes {
+		return status.Errorf(codes.InvalidArgument, "job_description exceeds %d bytes", l.MaxJobDescriptionBytes)
+	}
+	if l.MaxProfileBytes > 0 && proto.Size(req.GetProfile()) > l.MaxProfileBytes {
+		return status.Errorf(codes.InvalidArgument, "profile exceeds %d bytes", l.MaxProfileBytes)
+	}
+	if l.MaxItems <= 0 {
+		return nil
+	}
+	for _, f := range []struct {
+		field string
+		n     int
+	}{
+		{"include_project_ids", len(req.GetIncludeProjectIds())},
+		{"exclude_project_ids", len(req.GetExcludeProjectIds())},
+		{"formats", len(req.GetFormats())},
+	} {
+		if f.n > l.MaxItems {
+			return status.Errorf(codes.InvalidArgument, "%s has more than %d items", f.field, l.MaxItems)
+		}
+	}
+	for k, v := range req.GetProfile().GetFields() {
+		if items := v.GetListValue().GetValues(); len(items) > l.MaxItems {
+			return status.Errorf(codes.InvalidArgument, "profile.%s has more than %d items", k, l.MaxItems)
+		}
+	}
+	return nil
+}
+
+func (s *Server) languageSupported(lang string) bool {
+	if len(s.languages) == 0 {
+		return true
+	}
+	for _, l := range s.languages {
+		if strings.EqualFold(l, lang) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowStart takes a token from the caller's bucket: the authenticated
+// user's, or the peer IP's when auth is off. An empty bucket gets
+// ResourceExhausted with a retry-after header in seconds; a limiter error
+// lets the call through, as on the HTTP API.
+func (s *Server) allowStart(ctx context.Context) error {
+	if s.limiter == nil {
+		return nil
+	}
+	key := "ip:" + peerIP(ctx)
+	if p := auth.FromContext(ctx); p != nil {
+		key = "user:" + p.UserID
+	}
+	ok, wait, err := s.limiter.Allow(ctx, key)
+	if err != nil {
+		logctx.Warnf(ctx, "rate limit check for %s failed, allowing: %v", key, err)
+		return nil
+	}
+	if ok {
+		return nil
+	}
+	secs := int(math.Ceil(wait.Seconds()))
+	if secs < 1 {
+		secs = 1
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(secs)))
+	return status.Error(codes.ResourceExhausted, "too many job starts, retry later")
+}
+
+// peerIP returns the host of the caller's address, or "" when unknown.
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// GetJob returns a single job.
+func (s *Server) GetJob(ctx context.Context, req *jobsv1.GetJobRequest) (*jobsv1.Job, error) {
+	job, err := s.loadJob(ctx, req.GetJobId())
+	if err != nil {
+		return nil, err
+	}
+	if !allowUser(ctx, job.UserID.String()) {
+		return nil, permissionDenied()
+	}
+	return toProtoJob(job), nil
+}
+
+// ListJobs pages through a user's jobs, newest first.
+func (s *Server) ListJobs(ctx context.Context, req *jobsv1.ListJobsRequest) (*jobsv1.ListJobsResponse, error) {
+	uid, err := uuid.Parse(req.GetUserId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "user_id must be a UUID")
+	}
+	if !allowUser(ctx, uid.String()) {
+		return nil, permissionDenied()
+	}
+	if req.GetLimit() < 0 || req.GetOffset() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "limit and offset must not be negative")
+	}
+	page := domain.Page{Limit: int(req.GetLimit()), Offset: int(req.GetOffset())}
+	if page.Limit == 0 {
+		page.Limit = defaultPageSize
+	}
+	if page.Limit > maxPageSize {
+		page.Limit = maxPageSize
+	}
+
+	jobs, err := s.repo.ListByUser(ctx, uid, domain.JobFilter{Status: req.GetStatus()}, page)
+	if err != nil {
+		log.Printf("list jobs for user %s failed: %v", uid.String(), err)
+		return nil, status.Error(codes.Internal, "failed to list jobs")
+	}
+	resp := &jobsv1.ListJobsResponse{Limit: int32(page.Limit), Offset: int32(page.Offset)}
+	for _, j := range jobs {
+		resp.Jobs = append(resp.Jobs, toProtoJob(j))
+	}
+	return resp, nil
+}
+
+// WatchJob streams the job's progress events and returns after the
+// terminal one. Jobs this instance has no events for get a single event
+// derived from their stored status once they are finished.
+func (s *Server) WatchJob(req *jobsv1.WatchJobRequest, stream grpc.ServerStreamingServer[jobsv1.JobEvent]) error {
+	ctx := stream.Context()
+	broker := s.processor.Events()
+
+	id, err := uuid.Parse(req.GetJobId())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, "job_id must be a UUID")
+	}
+	job, err := s.loadJob(ctx, req.GetJobId())
+	if err != nil {
+		return err
+	}
+	if !allowUser(ctx, job.UserID.String()) {
+		return permissionDenied()
+	}
+	if _, ok := broker.Last(id); !ok {
+		if stage := terminalStage(job.Status); stage != "" {
+			return stream.Send(&jobsv1.JobEvent{JobId: job.ID.String(), Stage: stage, Terminal: true, At: timestamppb.New(job.UpdatedAt)})
+		}
+	}
+
+	events, unsubscribe := broker.Subscribe(id)
+	defer unsubscribe()
+	for {
+		select {
+		case ev := <-events:
+			if err := stream.Send(toProtoEvent(ev)); err != nil {
+				return err
+			}
+			if ev.Terminal {
+				return nil
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// terminalStage maps a finished job status to its terminal event stage.
+func terminalStage(jobStatus string) string {
+	switch jobStatus {
+	case "completed":
+		return usecase.EventDone
+	case "failed":
+		return usecase.EventFailed
+	case domain.StatusTimeout:
+		return usecase.EventTimeout
+	case "cancelled":
+		return usecase.EventCancelled
+	}
+	return ""
+}
+
+func (s *Server) loadJob(ctx context.Context, rawID string) (*domain.ResumeJob, error) {
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "job_id must be a UUID")
+	}
+	job, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrJobNotFound) {
+			return nil, status.Error(codes.NotFound, "job not found")
+		}
+		log.Printf("get job %s failed: %v", id.String(), err)
+		return nil, status.Error(codes.Internal, "failed to load job")
+	}
+	return job, nil
+}
+
+func toProtoJob(j *domain.ResumeJob) *jobsv1.Job {
+	return &jobsv1.Job{
+		JobId:     j.ID.String(),
+		UserId:    j.UserID.String(),
+		Status:    j.Status,
+		Language:  j.Language,
+		Metadata:  toStruct(j.Metadata),
+		CreatedAt: timestamppb.New(j.CreatedAt),
+		UpdatedAt: timestamppb.New(j.UpdatedAt),
+	}
+}
+
+func toProtoEvent(ev usecase.JobEvent) *jobsv1.JobEvent {
+	return &jobsv1.JobEvent{
+		JobId:    ev.JobID.String(),
+		Stage:    ev.Stage,
+		Detail:   ev.Detail,
+		Status:   ev.Status,
+		Error:    ev.Error,
+		Terminal: ev.Terminal,
+		At:       timestamppb.New(ev.At),
+	}
+}
+
+// toStruct converts job metadata through JSON, which accepts any value the
+// jobs table can store.
+func toStruct(m map[string]interface{}) *structpb.Struct {
+	out := &structpb.Struct{}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return out
+	}
+	if err := protojson.Unmarshal(b, out); err != nil {
+		log.Printf("warning: job metadata not representable as protobuf Struct: %v", err)
+	}
+	return out
+}
@@ -0,0 +1,50 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"resume-generator/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// instanceID names this process in resume_jobs.claimed_by. INSTANCE_ID
+// overrides the default of hostname and pid.
+func instanceID() string {
+	if v := os.Getenv("INSTANCE_ID"); v != "" {
+		return v
+	}
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// SweepStaleJobs requeues pending jobs that have not been updated for
+// STALE_JOB_AFTER, by default the pool's StaleAfter, e.g. because the
+// instance running them crashed. main calls it once on startup.
+func (h *Handler) SweepStaleJobs(ctx context.Context) (*usecase.RequeueResult, error) {
+	return h.jobs.RequeueStale(ctx, h.instanceID, h.staleAfter)
+}
+
+// RequeueStaleJobs runs a stale job sweep on demand. The optional olderThan
+// query param (a Go duration) overrides STALE_JOB_AFTER.
+func (h *Handler) RequeueStaleJobs(c *fiber.Ctx) error {
+	olderThan := h.staleAfter
+	if v := c.Query("olderThan"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid olderThan"})
+		}
+		olderThan = d
+	}
+
+	res, err := h.jobs.RequeueStale(c.Context(), h.instanceID, olderThan)
+	if err != nil {
+		log.Printf("requeue stale jobs failed: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to requeue stale jobs"})
+	}
+	return c.JSON(res)
+}
@@ -0,0 +1,139 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"resume-generator/internal/domain"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// GetJobHTML serves the rendered HTML artifact of a job. The HTML is kept
+// even when PDF rendering fails, which makes it the first thing to inspect
+// when a PDF looks wrong.
+func (h *Handler) GetJobHTML(c *fiber.Ctx) error {
+	return h.serveArtifact(c, "generated_html", "text/html; charset=utf-8", "")
+}
+
+// GetJobPDF downloads the PDF of a completed job as
+// resume-<job id>.pdf.
+func (h *Handler) GetJobPDF(c *fiber.Ctx) error {
+	return h.serveArtifact(c, "generated_pdf", "application/pdf", ".pdf")
+}
+
+// GetJobPreview serves the PNG thumbnail of a job's first page.
+func (h *Handler) GetJobPreview(c *fiber.Ctx) error {
+	return h.serveArtifact(c, "generated_preview", "image/png", "")
+}
+
+// GetJobPreviewPDF serves the first page of a job started with preview, which
+// is available before the full PDF.
+func (h *Handler) GetJobPreviewPDF(c *fiber.Ctx) error {
+	return h.serveArtifact(c, "preview_pdf", "application/pdf", "")
+}
+
+// GetJobCoverLetter serves the cover letter PDF of a job started with
+// coverLetter.
+func (h *Handler) GetJobCoverLetter(c *fiber.Ctx) error {
+	return h.serveArtifact(c, "generated_cover_letter", "application/pdf", "")
+}
+
+// GetJobText serves the plain-text resume of a job started with the "txt"
+// format.
+func (h *Handler) GetJobText(c *fiber.Ctx) error {
+	return h.serveArtifact(c, "generated_txt", "text/plain; charset=utf-8", "")
+}
+
+// GetJobTeX downloads the moderncv LaTeX source of a job started with the
+// "tex" format as resume-<job id>.tex.
+func (h *Handler) GetJobTeX(c *fiber.Ctx) error {
+	return h.serveArtifact(c, "generated_tex", "application/x-tex; charset=utf-8", ".tex")
+}
+
+// GetJobTeXPDF serves the PDF pdflatex compiled from the .tex export. It is
+// only produced when pdflatex is installed on the server.
+func (h *Handler) GetJobTeXPDF(c *fiber.Ctx) error {
+	return h.serveArtifact(c, "generated_tex_pdf", "application/pdf", "")
+}
+
+// GetJobDOCX serves the Word document of a job started with format "docx".
+// It is built from the rendered HTML, so it has the template's sections and
+// labels in the same order.
+func (h *Handler) GetJobDOCX(c *fiber.Ctx) error {
+	return h.serveArtifact(c, "generated_docx", "application/vnd.openxmlformats-officedocument.wordprocessingml.document", "")
+}
+
+// serveArtifact looks up the job in the :id route param and streams the
+// file stored under metadataKey. With a downloadExt the job must have
+// completed and the file is sent as an attachment named
+// resume-<job id><downloadExt>. It returns 404 when the job is unknown or
+// the artifact has not been produced yet, 410 when the recorded file was
+// cleaned up and 403 when the recorded path escapes the processor's
+// generated directory.
+func (h *Handler) serveArtifact(c *fiber.Ctx, metadataKey, contentType, downloadExt string) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid job id"})
+	}
+
+	job, err := h.repo.GetByID(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrJobNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "job not found"})
+		}
+		log.Printf("get job %s failed: %v", id.String(), err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to load job"})
+	}
+	if !allowUser(c, job.UserID.String()) {
+		return forbidden(c)
+	}
+
+	path, _ := job.Metadata[metadataKey].(string)
+	if path == "" || downloadExt != "" && job.Status != "completed" {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "artifact not ready", "status": job.Status})
+	}
+
+	if !withinDir(h.processor.GeneratedDir(), path) {
+		log.Printf("job %s: refusing to serve %s outside generated dir", id.String(), path)
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "artifact not available"})
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c.Status(fiber.StatusGone).JSON(fiber.Map{"error": "artifact was cleaned up"})
+	}
+	if err != nil {
+		log.Printf("job %s: read %s failed: %v", id.String(), path, err)
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "artifact not found"})
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	if downloadExt != "" {
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", "resume-"+job.ID.String()+downloadExt))
+	}
+	return c.Send(b)
+}
+
+// withinDir reports whether path resolves to a location inside dir.
+func withinDir(dir, path string) bool {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && rel != "."
+}
@@ -0,0 +1,65 @@
+package http
+
+import (
+	"strings"
+	"time"
+
+	"resume-generator/pkg/auth"
+	"resume-generator/pkg/logctx"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const principalLocal = "principal"
+
+// Auth requires a valid "Authorization: Bearer <jwt>" header and records
+// the caller for the handlers' ownership checks. Missing or invalid tokens
+// get 401. With a nil cfg, auth is off and every request passes.
+func Auth(cfg *auth.Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if cfg == nil {
+			return c.Next()
+		}
+		token, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+		if !ok || strings.TrimSpace(token) == "" {
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer`)
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "missing bearer token"})
+		}
+		p, err := cfg.Verify(strings.TrimSpace(token), time.Now())
+		if err != nil {
+			logctx.Debugf(c.UserContext(), "auth: rejected token: %v", err)
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid token"})
+		}
+		c.Locals(principalLocal, p)
+		c.SetUserContext(logctx.With(c.UserContext(), "caller", p.UserID))
+		return c.Next()
+	}
+}
+
+// AdminOnly rejects callers without the admin role with 403. It lets every
+// request through when auth is off.
+func AdminOnly() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if p := principal(c); p != nil && !p.Admin {
+			return forbidden(c)
+		}
+		return c.Next()
+	}
+}
+
+// principal returns the caller recorded by Auth, or nil when auth is off.
+func principal(c *fiber.Ctx) *auth.Principal {
+	p, _ := c.Locals(principalLocal).(*auth.Principal)
+	return p
+}
+
+// allowUser reports whether the caller may act for userID: the token's
+// subject and admins may, and everyone may when auth is off.
+func allowUser(c *fiber.Ctx, userID string) bool {
+	return principal(c).Allows(userID)
+}
+
+func forbidden(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "forbidden"})
+}
@@ -0,0 +1,162 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"resume-generator/internal/domain"
+	"resume-generator/internal/usecase"
+	"resume-generator/pkg/auth"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+var testSecret = []byte("s3cret")
+
+// hsToken returns an HS256 token for sub, valid for an hour.
+func hsToken(t *testing.T, sub string, admin bool) string {
+	t.Helper()
+	seg := func(v interface{}) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	claims := map[string]interface{}{"sub": sub, "exp": time.Now().Add(time.Hour).Unix()}
+	if admin {
+		claims["role"] = "admin"
+	}
+	signed := seg(map[string]string{"alg": "HS256", "typ": "JWT"}) + "." + seg(claims)
+	mac := hmac.New(sha256.New, testSecret)
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// memResumes serves fixed resume rows. Methods the tests do not reach
+// panic through the nil embedded interface.
+type memResumes struct {
+	usecase.ResumesRepo
+	rows map[uuid.UUID]*domain.Resume
+}
+
+func (r *memResumes) GetByID(ctx context.Context, id uuid.UUID) (*domain.Resume, error) {
+	if res, ok := r.rows[id]; ok {
+		return res, nil
+	}
+	return nil, domain.ErrResumeNotFound
+}
+
+func (r *memResumes) ListByUser(ctx context.Context, userID uuid.UUID, filter domain.ResumeFilter, page domain.Page) ([]*domain.Resume, error) {
+	var out []*domain.Resume
+	for _, res := range r.rows {
+		if res.UserID == userID {
+			out = append(out, res)
+		}
+	}
+	return out, nil
+}
+
+// newAuthApp serves the job start, resume list and download routes and an
+// admin-only route behind Auth, with one stored resume per user.
+func newAuthApp(t *testing.T, users ...uuid.UUID) (*fiber.App, map[uuid.UUID]uuid.UUID) {
+	t.Helper()
+	repo := &memRepo{}
+	p := usecase.NewProcessor(nil, repo, "English",
+		usecase.WithAIMode(usecase.AIModeOff),
+		usecase.WithDryRun(true),
+		usecase.WithOutputDir(t.TempDir()),
+	)
+	h := NewHandler(p, repo, "English")
+	t.Cleanup(func() { h.WaitForJobs(context.Background()) })
+
+	resumes := &memResumes{rows: map[uuid.UUID]*domain.Resume{}}
+	resumeOf := map[uuid.UUID]uuid.UUID{}
+	if err := os.MkdirAll(p.GeneratedDir(), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range users {
+		path := filepath.Join(p.GeneratedDir(), u.String()+".html")
+		if err := os.WriteFile(path, []byte("<html></html>"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		id := uuid.New()
+		resumes.rows[id] = &domain.Resume{ID: id, UserID: u, FileName: "resume.html", FilePath: path}
+		resumeOf[u] = id
+	}
+	rh := NewResumesHandler(p, resumes)
+
+	app := fiber.New()
+	app.Use(Auth(&auth.Config{Algorithm: "HS256", Secret: testSecret, AdminRole: "admin"}))
+	app.Post("/jobs/start", h.StartJob)
+	app.Get("/users/:userId/resumes", rh.ListUserResumes)
+	app.Get("/resumes/:id/download", rh.DownloadResume)
+	app.Post("/admin/ping", AdminOnly(), func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) })
+	return app, resumeOf
+}
+
+func TestAuth(t *testing.T) {
+	ana, bob := uuid.New(), uuid.New()
+	app, resumeOf := newAuthApp(t, ana, bob)
+	start := func(user uuid.UUID) string {
+		b, _ := json.Marshal(map[string]interface{}{"userId": user.String(), "dryRun": true, "profile": map[string]interface{}{"meta": map[string]interface{}{"name": "Ada Lovelace", "headline": "Backend Engineer"}}})
+		return string(b)
+	}
+	anaToken := hsToken(t, ana.String(), false)
+	adminToken := hsToken(t, uuid.NewString(), true)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		auth   string
+		want   int
+	}{
+		{name: "no token", method: "GET", path: "/users/" + ana.String() + "/resumes", want: fiber.StatusUnauthorized},
+		{name: "not bearer", method: "GET", path: "/users/" + ana.String() + "/resumes", auth: "Basic YW5hOnB3", want: fiber.StatusUnauthorized},
+		{name: "invalid token", method: "GET", path: "/users/" + ana.String() + "/resumes", auth: "Bearer " + anaToken + "x", want: fiber.StatusUnauthorized},
+
+		{name: "start own job", method: "POST", path: "/jobs/start", body: start(ana), auth: "Bearer " + anaToken, want: fiber.StatusAccepted},
+		{name: "start job for another user", method: "POST", path: "/jobs/start", body: start(bob), auth: "Bearer " + anaToken, want: fiber.StatusForbidden},
+		{name: "list own resumes", method: "GET", path: "/users/" + ana.String() + "/resumes", auth: "Bearer " + anaToken, want: fiber.StatusOK},
+		{name: "list another user's resumes", method: "GET", path: "/users/" + bob.String() + "/resumes", auth: "Bearer " + anaToken, want: fiber.StatusForbidden},
+		{name: "download own resume", method: "GET", path: "/resumes/" + resumeOf[ana].String() + "/download", auth: "Bearer " + anaToken, want: fiber.StatusOK},
+		{name: "download another user's resume", method: "GET", path: "/resumes/" + resumeOf[bob].String() + "/download", auth: "Bearer " + anaToken, want: fiber.StatusForbidden},
+		{name: "admin route", method: "POST", path: "/admin/ping", auth: "Bearer " + anaToken, want: fiber.StatusForbidden},
+
+		{name: "admin starts a job for a user", method: "POST", path: "/jobs/start", body: start(bob), auth: "Bearer " + adminToken, want: fiber.StatusAccepted},
+		{name: "admin lists a user's resumes", method: "GET", path: "/users/" + bob.String() + "/resumes", auth: "Bearer " + adminToken, want: fiber.StatusOK},
+		{name: "admin downloads a user's resume", method: "GET", path: "/resumes/" + resumeOf[bob].String() + "/download", auth: "Bearer " + adminToken, want: fiber.StatusOK},
+		{name: "admin uses the admin route", method: "POST", path: "/admin/ping", auth: "Bearer " + adminToken, want: fiber.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewReader([]byte(tt.body)))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.want {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+			if tt.want == fiber.StatusUnauthorized && resp.Header.Get(fiber.HeaderWWWAuthenticate) == "" {
+				t.Error("401 without a WWW-Authenticate header")
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package http
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"resume-generator/internal/domain"
+	"resume-generator/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// BundleHandler packs every artifact of a job into a single download.
+type BundleHandler struct {
+	jobs         usecase.JobsRepo
+	resumes      usecase.ResumesRepo
+	generatedDir string
+}
+
+func NewBundleHandler(p *usecase.Processor, jobs usecase.JobsRepo, resumes usecase.ResumesRepo) *BundleHandler {
+	return &BundleHandler{jobs: jobs, resumes: resumes, generatedDir: p.GeneratedDir()}
+}
+
+// bundleWarningKeys are the job metadata keys copied into the manifest's
+// warnings.
+var bundleWarningKeys = []string{"ai_warnings", "validation_errors", "pdf_render_error", "docx_render_error", "txt_render_error", "tex_render_error", "tex_compile_error", "preview_render_error", "cover_letter_error"}
+
+// bundleFile is an artifact on disk and its name inside the archive.
+type bundleFile struct {
+	name string
+	path string
+}
+
+// bundleManifest is written as manifest.json at the root of the bundle.
+type bundleManifest struct {
+	JobID     string                 `json:"jobId"`
+	UserID    string                 `json:"userId"`
+	ResumeID  string                 `json:"resumeId,omitempty"`
+	Status    string                 `json:"status"`
+	Language  string                 `json:"language"`
+	Template  string                 `json:"template"`
+	PaperSize string                 `json:"paper_size,omitempty"`
+	Files     []string               `json:"files"`
+	Warnings  map[string]interface{} `json:"warnings,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+	UpdatedAt time.Time              `json:"updated_at"`
+	BundledAt time.Time              `json:"bundled_at"`
+}
+
+// GetJobBundle streams a ZIP with the job's HTML, the PDF when rendering
+// succeeded, the final resume JSON and a manifest.json. The archive is
+// written straight to the response, so an error while streaming can only be
+// logged. It returns 404 until the job has produced its HTML.
+func (h *BundleHandler) GetJobBundle(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid job id"})
+	}
+
+	job, err := h.jobs.GetByID(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrJobNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "job not found"})
+		}
+		log.Printf("get job %s failed: %v", id.String(), err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to load job"})
+	}
+	if !allowUser(c, job.UserID.String()) {
+		return forbidden(c)
+	}
+
+	htmlPath, _ := job.Metadata["generated_html"].(string)
+	if htmlPath == "" {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "artifact not ready", "status": job.Status})
+	}
+
+	files := []bundleFile{{"resume.html", htmlPath}}
+	if pdfPath, _ := job.Metadata["generated_pdf"].(string); pdfPath != "" {
+		files = append(files, bundleFile{"resume.pdf", pdfPath})
+	}
+	if txtPath, _ := job.Metadata["generated_txt"].(string); txtPath != "" {
+		files = append(files, bundleFile{"resume.txt", txtPath})
+	}
+	if texPath, _ := job.Metadata["generated_tex"].(string); texPath != "" {
+		files = append(files, bundleFile{"resume.tex", texPath})
+	}
+	if letterPath, _ := job.Metadata["generated_cover_letter"].(string); letterPath != "" {
+		files = append(files, bundleFile{"cover_letter.pdf", letterPath})
+	}
+	for _, f := range files {
+		if !withinDir(h.generatedDir, f.path) {
+			log.Printf("job %s: refusing to bundle %s outside generated dir", id.String(), f.path)
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "artifact not available"})
+		}
+		if _, err := os.Stat(f.path); err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "artifact not found"})
+		}
+	}
+
+	var resumeJSON []byte
+	if job.ResumeID != nil {
+		resume, err := h.resumes.GetJSON(c.Context(), *job.ResumeID)
+		switch {
+		case err == nil:
+			resumeJSON, _ = json.MarshalIndent(resume, "", "  ")
+		case errors.Is(err, domain.ErrResumeNotFound), errors.Is(err, domain.ErrResumeJSONNotFound):
+		default:
+			log.Printf("job %s: load resume json failed: %v", id.String(), err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to load resume"})
+		}
+	}
+
+	manifest := bundleManifest{
+		JobID:     job.ID.String(),
+		UserID:    job.UserID.String(),
+		Status:    job.Status,
+		Language:  job.Language,
+		Template:  usecase.DefaultTemplate,
+		CreatedAt: job.CreatedAt,
+		UpdatedAt: job.UpdatedAt,
+		BundledAt: time.Now().UTC(),
+	}
+	if job.ResumeID != nil {
+		manifest.ResumeID = job.ResumeID.String()
+	}
+	if job.Template != "" {
+		manifest.Template = job.Template
+	}
+	manifest.PaperSize, _ = job.Metadata["paper_size"].(string)
+	for _, f := range files {
+		manifest.Files = append(manifest.Files, f.name)
+	}
+	if resumeJSON != nil {
+		manifest.Files = append(manifest.Files, "resume.json")
+	}
+	for _, k := range bundleWarningKeys {
+		if v, ok := job.Metadata[k]; ok && v != nil && v != "" {
+			if manifest.Warnings == nil {
+				manifest.Warnings = map[string]interface{}{}
+			}
+			manifest.Warnings[k] = v
+		}
+	}
+	manifestJSON, _ := json.MarshalIndent(manifest, "", "  ")
+
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", "resume-"+job.ID.String()+".zip"))
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		zw := zip.NewWriter(w)
+		if err := writeBundle(zw, files, resumeJSON, manifestJSON, manifest.UpdatedAt); err != nil {
+			log.Printf("job %s: write bundle failed: %v", id.String(), err)
+		}
+		if err := zw.Close(); err != nil {
+			log.Printf("job %s: close bundle failed: %v", id.String(), err)
+		}
+		w.Flush()
+	})
+	return nil
+}
+
+// writeBundle adds the artifact files, the resume JSON (when present) and
+// the manifest to zw.
+func writeBundle(zw *zip.Writer, files []bundleFile, resumeJSON, manifestJSON []byte, modified time.Time) error {
+	for _, f := range files {
+		if err := addZipFile(zw, f.name, f.path); err != nil {
+			return err
+		}
+	}
+	if resumeJSON != nil {
+		if err := addZipBytes(zw, "resume.json", resumeJSON, modified); err != nil {
+			return err
+		}
+	}
+	return addZipBytes(zw, "manifest.json", manifestJSON, time.Now())
+}
+
+// addZipFile copies the file at path into zw as name.
+func addZipFile(zw *zip.Writer, name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	hdr, err := zip.FileInfoHeader(fi)
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	hdr.Method = zip.Deflate
+	dst, err := zw.CreateHeader(hdr)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, f)
+	return err
+}
+
+// addZipBytes writes b into zw as name.
+func addZipBytes(zw *zip.Writer, name string, b []byte, modified time.Time) error {
+	dst, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return err
+	}
+	_, err = dst.Write(b)
+	return err
+}
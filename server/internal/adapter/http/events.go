@@ -0,0 +1,91 @@
+package http
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"resume-generator/internal/domain"
+	"resume-generator/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/valyala/fasthttp"
+)
+
+// sseHeartbeat keeps idle connections open through proxies.
+const sseHeartbeat = 15 * time.Second
+
+// JobEvents streams job progress as server-sent events until the job
+// reaches a terminal stage (done/failed/cancelled): one event per phase
+// change and one stage_progress event each time a split-flow stage starts,
+// completes or fails. The latest known event is sent immediately so
+// reconnecting clients catch up; the subscription ends when the client
+// disconnects.
+func (h *Handler) JobEvents(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid job id"})
+	}
+	if ok, err := h.jobAllowed(c, id); !ok {
+		return err
+	}
+
+	broker := h.processor.Events()
+	if _, ok := broker.Last(id); !ok {
+		// Not seen by this instance: fall back to the stored status so
+		// finished jobs still get a terminal event.
+		job, err := h.repo.GetByID(c.Context(), id)
+		if err != nil {
+			if errors.Is(err, domain.ErrJobNotFound) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "job not found"})
+			}
+			log.Printf("get job %s failed: %v", id.String(), err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to load job"})
+		}
+		switch job.Status {
+		case "completed":
+			broker.Publish(usecase.JobEvent{JobID: id, Stage: usecase.EventDone, Terminal: true})
+		case "failed":
+			reason, _ := job.Metadata["error"].(string)
+			broker.Publish(usecase.JobEvent{JobID: id, Stage: usecase.EventFailed, Error: reason, Terminal: true})
+		case domain.StatusTimeout:
+			reason, _ := job.Metadata["error"].(string)
+			broker.Publish(usecase.JobEvent{JobID: id, Stage: usecase.EventTimeout, Error: reason, Terminal: true})
+		case "cancelled":
+			broker.Publish(usecase.JobEvent{JobID: id, Stage: usecase.EventCancelled, Terminal: true})
+		}
+	}
+
+	events, unsubscribe := broker.Subscribe(id)
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(fasthttp.StreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+		ticker := time.NewTicker(sseHeartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case ev := <-events:
+				b, _ := json.Marshal(ev)
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Stage, b)
+				if err := w.Flush(); err != nil || ev.Terminal {
+					return
+				}
+			case <-ticker.C:
+				fmt.Fprint(w, ": ping\n\n")
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		}
+	}))
+	return nil
+}
@@ -0,0 +1,100 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+
+	"resume-generator/internal/domain"
+	"resume-generator/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// GenerateResume runs a job within the request and responds with the PDF,
+// for integrations that do not want to poll. It takes the same body as
+// StartJob; dryRun and the idempotency key are ignored. The job is still
+// persisted and bounded by SYNC_WAIT_TIMEOUT. A failed job gets 422 with the
+// stage that failed, a timed out one 504.
+func (h *Handler) GenerateResume(c *fiber.Ctx) error {
+	if ok, err := bodyWithin(c, h.limits.MaxBodyBytes); !ok {
+		return err
+	}
+	var req startReq
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid payload"})
+	}
+	req.DryRun = false
+	req.IdempotencyKey = ""
+
+	profile, fieldErrs := h.validateStartReq(req, "")
+	if len(fieldErrs) > 0 {
+		return c.Status(validationStatus(fieldErrs)).JSON(fiber.Map{"error": "validation failed", "errors": fieldErrs})
+	}
+	if !allowUser(c, req.UserID) {
+		return forbidden(c)
+	}
+	uid := uuid.MustParse(req.UserID)
+	if req.Language == "" {
+		req.Language = negotiateLanguage(c.Get(fiber.HeaderAcceptLanguage), h.languages)
+	}
+
+	job := h.newJob(uid, req)
+	if rid := requestID(c); rid != "" {
+		job.Metadata["request_id"] = rid
+	}
+	setProfile(job, profile)
+	if h.repo != nil {
+		if err := h.repo.Save(context.Background(), job); err != nil {
+			log.Printf("warning: failed to save job: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Context(), h.waitTimeout)
+	defer cancel()
+	if err := h.processor.Process(ctx, job); err != nil {
+		status := fiber.StatusUnprocessableEntity
+		if job.Status == domain.StatusTimeout {
+			status = fiber.StatusGatewayTimeout
+		}
+		return c.Status(status).JSON(fiber.Map{"jobId": job.ID.String(), "status": job.Status, "error": job.Metadata["error"], "failed_stage": job.Metadata["failed_stage"], "error_code": job.Metadata["error_code"]})
+	}
+
+	pdfPath, _ := job.Metadata["generated_pdf"].(string)
+	if pdfPath == "" {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"jobId": job.ID.String(), "status": job.Status, "error": job.Metadata["pdf_render_error"], "failed_stage": usecase.StageRender, "error_code": usecase.CodeRenderFailed})
+	}
+	f, err := os.Open(pdfPath)
+	if err != nil {
+		log.Printf("job %s: open pdf failed: %v", job.ID.String(), err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"jobId": job.ID.String(), "error": "failed to read pdf"})
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"jobId": job.ID.String(), "error": "failed to read pdf"})
+	}
+
+	c.Set("X-Job-Id", job.ID.String())
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", pdfFileName(job)))
+	return c.SendStream(f, int(fi.Size()))
+}
+
+var fileNameUnsafe = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// pdfFileName derives the download name from the resume's meta.name, e.g.
+// "Jane Doe" becomes Jane-Doe-Resume.pdf, falling back to resume.pdf.
+func pdfFileName(job *domain.ResumeJob) string {
+	meta, _ := job.Profile["meta"].(map[string]interface{})
+	name, _ := meta["name"].(string)
+	slug := strings.Trim(fileNameUnsafe.ReplaceAllString(name, "-"), "-")
+	if slug == "" {
+		return "resume.pdf"
+	}
+	return slug + "-Resume.pdf"
+}
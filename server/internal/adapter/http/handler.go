@@ -2,7 +2,13 @@ package http
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"resume-generator/internal/domain"
@@ -16,10 +22,83 @@ type Handler struct {
 	processor       *usecase.Processor
 	repo            usecase.JobsRepo
 	defaultLanguage string
+	idempotencyTTL  time.Duration
+	waitTimeout     time.Duration
+	staleAfter      time.Duration
+	instanceID      string
+	languages       []string
+	limits          Limits
+
+	// jobs runs background Process calls with bounded concurrency.
+	jobs *usecase.WorkerPool
 }
 
+// NewHandler builds the job HTTP handler. defaultLanguage is stored on jobs
+// whose start request omits "language".
 func NewHandler(p *usecase.Processor, r usecase.JobsRepo, defaultLanguage string) *Handler {
-	return &Handler{processor: p, repo: r, defaultLanguage: defaultLanguage}
+	ttl := defaultIdempotencyTTL
+	if v := os.Getenv("IDEMPOTENCY_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			ttl = d
+		}
+	}
+	workers := defaultMaxConcurrentJobs
+	for _, env := range []string{"WORKERS", "MAX_CONCURRENT_JOBS"} {
+		if n, err := strconv.Atoi(os.Getenv(env)); err == nil && n > 0 {
+			workers = n
+			break
+		}
+	}
+	waitTimeout := defaultWaitTimeout
+	if v := os.Getenv("SYNC_WAIT_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			waitTimeout = d
+		}
+	}
+	jobs := usecase.NewWorkerPool(p, workers, workers*jobQueueFactor)
+	staleAfter := jobs.StaleAfter()
+	if v := os.Getenv("STALE_JOB_AFTER"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			staleAfter = d
+		}
+	}
+	return &Handler{
+		processor:       p,
+		repo:            r,
+		defaultLanguage: defaultLanguage,
+		idempotencyTTL:  ttl,
+		waitTimeout:     waitTimeout,
+		staleAfter:      staleAfter,
+		instanceID:      instanceID(),
+		languages:       supportedLanguages(defaultLanguage),
+		limits:          limitsFromEnv(),
+		jobs:            jobs,
+	}
+}
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+
+	// defaultIdempotencyTTL is how long an Idempotency-Key maps to its
+	// original job when IDEMPOTENCY_TTL is not set.
+	defaultIdempotencyTTL = 24 * time.Hour
+
+	// defaultMaxConcurrentJobs is the worker count when neither WORKERS nor
+	// the older MAX_CONCURRENT_JOBS is set; up to jobQueueFactor jobs per
+	// worker may wait in the queue.
+	defaultMaxConcurrentJobs = 4
+	jobQueueFactor           = 4
+
+	// defaultWaitTimeout bounds POST /jobs/start?wait=true when
+	// SYNC_WAIT_TIMEOUT is not set.
+	defaultWaitTimeout = 2 * time.Minute
+)
+
+// WaitForJobs stops accepting new jobs and blocks until queued and running
+// jobs have finished or ctx is done.
+func (h *Handler) WaitForJobs(ctx context.Context) error {
+	return h.jobs.Close(ctx)
 }
 
 type startReq struct {
@@ -27,20 +106,188 @@ type startReq struct {
 	JobApplicationID string `json:"jobApplicationId"`
 	JobDescription   string `json:"jobDescription,omitempty"`
 	Language         string `json:"language,omitempty"`
+	Format           string `json:"format,omitempty"`
+	PaperSize        string `json:"paperSize,omitempty"`
+	IdempotencyKey   string `json:"idempotencyKey,omitempty"`
+	// NoCache skips cached AI responses, e.g. after the user's data changed.
+	NoCache bool `json:"noCache,omitempty"`
+	// DryRun stops after the HTML artifact, skipping PDF rendering.
+	DryRun bool `json:"dryRun,omitempty"`
+	// AIFlow picks the "split" or "single" AI flow instead of the server
+	// default.
+	AIFlow string `json:"aiFlow,omitempty"`
+	// Template names the layout to render, e.g. "compact"; "classic" when
+	// empty.
+	Template string `json:"template,omitempty"`
+	// Preview renders page 1 first and records it as preview_pdf, served
+	// at GET /jobs/:id/preview.pdf, while the full PDF is rendered.
+	Preview bool `json:"preview,omitempty"`
+	// CoverLetter also writes a cover letter for the job application,
+	// served at GET /jobs/:id/cover-letter.pdf. It is skipped without a
+	// jobApplicationId.
+	CoverLetter bool `json:"coverLetter,omitempty"`
+	// Theme overrides the layout's accent color and font, e.g.
+	// {"accentColor": "#0a66c2", "font": "Inter"}.
+	Theme *usecase.Theme `json:"theme,omitempty"`
+	// IncludeProjectIDs and ExcludeProjectIDs tailor the projects
+	// section; an include list matching no project is ignored.
+	IncludeProjectIDs []string `json:"includeProjectIds,omitempty"`
+	ExcludeProjectIDs []string `json:"excludeProjectIds,omitempty"`
+	// Order sorts experience and projects: "recent" or "relevance" to
+	// jobDescription. The order they were built in when empty.
+	Order string `json:"order,omitempty"`
+	// Formats lists extra outputs besides the PDF and HTML: "docx", "txt"
+	// and "tex". Format is the older single-value form.
+	Formats []string `json:"formats,omitempty"`
+	// ASCIIText folds the .txt output to ASCII; accented text is kept by
+	// default.
+	ASCIIText bool `json:"asciiText,omitempty"`
+
+	// Profile is an optional JSON object of profile overrides assigned to
+	// job.Profile. Honored keys: publications, certifications, extras,
+	// skills and education; other keys are passed to the AI as-is.
+	Profile json.RawMessage `json:"profile,omitempty"`
+}
+
+// parseProfile decodes the optional profile override, rejecting anything
+// that is not a JSON object or exceeds maxBytes.
+func parseProfile(raw json.RawMessage, maxBytes int) (map[string]interface{}, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	if len(raw) > maxBytes {
+		return nil, fmt.Errorf("profile too large: max %d bytes", maxBytes)
+	}
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, errors.New("invalid profile")
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("profile must be a JSON object")
+	}
+	return m, nil
 }
 
+// StartJob queues a job for the request body. A body that is not JSON or
+// exceeds the Limits gets 400, with one FieldError per field over its
+// limit; a body with otherwise invalid fields gets 422.
 func (h *Handler) StartJob(c *fiber.Ctx) error {
+	if ok, err := bodyWithin(c, h.limits.MaxBodyBytes); !ok {
+		return err
+	}
 	var req startReq
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid payload"})
 	}
 
-	uid, err := uuid.Parse(req.UserID)
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid userId"})
+	// Idempotency-Key header (or idempotencyKey body field): a retried start
+	// request returns the original job instead of creating a new one.
+	idemKey := strings.TrimSpace(c.Get("Idempotency-Key"))
+	profile, fieldErrs := h.validateStartReq(req, idemKey)
+	if len(fieldErrs) > 0 {
+		return c.Status(validationStatus(fieldErrs)).JSON(fiber.Map{"error": "validation failed", "errors": fieldErrs})
+	}
+	if !allowUser(c, req.UserID) {
+		return forbidden(c)
+	}
+	uid := uuid.MustParse(req.UserID)
+	if req.Language == "" {
+		req.Language = negotiateLanguage(c.Get(fiber.HeaderAcceptLanguage), h.languages)
+	}
+	if idemKey == "" {
+		idemKey = strings.TrimSpace(req.IdempotencyKey)
+	}
+	if idemKey != "" {
+		if existing, ok := h.findIdempotentJob(c.Context(), uid, idemKey); ok {
+			return c.Status(fiber.StatusOK).JSON(fiber.Map{"jobId": existing.ID.String(), "status": existing.Status})
+		}
+	}
+
+	job := h.newJob(uid, req)
+	job.IdempotencyKey = idemKey
+	if rid := requestID(c); rid != "" {
+		job.Metadata["request_id"] = rid
+	}
+	setProfile(job, profile)
+
+	// persist initial job (best-effort)
+	if h.repo != nil {
+		if err := h.repo.Save(context.Background(), job); err != nil {
+			// a concurrent request with the same key may have won the
+			// unique index; hand back that job instead
+			if idemKey != "" {
+				if existing, ok := h.findIdempotentJob(c.Context(), uid, idemKey); ok {
+					return c.Status(fiber.StatusOK).JSON(fiber.Map{"jobId": existing.ID.String(), "status": existing.Status})
+				}
+			}
+			log.Printf("warning: failed to save job: %v", err)
+		}
+	}
+
+	// with ?wait=true, subscribe before queueing so the terminal event
+	// cannot be missed
+	wait := c.QueryBool("wait")
+	var events <-chan usecase.JobEvent
+	if wait {
+		ch, unsubscribe := h.processor.Events().Subscribe(job.ID)
+		defer unsubscribe()
+		events = ch
+	}
+
+	// queue background processing; reject when all workers are busy
+	if err := h.jobs.Submit(job); err != nil {
+		log.Printf("job %s rejected: %v", job.ID.String(), err)
+		h.rejectJob(job, err)
+		if errors.Is(err, usecase.ErrPoolClosed) {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "server is shutting down"})
+		}
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": "too many jobs in progress, retry later"})
 	}
 
-	// Use provided language or fall back to default
+	if wait {
+		return h.awaitJob(c, job, events)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"jobId": job.ID.String(), "status": "started"})
+}
+
+// awaitJob blocks until job emits a terminal event or waitTimeout elapses.
+// It returns 200 with the artifact paths on success, 422 with the failure
+// reason and 504 when the job is still running; processing continues in the
+// background after a timeout. The response is built from the event alone,
+// since the worker owns job until it is saved.
+func (h *Handler) awaitJob(c *fiber.Ctx, job *domain.ResumeJob, events <-chan usecase.JobEvent) error {
+	ctx, cancel := context.WithTimeout(c.Context(), h.waitTimeout)
+	defer cancel()
+
+	for {
+		select {
+		case ev := <-events:
+			if !ev.Terminal {
+				continue
+			}
+			if ev.Stage == usecase.EventFailed || ev.Stage == usecase.EventTimeout {
+				return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"jobId": job.ID.String(), "status": ev.Stage, "error": ev.Error, "failed_stage": ev.Result["failed_stage"], "error_code": ev.Result["error_code"]})
+			}
+			if ev.Stage == usecase.EventCancelled {
+				return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"jobId": job.ID.String(), "status": "cancelled", "error": "job was cancelled"})
+			}
+			resp := fiber.Map{"jobId": job.ID.String(), "status": "completed"}
+			for k, v := range ev.Result {
+				resp[k] = v
+			}
+			return c.JSON(resp)
+		case <-ctx.Done():
+			return c.Status(fiber.StatusGatewayTimeout).JSON(fiber.Map{"jobId": job.ID.String(), "status": "processing", "error": "timed out waiting for job"})
+		}
+	}
+}
+
+// newJob builds a pending job from a start request, falling back to the
+// default language. Callers fill an empty req.Language from Accept-Language
+// first.
+func (h *Handler) newJob(uid uuid.UUID, req startReq) *domain.ResumeJob {
 	language := req.Language
 	if language == "" {
 		language = h.defaultLanguage
@@ -53,6 +300,7 @@ func (h *Handler) StartJob(c *fiber.Ctx) error {
 		Status:         "pending",
 		Metadata:       map[string]interface{}{},
 		Language:       language,
+		Template:       req.Template,
 		CreatedAt:      time.Now(),
 		UpdatedAt:      time.Now(),
 		Profile:        nil,
@@ -61,21 +309,404 @@ func (h *Handler) StartJob(c *fiber.Ctx) error {
 	if req.JobApplicationID != "" {
 		job.Metadata["job_application_id"] = req.JobApplicationID
 	}
+	if req.Format != "" {
+		job.Metadata["format"] = req.Format
+	}
+	if req.PaperSize != "" {
+		job.Metadata["paper_size"] = req.PaperSize
+	}
+	if req.NoCache {
+		job.Metadata["no_cache"] = true
+	}
+	if req.DryRun {
+		job.Metadata["dry_run"] = true
+	}
+	if req.Preview {
+		job.Metadata["preview"] = true
+	}
+	if req.CoverLetter {
+		job.Metadata["cover_letter"] = true
+	}
+	if req.AIFlow != "" {
+		job.Metadata["ai_flow"] = req.AIFlow
+	}
+	if req.Theme != nil {
+		if theme, err := req.Theme.Normalize(); err == nil {
+			usecase.SetTheme(job, theme)
+		}
+	}
+	usecase.SetTailoring(job, req.IncludeProjectIDs, req.ExcludeProjectIDs, req.Order)
+	usecase.SetFormats(job, req.Formats)
+	if req.ASCIIText {
+		job.Metadata["ascii_text"] = true
+	}
+	return job
+}
 
-	// persist initial job (best-effort)
-	if h.repo != nil {
-		if err := h.repo.Save(context.Background(), job); err != nil {
-			log.Printf("warning: failed to save job: %v", err)
+// setProfile assigns the caller's profile overrides and keeps a copy in
+// metadata, since Process replaces job.Profile with the generated resume and
+// a retry needs the original input.
+func setProfile(job *domain.ResumeJob, profile map[string]interface{}) {
+	if profile == nil {
+		return
+	}
+	job.Profile = profile
+	job.Metadata["profile_overrides"] = profile
+}
+
+// RetryJob reprocesses a failed job in place, keeping its id, profile
+// overrides and job_application_id. Only failed and timed out jobs can be
+// retried, at most usecase.MaxJobRetries times, and not when
+// metadata.retriable is false; other jobs, cancelled ones included, are
+// rejected with 409. Completed jobs should be regenerated
+// with a new StartJob request. The retry resumes after the AI stages that
+// validated in the failed run, listed as resumed_stages; ?fresh=true runs
+// every stage again. The retry is claimed with a conditional update on the
+// job's status, so of concurrent retries of one job only the first is
+// submitted and the others get 409.
+func (h *Handler) RetryJob(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid job id"})
+	}
+
+	job, err := h.repo.GetByID(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrJobNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "job not found"})
 		}
+		log.Printf("get job %s failed: %v", id.String(), err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to load job"})
+	}
+	if !allowUser(c, job.UserID.String()) {
+		return forbidden(c)
+	}
+
+	from := job.Status
+	if err := usecase.ResetForRetry(job); err != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error(), "status": job.Status})
+	}
+	claimed, err := h.repo.ClaimRetry(c.Context(), id, from)
+	if err != nil {
+		log.Printf("claim retry of job %s failed: %v", id.String(), err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to claim job"})
+	}
+	if !claimed {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "job is already being retried"})
+	}
+	if c.QueryBool("fresh") {
+		usecase.DiscardCheckpoints(job)
+	}
+	if rid := requestID(c); rid != "" {
+		job.Metadata["request_id"] = rid
+	}
+	if err := h.repo.Save(context.Background(), job); err != nil {
+		log.Printf("warning: failed to save job: %v", err)
 	}
 
-	// spawn background processing
-	go func(j *domain.ResumeJob) {
-		ctx := context.Background()
-		if err := h.processor.Process(ctx, j); err != nil {
-			log.Printf("job %s failed: %v", j.ID.String(), err)
+	// the worker owns job once it is submitted
+	accepted := fiber.Map{"jobId": job.ID.String(), "status": "started", "retry_count": job.Metadata["retry_count"], "resumed_stages": usecase.CheckpointedStages(job)}
+	if err := h.jobs.Submit(job); err != nil {
+		log.Printf("job %s rejected: %v", job.ID.String(), err)
+		h.rejectJob(job, err)
+		if errors.Is(err, usecase.ErrPoolClosed) {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "server is shutting down"})
 		}
-	}(job)
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": "too many jobs in progress, retry later"})
+	}
 
-	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"jobId": job.ID.String(), "status": "started"})
+	return c.Status(fiber.StatusAccepted).JSON(accepted)
+}
+
+// CancelJob aborts a job that is currently being processed. It returns 404
+// when the job is unknown or not running (e.g. still queued) and 409 when it
+// has already finished.
+func (h *Handler) CancelJob(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid job id"})
+	}
+
+	job, err := h.repo.GetByID(c.Context(), id)
+	if err != nil && !errors.Is(err, domain.ErrJobNotFound) {
+		log.Printf("get job %s failed: %v", id.String(), err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to load job"})
+	}
+	if job != nil && !allowUser(c, job.UserID.String()) {
+		return forbidden(c)
+	}
+	if job == nil && !allowUser(c, "") {
+		// only admins may cancel jobs that have no row
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "job not found"})
+	}
+	if job != nil && (job.Status == "completed" || job.Status == "failed" || job.Status == domain.StatusTimeout || job.Status == "cancelled") {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "job already finished", "status": job.Status})
+	}
+
+	if !h.processor.Cancel(id) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "job is not active"})
+	}
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"jobId": id.String(), "status": "cancelling"})
+}
+
+// InvalidateLabels drops cached label translations for the language query
+// param, or for every language when it is omitted.
+func (h *Handler) InvalidateLabels(c *fiber.Ctx) error {
+	language := c.Query("language")
+	if err := h.processor.LabelCache().Invalidate(c.Context(), language); err != nil {
+		log.Printf("invalidate labels failed: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to invalidate labels"})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// maxBatchSize caps the number of jobs accepted by StartBatch.
+const maxBatchSize = 20
+
+// StartBatch creates one job per item of a JSON array of start requests and
+// queues them on the worker pool. Invalid items are reported per index
+// without failing the rest of the batch.
+func (h *Handler) StartBatch(c *fiber.Ctx) error {
+	if ok, err := bodyWithin(c, h.limits.MaxBodyBytes*maxBatchSize); !ok {
+		return err
+	}
+	var reqs []startReq
+	if err := json.Unmarshal(c.Body(), &reqs); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid payload: expected a JSON array"})
+	}
+	if len(reqs) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "batch is empty"})
+	}
+	if len(reqs) > maxBatchSize {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": fmt.Sprintf("batch too large: max %d items", maxBatchSize)})
+	}
+
+	acceptLanguage := negotiateLanguage(c.Get(fiber.HeaderAcceptLanguage), h.languages)
+	items := make([]fiber.Map, 0, len(reqs))
+	for i, req := range reqs {
+		profile, fieldErrs := h.validateStartReq(req, "")
+		if len(fieldErrs) > 0 {
+			items = append(items, fiber.Map{"index": i, "error": "validation failed", "errors": fieldErrs})
+			continue
+		}
+		if !allowUser(c, req.UserID) {
+			items = append(items, fiber.Map{"index": i, "error": "forbidden"})
+			continue
+		}
+		uid := uuid.MustParse(req.UserID)
+		if req.Language == "" {
+			req.Language = acceptLanguage
+		}
+
+		job := h.newJob(uid, req)
+		setProfile(job, profile)
+		if rid := requestID(c); rid != "" {
+			job.Metadata["request_id"] = rid
+		}
+		if h.repo != nil {
+			if err := h.repo.Save(context.Background(), job); err != nil {
+				log.Printf("warning: failed to save job: %v", err)
+			}
+		}
+		if err := h.jobs.Submit(job); err != nil {
+			log.Printf("job %s rejected: %v", job.ID.String(), err)
+			h.rejectJob(job, err)
+			items = append(items, fiber.Map{"index": i, "jobId": job.ID.String(), "error": err.Error()})
+			continue
+		}
+		items = append(items, fiber.Map{"index": i, "jobId": job.ID.String(), "status": "started"})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"items": items})
+}
+
+// rejectJob records that job was never queued, as a retriable failure of
+// the submit stage, and frees its idempotency key so a retry can start a
+// fresh job.
+func (h *Handler) rejectJob(job *domain.ResumeJob, reason error) {
+	if h.repo == nil {
+		return
+	}
+	job.Status = "failed"
+	job.Metadata["error"] = reason.Error()
+	job.Metadata["failed_stage"] = usecase.StageSubmit
+	job.Metadata["error_code"] = usecase.ErrorCode(reason)
+	job.Metadata["retriable"] = true
+	job.UpdatedAt = time.Now()
+	if err := h.repo.Save(context.Background(), job); err != nil {
+		log.Printf("warning: failed to save rejected job: %v", err)
+	}
+	if job.IdempotencyKey != "" {
+		if err := h.repo.ReleaseIdempotencyKey(context.Background(), job.ID); err != nil {
+			log.Printf("warning: failed to release idempotency key for job %s: %v", job.ID.String(), err)
+		}
+	}
+}
+
+// findIdempotentJob looks up a live job for the user's idempotency key.
+// Keys of expired or failed jobs are released so the caller can start a new
+// run with them; pending, processing and completed jobs are returned as-is.
+func (h *Handler) findIdempotentJob(ctx context.Context, userID uuid.UUID, key string) (*domain.ResumeJob, bool) {
+	existing, err := h.repo.FindByIdempotencyKey(ctx, userID, key)
+	if err != nil {
+		if !errors.Is(err, domain.ErrJobNotFound) {
+			log.Printf("warning: idempotency lookup failed: %v", err)
+		}
+		return nil, false
+	}
+	if existing.Status == "failed" || existing.Status == domain.StatusTimeout || time.Since(existing.CreatedAt) > h.idempotencyTTL {
+		if err := h.repo.ReleaseIdempotencyKey(ctx, existing.ID); err != nil {
+			log.Printf("warning: failed to release idempotency key for job %s: %v", existing.ID.String(), err)
+		}
+		return nil, false
+	}
+	return existing, true
+}
+
+// Jobs returns the pool running background jobs, so other adapters can
+// share its concurrency limit.
+func (h *Handler) Jobs() *usecase.WorkerPool {
+	return h.jobs
+}
+
+// Limits returns the request size limits, so other adapters can enforce
+// the same ones.
+func (h *Handler) Limits() Limits {
+	return h.limits
+}
+
+// StaleAfter returns how long an unfinished job may go without an update
+// before it counts as abandoned.
+func (h *Handler) StaleAfter() time.Duration {
+	return h.staleAfter
+}
+
+// Languages returns the languages start requests may ask for.
+func (h *Handler) Languages() []string {
+	return append([]string(nil), h.languages...)
+}
+
+// jobAllowed loads the job's owner when auth is on and reports whether the
+// caller may access it. When it returns false the response, 403, 404 or
+// 500, has been written and err is what the handler returns.
+func (h *Handler) jobAllowed(c *fiber.Ctx, id uuid.UUID) (bool, error) {
+	if p := principal(c); p == nil || p.Admin {
+		return true, nil
+	}
+	job, err := h.repo.GetByID(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrJobNotFound) {
+			return false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "job not found"})
+		}
+		log.Printf("get job %s failed: %v", id.String(), err)
+		return false, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to load job"})
+	}
+	if !allowUser(c, job.UserID.String()) {
+		return false, forbidden(c)
+	}
+	return true, nil
+}
+
+// GetJob returns the current status, metadata and timestamps of a job so
+// clients can poll for completion after StartJob. A job that did not
+// complete also has its error_code, one of the usecase Code constants.
+func (h *Handler) GetJob(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid job id"})
+	}
+
+	job, err := h.repo.GetByID(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrJobNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "job not found"})
+		}
+		log.Printf("get job %s failed: %v", id.String(), err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to load job"})
+	}
+	if !allowUser(c, job.UserID.String()) {
+		return forbidden(c)
+	}
+
+	resp := fiber.Map{
+		"jobId":      job.ID.String(),
+		"userId":     job.UserID.String(),
+		"status":     job.Status,
+		"metadata":   job.Metadata,
+		"resumeId":   job.ResumeID,
+		"language":   job.Language,
+		"created_at": job.CreatedAt,
+		"updated_at": job.UpdatedAt,
+	}
+	if code, _ := job.Metadata["error_code"].(string); code != "" {
+		resp["error_code"] = code
+	}
+	return c.JSON(resp)
+}
+
+// ListUserJobs returns a page of the user's jobs, newest first. Supported
+// query params: limit (default 20, capped at 100), offset and status.
+func (h *Handler) ListUserJobs(c *fiber.Ctx) error {
+	uid, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid userId"})
+	}
+	if !allowUser(c, uid.String()) {
+		return forbidden(c)
+	}
+
+	page, err := parsePage(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	filter := domain.JobFilter{Status: c.Query("status")}
+
+	jobs, err := h.repo.ListByUser(c.Context(), uid, filter, page)
+	if err != nil {
+		log.Printf("list jobs for user %s failed: %v", uid.String(), err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to list jobs"})
+	}
+
+	items := make([]fiber.Map, 0, len(jobs))
+	for _, j := range jobs {
+		items = append(items, fiber.Map{
+			"jobId":          j.ID.String(),
+			"status":         j.Status,
+			"created_at":     j.CreatedAt,
+			"generated_html": j.Metadata["generated_html"],
+			"generated_pdf":  j.Metadata["generated_pdf"],
+			"user_copy":      j.Metadata["user_copy"],
+			"pdf_url":        j.Metadata["pdf_url"],
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"items":  items,
+		"limit":  page.Limit,
+		"offset": page.Offset,
+	})
+}
+
+// parsePage reads limit/offset query params, applying the default page size
+// and capping the limit at maxPageSize.
+func parsePage(c *fiber.Ctx) (domain.Page, error) {
+	page := domain.Page{Limit: defaultPageSize}
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return page, errors.New("invalid limit")
+		}
+		page.Limit = n
+	}
+	if page.Limit > maxPageSize {
+		page.Limit = maxPageSize
+	}
+	if v := c.Query("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return page, errors.New("invalid offset")
+		}
+		page.Offset = n
+	}
+	return page, nil
 }
@@ -0,0 +1,550 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"resume-generator/internal/domain"
+	"resume-generator/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// memRepo keeps copies of the saved jobs in memory. Methods the tests do
+// not reach panic through the nil embedded interface. onGet, when set, runs
+// before every GetByID.
+type memRepo struct {
+	usecase.JobsRepo
+
+	mu    sync.Mutex
+	saves []*domain.ResumeJob
+	onGet func()
+}
+
+// cloneJob returns a deep copy of j through its JSON form.
+func cloneJob(j *domain.ResumeJob) (*domain.ResumeJob, error) {
+	b, err := json.Marshal(j)
+	if err != nil {
+		return nil, err
+	}
+	var cp domain.ResumeJob
+	if err := json.Unmarshal(b, &cp); err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+func (r *memRepo) Save(ctx context.Context, j *domain.ResumeJob) error {
+	cp, err := cloneJob(j)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.saves = append(r.saves, cp)
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *memRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.ResumeJob, error) {
+	if r.onGet != nil {
+		r.onGet()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.saves) - 1; i >= 0; i-- {
+		if r.saves[i].ID == id {
+			return cloneJob(r.saves[i])
+		}
+	}
+	return nil, domain.ErrJobNotFound
+}
+
+// ClaimRetry records the job as pending when its latest save has status
+// from, like the conditional update in the jobs repository.
+func (r *memRepo) ClaimRetry(ctx context.Context, id uuid.UUID, from string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.saves) - 1; i >= 0; i-- {
+		if r.saves[i].ID != id {
+			continue
+		}
+		if r.saves[i].Status != from {
+			return false, nil
+		}
+		cp, err := cloneJob(r.saves[i])
+		if err != nil {
+			return false, err
+		}
+		cp.Status = domain.StatusPending
+		r.saves = append(r.saves, cp)
+		return true, nil
+	}
+	return false, nil
+}
+
+func (r *memRepo) UpdateStatus(ctx context.Context, id uuid.UUID, status string, progress map[string]interface{}) error {
+	return nil
+}
+
+func (r *memRepo) SetMetadata(ctx context.Context, id uuid.UUID, key string, value interface{}) error {
+	return nil
+}
+
+func (r *memRepo) FindByIdempotencyKey(ctx context.Context, userID uuid.UUID, key string) (*domain.ResumeJob, error) {
+	return nil, domain.ErrJobNotFound
+}
+
+func (r *memRepo) last() *domain.ResumeJob {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.saves) == 0 {
+		return nil
+	}
+	return r.saves[len(r.saves)-1]
+}
+
+// newTestApp serves StartJob, GetJob and RetryJob with a processor that
+// builds resumes without the AI service and stops after the HTML artifact;
+// opts change the processor further.
+func newTestApp(t *testing.T, opts ...usecase.ProcessorOption) (*fiber.App, *memRepo) {
+	t.Helper()
+	repo := &memRepo{}
+	opts = append([]usecase.ProcessorOption{
+		usecase.WithAIMode(usecase.AIModeOff),
+		usecase.WithDryRun(true),
+		usecase.WithOutputDir(t.TempDir()),
+	}, opts...)
+	p := usecase.NewProcessor(nil, repo, "English", opts...)
+	h := NewHandler(p, repo, "English")
+	t.Cleanup(func() { h.WaitForJobs(context.Background()) })
+	app := fiber.New()
+	app.Post("/jobs/start", h.StartJob)
+	app.Get("/jobs/:id", h.GetJob)
+	app.Post("/jobs/:id/retry", h.RetryJob)
+	return app, repo
+}
+
+func TestParseProfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantLen int
+		wantErr string
+	}{
+		{name: "absent", raw: ""},
+		{name: "null", raw: "null"},
+		{name: "object", raw: `{"summary":"x","publications":["a"]}`, wantLen: 2},
+		{name: "array", raw: `["a"]`, wantErr: "profile must be a JSON object"},
+		{name: "malformed", raw: `{"summary":`, wantErr: "invalid profile"},
+		{name: "too large", raw: `{"summary":"` + strings.Repeat("x", 64) + `"}`, wantErr: "profile too large: max 64 bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseProfile(json.RawMessage(tt.raw), 64)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("parseProfile() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != tt.wantLen {
+				t.Errorf("parseProfile() = %v, want %d keys", got, tt.wantLen)
+			}
+		})
+	}
+}
+
+// aiResume is what fakeAI answers every formatter call with; each stage
+// takes its own sections from it.
+const aiResume = `{
+	"meta": {"name": "Ada Lovelace", "headline": "Backend Engineer", "contact": {"email": "ada@example.com", "location": "London"}},
+	"summary": "Backend engineer building reliable Go services, data pipelines and the tooling around them.",
+	"snapshot": {
+		"tech": "Go, PostgreSQL, Kafka, Kubernetes",
+		"achievements": [
+			"Cut checkout p99 latency from 900ms to 180ms by reworking the ledger queries",
+			"Led the migration of 40 services to Kubernetes without downtime",
+			"Built the fraud scoring pipeline that blocks most chargebacks"
+		],
+		"selected_projects": [
+			"Ledger service processing 3M transactions a day, exactly once",
+			"Open source Go client for the instant payments API"
+		]
+	},
+	"skills": [{"category": "Languages", "items": ["Go", "SQL"]}],
+	"education": [{"institution": "University of London"}],
+	"experience": [{"company": "PayCo", "title": "Staff Engineer", "role": "Staff Engineer", "period": "2021 – Present", "bullets": ["Designed the double-entry ledger"]}],
+	"projects": [{"id": "ledger", "title": "Ledger Service", "description": "Double-entry ledger with idempotent postings, monthly partitioned tables and exactly-once delivery."}],
+	"publications": ["Scaling ledgers with PostgreSQL partitions — 2023. Talk at GopherCon."],
+	"certifications": [{"name": "AWS Certified Developer", "issuer": "Amazon"}],
+	"extras": [{"category": "Community", "text": "Go meetup organizer"}]
+}`
+
+// fakeAI stands in for the ai-service. Formatter calls get aiResume and
+// label translation gets nothing, so the English labels are used.
+// EnrichFields calls are recorded and answered with the overrides they
+// were sent, certification names expanded to objects issued by the Linux
+// Foundation.
+type fakeAI struct {
+	mu       sync.Mutex
+	enriched []map[string]interface{}
+}
+
+func (f *fakeAI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Input string `json:"input"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	output := aiResume
+	switch {
+	case strings.HasPrefix(req.Input, "Enrich only specific fields:\n"):
+		var body struct {
+			UserContext struct {
+				Overrides map[string]interface{} `json:"overrides"`
+			} `json:"userContext"`
+		}
+		json.Unmarshal([]byte(strings.TrimPrefix(req.Input, "Enrich only specific fields:\n")), &body)
+		fields := body.UserContext.Overrides
+		f.mu.Lock()
+		f.enriched = append(f.enriched, fields)
+		f.mu.Unlock()
+		if certs, ok := fields["certifications"].([]interface{}); ok {
+			for i, c := range certs {
+				if name, ok := c.(string); ok {
+					certs[i] = map[string]interface{}{"name": name, "issuer": "Linux Foundation"}
+				}
+			}
+		}
+		b, _ := json.Marshal(fields)
+		output = string(b)
+	case strings.HasPrefix(req.Input, "Translate UI labels"):
+		output = "{}"
+	}
+	json.NewEncoder(w).Encode(map[string]string{"agent": "auto", "output": output})
+}
+
+// newAITestApp is newTestApp with the AI stages on, calling ai.
+func newAITestApp(t *testing.T, ai *fakeAI) (*fiber.App, *memRepo) {
+	t.Helper()
+	srv := httptest.NewServer(ai)
+	t.Cleanup(srv.Close)
+	t.Setenv("AI_SERVICE_URL", srv.URL)
+	// no source databases: the AI works from the overrides alone
+	for _, env := range []string{"AUTH_DATABASE_URL", "JOBS_DATABASE_URL", "POSTS_DATABASE_URL", "MGMT_DATABASE_URL"} {
+		t.Setenv(env, "")
+	}
+	return newTestApp(t, usecase.WithAIMode(usecase.AIModeAuto))
+}
+
+func TestStartJobProfile(t *testing.T) {
+	userID := uuid.New().String()
+	publication := "Notes on the Analytical Engine — 1843. A description of the first published algorithm."
+	overrides := map[string]interface{}{
+		"meta":           map[string]interface{}{"name": "Ada Lovelace", "headline": "Backend Engineer"},
+		"publications":   []interface{}{publication},
+		"certifications": []interface{}{"Certified Kubernetes Administrator"},
+		"extras":         []interface{}{map[string]interface{}{"category": "Speaking", "text": "Keynote at the Difference Engine Conference"}},
+	}
+
+	tests := []struct {
+		name       string
+		profile    interface{}
+		wantStatus int
+		wantField  string
+	}{
+		{name: "overrides", profile: overrides, wantStatus: fiber.StatusOK},
+		{name: "not an object", profile: []interface{}{"x"}, wantStatus: fiber.StatusUnprocessableEntity, wantField: "profile"},
+		{name: "too large", profile: map[string]interface{}{"summary": strings.Repeat("x", 65<<10)}, wantStatus: fiber.StatusBadRequest, wantField: "profile"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ai := &fakeAI{}
+			app, repo := newAITestApp(t, ai)
+			body, _ := json.Marshal(map[string]interface{}{"userId": userID, "profile": tt.profile})
+			req := httptest.NewRequest("POST", "/jobs/start?wait=true", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			raw, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.wantStatus, raw)
+			}
+
+			if tt.wantField != "" {
+				var out struct {
+					Errors []FieldError `json:"errors"`
+				}
+				json.Unmarshal(raw, &out)
+				if len(out.Errors) != 1 || out.Errors[0].Field != tt.wantField {
+					t.Errorf("errors = %+v, want one for %q", out.Errors, tt.wantField)
+				}
+				if repo.last() != nil {
+					t.Error("a rejected request saved a job")
+				}
+				return
+			}
+
+			// the overrides reach EnrichFields...
+			if len(ai.enriched) != 1 {
+				t.Fatalf("EnrichFields called %d times, want 1", len(ai.enriched))
+			}
+			sent := ai.enriched[0]
+			if pubs, _ := sent["publications"].([]interface{}); len(pubs) != 1 || pubs[0] != publication {
+				t.Errorf("EnrichFields publications = %v, want the override", sent["publications"])
+			}
+			if certs, _ := sent["certifications"].([]interface{}); len(certs) != 1 {
+				t.Errorf("EnrichFields certifications = %v, want the override", sent["certifications"])
+			}
+			if extras, _ := sent["extras"].([]interface{}); len(extras) != 1 {
+				t.Errorf("EnrichFields extras = %v, want the override", sent["extras"])
+			}
+			if _, ok := sent["meta"]; ok {
+				t.Error("EnrichFields was sent meta, which is not an override it formats")
+			}
+
+			// ...and its answer ends up in the saved job
+			job := repo.last()
+			if job == nil {
+				t.Fatal("no job saved")
+			}
+			if job.Status != "completed" {
+				t.Fatalf("job status = %q: %v", job.Status, job.Metadata["error"])
+			}
+			if job.Metadata["ai_used"] != true {
+				t.Fatalf("metadata.ai_used = %v, ai_error = %v; want the AI flow", job.Metadata["ai_used"], job.Metadata["ai_error"])
+			}
+			if _, ok := job.Metadata["profile_overrides"].(map[string]interface{}); !ok {
+				t.Error("metadata.profile_overrides not kept")
+			}
+			if pubs, _ := job.Profile["publications"].([]interface{}); len(pubs) != 1 || pubs[0] != publication {
+				t.Errorf("publications = %v, want the override", job.Profile["publications"])
+			}
+			var cert map[string]interface{}
+			if certs, _ := job.Profile["certifications"].([]interface{}); len(certs) == 1 {
+				cert, _ = certs[0].(map[string]interface{})
+			}
+			if cert["name"] != "Certified Kubernetes Administrator" || cert["issuer"] != "Linux Foundation" {
+				t.Errorf("certifications = %v, want the enriched override", job.Profile["certifications"])
+			}
+			wantExtra := map[string]interface{}{"category": "Speaking", "text": "Keynote at the Difference Engine Conference"}
+			if extras, _ := job.Profile["extras"].([]interface{}); len(extras) != 1 || !reflect.DeepEqual(extras[0], wantExtra) {
+				t.Errorf("extras = %v, want the override", job.Profile["extras"])
+			}
+		})
+	}
+}
+
+func TestNewJobLanguage(t *testing.T) {
+	repo := &memRepo{}
+	p := usecase.NewProcessor(nil, repo, "Portuguese", usecase.WithOutputDir(t.TempDir()))
+	h := NewHandler(p, repo, "Portuguese")
+	defer h.WaitForJobs(context.Background())
+
+	tests := []struct {
+		requested string
+		want      string
+	}{
+		{"", "Portuguese"},
+		{"Spanish", "Spanish"},
+	}
+	for _, tt := range tests {
+		job := h.newJob(uuid.New(), startReq{Language: tt.requested})
+		if job.Language != tt.want {
+			t.Errorf("newJob(language %q).Language = %q, want %q", tt.requested, job.Language, tt.want)
+		}
+	}
+}
+
+// newBusyApp is newTestApp with a single worker stuck on an AI call and
+// its queue full, so the pool rejects the next job.
+func newBusyApp(t *testing.T) (*fiber.App, *memRepo) {
+	t.Helper()
+	called := make(chan struct{}, 1)
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case called <- struct{}{}:
+		default:
+		}
+		<-release
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("AI_SERVICE_URL", srv.URL)
+	for _, env := range []string{"AUTH_DATABASE_URL", "JOBS_DATABASE_URL", "POSTS_DATABASE_URL", "MGMT_DATABASE_URL"} {
+		t.Setenv(env, "")
+	}
+	t.Setenv("WORKERS", "1")
+	app, repo := newTestApp(t, usecase.WithAIMode(usecase.AIModeAuto))
+	// runs before newTestApp's cleanup waits for the jobs
+	t.Cleanup(func() { close(release) })
+
+	start := func() {
+		body, _ := json.Marshal(map[string]interface{}{"userId": uuid.New().String(), "profile": map[string]interface{}{"meta": map[string]interface{}{"name": "Ada Lovelace", "headline": "Backend Engineer"}}})
+		req := httptest.NewRequest("POST", "/jobs/start", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusAccepted {
+			t.Fatalf("filling the pool: status = %d", resp.StatusCode)
+		}
+	}
+	start()
+	<-called
+	for i := 0; i < jobQueueFactor; i++ {
+		start()
+	}
+	return app, repo
+}
+
+func TestStartJobErrorCode(t *testing.T) {
+	tests := []struct {
+		name       string
+		busy       bool
+		profile    map[string]interface{}
+		wantStatus int
+		wantStage  string
+		wantCode   string
+	}{
+		{
+			name:       "completed",
+			profile:    map[string]interface{}{"meta": map[string]interface{}{"name": "Ada Lovelace", "headline": "Backend Engineer"}},
+			wantStatus: fiber.StatusOK,
+		},
+		{
+			name:       "no name",
+			profile:    map[string]interface{}{"summary": "Backend engineer."},
+			wantStatus: fiber.StatusUnprocessableEntity,
+			wantStage:  usecase.StageValidation,
+			wantCode:   usecase.CodeValidation,
+		},
+		{
+			name:       "queue full",
+			busy:       true,
+			profile:    map[string]interface{}{"meta": map[string]interface{}{"name": "Ada Lovelace", "headline": "Backend Engineer"}},
+			wantStatus: fiber.StatusTooManyRequests,
+			wantStage:  usecase.StageSubmit,
+			wantCode:   usecase.CodeQueueFull,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var app *fiber.App
+			var repo *memRepo
+			if tt.busy {
+				app, repo = newBusyApp(t)
+			} else {
+				app, repo = newTestApp(t)
+			}
+			body, _ := json.Marshal(map[string]interface{}{"userId": uuid.New().String(), "profile": tt.profile})
+			req := httptest.NewRequest("POST", "/jobs/start?wait=true", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var started map[string]interface{}
+			json.NewDecoder(resp.Body).Decode(&started)
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %v", resp.StatusCode, tt.wantStatus, started)
+			}
+			if tt.wantStatus == fiber.StatusUnprocessableEntity && (started["failed_stage"] != tt.wantStage || started["error_code"] != tt.wantCode) {
+				t.Errorf("start response failed_stage %v, error_code %v; want %q, %q", started["failed_stage"], started["error_code"], tt.wantStage, tt.wantCode)
+			}
+
+			job := repo.last()
+			if job == nil {
+				t.Fatal("no job saved")
+			}
+			resp, err = app.Test(httptest.NewRequest("GET", "/jobs/"+job.ID.String(), nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var status map[string]interface{}
+			json.NewDecoder(resp.Body).Decode(&status)
+			code, ok := status["error_code"]
+			if tt.wantCode == "" && ok {
+				t.Errorf("GET /jobs/:id error_code = %v for a completed job", code)
+			}
+			if tt.wantCode != "" && code != tt.wantCode {
+				t.Errorf("GET /jobs/:id error_code = %v, want %q", code, tt.wantCode)
+			}
+			if meta, _ := status["metadata"].(map[string]interface{}); tt.wantStage != "" && meta["failed_stage"] != tt.wantStage {
+				t.Errorf("GET /jobs/:id metadata.failed_stage = %v, want %q", meta["failed_stage"], tt.wantStage)
+			}
+		})
+	}
+}
+
+func TestRetryJobRace(t *testing.T) {
+	app, repo := newTestApp(t)
+	job := &domain.ResumeJob{
+		ID:     uuid.New(),
+		UserID: uuid.New(),
+		Status: "failed",
+		Metadata: map[string]interface{}{
+			"retriable":         true,
+			"error":             "ai service unavailable",
+			"profile_overrides": map[string]interface{}{"meta": map[string]interface{}{"name": "Ada Lovelace", "headline": "Backend Engineer"}},
+		},
+		Language: "English",
+	}
+	if err := repo.Save(context.Background(), job); err != nil {
+		t.Fatal(err)
+	}
+
+	// hold both requests after GetByID until each has seen the failed job
+	var gets atomic.Int32
+	loaded := make(chan struct{})
+	repo.onGet = func() {
+		if gets.Add(1) == 2 {
+			close(loaded)
+		}
+		<-loaded
+	}
+
+	codes := make([]int, 2)
+	var wg sync.WaitGroup
+	for i := range codes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := app.Test(httptest.NewRequest("POST", "/jobs/"+job.ID.String()+"/retry", nil), -1)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			codes[i] = resp.StatusCode
+		}()
+	}
+	wg.Wait()
+
+	accepted, conflicts := 0, 0
+	for _, code := range codes {
+		switch code {
+		case fiber.StatusAccepted:
+			accepted++
+		case fiber.StatusConflict:
+			conflicts++
+		}
+	}
+	if accepted != 1 || conflicts != 1 {
+		t.Fatalf("status codes = %v, want one 202 and one 409", codes)
+	}
+}
@@ -0,0 +1,61 @@
+package http
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// negotiateLanguage picks the supported language the Accept-Language header
+// prefers most. Tags are ranked by q-value (ties keep header order) and
+// compared case-insensitively, first in full and then by primary subtag, so
+// "pt-PT" matches a supported "pt". It returns "" for an empty header, a
+// bare wildcard or when nothing matches, leaving the choice to the caller.
+func negotiateLanguage(header string, supported []string) string {
+	type tag struct {
+		name string
+		q    float64
+	}
+	var tags []tag
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		name := strings.TrimSpace(fields[0])
+		if name == "" {
+			continue
+		}
+		q := 1.0
+		for _, p := range fields[1:] {
+			p = strings.TrimSpace(p)
+			if v, ok := strings.CutPrefix(p, "q="); ok {
+				f, err := strconv.ParseFloat(v, 64)
+				if err != nil {
+					f = 0
+				}
+				q = f
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		tags = append(tags, tag{name, q})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	for _, t := range tags {
+		if t.name == "*" {
+			continue
+		}
+		for _, s := range supported {
+			if strings.EqualFold(s, t.name) {
+				return s
+			}
+		}
+		primary, _, _ := strings.Cut(t.name, "-")
+		for _, s := range supported {
+			if strings.EqualFold(s, primary) {
+				return s
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func TestNegotiateLanguage(t *testing.T) {
+	supported := []string{"English", "Portuguese", "en", "pt", "pt-BR", "es"}
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{"empty", "", ""},
+		{"exact", "pt-BR", "pt-BR"},
+		{"case-insensitive", "PT-br", "pt-BR"},
+		{"primary subtag", "pt-PT", "pt"},
+		{"full name", "portuguese", "Portuguese"},
+		{"q-values", "en;q=0.5, es;q=0.9", "es"},
+		{"ties keep header order", "es, en", "es"},
+		{"unsupported skipped", "fr-FR, de;q=0.9, en;q=0.1", "en"},
+		{"only unsupported", "fr, de", ""},
+		{"bare wildcard", "*", ""},
+		{"wildcard before a match", "*, es;q=0.5", "es"},
+		{"q=0 refuses", "es;q=0, en;q=0.2", "en"},
+		{"malformed q", "es;q=high, en;q=0.2", "en"},
+		{"spaces and empty parts", " , es ; q=0.8 ,", "es"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := negotiateLanguage(tt.header, supported); got != tt.want {
+				t.Errorf("negotiateLanguage(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStartJobAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		header   string
+		want     string
+	}{
+		{"header", "", "es-MX, en;q=0.8", "es"},
+		{"body wins", "Portuguese", "es", "Portuguese"},
+		{"unsupported header", "", "ja", "English"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, repo := newTestApp(t)
+			body, _ := json.Marshal(startReq{UserID: uuid.New().String(), Language: tt.language})
+			req := httptest.NewRequest("POST", "/jobs/start", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept-Language", tt.header)
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != fiber.StatusAccepted {
+				t.Fatalf("status = %d, want 202", resp.StatusCode)
+			}
+			repo.mu.Lock()
+			first := repo.saves[0]
+			repo.mu.Unlock()
+			if first.Language != tt.want {
+				t.Errorf("job language = %q, want %q", first.Language, tt.want)
+			}
+		})
+	}
+}
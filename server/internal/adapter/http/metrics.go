@@ -0,0 +1,22 @@
+package http
+
+import (
+	"bytes"
+	"log"
+
+	"resume-generator/pkg/metrics"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Metrics serves the metrics in metrics.Default in the Prometheus text
+// format.
+func Metrics(c *fiber.Ctx) error {
+	var buf bytes.Buffer
+	if err := metrics.Default.Write(&buf); err != nil {
+		log.Printf("write metrics failed: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to collect metrics"})
+	}
+	c.Set(fiber.HeaderContentType, metrics.ContentType)
+	return c.Send(buf.Bytes())
+}
@@ -0,0 +1,41 @@
+package http
+
+import (
+	"strings"
+
+	"resume-generator/pkg/logctx"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// HeaderRequestID carries the request id on requests and responses.
+const HeaderRequestID = "X-Request-ID"
+
+const requestIDLocal = "request_id"
+
+// maxRequestIDLen bounds caller-supplied request ids.
+const maxRequestIDLen = 128
+
+// RequestID assigns every request an id, honoring a caller-supplied
+// X-Request-ID, echoes it in the response and attaches it to the request's
+// logger.
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := strings.TrimSpace(c.Get(HeaderRequestID))
+		if id == "" || len(id) > maxRequestIDLen {
+			id = uuid.NewString()
+		}
+		c.Locals(requestIDLocal, id)
+		c.Set(HeaderRequestID, id)
+		c.SetUserContext(logctx.With(c.UserContext(), "request_id", id))
+		return c.Next()
+	}
+}
+
+// requestID returns the id assigned by RequestID, or "" when the middleware
+// is not installed.
+func requestID(c *fiber.Ctx) string {
+	id, _ := c.Locals(requestIDLocal).(string)
+	return id
+}
@@ -0,0 +1,73 @@
+package http
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"time"
+
+	"resume-generator/pkg/logctx"
+	"resume-generator/pkg/ratelimit"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RateLimit takes a token per request from the caller's bucket in l: the
+// authenticated user's, or the client IP's when auth is off. An empty
+// bucket gets 429 with Retry-After in seconds. A nil l lets every request
+// through, and so does a limiter error, so an unreachable Redis does not
+// take job starts down with it.
+func RateLimit(l ratelimit.Limiter) fiber.Handler {
+	return rateLimit(l, func(*fiber.Ctx) int { return 1 })
+}
+
+// RateLimitBatch is RateLimit for POST /jobs/start-batch: it takes a token
+// per item of the JSON array body, so a batch costs as much as starting
+// its jobs one by one. A batch is admitted or rejected whole.
+func RateLimitBatch(l ratelimit.Limiter) fiber.Handler {
+	return rateLimit(l, batchCost)
+}
+
+// batchCost counts the items of a start-batch body. A body StartBatch
+// will reject costs one token.
+func batchCost(c *fiber.Ctx) int {
+	var items []json.RawMessage
+	if err := json.Unmarshal(c.Body(), &items); err != nil || len(items) == 0 || len(items) > maxBatchSize {
+		return 1
+	}
+	return len(items)
+}
+
+func rateLimit(l ratelimit.Limiter, cost func(*fiber.Ctx) int) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if l == nil {
+			return c.Next()
+		}
+		key := "ip:" + c.IP()
+		if p := principal(c); p != nil {
+			key = "user:" + p.UserID
+		}
+		ok, wait, err := l.AllowN(c.UserContext(), key, cost(c))
+		if err != nil {
+			logctx.Warnf(c.UserContext(), "rate limit check for %s failed, allowing: %v", key, err)
+			return c.Next()
+		}
+		if !ok {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfterSeconds(wait)))
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": "too many job starts, retry later"})
+		}
+		return c.Next()
+	}
+}
+
+// retryAfterSeconds rounds wait up to whole seconds, at least one.
+func retryAfterSeconds(wait time.Duration) int {
+	s := math.Ceil(wait.Seconds())
+	if s < 1 {
+		return 1
+	}
+	if s > math.MaxInt32 {
+		return math.MaxInt32
+	}
+	return int(s)
+}
@@ -0,0 +1,149 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"resume-generator/pkg/auth"
+	"resume-generator/pkg/ratelimit"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newLimitedApp serves 204 behind RateLimit and RateLimitBatch. The
+// X-User header stands in for an authenticated caller and X-Forwarded-For
+// sets the client IP.
+func newLimitedApp(l ratelimit.Limiter) *fiber.App {
+	app := fiber.New(fiber.Config{ProxyHeader: fiber.HeaderXForwardedFor})
+	app.Use(func(c *fiber.Ctx) error {
+		if u := c.Get("X-User"); u != "" {
+			c.Locals(principalLocal, &auth.Principal{UserID: u})
+		}
+		return c.Next()
+	})
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) }
+	app.Post("/jobs/start", RateLimit(l), ok)
+	app.Post("/jobs/start-batch", RateLimitBatch(l), ok)
+	return app
+}
+
+func TestRateLimit(t *testing.T) {
+	// three starts a minute: the fourth in a burst waits a minute
+	l := ratelimit.NewMemoryLimiter(ratelimit.Config{Rate: 1.0 / 60, Burst: 3})
+	app := newLimitedApp(l)
+
+	tests := []struct {
+		name       string
+		user, ip   string
+		want       int
+		retryAfter string
+	}{
+		{"ana 1", "ana", "10.0.0.1", fiber.StatusNoContent, ""},
+		{"ana 2", "ana", "10.0.0.1", fiber.StatusNoContent, ""},
+		{"ana 3", "ana", "10.0.0.2", fiber.StatusNoContent, ""},
+		{"ana 4 rejected", "ana", "10.0.0.3", fiber.StatusTooManyRequests, "60"},
+		{"bob gets a separate bucket", "bob", "10.0.0.1", fiber.StatusNoContent, ""},
+		{"anonymous by ip", "", "10.0.0.1", fiber.StatusNoContent, ""},
+		{"anonymous by ip 2", "", "10.0.0.1", fiber.StatusNoContent, ""},
+		{"anonymous by ip 3", "", "10.0.0.1", fiber.StatusNoContent, ""},
+		{"anonymous by ip 4 rejected", "", "10.0.0.1", fiber.StatusTooManyRequests, "60"},
+		{"another ip", "", "10.0.0.9", fiber.StatusNoContent, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/jobs/start", nil)
+			req.Header.Set("X-User", tt.user)
+			req.Header.Set("X-Forwarded-For", tt.ip)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.want {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+			if got := resp.Header.Get(fiber.HeaderRetryAfter); got != tt.retryAfter {
+				t.Errorf("Retry-After = %q, want %q", got, tt.retryAfter)
+			}
+		})
+	}
+}
+
+func TestRateLimitBatch(t *testing.T) {
+	items := func(n int) string {
+		return "[" + strings.TrimSuffix(strings.Repeat(`{},`, n), ",") + "]"
+	}
+	tests := []struct {
+		name   string
+		bodies []string
+		want   []int
+	}{
+		{"a token per item", []string{items(3), items(3)}, []int{204, 429}},
+		{"admitted whole or not at all", []string{items(4), items(2), items(1)}, []int{204, 429, 204}},
+		{"malformed costs one", []string{"{", "[]", items(maxBatchSize + 1), items(2)}, []int{204, 204, 204, 204}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newLimitedApp(ratelimit.NewMemoryLimiter(ratelimit.Config{Rate: 1.0 / 60, Burst: 5}))
+			for i, body := range tt.bodies {
+				req := httptest.NewRequest("POST", "/jobs/start-batch", strings.NewReader(body))
+				req.Header.Set("X-User", "ana")
+				resp, err := app.Test(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if resp.StatusCode != tt.want[i] {
+					t.Errorf("request %d: status = %d, want %d", i, resp.StatusCode, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// failingLimiter fails every check, as an unreachable Redis does.
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	return false, 0, errors.New("redis: connection refused")
+}
+
+func (failingLimiter) AllowN(ctx context.Context, key string, n int) (bool, time.Duration, error) {
+	return false, 0, errors.New("redis: connection refused")
+}
+
+func TestRateLimitFailsOpen(t *testing.T) {
+	for _, l := range []ratelimit.Limiter{nil, failingLimiter{}} {
+		t.Run(fmt.Sprintf("%T", l), func(t *testing.T) {
+			resp, err := newLimitedApp(l).Test(httptest.NewRequest("POST", "/jobs/start", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != fiber.StatusNoContent {
+				t.Errorf("status = %d, want %d", resp.StatusCode, fiber.StatusNoContent)
+			}
+		})
+	}
+}
+
+func TestRetryAfterSeconds(t *testing.T) {
+	tests := []struct {
+		wait time.Duration
+		want int
+	}{
+		{0, 1},
+		{time.Millisecond, 1},
+		{time.Second, 1},
+		{1500 * time.Millisecond, 2},
+		{time.Minute, 60},
+		{time.Duration(1<<63 - 1), 1<<31 - 1},
+	}
+	for _, tt := range tests {
+		if got := retryAfterSeconds(tt.wait); got != tt.want {
+			t.Errorf("retryAfterSeconds(%v) = %d, want %d", tt.wait, got, tt.want)
+		}
+	}
+}
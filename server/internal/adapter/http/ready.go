@@ -0,0 +1,18 @@
+package http
+
+import (
+	ai "resume-generator/pkg/ai"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Ready reports whether the server can take jobs. It answers 503 while the
+// AI service's circuit breaker is open, since jobs would fail fast or fall
+// back to offline resumes.
+func (h *Handler) Ready(c *fiber.Ctx) error {
+	state := h.processor.AIBreakerState()
+	if state == ai.BreakerOpen {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"status": "unavailable", "ai_breaker": state})
+	}
+	return c.JSON(fiber.Map{"status": "ready", "ai_breaker": state})
+}
@@ -0,0 +1,300 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"resume-generator/internal/domain"
+	"resume-generator/internal/model"
+	"resume-generator/internal/usecase"
+	"resume-generator/pkg/export"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ResumesHandler exposes previously generated resumes.
+type ResumesHandler struct {
+	processor    *usecase.Processor
+	repo         usecase.ResumesRepo
+	generatedDir string
+}
+
+// NewResumesHandler serves resumes from repo; downloads are limited to files
+// inside the processor's generated directory.
+func NewResumesHandler(p *usecase.Processor, r usecase.ResumesRepo) *ResumesHandler {
+	return &ResumesHandler{processor: p, repo: r, generatedDir: p.GeneratedDir()}
+}
+
+// ListUserResumes returns a page of the user's generated resumes, newest
+// first. Supported query params: limit, offset and title (substring match).
+func (h *ResumesHandler) ListUserResumes(c *fiber.Ctx) error {
+	uid, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid userId"})
+	}
+	if !allowUser(c, uid.String()) {
+		return forbidden(c)
+	}
+
+	page, err := parsePage(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	filter := domain.ResumeFilter{Title: c.Query("title")}
+
+	resumes, err := h.repo.ListByUser(c.Context(), uid, filter, page)
+	if err != nil {
+		log.Printf("list resumes for user %s failed: %v", uid.String(), err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to list resumes"})
+	}
+
+	items := make([]fiber.Map, 0, len(resumes))
+	for _, r := range resumes {
+		items = append(items, fiber.Map{
+			"id":              r.ID.String(),
+			"title":           r.Title,
+			"file_name":       r.FileName,
+			"file_path":       r.FilePath,
+			"file_size":       r.FileSize,
+			"pdf_path":        r.PDFPath,
+			"created_at":      r.CreatedAt,
+			"updated_at":      r.UpdatedAt,
+			"extras":          r.Extras,
+			"generation_meta": r.GenerationMeta,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"items":  items,
+		"limit":  page.Limit,
+		"offset": page.Offset,
+	})
+}
+
+// GetResume returns the stored resume row, including how much of it the AI
+// synthesized.
+func (h *ResumesHandler) GetResume(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid resume id"})
+	}
+
+	res, err := h.repo.GetByID(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrResumeNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "resume not found"})
+		}
+		log.Printf("get resume %s failed: %v", id.String(), err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to load resume"})
+	}
+	if !allowUser(c, res.UserID.String()) {
+		return forbidden(c)
+	}
+	return c.JSON(res)
+}
+
+// GetResumeJSON returns the structured resume a generated resume was
+// rendered from.
+func (h *ResumesHandler) GetResumeJSON(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid resume id"})
+	}
+	if ok, err := h.resumeAllowed(c, id); !ok {
+		return err
+	}
+
+	data, err := h.repo.GetJSON(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrResumeNotFound) || errors.Is(err, domain.ErrResumeJSONNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		log.Printf("get resume json %s failed: %v", id.String(), err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to load resume"})
+	}
+	return c.JSON(data)
+}
+
+// ExportResume returns the structured resume converted to another resume
+// format as a download. The format query param picks it:
+//
+//   - jsonresume, the default: JSON Resume (https://jsonresume.org)
+//   - europass and europass-xml: the Europass CV as JSON or XML, in the
+//     language given by the locale param ("en" by default). The sections
+//     Europass has no place for are listed, comma-separated, in the
+//     X-Unmapped-Fields header.
+//
+// Other formats get 400.
+func (h *ResumesHandler) ExportResume(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid resume id"})
+	}
+	format := strings.ToLower(c.Query("format", "jsonresume"))
+	switch format {
+	case "jsonresume", "europass", "europass-xml":
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": fmt.Sprintf("unsupported export format %q", format)})
+	}
+	if ok, err := h.resumeAllowed(c, id); !ok {
+		return err
+	}
+
+	data, err := h.repo.GetJSON(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrResumeNotFound) || errors.Is(err, domain.ErrResumeJSONNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		log.Printf("get resume json %s failed: %v", id.String(), err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to load resume"})
+	}
+
+	var (
+		body []byte
+		ext  = ".json"
+	)
+	switch format {
+	case "jsonresume":
+		var out map[string]interface{}
+		if out, err = export.ToJSONResume(data); err == nil {
+			body, err = json.Marshal(out)
+		}
+	default:
+		var cv *export.Europass
+		if cv, err = export.ToEuropass(data, c.Query("locale")); err != nil {
+			break
+		}
+		if len(cv.Unmapped) > 0 {
+			c.Set("X-Unmapped-Fields", strings.Join(cv.Unmapped, ", "))
+		}
+		if format == "europass-xml" {
+			body, err = cv.XML()
+			ext = ".xml"
+		} else {
+			body, err = cv.JSON()
+		}
+	}
+	if err != nil {
+		log.Printf("export resume %s as %s failed: %v", id.String(), format, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to export resume"})
+	}
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", "resume-"+id.String()+ext))
+	c.Type(ext, "utf-8")
+	return c.Send(body)
+}
+
+// UpdateResumeJSON replaces the structured resume with the request body and
+// re-renders its HTML/PDF without calling the AI. A body that fails the
+// resume schema gets 422 with one entry per violation; a resume whose job
+// is still running gets 409.
+func (h *ResumesHandler) UpdateResumeJSON(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid resume id"})
+	}
+	if ok, err := h.resumeAllowed(c, id); !ok {
+		return err
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(c.Body(), &data); err != nil || data == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid payload: expected a JSON object"})
+	}
+
+	verrs, err := model.ValidateMapDetailed(data)
+	if err != nil {
+		log.Printf("validate resume %s failed: %v", id.String(), err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to validate resume"})
+	}
+	if len(verrs) > 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": "resume does not match schema", "errors": verrs})
+	}
+
+	job, err := h.processor.Rerender(c.Context(), id, data)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrResumeNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "resume not found"})
+		case errors.Is(err, usecase.ErrJobInProgress):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		log.Printf("rerender resume %s failed: %v", id.String(), err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to render resume"})
+	}
+
+	return c.JSON(fiber.Map{
+		"id":             id.String(),
+		"jobId":          job.ID.String(),
+		"generated_html": job.Metadata["generated_html"],
+		"generated_pdf":  job.Metadata["generated_pdf"],
+		"pdf_url":        job.Metadata["pdf_url"],
+	})
+}
+
+// DownloadResume sends the generated file of a resume as an attachment.
+func (h *ResumesHandler) DownloadResume(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid resume id"})
+	}
+
+	res, err := h.repo.GetByID(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrResumeNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "resume not found"})
+		}
+		log.Printf("get resume %s failed: %v", id.String(), err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to load resume"})
+	}
+	if !allowUser(c, res.UserID.String()) {
+		return forbidden(c)
+	}
+
+	if res.FilePath == "" {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "resume file not available"})
+	}
+	if !withinDir(h.generatedDir, res.FilePath) {
+		log.Printf("resume %s: refusing to serve %s outside generated dir", id.String(), res.FilePath)
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "resume file not available"})
+	}
+
+	b, err := os.ReadFile(res.FilePath)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "resume file not found"})
+	}
+
+	name := res.FileName
+	if name == "" {
+		name = filepath.Base(res.FilePath)
+	}
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", name))
+	c.Type(filepath.Ext(name))
+	return c.Send(b)
+}
+
+// resumeAllowed loads the resume's owner when auth is on and reports
+// whether the caller may access it. When it returns false the response,
+// 403, 404 or 500, has been written and err is what the handler returns.
+func (h *ResumesHandler) resumeAllowed(c *fiber.Ctx, id uuid.UUID) (bool, error) {
+	if p := principal(c); p == nil || p.Admin {
+		return true, nil
+	}
+	res, err := h.repo.GetByID(c.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrResumeNotFound) {
+			return false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "resume not found"})
+		}
+		log.Printf("get resume %s failed: %v", id.String(), err)
+		return false, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to load resume"})
+	}
+	if !allowUser(c, res.UserID.String()) {
+		return false, forbidden(c)
+	}
+	return true, nil
+}
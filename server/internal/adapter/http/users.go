@@ -0,0 +1,55 @@
+package http
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"resume-generator/internal/usecase"
+	"resume-generator/pkg/storage"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// UserDataHandler manages all data stored for a user.
+type UserDataHandler struct {
+	jobs       usecase.JobsRepo
+	resumes    usecase.ResumesRepo
+	store      storage.Storage
+	outputDir  string
+	staleAfter time.Duration
+}
+
+// NewUserDataHandler purges local files only inside the processor's output
+// dir, and the users' copies in its Storage. Unfinished jobs not updated
+// for staleAfter are cancelled rather than blocking a purge.
+func NewUserDataHandler(p *usecase.Processor, jobs usecase.JobsRepo, resumes usecase.ResumesRepo, staleAfter time.Duration) *UserDataHandler {
+	return &UserDataHandler{jobs: jobs, resumes: resumes, store: p.Storage(), outputDir: p.OutputDir(), staleAfter: staleAfter}
+}
+
+// PurgeUserData deletes the user's jobs, resumes and generated files and
+// reports how many were removed. It returns 409 with the ids of the user's
+// pending jobs instead; cancel them with DELETE /jobs/:id and retry. Jobs
+// left unfinished by a crashed instance are cancelled and purged.
+func (h *UserDataHandler) PurgeUserData(c *fiber.Ctx) error {
+	uid, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid userId"})
+	}
+	if !allowUser(c, uid.String()) {
+		return forbidden(c)
+	}
+
+	res, err := usecase.PurgeUserData(c.Context(), h.jobs, h.resumes, h.store, h.outputDir, uid, h.staleAfter)
+	if err != nil {
+		if errors.Is(err, usecase.ErrUserHasActiveJobs) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error(), "activeJobIds": res.ActiveJobIDs})
+		}
+		log.Printf("purge data for user %s failed: %v", uid.String(), err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to purge user data"})
+	}
+
+	log.Printf("purged data for user %s: %d jobs, %d resumes, %d files, %d stored objects", uid.String(), res.Jobs, res.Resumes, res.Files, res.Objects)
+	return c.JSON(fiber.Map{"userId": uid.String(), "deleted": res})
+}
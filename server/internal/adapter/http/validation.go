@@ -0,0 +1,232 @@
+package http
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"resume-generator/internal/usecase"
+	infra "resume-generator/pkg/infrastructure"
+	"resume-generator/templates"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Codes reported in FieldError.Code. Clients may switch on them; the
+// messages are for humans and may change.
+const (
+	CodeRequired    = "required"
+	CodeInvalidUUID = "invalid_uuid"
+	CodeUnsupported = "unsupported"
+	CodeTooLarge    = "too_large"
+	CodeInvalid     = "invalid"
+	CodeConflict    = "conflict"
+)
+
+// FieldError describes one invalid field of a request body.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Limits bound the size of start requests so a single request cannot
+// exhaust memory or the AI's token budget.
+type Limits struct {
+	// MaxBodyBytes caps the request body; a batch may hold that much per
+	// item.
+	MaxBodyBytes           int
+	MaxJobDescriptionBytes int
+	MaxProfileBytes        int
+	// MaxItems caps each list in the profile override and the project id
+	// and format lists.
+	MaxItems int
+}
+
+// DefaultLimits apply unless overridden by the environment.
+var DefaultLimits = Limits{
+	MaxBodyBytes:           256 << 10,
+	MaxJobDescriptionBytes: 32 << 10,
+	MaxProfileBytes:        64 << 10,
+	MaxItems:               100,
+}
+
+// limitsFromEnv reads MAX_BODY_BYTES, MAX_JOB_DESCRIPTION_BYTES,
+// MAX_PROFILE_BYTES and MAX_OVERRIDE_ITEMS, keeping the default for unset
+// or invalid values.
+func limitsFromEnv() Limits {
+	l := DefaultLimits
+	for env, dst := range map[string]*int{
+		"MAX_BODY_BYTES":            &l.MaxBodyBytes,
+		"MAX_JOB_DESCRIPTION_BYTES": &l.MaxJobDescriptionBytes,
+		"MAX_PROFILE_BYTES":         &l.MaxProfileBytes,
+		"MAX_OVERRIDE_ITEMS":        &l.MaxItems,
+	} {
+		if n, err := strconv.Atoi(os.Getenv(env)); err == nil && n > 0 {
+			*dst = n
+		}
+	}
+	return l
+}
+
+// bodyWithin reports whether the request body fits in max bytes. When it
+// returns false, 400 has been written and err is what the handler returns.
+func bodyWithin(c *fiber.Ctx, max int) (bool, error) {
+	if len(c.Body()) <= max {
+		return true, nil
+	}
+	return false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": fmt.Sprintf("request body exceeds %d bytes", max)})
+}
+
+// validationStatus is 400 when a field exceeds its limit and 422 for
+// every other invalid field.
+func validationStatus(errs []FieldError) int {
+	for _, e := range errs {
+		if e.Code == CodeTooLarge {
+			return fiber.StatusBadRequest
+		}
+	}
+	return fiber.StatusUnprocessableEntity
+}
+
+// defaultSupportedLanguages is used when SUPPORTED_LANGUAGES is not set.
+// Both names and codes are accepted since the value is passed to the AI as
+// written.
+var defaultSupportedLanguages = []string{
+	"English", "Portuguese", "Spanish", "French", "German", "Italian",
+	"en", "pt", "pt-BR", "es", "fr", "de", "it",
+}
+
+// supportedLanguages reads the comma-separated SUPPORTED_LANGUAGES env var,
+// always including defaultLanguage.
+func supportedLanguages(defaultLanguage string) []string {
+	langs := defaultSupportedLanguages
+	if v := os.Getenv("SUPPORTED_LANGUAGES"); v != "" {
+		langs = nil
+		for _, l := range strings.Split(v, ",") {
+			if l = strings.TrimSpace(l); l != "" {
+				langs = append(langs, l)
+			}
+		}
+	}
+	return append(append([]string(nil), langs...), defaultLanguage)
+}
+
+// languageSupported reports whether lang is in h.languages, ignoring case.
+func (h *Handler) languageSupported(lang string) bool {
+	for _, l := range h.languages {
+		if strings.EqualFold(l, lang) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateStartReq checks every field of a start request and returns the
+// decoded profile override together with all field errors found.
+// headerKey is the Idempotency-Key header, which must agree with
+// req.IdempotencyKey when both are set.
+func (h *Handler) validateStartReq(req startReq, headerKey string) (map[string]interface{}, []FieldError) {
+	var errs []FieldError
+
+	if req.UserID == "" {
+		errs = append(errs, FieldError{"userId", CodeRequired, "userId is required"})
+	} else if _, err := uuid.Parse(req.UserID); err != nil {
+		errs = append(errs, FieldError{"userId", CodeInvalidUUID, "userId must be a UUID"})
+	}
+
+	if req.JobApplicationID != "" {
+		if _, err := uuid.Parse(req.JobApplicationID); err != nil {
+			errs = append(errs, FieldError{"jobApplicationId", CodeInvalidUUID, "jobApplicationId must be a UUID"})
+		}
+	}
+
+	if req.Language != "" && !h.languageSupported(req.Language) {
+		errs = append(errs, FieldError{"language", CodeUnsupported, fmt.Sprintf("language %q is not supported", req.Language)})
+	}
+
+	if len(req.JobDescription) > h.limits.MaxJobDescriptionBytes {
+		errs = append(errs, FieldError{"jobDescription", CodeTooLarge, fmt.Sprintf("jobDescription exceeds %d bytes", h.limits.MaxJobDescriptionBytes)})
+	}
+	for _, l := range []struct {
+		field string
+		items []string
+	}{
+		{"includeProjectIds", req.IncludeProjectIDs},
+		{"excludeProjectIds", req.ExcludeProjectIDs},
+		{"formats", req.Formats},
+	} {
+		if len(l.items) > h.limits.MaxItems {
+			errs = append(errs, FieldError{l.field, CodeTooLarge, fmt.Sprintf("%s has more than %d items", l.field, h.limits.MaxItems)})
+		}
+	}
+
+	if req.Format != "" && !usecase.ValidFormat(req.Format) {
+		errs = append(errs, FieldError{"format", CodeUnsupported, "format must be one of " + strings.Join(usecase.Formats, ", ")})
+	}
+	for i, f := range req.Formats {
+		if !usecase.ValidFormat(f) {
+			errs = append(errs, FieldError{fmt.Sprintf("formats[%d]", i), CodeUnsupported, "formats must be among " + strings.Join(usecase.Formats, ", ")})
+		}
+	}
+
+	if req.PaperSize != "" {
+		if _, ok := infra.RenderOptionsForPaper(req.PaperSize); !ok {
+			errs = append(errs, FieldError{"paperSize", CodeUnsupported, `paperSize must be "A4", "Letter" or "Legal"`})
+		}
+	}
+
+	switch req.AIFlow {
+	case "", usecase.FlowSplit, usecase.FlowSingle:
+	default:
+		errs = append(errs, FieldError{"aiFlow", CodeUnsupported, `aiFlow must be "split" or "single"`})
+	}
+
+	if _, ok := templates.Lookup(req.Template); !ok {
+		errs = append(errs, FieldError{"template", CodeUnsupported, "template must be one of " + strings.Join(templates.Names(), ", ")})
+	}
+
+	if req.Theme != nil {
+		if req.Theme.AccentColor != "" && !usecase.ValidAccentColor(req.Theme.AccentColor) {
+			errs = append(errs, FieldError{"theme.accentColor", CodeInvalid, `theme.accentColor must be a hex color such as "#0a66c2"`})
+		}
+		if req.Theme.Font != "" {
+			if _, ok := usecase.LookupThemeFont(req.Theme.Font); !ok {
+				errs = append(errs, FieldError{"theme.font", CodeUnsupported, "theme.font must be one of " + strings.Join(usecase.ThemeFonts, ", ")})
+			}
+		}
+	}
+
+	if !usecase.ValidOrder(req.Order) {
+		errs = append(errs, FieldError{"order", CodeUnsupported, `order must be "recent" or "relevance"`})
+	}
+
+	bodyKey := strings.TrimSpace(req.IdempotencyKey)
+	if headerKey != "" && bodyKey != "" && headerKey != bodyKey {
+		errs = append(errs, FieldError{"idempotencyKey", CodeConflict, "idempotencyKey differs from the Idempotency-Key header"})
+	}
+
+	profile, err := parseProfile(req.Profile, h.limits.MaxProfileBytes)
+	if err != nil {
+		code := CodeInvalid
+		if len(req.Profile) > h.limits.MaxProfileBytes {
+			code = CodeTooLarge
+		}
+		errs = append(errs, FieldError{"profile", code, err.Error()})
+	}
+	keys := make([]string, 0, len(profile))
+	for k := range profile {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if items, ok := profile[k].([]interface{}); ok && len(items) > h.limits.MaxItems {
+			errs = append(errs, FieldError{"profile." + k, CodeTooLarge, fmt.Sprintf("profile.%s has more than %d items", k, h.limits.MaxItems)})
+		}
+	}
+
+	return profile, errs
+}
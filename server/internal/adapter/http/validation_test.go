@@ -0,0 +1,117 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateStartReq(t *testing.T) {
+	h := &Handler{languages: supportedLanguages("English"), limits: Limits{MaxJobDescriptionBytes: 10, MaxProfileBytes: 64, MaxItems: 2}}
+	userID := "0b5ad6c4-2d7b-4c7e-9d4f-1a2b3c4d5e6f"
+
+	tests := []struct {
+		name      string
+		req       startReq
+		headerKey string
+		want      []FieldError
+	}{
+		{name: "valid", req: startReq{UserID: userID, Language: "pt-br"}},
+		{
+			name: "missing userId",
+			req:  startReq{},
+			want: []FieldError{{"userId", CodeRequired, "userId is required"}},
+		},
+		{
+			name: "bad ids and language",
+			req:  startReq{UserID: "42", JobApplicationID: "job-1", Language: "Klingon"},
+			want: []FieldError{
+				{"userId", CodeInvalidUUID, "userId must be a UUID"},
+				{"jobApplicationId", CodeInvalidUUID, "jobApplicationId must be a UUID"},
+				{"language", CodeUnsupported, `language "Klingon" is not supported`},
+			},
+		},
+		{
+			name: "too large",
+			req: startReq{
+				UserID:            userID,
+				JobDescription:    strings.Repeat("x", 11),
+				IncludeProjectIDs: []string{"a", "b", "c"},
+				Profile:           json.RawMessage(`{"extras":["a","b","c"]}`),
+			},
+			want: []FieldError{
+				{"jobDescription", CodeTooLarge, "jobDescription exceeds 10 bytes"},
+				{"includeProjectIds", CodeTooLarge, "includeProjectIds has more than 2 items"},
+				{"profile.extras", CodeTooLarge, "profile.extras has more than 2 items"},
+			},
+		},
+		{
+			name: "unsupported values",
+			req:  startReq{UserID: userID, Format: "gif", AIFlow: "both"},
+			want: []FieldError{
+				{"format", CodeUnsupported, "format must be one of pdf, docx, txt, tex"},
+				{"aiFlow", CodeUnsupported, `aiFlow must be "split" or "single"`},
+			},
+		},
+		{
+			name:      "idempotency keys disagree",
+			req:       startReq{UserID: userID, IdempotencyKey: "a"},
+			headerKey: "b",
+			want:      []FieldError{{"idempotencyKey", CodeConflict, "idempotencyKey differs from the Idempotency-Key header"}},
+		},
+		{
+			name: "profile not an object",
+			req:  startReq{UserID: userID, Profile: json.RawMessage(`[1]`)},
+			want: []FieldError{{"profile", CodeInvalid, "profile must be a JSON object"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, got := h.validateStartReq(tt.req, tt.headerKey)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("validateStartReq() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStartJobValidationResponse(t *testing.T) {
+	app, _ := newTestApp(t)
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "invalid fields",
+			body:       `{"jobApplicationId":"x"}`,
+			wantStatus: 422,
+			wantBody:   `{"error":"validation failed","errors":[{"field":"userId","code":"required","message":"userId is required"},{"field":"jobApplicationId","code":"invalid_uuid","message":"jobApplicationId must be a UUID"}]}`,
+		},
+		{
+			name:       "malformed body",
+			body:       `{"userId":`,
+			wantStatus: 400,
+			wantBody:   `{"error":"invalid payload"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/jobs/start", bytes.NewReader([]byte(tt.body)))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.wantStatus || string(body) != tt.wantBody {
+				t.Errorf("response = %d %s, want %d %s", resp.StatusCode, body, tt.wantStatus, tt.wantBody)
+			}
+		})
+	}
+}
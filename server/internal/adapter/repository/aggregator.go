@@ -5,6 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
+	"sync"
+
+	"resume-generator/internal/model"
 
 	"github.com/jackc/pgx/v4/pgxpool"
 )
@@ -26,17 +30,32 @@ func queryJSON(ctx context.Context, pool *pgxpool.Pool, sql string, args ...inte
 	return out, nil
 }
 
-// connectPool connects using an env var and returns the pool or error.
+// connectPool returns the shared pool for the DSN in env, connecting on
+// first use.
 func connectPool(ctx context.Context, env string) (*pgxpool.Pool, error) {
 	dsn := os.Getenv(env)
 	if dsn == "" {
 		return nil, fmt.Errorf("env %s not set", env)
 	}
-	pool, err := pgxpool.Connect(ctx, dsn)
-	if err != nil {
-		return nil, err
-	}
-	return pool, nil
+	return DefaultPools.Get(ctx, dsn)
+}
+
+// aggregateGroup fetches the rows one database contributes to an
+// AggregateResult.
+type aggregateGroup struct {
+	env   string
+	fetch func(ctx context.Context, pool *pgxpool.Pool, userID string, res AggregateResult)
+}
+
+// aggregateGroups lists the source databases. They are queried
+// concurrently and merged in this order, so a later group wins when two
+// set the same key (the management DB's project case studies replace the
+// posts DB's projects).
+var aggregateGroups = []aggregateGroup{
+	{env: "AUTH_DATABASE_URL", fetch: aggregateAuth},
+	{env: "JOBS_DATABASE_URL", fetch: aggregateJobs},
+	{env: "POSTS_DATABASE_URL", fetch: aggregatePosts},
+	{env: "MGMT_DATABASE_URL", fetch: aggregateMgmt},
 }
 
 // AggregateForUser attempts to collect profile, experiences, projects,
@@ -44,117 +63,178 @@ func connectPool(ctx context.Context, env string) (*pgxpool.Pool, error) {
 // It is intentionally best-effort: missing tables or columns will be skipped
 // and the function will return whatever it could fetch.
 func AggregateForUser(ctx context.Context, userID string) (AggregateResult, error) {
-	res := AggregateResult{}
+	parts := make([]AggregateResult, len(aggregateGroups))
+	var wg sync.WaitGroup
+	for i, g := range aggregateGroups {
+		wg.Add(1)
+		go func(i int, g aggregateGroup) {
+			defer wg.Done()
+			pool, err := connectPool(ctx, g.env)
+			if err != nil {
+				return
+			}
+			part := AggregateResult{}
+			g.fetch(ctx, pool, userID, part)
+			parts[i] = part
+		}(i, g)
+	}
+	wg.Wait()
 
-	// Auth DB: users, profiles
-	if pool, err := connectPool(ctx, "AUTH_DATABASE_URL"); err == nil {
-		defer pool.Close()
-		if v, err := queryJSON(ctx, pool, `SELECT to_jsonb(u) FROM users u WHERE u.id::text=$1 LIMIT 1`, userID); err == nil {
-			res["user"] = v
+	res := AggregateResult{}
+	for _, part := range parts {
+		for k, v := range part {
+			res[k] = v
 		}
-		if v, err := queryJSON(ctx, pool, `SELECT coalesce(json_agg(row_to_json(p)), '[]') FROM profiles p WHERE p.user_id::text=$1`, userID); err == nil {
-			// store raw profiles
-			res["profiles"] = v
-			// normalize profile.social_links if present and stored as string
-			if arr, ok := v.([]interface{}); ok {
-				for i, it := range arr {
-					if pm, ok := it.(map[string]interface{}); ok {
-						if slRaw, has := pm["social_links"]; has {
-							switch s := slRaw.(type) {
-							case string:
-								var parsed map[string]string
-								if err := json.Unmarshal([]byte(s), &parsed); err == nil {
-									out := map[string]interface{}{}
-									for k, vv := range parsed {
-										out[k] = vv
-									}
-									pm["social_links"] = out
+	}
+	return res, nil
+}
+
+// aggregateAuth reads users and profiles from the auth DB.
+func aggregateAuth(ctx context.Context, pool *pgxpool.Pool, userID string, res AggregateResult) {
+	if v, err := queryJSON(ctx, pool, `SELECT to_jsonb(u) FROM users u WHERE u.id::text=$1 LIMIT 1`, userID); err == nil {
+		res["user"] = v
+	}
+	if v, err := queryJSON(ctx, pool, `SELECT coalesce(json_agg(row_to_json(p)), '[]') FROM profiles p WHERE p.user_id::text=$1`, userID); err == nil {
+		// store raw profiles
+		res["profiles"] = v
+		// normalize profile.social_links if present and stored as string,
+		// then keep only the links that are valid https URLs
+		if arr, ok := v.([]interface{}); ok {
+			for i, it := range arr {
+				if pm, ok := it.(map[string]interface{}); ok {
+					if slRaw, has := pm["social_links"]; has {
+						switch s := slRaw.(type) {
+						case string:
+							var parsed map[string]string
+							if err := json.Unmarshal([]byte(s), &parsed); err == nil {
+								out := map[string]interface{}{}
+								for k, vv := range parsed {
+									out[k] = vv
 								}
-							case map[string]interface{}:
-								// already an object, keep as-is
-							default:
-								if b, err := json.Marshal(s); err == nil {
-									var parsed map[string]interface{}
-									if err2 := json.Unmarshal(b, &parsed); err2 == nil {
-										pm["social_links"] = parsed
-									}
+								pm["social_links"] = out
+							}
+						case map[string]interface{}:
+							// already an object, keep as-is
+						default:
+							if b, err := json.Marshal(s); err == nil {
+								var parsed map[string]interface{}
+								if err2 := json.Unmarshal(b, &parsed); err2 == nil {
+									pm["social_links"] = parsed
 								}
 							}
 						}
-						arr[i] = pm
-					} else {
-						arr[i] = it
+						if links, ok := pm["social_links"].(map[string]interface{}); ok {
+							if links = model.NormalizeSocialLinks(links); links != nil {
+								pm["social_links"] = links
+							} else {
+								delete(pm, "social_links")
+							}
+						}
 					}
+					// surface the avatar as photo_url, falling back to the user's
+					if photo := avatarURL(pm); photo != "" {
+						pm["photo_url"] = photo
+					} else if um, ok := res["user"].(map[string]interface{}); ok {
+						if photo := avatarURL(um); photo != "" {
+							pm["photo_url"] = photo
+						}
+					}
+					arr[i] = pm
+				} else {
+					arr[i] = it
 				}
-				res["profiles"] = arr
 			}
+			res["profiles"] = arr
 		}
 	}
+}
 
-	// Jobs DB: resumes, resume_jobs, job_applications
-	if pool, err := connectPool(ctx, "JOBS_DATABASE_URL"); err == nil {
-		defer pool.Close()
-		if v, err := queryJSON(ctx, pool, `SELECT coalesce(json_agg(row_to_json(r)), '[]') FROM resumes r WHERE r.user_id::text=$1`, userID); err == nil {
-			res["resumes"] = v
-		}
-		if v, err := queryJSON(ctx, pool, `SELECT coalesce(json_agg(row_to_json(j)), '[]') FROM job_applications j WHERE j.user_id::text=$1`, userID); err == nil {
-			res["job_applications"] = v
+// avatarColumns are the row keys checked, in order, for a profile photo.
+var avatarColumns = []string{"photo_url", "avatar_url", "avatar", "picture", "image_url"}
+
+// avatarURL returns the first http(s) URL found under avatarColumns in row,
+// or "".
+func avatarURL(row map[string]interface{}) string {
+	for _, k := range avatarColumns {
+		if s, ok := row[k].(string); ok && model.IsHTTPURL(s) {
+			return strings.TrimSpace(s)
 		}
 	}
+	return ""
+}
 
-	// Posts DB: projects, publications, case studies, impact metrics
-	if pool, err := connectPool(ctx, "POSTS_DATABASE_URL"); err == nil {
-		defer pool.Close()
-		if v, err := queryJSON(ctx, pool, `SELECT coalesce(json_agg(row_to_json(p)), '[]') FROM projects p WHERE p.owner_id::text=$1 OR p.user_id::text=$1`, userID); err == nil {
-			res["projects"] = v
-		}
-		if v, err := queryJSON(ctx, pool, `SELECT coalesce(json_agg(row_to_json(c)), '[]') FROM case_studies c WHERE c.author_id::text=$1 OR c.user_id::text=$1`, userID); err == nil {
-			res["case_studies"] = v
-		}
-		// publications table uses `user_id`; some schemas do not have `author_id`.
-		if v, err := queryJSON(ctx, pool, `SELECT coalesce(json_agg(row_to_json(pub)), '[]') FROM publications pub WHERE pub.user_id::text=$1`, userID); err == nil {
-			res["publications"] = v
-		}
-		if v, err := queryJSON(ctx, pool, `SELECT coalesce(json_agg(row_to_json(m)), '[]') FROM impact_metrics m WHERE m.user_id::text=$1`, userID); err == nil {
-			res["impact_metrics"] = v
-		}
+// aggregateJobs reads resumes and job_applications from the jobs DB.
+func aggregateJobs(ctx context.Context, pool *pgxpool.Pool, userID string, res AggregateResult) {
+	if v, err := queryJSON(ctx, pool, `SELECT coalesce(json_agg(row_to_json(r)), '[]') FROM resumes r WHERE r.user_id::text=$1`, userID); err == nil {
+		res["resumes"] = v
 	}
+	if v, err := queryJSON(ctx, pool, `SELECT coalesce(json_agg(row_to_json(j)), '[]') FROM job_applications j WHERE j.user_id::text=$1`, userID); err == nil {
+		res["job_applications"] = v
+	}
+}
 
-	// Management DB: experiences, testimonials, technologies, projects, case studies
-	if pool, err := connectPool(ctx, "MGMT_DATABASE_URL"); err == nil {
-		defer pool.Close()
-		if v, err := queryJSON(ctx, pool, `SELECT coalesce(json_agg(row_to_json(e)), '[]') FROM experiences e WHERE e.user_id::text=$1`, userID); err == nil {
-			res["experiences"] = v
-		}
-		if v, err := queryJSON(ctx, pool, `SELECT coalesce(json_agg(row_to_json(t)), '[]') FROM testimonials t WHERE t.user_id::text=$1 OR t.author_id::text=$1`, userID); err == nil {
-			res["testimonials"] = v
-		}
-		if v, err := queryJSON(ctx, pool, `SELECT coalesce(json_agg(row_to_json(pt)), '[]') FROM project_technologies pt WHERE pt.user_id::text=$1 OR pt.project_owner_id::text=$1`, userID); err == nil {
-			res["project_technologies"] = v
-		}
-		// Fetch project case studies and store as "projects" for resume generation
-		if v, err := queryJSON(ctx, pool, `SELECT coalesce(json_agg(row_to_json(cs)), '[]') FROM project_case_studies cs WHERE cs.project_id IN (SELECT id FROM projects WHERE user_id::text=$1)`, userID); err == nil {
-			res["projects"] = v
-		}
-		// Attempt to fetch certifications from the management DB (optional)
-		if v, err := queryJSON(ctx, pool, `SELECT coalesce(json_agg(row_to_json(c)), '[]') FROM certifications c WHERE c.user_id::text=$1`, userID); err == nil {
-			res["certifications"] = v
-		}
+// aggregatePosts reads projects, publications, case studies and impact
+// metrics from the posts DB.
+func aggregatePosts(ctx context.Context, pool *pgxpool.Pool, userID string, res AggregateResult) {
+	if v, err := queryJSON(ctx, pool, `SELECT coalesce(json_agg(row_to_json(p)), '[]') FROM projects p WHERE p.owner_id::text=$1 OR p.user_id::text=$1`, userID); err == nil {
+		res["projects"] = v
+	}
+	if v, err := queryJSON(ctx, pool, `SELECT coalesce(json_agg(row_to_json(c)), '[]') FROM case_studies c WHERE c.author_id::text=$1 OR c.user_id::text=$1`, userID); err == nil {
+		res["case_studies"] = v
+	}
+	// publications table uses `user_id`; some schemas do not have `author_id`.
+	if v, err := queryJSON(ctx, pool, `SELECT coalesce(json_agg(row_to_json(pub)), '[]') FROM publications pub WHERE pub.user_id::text=$1`, userID); err == nil {
+		res["publications"] = v
+	}
+	if v, err := queryJSON(ctx, pool, `SELECT coalesce(json_agg(row_to_json(m)), '[]') FROM impact_metrics m WHERE m.user_id::text=$1`, userID); err == nil {
+		res["impact_metrics"] = v
+	}
+}
 
-		// Attempt to fetch extras from the management DB (optional)
-		if v, err := queryJSON(ctx, pool, `SELECT coalesce(json_agg(row_to_json(e)), '[]') FROM extras e WHERE e.user_id::text=$1`, userID); err == nil {
-			res["extras"] = v
-		}
+// aggregateMgmt reads experiences, testimonials, technologies, project case
+// studies, certifications, education and extras from the management DB.
+func aggregateMgmt(ctx context.Context, pool *pgxpool.Pool, userID string, res AggregateResult) {
+	if v, err := queryJSON(ctx, pool, `SELECT coalesce(json_agg(row_to_json(e)), '[]') FROM experiences e WHERE e.user_id::text=$1`, userID); err == nil {
+		res["experiences"] = v
+	}
+	if v, err := queryJSON(ctx, pool, `SELECT coalesce(json_agg(row_to_json(t)), '[]') FROM testimonials t WHERE t.user_id::text=$1 OR t.author_id::text=$1`, userID); err == nil {
+		res["testimonials"] = v
+	}
+	if v, err := queryJSON(ctx, pool, `SELECT coalesce(json_agg(row_to_json(pt)), '[]') FROM project_technologies pt WHERE pt.user_id::text=$1 OR pt.project_owner_id::text=$1`, userID); err == nil {
+		res["project_technologies"] = v
+	}
+	// Technologies used across the user's projects seed the categorized
+	// skills section
+	if v, err := queryJSON(ctx, pool, `SELECT coalesce(json_agg(row_to_json(t)), '[]') FROM technologies t WHERE t.id IN (SELECT pt.technology_id FROM project_technologies pt WHERE pt.user_id::text=$1 OR pt.project_owner_id::text=$1)`, userID); err == nil {
+		res["technologies"] = v
+	}
+	if skills := seedSkills(res["technologies"], res["project_technologies"]); len(skills) > 0 {
+		res["skills"] = skills
+	}
+	// Fetch project case studies and store as "projects" for resume generation
+	if v, err := queryJSON(ctx, pool, `SELECT coalesce(json_agg(row_to_json(cs)), '[]') FROM project_case_studies cs WHERE cs.project_id IN (SELECT id FROM projects WHERE user_id::text=$1)`, userID); err == nil {
+		res["projects"] = v
+	}
+	// Attempt to fetch certifications from the management DB (optional)
+	if v, err := queryJSON(ctx, pool, `SELECT coalesce(json_agg(row_to_json(c)), '[]') FROM certifications c WHERE c.user_id::text=$1`, userID); err == nil {
+		res["certifications"] = v
 	}
 
-	return res, nil
+	// Attempt to fetch education history from the management DB (optional)
+	if v, err := queryJSON(ctx, pool, `SELECT coalesce(json_agg(row_to_json(ed)), '[]') FROM education ed WHERE ed.user_id::text=$1`, userID); err == nil {
+		res["education"] = v
+	}
+
+	// Attempt to fetch extras from the management DB (optional)
+	if v, err := queryJSON(ctx, pool, `SELECT coalesce(json_agg(row_to_json(e)), '[]') FROM extras e WHERE e.user_id::text=$1`, userID); err == nil {
+		res["extras"] = v
+	}
 }
 
 // GetJobApplicationByID fetches a single job_application row by its text uuid.
 func GetJobApplicationByID(ctx context.Context, id string) (interface{}, error) {
 	// connect to Jobs DB and fetch single json object
 	if pool, err := connectPool(ctx, "JOBS_DATABASE_URL"); err == nil {
-		defer pool.Close()
 		var raw []byte
 		err := pool.QueryRow(ctx, `SELECT to_jsonb(j) FROM job_applications j WHERE j.id::text=$1 LIMIT 1`, id).Scan(&raw)
 		if err != nil {
@@ -169,3 +249,57 @@ func GetJobApplicationByID(ctx context.Context, id string) (interface{}, error)
 		return nil, err
 	}
 }
+
+// seedSkills groups technology names by category into the resume's
+// [{category, items}] skills shape. Rows come from the technologies table
+// and, when that is unavailable, from project_technologies. Names are
+// de-duplicated case-insensitively; rows without a category land in "tools".
+func seedSkills(technologies, projectTechs interface{}) []interface{} {
+	rows, _ := technologies.([]interface{})
+	if len(rows) == 0 {
+		rows, _ = projectTechs.([]interface{})
+	}
+
+	var order []string
+	groups := map[string][]string{}
+	seen := map[string]bool{}
+	for _, r := range rows {
+		m, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name := ""
+		for _, k := range []string{"name", "technology_name", "technology", "title"} {
+			if s, ok := m[k].(string); ok && strings.TrimSpace(s) != "" {
+				name = strings.TrimSpace(s)
+				break
+			}
+		}
+		if name == "" || seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+
+		cat := "tools"
+		for _, k := range []string{"category", "type", "kind"} {
+			if s, ok := m[k].(string); ok && strings.TrimSpace(s) != "" {
+				cat = strings.ToLower(strings.TrimSpace(s))
+				break
+			}
+		}
+		if _, ok := groups[cat]; !ok {
+			order = append(order, cat)
+		}
+		groups[cat] = append(groups[cat], name)
+	}
+
+	out := make([]interface{}, 0, len(order))
+	for _, cat := range order {
+		items := make([]interface{}, 0, len(groups[cat]))
+		for _, it := range groups[cat] {
+			items = append(items, it)
+		}
+		out = append(out, map[string]interface{}{"category": cat, "items": items})
+	}
+	return out
+}
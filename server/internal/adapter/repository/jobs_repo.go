@@ -3,13 +3,19 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
 	"strings"
+	"time"
 
 	"resume-generator/internal/domain"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
+
+	"resume-generator/pkg/logctx"
 )
 
 type JobsRepo struct {
@@ -25,36 +31,59 @@ func (r *JobsRepo) Save(ctx context.Context, j *domain.ResumeJob) error {
 		return nil
 	}
 
+	// a resumes row is written once the job has completed with an
+	// artifact; the saves before that would list half-made resumes. Its id
+	// is set first so the job row references it.
+	filePath, _ := j.Metadata["generated_html"].(string)
+	withResume := j.Status == "completed" && filePath != ""
+	if withResume && j.ResumeID == nil {
+		id := uuid.New()
+		j.ResumeID = &id
+	}
+
 	metaB, _ := json.Marshal(j.Metadata)
 
-	_, err := r.pool.Exec(ctx, `INSERT INTO resume_jobs (id, user_id, job_description, status, metadata, resume_id, created_at, updated_at)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
-		ON CONFLICT (id) DO UPDATE SET user_id = EXCLUDED.user_id, job_description = EXCLUDED.job_description, status = EXCLUDED.status, metadata = EXCLUDED.metadata, resume_id = EXCLUDED.resume_id, updated_at = EXCLUDED.updated_at`,
-		j.ID, j.UserID, j.JobDescription, j.Status, metaB, j.ResumeID, j.CreatedAt, j.UpdatedAt)
+	var idemKey interface{}
+	if j.IdempotencyKey != "" {
+		idemKey = j.IdempotencyKey
+	}
+
+	var tpl interface{}
+	if j.Template != "" {
+		tpl = j.Template
+	}
+
+	_, err := r.pool.Exec(ctx, `INSERT INTO resume_jobs (id, user_id, job_description, status, metadata, resume_id, idempotency_key, language, template, created_at, updated_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
+		ON CONFLICT (id) DO UPDATE SET user_id = EXCLUDED.user_id, job_description = EXCLUDED.job_description, status = EXCLUDED.status, metadata = EXCLUDED.metadata, resume_id = EXCLUDED.resume_id, language = EXCLUDED.language, template = EXCLUDED.template, updated_at = EXCLUDED.updated_at`,
+		j.ID, j.UserID, j.JobDescription, j.Status, metaB, j.ResumeID, idemKey, j.Language, tpl, j.CreatedAt, j.UpdatedAt)
 
 	if err != nil {
 		return err
 	}
 
-	// Best-effort: persist a resume row (including extras_raw and extras JSONB)
-	var resumeID uuid.UUID
-	if j.ResumeID != nil {
-		resumeID = *j.ResumeID
-	} else {
-		resumeID = uuid.New()
-		j.ResumeID = &resumeID
+	if !withResume {
+		return nil
 	}
+	// Best-effort: persist a resume row (including extras_raw and extras JSONB)
+	resumeID := *j.ResumeID
 
-	filePath := ""
+	// file_size is the bytes on disk of the HTML plus the PDF; the PDF is
+	// missing when rendering failed
 	fileName := ""
-	fileSize := 0
-	if j.Metadata != nil {
-		if p, ok := j.Metadata["generated_html"].(string); ok && p != "" {
-			filePath = p
-			parts := strings.Split(p, "/")
-			if len(parts) > 0 {
-				fileName = parts[len(parts)-1]
-			}
+	var pdfPath interface{}
+	var fileSize int64
+	parts := strings.Split(filePath, "/")
+	if len(parts) > 0 {
+		fileName = parts[len(parts)-1]
+	}
+	if fi, err := os.Stat(filePath); err == nil {
+		fileSize += fi.Size()
+	}
+	if p, ok := j.Metadata["generated_pdf"].(string); ok && p != "" {
+		pdfPath = p
+		if fi, err := os.Stat(p); err == nil {
+			fileSize += fi.Size()
 		}
 	}
 
@@ -92,12 +121,278 @@ func (r *JobsRepo) Save(ctx context.Context, j *domain.ResumeJob) error {
 		}
 	}
 
-	if _, e := r.pool.Exec(ctx, `INSERT INTO resumes (id, user_id, title, file_name, file_path, file_size, extras_raw, extras, created_at, updated_at)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
-		ON CONFLICT (id) DO UPDATE SET title = EXCLUDED.title, file_name = EXCLUDED.file_name, file_path = EXCLUDED.file_path, file_size = EXCLUDED.file_size, extras_raw = EXCLUDED.extras_raw, extras = EXCLUDED.extras, updated_at = EXCLUDED.updated_at`,
-		resumeID, j.UserID, title, fileName, filePath, fileSize, extrasRaw, extrasJSON, j.CreatedAt, j.UpdatedAt); e != nil {
-		fmt.Printf("jobs_repo: unable to upsert resumes row (non-fatal): %v\n", e)
+	// a job loaded back from the DB has no resume JSON; saving it must not
+	// clear the stored one
+	var resumeJSON []byte
+	if j.Resume != nil {
+		if b, e := json.Marshal(j.Resume); e == nil {
+			resumeJSON = b
+		}
+	}
+
+	var genMetaJSON []byte
+	if gm := generationMeta(j.Metadata); gm != nil {
+		genMetaJSON, _ = json.Marshal(gm)
+	}
+
+	if _, e := r.pool.Exec(ctx, `INSERT INTO resumes (id, user_id, title, file_name, file_path, file_size, pdf_path, extras_raw, extras, resume_json, generation_meta, created_at, updated_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13)
+		ON CONFLICT (id) DO UPDATE SET title = EXCLUDED.title, file_name = EXCLUDED.file_name, file_path = EXCLUDED.file_path, file_size = EXCLUDED.file_size, pdf_path = EXCLUDED.pdf_path, extras_raw = EXCLUDED.extras_raw, extras = EXCLUDED.extras, resume_json = coalesce(EXCLUDED.resume_json, resumes.resume_json), generation_meta = coalesce(EXCLUDED.generation_meta, resumes.generation_meta), updated_at = EXCLUDED.updated_at`,
+		resumeID, j.UserID, title, fileName, filePath, fileSize, pdfPath, extrasRaw, extrasJSON, resumeJSON, genMetaJSON, j.CreatedAt, j.UpdatedAt); e != nil {
+		logctx.Warnf(ctx, "jobs_repo: unable to upsert resumes row (non-fatal): %v", e)
 	}
 
 	return nil
 }
+
+// generationMeta collects the AI provenance recorded in job metadata, or
+// returns nil before the AI step has run. Metadata loaded back from the DB
+// holds []interface{} where Process stored []string.
+func generationMeta(meta map[string]interface{}) *domain.GenerationMeta {
+	synth, ok := meta["ai_synthesized"].(bool)
+	if !ok {
+		return nil
+	}
+	return &domain.GenerationMeta{
+		AIWarnings:        stringSlice(meta["ai_warnings"]),
+		AISynthesized:     synth,
+		SynthesizedFields: stringSlice(meta["synthesized_fields"]),
+		SourcedFields:     stringSlice(meta["sourced_fields"]),
+	}
+}
+
+// stringSlice returns the strings in v, which may be a []string or a
+// decoded JSON array; anything else yields an empty slice.
+func stringSlice(v interface{}) []string {
+	out := []string{}
+	switch t := v.(type) {
+	case []string:
+		out = append(out, t...)
+	case []interface{}:
+		for _, e := range t {
+			if s, ok := e.(string); ok {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
+
+// jobColumns is the column list read by scanJob.
+const jobColumns = `id, user_id, coalesce(job_description, ''), status, metadata, resume_id, coalesce(idempotency_key, ''), coalesce(language, ''), coalesce(template, ''), created_at, updated_at`
+
+// scanJob reads a resume_jobs row selected with jobColumns.
+func scanJob(row pgx.Row) (*domain.ResumeJob, error) {
+	j := &domain.ResumeJob{}
+	var metaB []byte
+	if err := row.Scan(&j.ID, &j.UserID, &j.JobDescription, &j.Status, &metaB, &j.ResumeID, &j.IdempotencyKey, &j.Language, &j.Template, &j.CreatedAt, &j.UpdatedAt); err != nil {
+		return nil, err
+	}
+	j.Metadata = map[string]interface{}{}
+	if len(metaB) > 0 {
+		if err := json.Unmarshal(metaB, &j.Metadata); err != nil {
+			return nil, fmt.Errorf("decode job metadata: %w", err)
+		}
+	}
+	return j, nil
+}
+
+// GetByID loads a single job from resume_jobs. It returns
+// domain.ErrJobNotFound when the id is unknown or the jobs DB is not
+// configured.
+func (r *JobsRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.ResumeJob, error) {
+	if r.pool == nil {
+		return nil, domain.ErrJobNotFound
+	}
+
+	j, err := scanJob(r.pool.QueryRow(ctx, `SELECT `+jobColumns+` FROM resume_jobs WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrJobNotFound
+		}
+		return nil, err
+	}
+	return j, nil
+}
+
+// FindByResumeID returns the job that produced the resume. It returns
+// domain.ErrJobNotFound when no job references it.
+func (r *JobsRepo) FindByResumeID(ctx context.Context, resumeID uuid.UUID) (*domain.ResumeJob, error) {
+	if r.pool == nil {
+		return nil, domain.ErrJobNotFound
+	}
+
+	j, err := scanJob(r.pool.QueryRow(ctx, `SELECT `+jobColumns+` FROM resume_jobs WHERE resume_id = $1 ORDER BY updated_at DESC LIMIT 1`, resumeID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrJobNotFound
+		}
+		return nil, err
+	}
+	return j, nil
+}
+
+// FindByIdempotencyKey returns the user's job created with the given
+// idempotency key, or domain.ErrJobNotFound.
+func (r *JobsRepo) FindByIdempotencyKey(ctx context.Context, userID uuid.UUID, key string) (*domain.ResumeJob, error) {
+	if r.pool == nil {
+		return nil, domain.ErrJobNotFound
+	}
+
+	j, err := scanJob(r.pool.QueryRow(ctx, `SELECT `+jobColumns+` FROM resume_jobs WHERE user_id = $1 AND idempotency_key = $2`, userID, key))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrJobNotFound
+		}
+		return nil, err
+	}
+	return j, nil
+}
+
+// ReleaseIdempotencyKey clears the idempotency key of a job so the key can be
+// reused once it has expired.
+func (r *JobsRepo) ReleaseIdempotencyKey(ctx context.Context, id uuid.UUID) error {
+	if r.pool == nil {
+		return nil
+	}
+	_, err := r.pool.Exec(ctx, `UPDATE resume_jobs SET idempotency_key = NULL WHERE id = $1`, id)
+	return err
+}
+
+// FindStale returns up to limit unfinished jobs not updated since before,
+// oldest first.
+func (r *JobsRepo) FindStale(ctx context.Context, before time.Time, limit int) ([]*domain.ResumeJob, error) {
+	out := []*domain.ResumeJob{}
+	if r.pool == nil {
+		return out, nil
+	}
+
+	rows, err := r.pool.Query(ctx, `SELECT `+jobColumns+`
+		FROM resume_jobs
+		WHERE status = ANY($3) AND updated_at < $1
+		ORDER BY updated_at ASC
+		LIMIT $2`, before, limit, domain.ActiveStatuses)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, j)
+	}
+	return out, rows.Err()
+}
+
+// ClaimStale marks a stale job as claimed by instance and bumps updated_at.
+// The update only matches while the job is unfinished and not updated
+// since before, so when several instances race for the same job exactly one
+// gets true.
+func (r *JobsRepo) ClaimStale(ctx context.Context, id uuid.UUID, instance string, before time.Time) (bool, error) {
+	if r.pool == nil {
+		return false, nil
+	}
+	tag, err := r.pool.Exec(ctx, `UPDATE resume_jobs
+		SET claimed_by = $2, claimed_at = now(), updated_at = now()
+		WHERE id = $1 AND status = ANY($4) AND updated_at < $3`, id, instance, before, domain.ActiveStatuses)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// ClaimRetry moves a job from status from back to pending and bumps
+// updated_at. The update only matches while the job still has that status,
+// so when several retries of the same job race exactly one gets true.
+func (r *JobsRepo) ClaimRetry(ctx context.Context, id uuid.UUID, from string) (bool, error) {
+	if r.pool == nil {
+		return false, nil
+	}
+	tag, err := r.pool.Exec(ctx, `UPDATE resume_jobs SET status = $3, updated_at = now() WHERE id = $1 AND status = $2`, id, from, domain.StatusPending)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// UpdateStatus sets the status of a job and bumps updated_at without
+// rewriting the rest of the row. A non-nil progress replaces
+// metadata.progress.
+func (r *JobsRepo) UpdateStatus(ctx context.Context, id uuid.UUID, status string, progress map[string]interface{}) error {
+	if r.pool == nil {
+		return nil
+	}
+	if progress == nil {
+		_, err := r.pool.Exec(ctx, `UPDATE resume_jobs SET status = $2, updated_at = now() WHERE id = $1`, id, status)
+		return err
+	}
+	progressB, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+	_, err = r.pool.Exec(ctx, `UPDATE resume_jobs
+		SET status = $2, metadata = jsonb_set(coalesce(metadata, '{}'::jsonb), '{progress}', $3::jsonb), updated_at = now()
+		WHERE id = $1`, id, status, progressB)
+	return err
+}
+
+// SetMetadata sets metadata[key] of a job to value and bumps updated_at
+// without rewriting the rest of the row.
+func (r *JobsRepo) SetMetadata(ctx context.Context, id uuid.UUID, key string, value interface{}) error {
+	if r.pool == nil {
+		return nil
+	}
+	valueB, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	_, err = r.pool.Exec(ctx, `UPDATE resume_jobs
+		SET metadata = jsonb_set(coalesce(metadata, '{}'::jsonb), ARRAY[$2::text], $3::jsonb), updated_at = now()
+		WHERE id = $1`, id, key, valueB)
+	return err
+}
+
+// DeleteJobsByUser removes every job of the user and returns how many rows
+// were deleted.
+func (r *JobsRepo) DeleteJobsByUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	if r.pool == nil {
+		return 0, nil
+	}
+	tag, err := r.pool.Exec(ctx, `DELETE FROM resume_jobs WHERE user_id = $1`, userID)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// ListByUser returns the user's jobs ordered by created_at descending (newest
+// first, ties broken by id) so clients can page through them with a stable
+// offset. An empty filter.Status matches every status.
+func (r *JobsRepo) ListByUser(ctx context.Context, userID uuid.UUID, filter domain.JobFilter, page domain.Page) ([]*domain.ResumeJob, error) {
+	out := []*domain.ResumeJob{}
+	if r.pool == nil {
+		return out, nil
+	}
+
+	rows, err := r.pool.Query(ctx, `SELECT `+jobColumns+`
+		FROM resume_jobs
+		WHERE user_id = $1 AND ($2 = '' OR status = $2) AND (NOT $5 OR status = ANY($6))
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3 OFFSET $4`, userID, filter.Status, page.Limit, page.Offset, filter.Active, domain.ActiveStatuses)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, j)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// LabelsRepo persists translated section labels in label_translations. It
+// satisfies ai.LabelStore.
+type LabelsRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewLabelsRepo(pool *pgxpool.Pool) *LabelsRepo {
+	return &LabelsRepo{pool: pool}
+}
+
+// LoadLabels returns the stored labels for language; ok is false when there
+// is no row or the jobs DB is not configured.
+func (r *LabelsRepo) LoadLabels(ctx context.Context, language string) (map[string]string, bool, error) {
+	if r.pool == nil {
+		return nil, false, nil
+	}
+	var raw []byte
+	err := r.pool.QueryRow(ctx, `SELECT labels FROM label_translations WHERE language = $1`, language).Scan(&raw)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	var labels map[string]string
+	if err := json.Unmarshal(raw, &labels); err != nil {
+		return nil, false, err
+	}
+	return labels, true, nil
+}
+
+// SaveLabels upserts the labels for language.
+func (r *LabelsRepo) SaveLabels(ctx context.Context, language string, labels map[string]string) error {
+	if r.pool == nil {
+		return nil
+	}
+	b, err := json.Marshal(labels)
+	if err != nil {
+		return err
+	}
+	_, err = r.pool.Exec(ctx, `INSERT INTO label_translations (language, labels, updated_at)
+		VALUES ($1, $2, now())
+		ON CONFLICT (language) DO UPDATE SET labels = EXCLUDED.labels, updated_at = EXCLUDED.updated_at`, language, b)
+	return err
+}
+
+// DeleteLabels removes the row for language, or every row when language is
+// empty.
+func (r *LabelsRepo) DeleteLabels(ctx context.Context, language string) error {
+	if r.pool == nil {
+		return nil
+	}
+	_, err := r.pool.Exec(ctx, `DELETE FROM label_translations WHERE $1 = '' OR language = $1`, language)
+	return err
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// PoolCache keeps one long-lived pool per DSN so aggregation does not
+// connect and close pools on every job.
+type PoolCache struct {
+	mu    sync.Mutex
+	pools map[string]*pgxpool.Pool
+	// owned marks pools created by the cache, which Close releases;
+	// pools handed in with Set are closed by their owner.
+	owned map[string]bool
+	// checked records when each pool last answered a ping.
+	checked map[string]time.Time
+}
+
+// poolHealthInterval is how long a pool is trusted after a successful ping
+// before Get pings it again; poolPingTimeout bounds that ping.
+const (
+	poolHealthInterval = 30 * time.Second
+	poolPingTimeout    = 2 * time.Second
+)
+
+func NewPoolCache() *PoolCache {
+	return &PoolCache{pools: map[string]*pgxpool.Pool{}, owned: map[string]bool{}, checked: map[string]time.Time{}}
+}
+
+// DefaultPools is the cache used by AggregateForUser and
+// GetJobApplicationByID.
+var DefaultPools = NewPoolCache()
+
+// Set injects an existing pool for dsn, e.g. the jobs pool main already
+// holds. The caller stays responsible for closing it.
+func (c *PoolCache) Set(dsn string, pool *pgxpool.Pool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if old, ok := c.pools[dsn]; ok && c.owned[dsn] && old != pool {
+		old.Close()
+	}
+	c.pools[dsn] = pool
+	delete(c.owned, dsn)
+	c.checked[dsn] = time.Now()
+}
+
+// Get returns the pool for dsn, connecting on first use. Failed connections
+// are not cached, so the next call retries. A cached pool that has not been
+// checked for poolHealthInterval is pinged first; when the ping fails a pool
+// the cache owns is closed and replaced.
+func (c *PoolCache) Get(ctx context.Context, dsn string) (*pgxpool.Pool, error) {
+	c.mu.Lock()
+	pool, ok := c.pools[dsn]
+	fresh := time.Since(c.checked[dsn]) < poolHealthInterval
+	owned := c.owned[dsn]
+	c.mu.Unlock()
+	if ok {
+		if fresh || c.ping(ctx, dsn, pool) || !owned {
+			return pool, nil
+		}
+		c.mu.Lock()
+		stale := c.pools[dsn] == pool
+		if stale {
+			delete(c.pools, dsn)
+			delete(c.owned, dsn)
+			delete(c.checked, dsn)
+		}
+		c.mu.Unlock()
+		if stale {
+			// Close waits for in-flight queries, so not under c.mu
+			pool.Close()
+		}
+	}
+
+	pool, err := pgxpool.Connect(ctx, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if existing, ok := c.pools[dsn]; ok {
+		// lost a race with a concurrent Get
+		pool.Close()
+		return existing, nil
+	}
+	c.pools[dsn] = pool
+	c.owned[dsn] = true
+	c.checked[dsn] = time.Now()
+	return pool, nil
+}
+
+// ping checks pool and records the time of a successful answer.
+func (c *PoolCache) ping(ctx context.Context, dsn string, pool *pgxpool.Pool) bool {
+	pctx, cancel := context.WithTimeout(ctx, poolPingTimeout)
+	defer cancel()
+	if err := pool.Ping(pctx); err != nil {
+		return false
+	}
+	c.mu.Lock()
+	c.checked[dsn] = time.Now()
+	c.mu.Unlock()
+	return true
+}
+
+// Close closes every pool the cache connected itself.
+func (c *PoolCache) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for dsn, pool := range c.pools {
+		if c.owned[dsn] {
+			pool.Close()
+		}
+	}
+	c.pools = map[string]*pgxpool.Pool{}
+	c.owned = map[string]bool{}
+	c.checked = map[string]time.Time{}
+}
+
+// CloseAllPools closes the pools DefaultPools connected. main calls it on
+// shutdown.
+func CloseAllPools() {
+	DefaultPools.Close()
+}
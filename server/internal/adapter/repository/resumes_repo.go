@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"resume-generator/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// ResumesRepo reads the resumes rows written by JobsRepo.Save.
+type ResumesRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewResumesRepo(pool *pgxpool.Pool) *ResumesRepo {
+	return &ResumesRepo{pool: pool}
+}
+
+// resumeColumns is the column list read by scanResume.
+const resumeColumns = `id, user_id, coalesce(title, ''), coalesce(file_name, ''), coalesce(file_path, ''), coalesce(file_size, 0), coalesce(pdf_path, ''), extras, generation_meta, created_at, coalesce(updated_at, created_at)`
+
+// scanResume reads a resumes row selected with resumeColumns.
+func scanResume(row pgx.Row) (*domain.Resume, error) {
+	r := &domain.Resume{}
+	var extrasB, genMetaB []byte
+	if err := row.Scan(&r.ID, &r.UserID, &r.Title, &r.FileName, &r.FilePath, &r.FileSize, &r.PDFPath, &extrasB, &genMetaB, &r.CreatedAt, &r.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if len(extrasB) > 0 {
+		if err := json.Unmarshal(extrasB, &r.Extras); err != nil {
+			return nil, fmt.Errorf("decode resume extras: %w", err)
+		}
+	}
+	if len(genMetaB) > 0 {
+		r.GenerationMeta = &domain.GenerationMeta{}
+		if err := json.Unmarshal(genMetaB, r.GenerationMeta); err != nil {
+			return nil, fmt.Errorf("decode resume generation_meta: %w", err)
+		}
+	}
+	return r, nil
+}
+
+// GetByID loads a single resume. It returns domain.ErrResumeNotFound when
+// the id is unknown or the jobs DB is not configured.
+func (r *ResumesRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Resume, error) {
+	if r.pool == nil {
+		return nil, domain.ErrResumeNotFound
+	}
+
+	res, err := scanResume(r.pool.QueryRow(ctx, `SELECT `+resumeColumns+` FROM resumes WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrResumeNotFound
+		}
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetJSON returns the structured resume stored for id. It returns
+// domain.ErrResumeNotFound for unknown ids and domain.ErrResumeJSONNotFound
+// when the row has no JSON.
+func (r *ResumesRepo) GetJSON(ctx context.Context, id uuid.UUID) (map[string]interface{}, error) {
+	if r.pool == nil {
+		return nil, domain.ErrResumeNotFound
+	}
+
+	var raw []byte
+	if err := r.pool.QueryRow(ctx, `SELECT resume_json FROM resumes WHERE id = $1`, id).Scan(&raw); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrResumeNotFound
+		}
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, domain.ErrResumeJSONNotFound
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("decode resume json: %w", err)
+	}
+	return out, nil
+}
+
+// ListByUser returns the user's resumes, newest first, optionally filtered
+// by a case-insensitive title substring. Rows without a file, written for
+// unfinished jobs by earlier versions, are skipped.
+func (r *ResumesRepo) ListByUser(ctx context.Context, userID uuid.UUID, filter domain.ResumeFilter, page domain.Page) ([]*domain.Resume, error) {
+	out := []*domain.Resume{}
+	if r.pool == nil {
+		return out, nil
+	}
+
+	rows, err := r.pool.Query(ctx, `SELECT `+resumeColumns+`
+		FROM resumes
+		WHERE user_id = $1 AND file_path <> '' AND ($2 = '' OR title ILIKE '%' || $2 || '%')
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3 OFFSET $4`, userID, filter.Title, page.Limit, page.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		res, err := scanResume(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, res)
+	}
+	return out, rows.Err()
+}
+
+// DeleteResumesByUser removes every resume of the user and returns how many
+// rows were deleted.
+func (r *ResumesRepo) DeleteResumesByUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	if r.pool == nil {
+		return 0, nil
+	}
+	tag, err := r.pool.Exec(ctx, `DELETE FROM resumes WHERE user_id = $1`, userID)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
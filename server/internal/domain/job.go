@@ -1,11 +1,16 @@
 package domain
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// ErrJobNotFound is returned by repositories when no job matches the
+// requested id.
+var ErrJobNotFound = errors.New("job not found")
+
 type ResumeJob struct {
 	ID             uuid.UUID              `json:"id"`
 	UserID         uuid.UUID              `json:"user_id"`
@@ -14,7 +19,57 @@ type ResumeJob struct {
 	Metadata       map[string]interface{} `json:"metadata"`
 	ResumeID       *uuid.UUID             `json:"resume_id,omitempty"`
 	Language       string                 `json:"language"`
+	// Template names the layout the resume is rendered with, e.g.
+	// "compact"; empty means the default layout.
+	Template       string                 `json:"template,omitempty"`
+	IdempotencyKey string                 `json:"idempotency_key,omitempty"`
 	CreatedAt      time.Time              `json:"created_at"`
 	UpdatedAt      time.Time              `json:"updated_at"`
 	Profile        map[string]interface{} `json:"profile"`
+	// Resume is the final resume map rendered into the template. It is
+	// stored on the job's resumes row, not in resume_jobs.
+	Resume map[string]interface{} `json:"resume,omitempty"`
+}
+
+// Statuses a job moves through while it is processed, in order. A job is
+// "pending" until a worker picks it up and ends "completed", "failed",
+// "cancelled" or StatusTimeout.
+const (
+	StatusPending     = "pending"
+	StatusAggregating = "aggregating"
+	StatusFormatting  = "formatting"
+	StatusValidating  = "validating"
+	StatusRendering   = "rendering"
+	StatusSaving      = "saving"
+)
+
+// StatusTimeout is the terminal status of a job that ran out of time; like a
+// failed job it can be retried.
+const StatusTimeout = "timeout"
+
+// ActiveStatuses are the statuses of jobs that have not finished yet.
+var ActiveStatuses = []string{StatusPending, StatusAggregating, StatusFormatting, StatusValidating, StatusRendering, StatusSaving}
+
+// IsActiveStatus reports whether a job with status is still queued or
+// being processed.
+func IsActiveStatus(status string) bool {
+	for _, s := range ActiveStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// JobFilter narrows job listings. Empty fields match everything; Active
+// matches every status in ActiveStatuses.
+type JobFilter struct {
+	Status string
+	Active bool
+}
+
+// Page describes a limit/offset window over an ordered listing.
+type Page struct {
+	Limit  int
+	Offset int
 }
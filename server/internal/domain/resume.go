@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrResumeNotFound is returned by repositories when no resume matches the
+// requested id.
+var ErrResumeNotFound = errors.New("resume not found")
+
+// Resume is a generated resume row as stored in the resumes table.
+type Resume struct {
+	ID        uuid.UUID   `json:"id"`
+	UserID    uuid.UUID   `json:"user_id"`
+	Title     string      `json:"title"`
+	FileName  string      `json:"file_name"`
+	FilePath  string      `json:"file_path"`
+	FileSize  int64       `json:"file_size"`
+	PDFPath   string      `json:"pdf_path,omitempty"`
+	Extras    interface{} `json:"extras,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+
+	// GenerationMeta is nil for resumes generated before it was recorded.
+	GenerationMeta *GenerationMeta `json:"generation_meta,omitempty"`
+}
+
+// GenerationMeta records how much of a resume the AI had to make up.
+// SynthesizedFields lists sections with no matching source data;
+// SourcedFields lists sections built from the user's data.
+type GenerationMeta struct {
+	AIWarnings        []string `json:"ai_warnings"`
+	AISynthesized     bool     `json:"ai_synthesized"`
+	SynthesizedFields []string `json:"synthesized_fields"`
+	SourcedFields     []string `json:"sourced_fields"`
+}
+
+// ErrResumeJSONNotFound is returned when a resume exists but its structured
+// JSON was never stored, e.g. for rows written before it was persisted.
+var ErrResumeJSONNotFound = errors.New("resume JSON not available")
+
+// ResumeFilter narrows resume listings. Title matches case-insensitively as
+// a substring; empty fields match everything.
+type ResumeFilter struct {
+	Title string
+}
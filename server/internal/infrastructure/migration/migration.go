@@ -24,6 +24,54 @@ func RunMigrations(ctx context.Context, pool *pgxpool.Pool) error {
 				return addExtrasJSONBToResumes(ctx, pool)
 			},
 		},
+		{
+			Name: "add_idempotency_key_to_resume_jobs",
+			Up: func(ctx context.Context, pool *pgxpool.Pool) error {
+				return addIdempotencyKeyToResumeJobs(ctx, pool)
+			},
+		},
+		{
+			Name: "add_language_to_resume_jobs",
+			Up: func(ctx context.Context, pool *pgxpool.Pool) error {
+				return addLanguageToResumeJobs(ctx, pool)
+			},
+		},
+		{
+			Name: "create_label_translations",
+			Up: func(ctx context.Context, pool *pgxpool.Pool) error {
+				return createLabelTranslations(ctx, pool)
+			},
+		},
+		{
+			Name: "add_claim_columns_to_resume_jobs",
+			Up: func(ctx context.Context, pool *pgxpool.Pool) error {
+				return addClaimColumnsToResumeJobs(ctx, pool)
+			},
+		},
+		{
+			Name: "add_resume_json_to_resumes",
+			Up: func(ctx context.Context, pool *pgxpool.Pool) error {
+				return addResumeJSONToResumes(ctx, pool)
+			},
+		},
+		{
+			Name: "add_pdf_path_to_resumes",
+			Up: func(ctx context.Context, pool *pgxpool.Pool) error {
+				return addPDFPathToResumes(ctx, pool)
+			},
+		},
+		{
+			Name: "add_generation_meta_to_resumes",
+			Up: func(ctx context.Context, pool *pgxpool.Pool) error {
+				return addGenerationMetaToResumes(ctx, pool)
+			},
+		},
+		{
+			Name: "add_template_to_resume_jobs",
+			Up: func(ctx context.Context, pool *pgxpool.Pool) error {
+				return addTemplateToResumeJobs(ctx, pool)
+			},
+		},
 	}
 
 	for _, m := range migrations {
@@ -77,3 +125,148 @@ func addExtrasJSONBToResumes(ctx context.Context, pool *pgxpool.Pool) error {
 	slog.Info("Successfully added extras JSONB column to resumes table")
 	return nil
 }
+
+// addIdempotencyKeyToResumeJobs adds the idempotency_key column and a unique
+// index per user so retried start requests map to the original job
+func addIdempotencyKeyToResumeJobs(ctx context.Context, pool *pgxpool.Pool) error {
+	query := `
+		ALTER TABLE resume_jobs
+		ADD COLUMN IF NOT EXISTS idempotency_key TEXT;
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_resume_jobs_user_idempotency_key
+		ON resume_jobs (user_id, idempotency_key)
+		WHERE idempotency_key IS NOT NULL;
+	`
+
+	if _, err := pool.Exec(ctx, query); err != nil {
+		slog.Warn("Error adding idempotency_key column (may already exist)", "error", err)
+		return nil
+	}
+
+	slog.Info("Successfully added idempotency_key column to resume_jobs table")
+	return nil
+}
+
+// addLanguageToResumeJobs adds the language column used to persist the
+// per-job output language
+func addLanguageToResumeJobs(ctx context.Context, pool *pgxpool.Pool) error {
+	query := `
+		ALTER TABLE resume_jobs
+		ADD COLUMN IF NOT EXISTS language TEXT;
+	`
+
+	if _, err := pool.Exec(ctx, query); err != nil {
+		slog.Warn("Error adding language column (may already exist)", "error", err)
+		return nil
+	}
+
+	slog.Info("Successfully added language column to resume_jobs table")
+	return nil
+}
+
+// createLabelTranslations creates the table caching translated section
+// labels per language
+func createLabelTranslations(ctx context.Context, pool *pgxpool.Pool) error {
+	query := `
+		CREATE TABLE IF NOT EXISTS label_translations (
+			language TEXT PRIMARY KEY,
+			labels JSONB NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);
+	`
+
+	if _, err := pool.Exec(ctx, query); err != nil {
+		slog.Warn("Error creating label_translations table", "error", err)
+		return nil
+	}
+
+	slog.Info("Successfully created label_translations table")
+	return nil
+}
+
+// addClaimColumnsToResumeJobs adds claimed_by/claimed_at, recording which
+// instance requeued a stale job so two instances never pick up the same one
+func addClaimColumnsToResumeJobs(ctx context.Context, pool *pgxpool.Pool) error {
+	query := `
+		ALTER TABLE resume_jobs
+		ADD COLUMN IF NOT EXISTS claimed_by TEXT,
+		ADD COLUMN IF NOT EXISTS claimed_at TIMESTAMPTZ;
+		CREATE INDEX IF NOT EXISTS idx_resume_jobs_status_updated_at
+		ON resume_jobs (status, updated_at);
+	`
+
+	if _, err := pool.Exec(ctx, query); err != nil {
+		slog.Warn("Error adding claim columns (may already exist)", "error", err)
+		return nil
+	}
+
+	slog.Info("Successfully added claim columns to resume_jobs table")
+	return nil
+}
+
+// addResumeJSONToResumes adds the resume_json JSONB column holding the
+// structured resume each row was rendered from
+func addResumeJSONToResumes(ctx context.Context, pool *pgxpool.Pool) error {
+	query := `
+		ALTER TABLE resumes
+		ADD COLUMN IF NOT EXISTS resume_json JSONB;
+	`
+
+	if _, err := pool.Exec(ctx, query); err != nil {
+		slog.Warn("Error adding resume_json column (may already exist)", "error", err)
+		return nil
+	}
+
+	slog.Info("Successfully added resume_json column to resumes table")
+	return nil
+}
+
+// addPDFPathToResumes adds the pdf_path column next to file_path, which
+// holds the HTML
+func addPDFPathToResumes(ctx context.Context, pool *pgxpool.Pool) error {
+	query := `
+		ALTER TABLE resumes
+		ADD COLUMN IF NOT EXISTS pdf_path TEXT;
+	`
+
+	if _, err := pool.Exec(ctx, query); err != nil {
+		slog.Warn("Error adding pdf_path column (may already exist)", "error", err)
+		return nil
+	}
+
+	slog.Info("Successfully added pdf_path column to resumes table")
+	return nil
+}
+
+// addGenerationMetaToResumes adds generation_meta, which records the AI
+// warnings and which sections were synthesized rather than sourced
+func addGenerationMetaToResumes(ctx context.Context, pool *pgxpool.Pool) error {
+	query := `
+		ALTER TABLE resumes
+		ADD COLUMN IF NOT EXISTS generation_meta JSONB;
+	`
+
+	if _, err := pool.Exec(ctx, query); err != nil {
+		slog.Warn("Error adding generation_meta column (may already exist)", "error", err)
+		return nil
+	}
+
+	slog.Info("Successfully added generation_meta column to resumes table")
+	return nil
+}
+
+// addTemplateToResumeJobs adds the template column holding the name of the
+// layout a job renders with; NULL means the default layout
+func addTemplateToResumeJobs(ctx context.Context, pool *pgxpool.Pool) error {
+	query := `
+		ALTER TABLE resume_jobs
+		ADD COLUMN IF NOT EXISTS template TEXT;
+	`
+
+	if _, err := pool.Exec(ctx, query); err != nil {
+		slog.Warn("Error adding template column (may already exist)", "error", err)
+		return nil
+	}
+
+	slog.Info("Successfully added template column to resume_jobs table")
+	return nil
+}
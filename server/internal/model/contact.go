@@ -0,0 +1,165 @@
+package model
+
+import (
+	"net/mail"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// contactAliases maps keys seen in meta.contact to the canonical fields
+// email, phone, location and website.
+var contactAliases = map[string]string{
+	"email":        "email",
+	"e-mail":       "email",
+	"mail":         "email",
+	"phone":        "phone",
+	"phone_number": "phone",
+	"telephone":    "phone",
+	"tel":          "phone",
+	"mobile":       "phone",
+	"location":     "location",
+	"city":         "location",
+	"address":      "location",
+	"website":      "website",
+	"url":          "website",
+	"site":         "website",
+	"homepage":     "website",
+	"portfolio":    "website",
+}
+
+// NormalizeContact coerces meta.contact into an object with the optional
+// string fields email, phone, location and website; github is kept for
+// older resumes. A plain string or a list of strings is sorted into those
+// fields by what each value looks like. Invalid emails, phone numbers and
+// websites are dropped rather than printed, and a contact left empty is
+// removed.
+func NormalizeContact(meta map[string]interface{}) {
+	if meta == nil {
+		return
+	}
+	raw, ok := meta["contact"]
+	if !ok {
+		return
+	}
+
+	contact := map[string]interface{}{}
+	set := func(field, value string) {
+		if _, has := contact[field]; !has && value != "" {
+			contact[field] = value
+		}
+	}
+	switch v := raw.(type) {
+	case string:
+		for _, part := range strings.Split(v, "|") {
+			set(classifyContact(part))
+		}
+	case []interface{}:
+		for _, it := range v {
+			if s, ok := it.(string); ok {
+				set(classifyContact(s))
+			}
+		}
+	case map[string]interface{}:
+		// canonical keys win over their aliases
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			ci := contactAliases[keys[i]] == keys[i]
+			cj := contactAliases[keys[j]] == keys[j]
+			if ci != cj {
+				return ci
+			}
+			return keys[i] < keys[j]
+		})
+		for _, k := range keys {
+			s, ok := v[k].(string)
+			if !ok {
+				continue
+			}
+			key := strings.ToLower(strings.TrimSpace(k))
+			if key == "github" {
+				if IsHTTPURL(s) {
+					contact["github"] = strings.TrimSpace(s)
+				}
+				continue
+			}
+			if field, ok := contactAliases[key]; ok {
+				set(field, cleanContact(field, s))
+			}
+		}
+	}
+
+	if len(contact) == 0 {
+		delete(meta, "contact")
+		return
+	}
+	meta["contact"] = contact
+}
+
+// classifyContact returns the field s belongs in and its cleaned value.
+func classifyContact(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	for _, field := range []string{"email", "website", "phone"} {
+		if v := cleanContact(field, s); v != "" {
+			return field, v
+		}
+	}
+	return "location", s
+}
+
+// cleanContact returns value trimmed and, for email, phone and website,
+// only when it is valid for the field.
+func cleanContact(field, value string) string {
+	value = strings.TrimSpace(value)
+	switch field {
+	case "email":
+		if !IsEmail(value) {
+			return ""
+		}
+	case "phone":
+		if !isPhone(value) {
+			return ""
+		}
+	case "website":
+		if value != "" && !strings.Contains(value, "://") && strings.Contains(value, ".") && !strings.ContainsAny(value, " @") {
+			value = "https://" + value
+		}
+		u, err := url.Parse(value)
+		if !IsHTTPURL(value) || err != nil || !strings.Contains(strings.Trim(u.Hostname(), "."), ".") {
+			return ""
+		}
+	}
+	return value
+}
+
+// IsEmail reports whether s is a bare address such as "ana@example.com",
+// without a display name, whose domain has a dot.
+func IsEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Address != s || addr.Name != "" {
+		return false
+	}
+	at := strings.LastIndex(s, "@")
+	domain := s[at+1:]
+	return strings.Contains(domain, ".") && !strings.HasPrefix(domain, ".") && !strings.HasSuffix(domain, ".")
+}
+
+// isPhone reports whether s is a phone number: 7 to 15 digits, optionally
+// with a leading + and the usual separators.
+func isPhone(s string) bool {
+	digits := 0
+	for i, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case r == '+' && i == 0:
+		case strings.ContainsRune(" -.()/", r):
+		default:
+			return false
+		}
+	}
+	return digits >= 7 && digits <= 15
+}
@@ -0,0 +1,16 @@
+package model
+
+import (
+	"net/url"
+	"strings"
+)
+
+// IsHTTPURL reports whether s is an absolute http or https URL with a host,
+// the only kind of link accepted for meta.photo_url.
+func IsHTTPURL(s string) bool {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return u.Scheme == "http" || u.Scheme == "https"
+}
@@ -6,6 +6,8 @@ type Meta struct {
 	Name     string            `json:"name"`
 	Headline string            `json:"headline"`
 	Contact  map[string]string `json:"contact,omitempty"`
+	// PhotoURL is an optional http(s) link to a headshot shown in the header.
+	PhotoURL string `json:"photo_url,omitempty"`
 }
 
 type Snapshot struct {
@@ -30,11 +32,27 @@ type Project struct {
 	Bullets     []string `json:"bullets,omitempty"`
 }
 
+type SkillGroup struct {
+	Category string   `json:"category"`
+	Items    []string `json:"items"`
+}
+
+type EducationEntry struct {
+	Institution string `json:"institution"`
+	Degree      string `json:"degree,omitempty"`
+	Field       string `json:"field,omitempty"`
+	StartDate   string `json:"start_date,omitempty"`
+	EndDate     string `json:"end_date,omitempty"`
+	GPA         string `json:"gpa,omitempty"`
+}
+
 type Resume struct {
 	Meta           Meta                  `json:"meta"`
 	Summary        string                `json:"summary"`
 	Snapshot       Snapshot              `json:"snapshot"`
+	Skills         []SkillGroup          `json:"skills,omitempty"`
 	Experience     []Role                `json:"experience"`
+	Education      []EducationEntry      `json:"education,omitempty"`
 	Projects       []Project             `json:"projects"`
 	Publications   []string              `json:"publications,omitempty"`
 	Certifications []string              `json:"certifications,omitempty"`
@@ -0,0 +1,93 @@
+package model
+
+import (
+	"net/url"
+	"strings"
+)
+
+// socialProfileURLs maps the social_links keys of known platforms to the
+// profile URL a bare handle expands to.
+var socialProfileURLs = map[string]string{
+	"github":        "https://github.com/",
+	"gitlab":        "https://gitlab.com/",
+	"linkedin":      "https://www.linkedin.com/in/",
+	"twitter":       "https://twitter.com/",
+	"x":             "https://x.com/",
+	"medium":        "https://medium.com/@",
+	"dev":           "https://dev.to/",
+	"devto":         "https://dev.to/",
+	"stackoverflow": "https://stackoverflow.com/users/",
+}
+
+// NormalizeSocialLinks returns links with keys lower-cased and every value
+// turned into an https URL: a missing scheme is added, http is upgraded
+// and a bare handle such as "@ana" is expanded for the platforms in
+// socialProfileURLs. Values that cannot be made a valid URL are dropped;
+// nil is returned when none are left.
+func NormalizeSocialLinks(links map[string]interface{}) map[string]interface{} {
+	out := map[string]interface{}{}
+	for k, v := range links {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(k))
+		if link := socialLinkURL(key, s); key != "" && link != "" {
+			out[key] = link
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// NormalizeMetaSocialLinks applies NormalizeSocialLinks to
+// meta.social_links, removing it when no link is valid.
+func NormalizeMetaSocialLinks(meta map[string]interface{}) {
+	if meta == nil {
+		return
+	}
+	raw, ok := meta["social_links"]
+	if !ok {
+		return
+	}
+	links, _ := raw.(map[string]interface{})
+	if links = NormalizeSocialLinks(links); links == nil {
+		delete(meta, "social_links")
+		return
+	}
+	meta["social_links"] = links
+}
+
+// socialLinkURL returns the https URL for the value of the social_links
+// key, or "" when there is none.
+func socialLinkURL(key, value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.ContainsAny(value, " \t\n") {
+		return ""
+	}
+	lower := strings.ToLower(value)
+	switch {
+	case strings.HasPrefix(lower, "https://"):
+	case strings.HasPrefix(lower, "http://"):
+		value = "https://" + value[len("http://"):]
+	case strings.Contains(value, "://"):
+		return ""
+	case !strings.ContainsAny(value, "./"):
+		// a handle, only meaningful for a known platform
+		base, ok := socialProfileURLs[key]
+		if !ok {
+			return ""
+		}
+		value = base + strings.TrimPrefix(value, "@")
+	default:
+		value = "https://" + strings.TrimPrefix(value, "//")
+	}
+
+	u, err := url.Parse(value)
+	if err != nil || u.User != nil || !strings.Contains(strings.Trim(u.Hostname(), "."), ".") {
+		return ""
+	}
+	return value
+}
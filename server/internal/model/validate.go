@@ -3,58 +3,108 @@ package model
 import (
 	"fmt"
 	"path/filepath"
+	"sync"
 
 	"github.com/xeipuuv/gojsonschema"
+
+	"resume-generator/pkg/metrics"
+	"resume-generator/templates"
 )
 
-// ValidateMap validates a generic map against the resume.schema.json file.
-func ValidateMap(m map[string]interface{}) error {
-	// Use absolute canonical file:// path for the schema so loaders on all
-	// platforms (including Windows) resolve file references correctly.
-	abs, err := filepath.Abs("templates/resume.schema.json")
+// compiled schemas keyed by the path passed to loadSchema, so per-stage
+// validations don't re-read and re-compile the JSON on every call
+var (
+	schemaMu    sync.Mutex
+	schemaCache = map[string]*gojsonschema.Schema{}
+)
+
+// loadSchema returns the compiled schema at schemaRel (relative to the
+// templates directory), compiling and caching it on first use.
+func loadSchema(schemaRel string) (*gojsonschema.Schema, error) {
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+	if s, ok := schemaCache[schemaRel]; ok {
+		return s, nil
+	}
+
+	b, err := templates.ReadFile(schemaRel)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	schemaPath := "file://" + filepath.ToSlash(abs)
-	schemaLoader := gojsonschema.NewReferenceLoader(schemaPath)
-	docLoader := gojsonschema.NewGoLoader(m)
+	s, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(b))
+	if err != nil {
+		return nil, err
+	}
+	schemaCache[schemaRel] = s
+	return s, nil
+}
+
+// ValidationError describes a single schema violation.
+type ValidationError struct {
+	Field       string      `json:"field"`
+	Type        string      `json:"type"`
+	Description string      `json:"description"`
+	Value       interface{} `json:"value,omitempty"`
+}
 
-	res, err := gojsonschema.Validate(schemaLoader, docLoader)
+func (e ValidationError) String() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Description)
+}
+
+// ResumeSchema is the schema a complete resume must satisfy.
+const ResumeSchema = "resume.schema.json"
+
+// ValidateMap validates a generic map against the resume.schema.json file.
+func ValidateMap(m map[string]interface{}) error {
+	return ValidateMapWithSchema(ResumeSchema, m)
+}
+
+// ValidateMapDetailed validates m against resume.schema.json and returns one
+// ValidationError per violation. The error is non-nil only when the schema
+// itself cannot be loaded or applied.
+func ValidateMapDetailed(m map[string]interface{}) ([]ValidationError, error) {
+	return validateDetailed(ResumeSchema, m)
+}
+
+// ValidateMapWithSchema validates a map against a provided schema file
+// relative to the templates directory (e.g., "schema/experience.schema.json").
+func ValidateMapWithSchema(schemaRel string, m map[string]interface{}) error {
+	verrs, err := validateDetailed(schemaRel, m)
 	if err != nil {
 		return err
 	}
-	if res.Valid() {
+	if len(verrs) == 0 {
 		return nil
 	}
 	// collect errors
 	msgs := ""
-	for _, e := range res.Errors() {
+	for _, e := range verrs {
 		msgs += fmt.Sprintf("%s; ", e.String())
 	}
 	return fmt.Errorf("schema validation failed: %s", msgs)
 }
 
-// ValidateMapWithSchema validates a map against a provided schema file
-// relative to the repository root (e.g., "templates/schema/experience.schema.json").
-func ValidateMapWithSchema(schemaRel string, m map[string]interface{}) error {
-	abs, err := filepath.Abs(schemaRel)
+func validateDetailed(schemaRel string, m map[string]interface{}) ([]ValidationError, error) {
+	schema, err := loadSchema(schemaRel)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	schemaPath := "file://" + filepath.ToSlash(abs)
-	schemaLoader := gojsonschema.NewReferenceLoader(schemaPath)
-	docLoader := gojsonschema.NewGoLoader(m)
 
-	res, err := gojsonschema.Validate(schemaLoader, docLoader)
+	res, err := schema.Validate(gojsonschema.NewGoLoader(m))
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if res.Valid() {
-		return nil
+	if !res.Valid() {
+		metrics.ValidationFailures.WithLabelValues(filepath.Base(schemaRel)).Inc()
 	}
-	msgs := ""
+	var out []ValidationError
 	for _, e := range res.Errors() {
-		msgs += fmt.Sprintf("%s; ", e.String())
+		out = append(out, ValidationError{
+			Field:       e.Field(),
+			Type:        e.Type(),
+			Description: e.Description(),
+			Value:       e.Value(),
+		})
 	}
-	return fmt.Errorf("schema validation failed: %s", msgs)
+	return out, nil
 }
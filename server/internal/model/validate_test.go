@@ -0,0 +1,150 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateMapWithSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		m       map[string]interface{}
+		wantErr string
+	}{
+		{
+			name:   "valid experience",
+			schema: "schema/experience.schema.json",
+			m: map[string]interface{}{
+				"experience": []interface{}{map[string]interface{}{"company": "Acme", "title": "Engineer"}},
+			},
+		},
+		{
+			name:    "missing required field",
+			schema:  "schema/experience.schema.json",
+			m:       map[string]interface{}{"experience": []interface{}{map[string]interface{}{"company": "Acme"}}},
+			wantErr: "experience.0: title is required",
+		},
+		{
+			name:    "wrong type",
+			schema:  "schema/experience.schema.json",
+			m:       map[string]interface{}{"experience": "Acme"},
+			wantErr: "experience: Invalid type",
+		},
+		{
+			name:    "unknown schema",
+			schema:  "schema/missing.schema.json",
+			m:       map[string]interface{}{},
+			wantErr: "missing.schema.json",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateMapWithSchema(tt.schema, tt.m)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateMapWithSchema() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ValidateMapWithSchema() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadSchemaCaches(t *testing.T) {
+	first, err := loadSchema("schema/profile.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := loadSchema("schema/profile.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Error("loadSchema compiled the same schema twice")
+	}
+	if _, err := loadSchema("schema/missing.schema.json"); err == nil {
+		t.Error("loadSchema succeeded for a missing file")
+	}
+	schemaMu.Lock()
+	_, cached := schemaCache["schema/missing.schema.json"]
+	schemaMu.Unlock()
+	if cached {
+		t.Error("a schema that failed to load was cached")
+	}
+}
+
+func validResume() map[string]interface{} {
+	return map[string]interface{}{
+		"meta":    map[string]interface{}{"name": "Ada", "headline": "Engineer"},
+		"summary": "Builds things.",
+		"snapshot": map[string]interface{}{
+			"tech":              "Go",
+			"achievements":      []interface{}{"a", "b", "c"},
+			"selected_projects": []interface{}{"x", "y"},
+		},
+		"experience": []interface{}{},
+		"projects":   []interface{}{},
+	}
+}
+
+func TestValidateMapDetailed(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(m map[string]interface{})
+		want   []ValidationError // Field and Type only
+	}{
+		{"valid", func(map[string]interface{}) {}, nil},
+		{
+			name:   "missing summary",
+			modify: func(m map[string]interface{}) { delete(m, "summary") },
+			want:   []ValidationError{{Field: "(root)", Type: "required"}},
+		},
+		{
+			name: "several violations",
+			modify: func(m map[string]interface{}) {
+				m["snapshot"].(map[string]interface{})["achievements"] = []interface{}{"a"}
+				m["meta"].(map[string]interface{})["contact"] = map[string]interface{}{"email": "not-an-email"}
+			},
+			want: []ValidationError{
+				{Field: "meta.contact.email", Type: "format"},
+				{Field: "snapshot.achievements", Type: "array_min_items"},
+			},
+		},
+		{
+			name:   "wrong type",
+			modify: func(m map[string]interface{}) { m["projects"] = "none" },
+			want:   []ValidationError{{Field: "projects", Type: "invalid_type"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := validResume()
+			tt.modify(m)
+			got, err := ValidateMapDetailed(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ValidateMapDetailed() = %v, want %d errors", got, len(tt.want))
+			}
+			for _, w := range tt.want {
+				found := false
+				for _, g := range got {
+					if g.Field == w.Field && g.Type == w.Type {
+						found = true
+						if g.Description == "" {
+							t.Errorf("%s: empty description", g.Field)
+						}
+					}
+				}
+				if !found {
+					t.Errorf("ValidateMapDetailed() = %v, missing %s %s", got, w.Field, w.Type)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"resume-generator/internal/domain"
+
+	"github.com/google/uuid"
+
+	"resume-generator/pkg/logctx"
+)
+
+// EventCancelled is the terminal event of a job stopped through Cancel.
+const EventCancelled = "cancelled"
+
+// activeJob is the registry entry of a job currently inside Process.
+type activeJob struct {
+	cancel    context.CancelFunc
+	cancelled bool
+}
+
+// jobRegistry tracks the cancel functions of running jobs.
+type jobRegistry struct {
+	mu   sync.Mutex
+	jobs map[uuid.UUID]*activeJob
+}
+
+func (r *jobRegistry) add(id uuid.UUID, cancel context.CancelFunc) *activeJob {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.jobs == nil {
+		r.jobs = map[uuid.UUID]*activeJob{}
+	}
+	a := &activeJob{cancel: cancel}
+	r.jobs[id] = a
+	return a
+}
+
+func (r *jobRegistry) remove(id uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.jobs, id)
+}
+
+// wasCancelled reports whether Cancel was called for a.
+func (r *jobRegistry) wasCancelled(a *activeJob) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return a.cancelled
+}
+
+// Cancel aborts a job that is currently being processed. It reports false
+// when the job is not active, e.g. still queued or already finished. The
+// job is marked cancelled once Process returns.
+func (p *Processor) Cancel(id uuid.UUID) bool {
+	p.active.mu.Lock()
+	defer p.active.mu.Unlock()
+	a, ok := p.active.jobs[id]
+	if !ok {
+		return false
+	}
+	a.cancelled = true
+	a.cancel()
+	return true
+}
+
+// markCancelled records a cancelled job, persists it best-effort and
+// publishes the terminal event.
+func (p *Processor) markCancelled(job *domain.ResumeJob) {
+	ctx := jobContext(context.Background(), job)
+	p.progressMu.Lock()
+	if job.Metadata == nil {
+		job.Metadata = map[string]interface{}{}
+	}
+	job.Status = "cancelled"
+	job.Metadata["cancelled_at"] = time.Now().UTC().Format(time.RFC3339)
+	job.Metadata["error_code"] = CodeCancelled
+	job.UpdatedAt = time.Now()
+	result := eventResult(job)
+	p.progressMu.Unlock()
+
+	if p.repo != nil {
+		if err := p.repo.Save(ctx, job); err != nil {
+			logctx.Warnf(ctx, "processor: failed to save cancelled job %s: %v", job.ID.String(), err)
+		}
+	}
+	p.events.Publish(JobEvent{JobID: job.ID, Stage: EventCancelled, Terminal: true, Result: result})
+}
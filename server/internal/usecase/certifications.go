@@ -0,0 +1,135 @@
+package usecase
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"resume-generator/internal/model"
+	ai "resume-generator/pkg/ai"
+	"resume-generator/pkg/logctx"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// certificationsSchema is the focused schema certifications are checked
+// against before they are merged into a resume.
+const certificationsSchema = "schema/publications.schema.json"
+
+// repairCertifications checks each certification in m on its own against
+// certificationsSchema. An invalid item is run through
+// ai.SanitizeCertifications and checked again; items that still fail are
+// dropped and logged instead of failing the whole resume. Plain strings are
+// treated as a certification name. It returns the number of dropped items.
+func repairCertifications(ctx context.Context, m map[string]interface{}) int {
+	arr, ok := m["certifications"].([]interface{})
+	if !ok {
+		return 0
+	}
+
+	kept := make([]interface{}, 0, len(arr))
+	dropped := 0
+	for i, it := range arr {
+		var item map[string]interface{}
+		switch v := it.(type) {
+		case map[string]interface{}:
+			item = v
+		case string:
+			item = map[string]interface{}{"name": v}
+		default:
+			logctx.Warnf(ctx, "processor: dropped certification %d: unexpected %T", i, it)
+			dropped++
+			continue
+		}
+
+		one := map[string]interface{}{"certifications": []interface{}{item}}
+		if err := model.ValidateMapWithSchema(certificationsSchema, one); err != nil {
+			ai.SanitizeCertifications(one)
+			if err := model.ValidateMapWithSchema(certificationsSchema, one); err != nil {
+				logctx.Warnf(ctx, "processor: dropped certification %d (%v): %v", i, item["name"], err)
+				dropped++
+				continue
+			}
+			item = one["certifications"].([]interface{})[0].(map[string]interface{})
+		}
+		kept = append(kept, item)
+	}
+	m["certifications"] = kept
+	return dropped
+}
+
+// compactCertificationDates shortens certification dates in resumeMap to the
+// year for compact display.
+func compactCertificationDates(resumeMap map[string]interface{}) {
+	if certsRaw, ok := resumeMap["certifications"]; ok {
+		if certsArr, ok := certsRaw.([]interface{}); ok {
+			for i, it := range certsArr {
+				switch c := it.(type) {
+				case map[string]interface{}:
+					if d, ok := c["date"].(string); ok && len(d) >= 4 {
+						// prefer first 4 characters as year when possible
+						c["date"] = d[:4]
+					}
+					certsArr[i] = c
+				default:
+					// leave as-is
+				}
+			}
+			resumeMap["certifications"] = certsArr
+		}
+	}
+}
+
+// labelCertificationURLs sets url_label on each certification in resumeMap,
+// which the template shows instead of the full URL: the registrable domain
+// of url, else the issuer, else "link".
+func labelCertificationURLs(resumeMap map[string]interface{}) {
+	if certsRaw, ok := resumeMap["certifications"]; ok {
+		if certsArr, ok := certsRaw.([]interface{}); ok {
+			for i, it := range certsArr {
+				switch c := it.(type) {
+				case map[string]interface{}:
+					label := ""
+					if uRaw, ok := c["url"]; ok {
+						if us, ok := uRaw.(string); ok && us != "" {
+							// ensure scheme present for parsing
+							candidate := us
+							if !strings.HasPrefix(candidate, "http://") && !strings.HasPrefix(candidate, "https://") {
+								candidate = "https://" + candidate
+							}
+							if parsed, err := url.Parse(candidate); err == nil {
+								host := parsed.Hostname()
+								// attempt eTLD+1 extraction for tidy labels
+								if etld, err2 := publicsuffix.EffectiveTLDPlusOne(host); err2 == nil {
+									label = strings.TrimPrefix(etld, "www.")
+								} else {
+									// fallback to hostname without port and www
+									if host == "" {
+										label = candidate
+									} else {
+										label = strings.TrimPrefix(host, "www.")
+									}
+								}
+							} else {
+								label = us
+							}
+						}
+					}
+					if label == "" {
+						// prefer issuer if present for human-friendly label
+						if iss, ok := c["issuer"].(string); ok && iss != "" {
+							label = iss
+						} else {
+							label = "link"
+						}
+					}
+					c["url_label"] = label
+					certsArr[i] = c
+				default:
+					// leave non-object entries as-is
+				}
+			}
+			resumeMap["certifications"] = certsArr
+		}
+	}
+}
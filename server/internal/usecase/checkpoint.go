@@ -0,0 +1,102 @@
+package usecase
+
+import (
+	"encoding/json"
+	"sort"
+
+	"resume-generator/internal/domain"
+)
+
+// stageCheckpointsKey is the job metadata key holding, per validated
+// pipeline stage, the resume sections that stage produced:
+//
+//	{stage name: {resume key: value}}
+//
+// The checkpoints are persisted with the stage progress, kept when a
+// failed or timed out job is retried so the retry resumes after the last
+// good stage instead of asking the AI again, and dropped once the job
+// completes.
+const stageCheckpointsKey = "stage_checkpoints"
+
+// saveCheckpoint records the sections st owns in resumeMap as its
+// checkpoint. The values are copied so later stages editing resumeMap do
+// not change it. The caller persists the job.
+func (p *Processor) saveCheckpoint(job *domain.ResumeJob, st pipelineStage, resumeMap map[string]interface{}) {
+	sections := map[string]interface{}{}
+	for _, k := range st.Keys {
+		if v, ok := resumeMap[k]; ok {
+			sections[k] = v
+		}
+	}
+	snapshot, err := copyJSON(sections)
+	if err != nil {
+		return
+	}
+
+	p.progressMu.Lock()
+	defer p.progressMu.Unlock()
+	if job.Metadata == nil {
+		job.Metadata = map[string]interface{}{}
+	}
+	checkpoints, ok := job.Metadata[stageCheckpointsKey].(map[string]interface{})
+	if !ok {
+		checkpoints = map[string]interface{}{}
+		job.Metadata[stageCheckpointsKey] = checkpoints
+	}
+	checkpoints[st.Name] = snapshot
+}
+
+// restoreCheckpoint copies the checkpoint of st, if job has one, into
+// resumeMap and reports whether the restored sections still validate. A
+// checkpoint that no longer validates is left in resumeMap for the stage to
+// enrich.
+func (p *Processor) restoreCheckpoint(job *domain.ResumeJob, st pipelineStage, resumeMap map[string]interface{}) bool {
+	p.progressMu.Lock()
+	checkpoints, _ := job.Metadata[stageCheckpointsKey].(map[string]interface{})
+	saved, ok := checkpoints[st.Name].(map[string]interface{})
+	var sections map[string]interface{}
+	var err error
+	if ok {
+		sections, err = copyJSON(saved)
+	}
+	p.progressMu.Unlock()
+	if !ok || err != nil || len(sections) == 0 {
+		return false
+	}
+	for k, v := range sections {
+		resumeMap[k] = v
+	}
+	return st.Validate(resumeMap).Valid
+}
+
+// CheckpointedStages returns the names of the stages job has checkpoints
+// for, sorted, which a retry of the job will not run again.
+func CheckpointedStages(job *domain.ResumeJob) []string {
+	checkpoints, _ := job.Metadata[stageCheckpointsKey].(map[string]interface{})
+	names := make([]string, 0, len(checkpoints))
+	for name := range checkpoints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DiscardCheckpoints drops job's stage checkpoints so its next run starts
+// from scratch.
+func DiscardCheckpoints(job *domain.ResumeJob) {
+	delete(job.Metadata, stageCheckpointsKey)
+}
+
+// copyJSON deep-copies m through JSON, which also gives the values the
+// types they have once the job is reloaded from the database.
+func copyJSON(m map[string]interface{}) (map[string]interface{}, error) {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
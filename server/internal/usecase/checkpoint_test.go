@@ -0,0 +1,182 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"resume-generator/internal/domain"
+	ai "resume-generator/pkg/ai"
+
+	"github.com/google/uuid"
+)
+
+// countingStage owns key and is valid once key is set. Its enrichment
+// counts its calls and fails with err, or sets key.
+func countingStage(key string, err error) (pipelineStage, *int) {
+	calls := 0
+	return pipelineStage{
+		Name: key,
+		Keys: []string{key},
+		Validate: func(m map[string]interface{}) *StageValidationResult {
+			if s, _ := m[key].(string); s != "" {
+				return &StageValidationResult{Valid: true}
+			}
+			return &StageValidationResult{Missing: []string{key}}
+		},
+		Enrich: func(_ context.Context, _ *ai.Client, _, m map[string]interface{}, _ *StageValidationResult) error {
+			calls++
+			if err != nil {
+				return err
+			}
+			m[key] = "formatted"
+			return nil
+		},
+	}, &calls
+}
+
+func TestSaveCheckpoint(t *testing.T) {
+	p := &Processor{events: NewEventBroker()}
+	job := &domain.ResumeJob{ID: uuid.New()}
+	st, _ := countingStage("experience", nil)
+	st.Keys = []string{"experience", "skills"}
+	resume := map[string]interface{}{
+		"experience": []interface{}{map[string]interface{}{"company": "PayCo", "years": 3}},
+		"summary":    "not owned by the stage",
+	}
+
+	p.saveCheckpoint(job, st, resume)
+	resume["experience"].([]interface{})[0].(map[string]interface{})["company"] = "edited later"
+
+	want := map[string]interface{}{
+		"experience": map[string]interface{}{
+			"experience": []interface{}{map[string]interface{}{"company": "PayCo", "years": float64(3)}},
+		},
+	}
+	if got := job.Metadata[stageCheckpointsKey]; !reflect.DeepEqual(got, want) {
+		t.Errorf("checkpoints = %v, want %v", got, want)
+	}
+}
+
+func TestRestoreCheckpoint(t *testing.T) {
+	tests := []struct {
+		name        string
+		checkpoints interface{}
+		want        bool
+		wantSummary interface{}
+	}{
+		{"none", nil, false, nil},
+		{"other stage", map[string]interface{}{"experience": map[string]interface{}{"experience": []interface{}{}}}, false, nil},
+		{"valid", map[string]interface{}{"summary": map[string]interface{}{"summary": "Go engineer"}}, true, "Go engineer"},
+		{"no longer valid", map[string]interface{}{"summary": map[string]interface{}{"summary": ""}}, false, ""},
+		{"empty", map[string]interface{}{"summary": map[string]interface{}{}}, false, nil},
+		{"malformed", map[string]interface{}{"summary": "Go engineer"}, false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Processor{events: NewEventBroker()}
+			job := &domain.ResumeJob{ID: uuid.New(), Metadata: map[string]interface{}{}}
+			if tt.checkpoints != nil {
+				job.Metadata[stageCheckpointsKey] = tt.checkpoints
+			}
+			st, _ := countingStage("summary", nil)
+			resume := map[string]interface{}{}
+			if got := p.restoreCheckpoint(job, st, resume); got != tt.want {
+				t.Errorf("restoreCheckpoint() = %v, want %v", got, tt.want)
+			}
+			if resume["summary"] != tt.wantSummary {
+				t.Errorf("summary = %v, want %v", resume["summary"], tt.wantSummary)
+			}
+		})
+	}
+}
+
+// TestRetryResumesFromCheckpoint fails a job in its second stage, retries
+// it after a round trip through JSON, as when it is reloaded from the
+// database, and checks the first stage is restored rather than run again.
+func TestRetryResumesFromCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	p := &Processor{events: NewEventBroker(), stageAttempts: 2}
+	job := &domain.ResumeJob{ID: uuid.New(), Metadata: map[string]interface{}{}}
+
+	experience, _ := countingStage("experience", nil)
+	summary, _ := countingStage("summary", errors.New("ai-service returned non-200 status"))
+	resume := map[string]interface{}{}
+	if !p.runStage(ctx, job, nil, nil, resume, 0, experience) || p.runStage(ctx, job, nil, nil, resume, 1, summary) {
+		t.Fatal("first run: want experience to validate and summary to fail")
+	}
+	job.Status = "failed"
+	job.Metadata["retriable"] = true
+	if err := ResetForRetry(job); err != nil {
+		t.Fatal(err)
+	}
+	job = roundTrip(t, job)
+	if got := CheckpointedStages(job); !reflect.DeepEqual(got, []string{"experience"}) {
+		t.Fatalf("CheckpointedStages() after the retry reset = %v, want [experience]", got)
+	}
+
+	experience, experienceCalls := countingStage("experience", nil)
+	summary, summaryCalls := countingStage("summary", nil)
+	resume = map[string]interface{}{}
+	if !p.runStage(ctx, job, nil, nil, resume, 0, experience) || !p.runStage(ctx, job, nil, nil, resume, 1, summary) {
+		t.Fatal("retry: want both stages to validate")
+	}
+	if *experienceCalls != 0 || *summaryCalls != 1 {
+		t.Errorf("retry enriched experience %d times and summary %d times, want 0 and 1", *experienceCalls, *summaryCalls)
+	}
+	if resume["experience"] != "formatted" {
+		t.Errorf("experience = %v, want the checkpointed value", resume["experience"])
+	}
+	entry, _ := job.Metadata["stage_progress"].(map[string]interface{})["experience"].(map[string]interface{})
+	if entry["restored"] != true || entry["status"] != StageCompleted {
+		t.Errorf("experience stage_progress = %v, want completed and restored", entry)
+	}
+	if got := CheckpointedStages(job); !reflect.DeepEqual(got, []string{"experience", "summary"}) {
+		t.Errorf("CheckpointedStages() after the retry = %v", got)
+	}
+}
+
+func TestProcessDiscardsCheckpoints(t *testing.T) {
+	p := NewProcessor(&blockingRenderer{release: closedChan()}, nil, "English", WithAIMode(AIModeOff), WithOutputDir(t.TempDir()))
+	job := offlineJob()
+	job.Metadata[stageCheckpointsKey] = map[string]interface{}{"summary_meta": map[string]interface{}{"summary": "old"}}
+	if err := p.Process(context.Background(), job); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := job.Metadata[stageCheckpointsKey]; ok {
+		t.Error("a completed job kept its checkpoints")
+	}
+}
+
+func TestCheckpointedStages(t *testing.T) {
+	job := &domain.ResumeJob{Metadata: map[string]interface{}{
+		stageCheckpointsKey: map[string]interface{}{"summary_meta": map[string]interface{}{}, "experience": map[string]interface{}{}},
+	}}
+	if got, want := CheckpointedStages(job), []string{"experience", "summary_meta"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("CheckpointedStages() = %v, want %v", got, want)
+	}
+	DiscardCheckpoints(job)
+	if got := CheckpointedStages(job); len(got) != 0 {
+		t.Errorf("CheckpointedStages() after DiscardCheckpoints = %v", got)
+	}
+	if got := CheckpointedStages(&domain.ResumeJob{}); got == nil || len(got) != 0 {
+		t.Errorf("CheckpointedStages() of a job without metadata = %#v, want empty", got)
+	}
+}
+
+// roundTrip returns job encoded and decoded as JSON, as it comes back from
+// the database.
+func roundTrip(t *testing.T, job *domain.ResumeJob) *domain.ResumeJob {
+	t.Helper()
+	b, err := json.Marshal(job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out domain.ResumeJob
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	return &out
+}
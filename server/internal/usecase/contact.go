@@ -0,0 +1,11 @@
+package usecase
+
+import "resume-generator/internal/model"
+
+// normalizeMetaContact puts meta.contact of resume in its canonical shape
+// with model.NormalizeContact.
+func normalizeMetaContact(resume map[string]interface{}) {
+	if meta, ok := resume["meta"].(map[string]interface{}); ok {
+		model.NormalizeContact(meta)
+	}
+}
@@ -0,0 +1,98 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	repo "resume-generator/internal/adapter/repository"
+	"resume-generator/internal/domain"
+	"resume-generator/internal/model"
+	infra "resume-generator/pkg/infrastructure"
+	"resume-generator/pkg/logctx"
+	"resume-generator/templates"
+)
+
+// Cover letter template, stylesheet and the schema the AI's letter must
+// match, in the templates FS.
+const (
+	coverLetterHTML   = "cover_letter.html"
+	coverLetterCSS    = "cover_letter.css"
+	coverLetterSchema = "schema/cover_letter.schema.json"
+)
+
+// renderCoverLetter writes a cover letter PDF next to the resume for jobs
+// started with cover_letter and records it as generated_cover_letter. The
+// letter is written by the AI from the rendered resume and the job's
+// application, so jobs without a job_application_id, or whose application
+// cannot be loaded, or with the AI disabled, are skipped. Failures are
+// recorded in metadata.cover_letter_error and never fail the job.
+func (p *Processor) renderCoverLetter(ctx context.Context, job *domain.ResumeJob, opts infra.RenderOptions, path string) {
+	if job.Metadata["cover_letter"] != true {
+		return
+	}
+	jaid, _ := job.Metadata["job_application_id"].(string)
+	if jaid == "" || p.aiMode == AIModeOff {
+		logctx.Debugf(ctx, "processor: no job application or AI for the cover letter, skipping")
+		return
+	}
+	ja, err := repo.GetJobApplicationByID(ctx, jaid)
+	if err != nil {
+		logctx.Warnf(ctx, "processor: failed to fetch job_application %s for the cover letter: %v", jaid, err)
+		return
+	}
+
+	if err := p.writeCoverLetter(ctx, job, ja, opts, path); err != nil {
+		logctx.Warnf(ctx, "processor: cover letter failed: %v", err)
+		job.Metadata["cover_letter_error"] = sanitizeError(err)
+		return
+	}
+	job.Metadata["generated_cover_letter"] = path
+}
+
+func (p *Processor) writeCoverLetter(ctx context.Context, job *domain.ResumeJob, jobApplication interface{}, opts infra.RenderOptions, path string) error {
+	letter, err := p.aiClient.WithLanguage(job.Language).FormatCoverLetter(ctx, map[string]interface{}{
+		"resume":          job.Profile,
+		"job_application": jobApplication,
+	})
+	if err != nil {
+		return err
+	}
+	if err := model.ValidateMapWithSchema(coverLetterSchema, letter); err != nil {
+		return err
+	}
+
+	tpl, err := template.New(coverLetterHTML).Funcs(templates.FuncMap()).ParseFS(templates.FS(), coverLetterHTML)
+	if err != nil {
+		return err
+	}
+	ja, _ := jobApplication.(map[string]interface{})
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, map[string]interface{}{
+		"Profile": job.Profile,
+		"Letter":  letter,
+		"Company": rowString(ja, "company_name", "company"),
+		"Role":    rowString(ja, "job_title", "title", "position"),
+		"Date":    time.Now().Format("January 2, 2006"),
+	}); err != nil {
+		return err
+	}
+	html := p.inlineStyles(ctx, job, buf.String(), coverLetterCSS)
+
+	opts.PageRanges = ""
+	opts.Metadata.Title = strings.TrimSpace(opts.Metadata.Author + " Cover Letter")
+	opts.Metadata.Keywords = ""
+	pdfBytes, err := p.renderer.RenderHTMLToPDFWithOptions(ctx, html, opts)
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(string(pdfBytes), "%PDF") {
+		return fmt.Errorf("invalid PDF output (len=%d)", len(pdfBytes))
+	}
+	return ioutil.WriteFile(filepath.Clean(path), pdfBytes, 0o644)
+}
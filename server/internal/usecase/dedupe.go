@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// dedupeTitleSimilarity is the minimum similarity, 1 minus the edit
+// distance over the longer length, at which two normalized titles are
+// taken for the same item.
+const dedupeTitleSimilarity = 0.9
+
+// dedupeMergedSections removes near-duplicate publications and
+// certifications from resumeMap once every merge has run. The AI and the
+// aggregated rows often describe the same publication differently
+// ("Scaling Go Microservices" and "Scaling Go Microservices — 2023.
+// Techniques to…"); of each group the most descriptive variant is kept, in
+// the position of the first one.
+func dedupeMergedSections(resumeMap map[string]interface{}) {
+	for _, k := range []string{"publications", "certifications"} {
+		if arr, ok := resumeMap[k].([]interface{}); ok && len(arr) > 1 {
+			resumeMap[k] = dedupeItems(arr)
+		}
+	}
+}
+
+// dedupeItems returns items without near-duplicates, matched by
+// sameItem on the title of strings and the title or name of objects.
+// Items without a title are kept as they are.
+func dedupeItems(items []interface{}) []interface{} {
+	out := make([]interface{}, 0, len(items))
+	// keys holds the title keys of every variant merged into out[i]
+	keys := make([][][]string, 0, len(items))
+	for _, it := range items {
+		key := dedupeKey(itemTitle(it))
+		dup := -1
+		if len(key) > 0 {
+		find:
+			for i, group := range keys {
+				for _, k := range group {
+					if sameItem(k, key) {
+						dup = i
+						break find
+					}
+				}
+			}
+		}
+		if dup < 0 {
+			out = append(out, it)
+			if len(key) > 0 {
+				keys = append(keys, [][]string{key})
+			} else {
+				keys = append(keys, nil)
+			}
+			continue
+		}
+		keys[dup] = append(keys[dup], key)
+		if descriptiveness(it) > descriptiveness(out[dup]) {
+			out[dup] = it
+		}
+	}
+	return out
+}
+
+// dedupeKey is the normalized words of a title without the years, so a
+// trailing "(2023)" or "— 2023" does not tell two variants apart.
+func dedupeKey(title string) []string {
+	var words []string
+	for _, w := range strings.Fields(normalizeTitle(title)) {
+		if !isYear(w) {
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+func isYear(w string) bool {
+	if len(w) != 4 || (w[:2] != "19" && w[:2] != "20") {
+		return false
+	}
+	return strings.Trim(w, "0123456789") == ""
+}
+
+// sameItem reports whether two title keys name the same item: the shorter
+// one, of at least two words, starts the longer, as when one variant
+// appends a description, or the two differ only by a typo or two.
+func sameItem(a, b []string) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	if len(a) >= 2 || len(a) == len(b) {
+		prefix := true
+		for i := range a {
+			if a[i] != b[i] {
+				prefix = false
+				break
+			}
+		}
+		if prefix {
+			return true
+		}
+	}
+	sa, sb := strings.Join(a, " "), strings.Join(b, " ")
+	longest := utf8.RuneCountInString(sb)
+	if n := utf8.RuneCountInString(sa); n > longest {
+		longest = n
+	}
+	return 1-float64(levenshtein(sa, sb))/float64(longest) >= dedupeTitleSimilarity
+}
+
+// descriptiveness ranks duplicate variants: objects by their number of
+// non-empty text fields, then by the length of their text.
+func descriptiveness(it interface{}) int {
+	switch t := it.(type) {
+	case string:
+		return utf8.RuneCountInString(t)
+	case map[string]interface{}:
+		fields, length := 0, 0
+		for _, v := range t {
+			if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
+				fields++
+				length += utf8.RuneCountInString(s)
+			}
+		}
+		return fields<<16 + length
+	}
+	return 0
+}
+
+// levenshtein returns the edit distance between a and b in runes.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
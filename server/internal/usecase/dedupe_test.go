@@ -0,0 +1,124 @@
+package usecase
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDedupeItems(t *testing.T) {
+	aws := map[string]interface{}{"name": "AWS Certified Solutions Architect – Associate"}
+	awsFull := map[string]interface{}{"name": "AWS Certified Solutions Architect - Associate", "issuer": "Amazon", "date": "2022"}
+	cka := map[string]interface{}{"name": "Certified Kubernetes Administrator (CKA)", "issuer": "CNCF"}
+	untitled := map[string]interface{}{"url": "https://example.com/talk"}
+
+	tests := []struct {
+		name  string
+		items []interface{}
+		want  []interface{}
+	}{
+		{
+			// the AI's variant next to the aggregated title
+			name: "title and title with description",
+			items: []interface{}{
+				"Scaling Go Microservices",
+				"Scaling Go Microservices — 2023. Techniques to keep p99 latency flat as traffic grows",
+			},
+			want: []interface{}{"Scaling Go Microservices — 2023. Techniques to keep p99 latency flat as traffic grows"},
+		},
+		{
+			name: "longer variant takes the position of the first",
+			items: []interface{}{
+				"Observability in Practice",
+				"Building Event-Driven Systems",
+				"Observability in Practice (2022)",
+			},
+			want: []interface{}{"Observability in Practice (2022)", "Building Event-Driven Systems"},
+		},
+		{
+			name:  "case and punctuation",
+			items: []interface{}{"Scaling Go Microservices", "scaling go microservices!"},
+			want:  []interface{}{"scaling go microservices!"},
+		},
+		{
+			name:  "typo",
+			items: []interface{}{"Distributed Tracing with OpenTelemetry", "Distributed Tracing with OpenTelemtry"},
+			want:  []interface{}{"Distributed Tracing with OpenTelemetry"},
+		},
+		{
+			name: "three variants",
+			items: []interface{}{
+				"Scaling Go Microservices (2023)",
+				"Scaling Go Microservices",
+				"Scaling Go Microservices — 2023. Techniques to…",
+			},
+			want: []interface{}{"Scaling Go Microservices — 2023. Techniques to…"},
+		},
+		{
+			name:  "different titles",
+			items: []interface{}{"Go Generics in Practice", "Go Concurrency in Practice", "Go", "Go Concurrency Patterns"},
+			want:  []interface{}{"Go Generics in Practice", "Go Concurrency in Practice", "Go", "Go Concurrency Patterns"},
+		},
+		{
+			name:  "certification with more fields wins",
+			items: []interface{}{aws, awsFull},
+			want:  []interface{}{awsFull},
+		},
+		{
+			name:  "certification name and object",
+			items: []interface{}{"Certified Kubernetes Administrator", cka},
+			want:  []interface{}{cka},
+		},
+		{
+			name:  "untitled items kept",
+			items: []interface{}{untitled, untitled, ""},
+			want:  []interface{}{untitled, untitled, ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dedupeItems(tt.items); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("dedupeItems() =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDedupeMergedSections(t *testing.T) {
+	resume := map[string]interface{}{
+		"publications":   []interface{}{"Scaling Go Microservices", "Scaling Go Microservices — 2023. Techniques to…"},
+		"certifications": []interface{}{map[string]interface{}{"name": "CKA"}, map[string]interface{}{"name": "CKA", "issuer": "CNCF"}},
+		"extras":         []interface{}{"Go meetup", "Go meetup"},
+		"projects":       "not an array",
+	}
+	dedupeMergedSections(resume)
+	want := map[string]interface{}{
+		"publications":   []interface{}{"Scaling Go Microservices — 2023. Techniques to…"},
+		"certifications": []interface{}{map[string]interface{}{"name": "CKA", "issuer": "CNCF"}},
+		"extras":         []interface{}{"Go meetup", "Go meetup"},
+		"projects":       "not an array",
+	}
+	if !reflect.DeepEqual(resume, want) {
+		t.Errorf("dedupeMergedSections() =\n%v\nwant\n%v", resume, want)
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"são paulo", "sao paulo", 1},
+		{"flaw", "lawn", 2},
+	}
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := levenshtein(tt.b, tt.a); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package usecase
+
+import (
+	"sync"
+	"time"
+
+	"resume-generator/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// Job event stages published while a job is processed.
+const (
+	EventAggregating = "aggregating"
+	EventFormatting  = "formatting"
+	EventValidating  = "validating"
+	EventRendering   = "rendering"
+	EventSaving      = "saving"
+	EventDone        = "done"
+	EventFailed      = "failed"
+	EventTimeout     = "timeout"
+	// EventStageProgress reports a change in stage_progress; Detail names
+	// the split-flow stage and Status is its new StageXxx status.
+	EventStageProgress = "stage_progress"
+	// EventPreview reports that the first page of a job started in preview
+	// mode is available as metadata.preview_pdf.
+	EventPreview = "preview"
+)
+
+// JobEvent is a single progress notification for a job.
+type JobEvent struct {
+	JobID    uuid.UUID `json:"jobId"`
+	Stage    string    `json:"stage"`
+	Detail   string    `json:"detail,omitempty"`
+	Status   string    `json:"status,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Terminal bool      `json:"terminal"`
+	At       time.Time `json:"at"`
+	// Result is set on the processor's terminal events to the job metadata
+	// under resultKeys, so a waiting client need not read the job the
+	// worker may still be writing.
+	Result map[string]interface{} `json:"result,omitempty"`
+}
+
+// resultKeys are the metadata keys copied into JobEvent.Result: the
+// artifacts of a completed job and the classification of a failed one.
+var resultKeys = []string{"generated_html", "generated_pdf", "generated_txt", "generated_tex", "generated_tex_pdf", "generated_json", "generated_docx", "generated_cover_letter", "user_copy", "html_url", "pdf_url", "failed_stage", "error_code"}
+
+// eventResult copies the resultKeys of job's metadata. The caller holds
+// progressMu.
+func eventResult(job *domain.ResumeJob) map[string]interface{} {
+	out := map[string]interface{}{}
+	for _, k := range resultKeys {
+		if v, ok := job.Metadata[k]; ok {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// eventRetention is how long the last event of a finished job is kept so
+// late subscribers still see the outcome.
+const eventRetention = 10 * time.Minute
+
+// EventBroker fans job events out to in-process subscribers. It remembers
+// the latest event per job so reconnecting clients catch up immediately.
+type EventBroker struct {
+	mu   sync.Mutex
+	subs map[uuid.UUID]map[chan JobEvent]struct{}
+	last map[uuid.UUID]JobEvent
+}
+
+func NewEventBroker() *EventBroker {
+	return &EventBroker{
+		subs: map[uuid.UUID]map[chan JobEvent]struct{}{},
+		last: map[uuid.UUID]JobEvent{},
+	}
+}
+
+// Publish records ev as the latest event for its job and delivers it to
+// current subscribers. Slow subscribers drop progress events rather than
+// block the processor; a terminal event evicts the oldest queued one when a
+// subscriber's buffer is full, so it always arrives.
+func (b *EventBroker) Publish(ev JobEvent) {
+	if b == nil {
+		return
+	}
+	if ev.At.IsZero() {
+		ev.At = time.Now().UTC()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.last[ev.JobID] = ev
+	for ch := range b.subs[ev.JobID] {
+		if ev.Terminal {
+			deliver(ch, ev)
+			continue
+		}
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+	if ev.Terminal {
+		id := ev.JobID
+		time.AfterFunc(eventRetention, func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			if cur, ok := b.last[id]; ok && cur.Terminal {
+				delete(b.last, id)
+			}
+		})
+	}
+}
+
+// deliver queues ev on ch, discarding queued events until it fits. Only
+// Publish sends on ch and it holds b.mu, so the loop ends once a receive
+// makes room.
+func deliver(ch chan JobEvent, ev JobEvent) {
+	for {
+		select {
+		case ch <- ev:
+			return
+		default:
+		}
+		select {
+		case <-ch:
+		default:
+		}
+	}
+}
+
+// Last returns the most recent event published for the job.
+func (b *EventBroker) Last(jobID uuid.UUID) (JobEvent, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ev, ok := b.last[jobID]
+	return ev, ok
+}
+
+// Subscribe registers a listener for the job. The latest known event, if
+// any, is queued on the returned channel immediately. Call the returned
+// function to unsubscribe.
+func (b *EventBroker) Subscribe(jobID uuid.UUID) (<-chan JobEvent, func()) {
+	ch := make(chan JobEvent, 16)
+
+	b.mu.Lock()
+	if b.subs[jobID] == nil {
+		b.subs[jobID] = map[chan JobEvent]struct{}{}
+	}
+	b.subs[jobID][ch] = struct{}{}
+	if ev, ok := b.last[jobID]; ok {
+		ch <- ev
+	}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			delete(b.subs[jobID], ch)
+			if len(b.subs[jobID]) == 0 {
+				delete(b.subs, jobID)
+			}
+		})
+	}
+}
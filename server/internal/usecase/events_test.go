@@ -0,0 +1,58 @@
+package usecase
+
+import (
+	"reflect"
+	"testing"
+
+	"resume-generator/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestEventBrokerDeliversTerminalToFullSubscriber(t *testing.T) {
+	b := NewEventBroker()
+	id := uuid.New()
+	events, unsubscribe := b.Subscribe(id)
+	defer unsubscribe()
+
+	for i := 0; i < 2*cap(events); i++ {
+		b.Publish(JobEvent{JobID: id, Stage: EventRendering})
+	}
+	b.Publish(JobEvent{JobID: id, Stage: EventDone, Terminal: true})
+
+	var last JobEvent
+	for n := len(events); n > 0; n-- {
+		last = <-events
+	}
+	if !last.Terminal || last.Stage != EventDone {
+		t.Fatalf("last queued event = %+v, want the terminal %q event", last, EventDone)
+	}
+}
+
+func TestEventResult(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[string]interface{}
+		want     map[string]interface{}
+	}{
+		{
+			name:     "completed",
+			metadata: map[string]interface{}{"generated_pdf": "a.pdf", "pdf_url": "https://x/a.pdf", "profile_overrides": map[string]interface{}{}},
+			want:     map[string]interface{}{"generated_pdf": "a.pdf", "pdf_url": "https://x/a.pdf"},
+		},
+		{
+			name:     "failed",
+			metadata: map[string]interface{}{"error": "boom", "failed_stage": StageRender, "error_code": CodeRenderFailed},
+			want:     map[string]interface{}{"failed_stage": StageRender, "error_code": CodeRenderFailed},
+		},
+		{"no metadata", nil, map[string]interface{}{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := eventResult(&domain.ResumeJob{Metadata: tt.metadata})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("eventResult() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
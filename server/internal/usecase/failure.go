@@ -0,0 +1,211 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// Pipeline stages recorded in metadata.failed_stage when a job fails.
+const (
+	// StageAggregate is recorded when the profile data could not be read
+	// and the job has no overrides to build the resume from instead.
+	StageAggregate  = "aggregation"
+	StageAI         = "ai"
+	StageValidation = "validation"
+	StageRender     = "render"
+	StageStore      = "store"
+	StageSave       = "save"
+	// StageTimeout is recorded when the job ran out of time; the phase it
+	// was in is kept in metadata.timed_out_during.
+	StageTimeout = "timeout"
+	// StageInternal covers panics and errors not attributed to a stage.
+	StageInternal = "internal"
+	// StageSubmit is recorded when the worker pool refused the job, so it
+	// never ran.
+	StageSubmit = "submit"
+)
+
+// Sentinel errors classifying why Process failed; test for them with
+// errors.Is. A ProcessError matches the sentinel of its stage, so
+// failures of the AI stage are ErrAIUnavailable, whatever the cause.
+var (
+	ErrAggregation   = errors.New("profile data aggregation failed")
+	ErrAIUnavailable = errors.New("ai service unavailable")
+	ErrValidation    = errors.New("resume validation failed")
+	ErrRenderFailed  = errors.New("resume rendering failed")
+	ErrStorage       = errors.New("storing the resume failed")
+)
+
+// Error codes recorded in metadata.error_code of a job that did not
+// complete, so clients can tell whether to retry, fix their input or
+// alert an operator without parsing the message.
+const (
+	CodeAggregation   = "aggregation_failed"
+	CodeAIUnavailable = "ai_unavailable"
+	CodeValidation    = "validation_failed"
+	CodeRenderFailed  = "render_failed"
+	CodeStorage       = "storage_failed"
+	CodeTimeout       = "timeout"
+	CodeCancelled     = "cancelled"
+	CodeInternal      = "internal"
+	CodeQueueFull     = "queue_full"
+	CodeShuttingDown  = "shutting_down"
+)
+
+// stageSentinels maps each stage to the sentinel its errors match.
+var stageSentinels = map[string]error{
+	StageAggregate:  ErrAggregation,
+	StageAI:         ErrAIUnavailable,
+	StageValidation: ErrValidation,
+	StageRender:     ErrRenderFailed,
+	StageStore:      ErrStorage,
+	StageSave:       ErrStorage,
+}
+
+// errorCodes maps the sentinel errors to their codes, in the order
+// ErrorCode checks them.
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{ErrAggregation, CodeAggregation},
+	{ErrAIUnavailable, CodeAIUnavailable},
+	{ErrValidation, CodeValidation},
+	{ErrRenderFailed, CodeRenderFailed},
+	{ErrStorage, CodeStorage},
+	{ErrQueueFull, CodeQueueFull},
+	{ErrPoolClosed, CodeShuttingDown},
+}
+
+// ErrorCode classifies an error returned by Process or WorkerPool.Submit:
+// one of the Code constants, CodeInternal when it matches none of the
+// sentinels.
+func ErrorCode(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, context.DeadlineExceeded):
+		return CodeTimeout
+	case errors.Is(err, context.Canceled):
+		return CodeCancelled
+	}
+	for _, c := range errorCodes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	return CodeInternal
+}
+
+// stageErrorCode returns the error code of failures attributed to stage.
+func stageErrorCode(stage string) string {
+	if stage == StageTimeout {
+		return CodeTimeout
+	}
+	if sentinel, ok := stageSentinels[stage]; ok {
+		return ErrorCode(sentinel)
+	}
+	return CodeInternal
+}
+
+// maxErrorLen bounds the error message stored on a failed job.
+const maxErrorLen = 500
+
+// ProcessError is returned by Process for failures attributed to a
+// pipeline stage. Retriable reports whether running the job again may
+// succeed: an unreachable AI service may recover, a resume that does not
+// validate or an unknown template will fail the same way. Use errors.As to
+// get it from Process's error; Unwrap returns the underlying error.
+type ProcessError struct {
+	Stage     string
+	Retriable bool
+	Err       error
+}
+
+func (e *ProcessError) Error() string { return e.Stage + ": " + e.Err.Error() }
+
+func (e *ProcessError) Unwrap() error { return e.Err }
+
+// Is reports whether target is the sentinel error of e's stage.
+func (e *ProcessError) Is(target error) bool {
+	sentinel, ok := stageSentinels[e.Stage]
+	return ok && target == sentinel
+}
+
+// retriableStages are the stages whose failures are retriable by default:
+// the profile database, the AI service, Chrome and storage may recover.
+// Validation failures are not, except for AI output, which varies between
+// calls.
+var retriableStages = map[string]bool{
+	StageAggregate: true,
+	StageAI:        true,
+	StageRender:    true,
+	StageStore:     true,
+	StageSave:      true,
+}
+
+// stageErr wraps err with stage, retriable when the stage is. Errors
+// already attributed to a stage keep the innermost one, and nil stays nil.
+func stageErr(stage string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var pe *ProcessError
+	if errors.As(err, &pe) {
+		return err
+	}
+	return &ProcessError{Stage: stage, Retriable: retriableStages[stage], Err: err}
+}
+
+// permanentErr wraps err with stage like stageErr, but as not retriable.
+func permanentErr(stage string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ProcessError{Stage: stage, Err: err}
+}
+
+// failureStage returns the stage err is attributed to, or StageInternal.
+func failureStage(err error) string {
+	var pe *ProcessError
+	if errors.As(err, &pe) {
+		return pe.Stage
+	}
+	return StageInternal
+}
+
+// failureRetriable reports whether retrying a job that failed with err may
+// help. Errors not attributed to a stage are assumed transient.
+func failureRetriable(err error) bool {
+	var pe *ProcessError
+	if errors.As(err, &pe) {
+		return pe.Retriable
+	}
+	return true
+}
+
+var (
+	credentialsInURL = regexp.MustCompile(`://[^/\s:@]+:[^/\s@]+@`)
+	bearerToken      = regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9._~+/=-]+`)
+)
+
+// sanitizeError turns err into a single-line message safe to show users:
+// the stage prefix is dropped (it is stored separately), credentials in
+// URLs and bearer tokens are masked and the result is truncated to
+// maxErrorLen bytes.
+func sanitizeError(err error) string {
+	var pe *ProcessError
+	msg := err.Error()
+	if errors.As(err, &pe) {
+		msg = pe.Err.Error()
+	}
+	msg = strings.Join(strings.Fields(msg), " ")
+	msg = credentialsInURL.ReplaceAllString(msg, "://***@")
+	msg = bearerToken.ReplaceAllString(msg, "Bearer ***")
+	if len(msg) > maxErrorLen {
+		msg = strings.ToValidUTF8(msg[:maxErrorLen], "") + "..."
+	}
+	return msg
+}
@@ -0,0 +1,235 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"resume-generator/internal/domain"
+)
+
+func TestStageErr(t *testing.T) {
+	cause := errors.New("boom")
+	tests := []struct {
+		name      string
+		err       error
+		stage     string
+		retriable bool
+		sentinel  error
+		code      string
+	}{
+		{"aggregation", stageErr(StageAggregate, cause), StageAggregate, true, ErrAggregation, CodeAggregation},
+		{"ai", stageErr(StageAI, cause), StageAI, true, ErrAIUnavailable, CodeAIUnavailable},
+		{"validation", stageErr(StageValidation, cause), StageValidation, false, ErrValidation, CodeValidation},
+		{"render", stageErr(StageRender, cause), StageRender, true, ErrRenderFailed, CodeRenderFailed},
+		{"permanent render", permanentErr(StageRender, cause), StageRender, false, ErrRenderFailed, CodeRenderFailed},
+		{"store", stageErr(StageStore, cause), StageStore, true, ErrStorage, CodeStorage},
+		{"save", stageErr(StageSave, cause), StageSave, true, ErrStorage, CodeStorage},
+		{"innermost stage kept", stageErr(StageRender, fmt.Errorf("retry: %w", permanentErr(StageValidation, cause))), StageValidation, false, ErrValidation, CodeValidation},
+	}
+	sentinels := []error{ErrAggregation, ErrAIUnavailable, ErrValidation, ErrRenderFailed, ErrStorage}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pe *ProcessError
+			if !errors.As(tt.err, &pe) {
+				t.Fatalf("errors.As(%v) found no ProcessError", tt.err)
+			}
+			if pe.Stage != tt.stage || pe.Retriable != tt.retriable {
+				t.Errorf("ProcessError{Stage: %q, Retriable: %v}, want {%q, %v}", pe.Stage, pe.Retriable, tt.stage, tt.retriable)
+			}
+			if !errors.Is(tt.err, cause) {
+				t.Error("the underlying error is not unwrapped")
+			}
+			for _, s := range sentinels {
+				if got := errors.Is(tt.err, s); got != (s == tt.sentinel) {
+					t.Errorf("errors.Is(err, %q) = %v", s, got)
+				}
+			}
+			if got := failureStage(tt.err); got != tt.stage {
+				t.Errorf("failureStage() = %q, want %q", got, tt.stage)
+			}
+			if got := failureRetriable(tt.err); got != tt.retriable {
+				t.Errorf("failureRetriable() = %v, want %v", got, tt.retriable)
+			}
+			if got := ErrorCode(tt.err); got != tt.code {
+				t.Errorf("ErrorCode() = %q, want %q", got, tt.code)
+			}
+			if got := stageErrorCode(tt.stage); got != tt.code {
+				t.Errorf("stageErrorCode(%q) = %q, want %q", tt.stage, got, tt.code)
+			}
+		})
+	}
+
+	if stageErr(StageRender, nil) != nil || permanentErr(StageRender, nil) != nil {
+		t.Error("a nil error was wrapped")
+	}
+	if got := stageErr(StageAI, cause).Error(); got != "ai: boom" {
+		t.Errorf("Error() = %q, want %q", got, "ai: boom")
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"nil", nil, ""},
+		{"deadline", fmt.Errorf("format: %w", context.DeadlineExceeded), CodeTimeout},
+		{"deadline inside a stage", stageErr(StageAI, context.DeadlineExceeded), CodeTimeout},
+		{"canceled", context.Canceled, CodeCancelled},
+		{"wrapped sentinel", fmt.Errorf("%w: no rows", ErrAggregation), CodeAggregation},
+		{"queue full", ErrQueueFull, CodeQueueFull},
+		{"pool closed", ErrPoolClosed, CodeShuttingDown},
+		{"unclassified", errors.New("panic: nil map"), CodeInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ErrorCode(tt.err); got != tt.want {
+				t.Errorf("ErrorCode(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+	if failureStage(errors.New("x")) != StageInternal || !failureRetriable(errors.New("x")) {
+		t.Error("unattributed errors should be internal and retriable")
+	}
+	if got := stageErrorCode(StageTimeout); got != CodeTimeout {
+		t.Errorf("stageErrorCode(timeout) = %q", got)
+	}
+	if got := stageErrorCode(StageInternal); got != CodeInternal {
+		t.Errorf("stageErrorCode(internal) = %q", got)
+	}
+}
+
+func TestSanitizeError(t *testing.T) {
+	long := strings.Repeat("é", maxErrorLen)
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"stage prefix dropped", stageErr(StageStore, errors.New("disk full")), "disk full"},
+		{"whitespace collapsed", errors.New("line one\n\tline  two"), "line one line two"},
+		{"url credentials", errors.New(`dial postgres://app:s3cret@db:5432/resumes: refused`), "dial postgres://***@db:5432/resumes: refused"},
+		{"bearer token", errors.New("401 for Authorization: bearer abc.DEF-123"), "401 for Authorization: Bearer ***"},
+		{"truncated on a rune boundary", errors.New(long), strings.Repeat("é", maxErrorLen/2) + "..."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeError(tt.err); got != tt.want {
+				t.Errorf("sanitizeError() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// failingStorage fails every Put.
+type failingStorage struct{}
+
+func (failingStorage) Put(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	return "", errors.New("bucket unreachable")
+}
+
+func (failingStorage) Delete(ctx context.Context, key string) error { return nil }
+
+// failingSave is a savedJobs whose first Save, the completed job's, fails.
+type failingSave struct {
+	savedJobs
+	failed bool
+}
+
+func (r *failingSave) Save(ctx context.Context, j *domain.ResumeJob) error {
+	if !r.failed {
+		r.failed = true
+		return errors.New("connection reset")
+	}
+	return r.savedJobs.Save(ctx, j)
+}
+
+func TestProcessFailureStages(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []ProcessorOption
+		edit      func(*domain.ResumeJob)
+		stage     string
+		retriable bool
+		sentinel  error
+	}{
+		{
+			name:     "validation",
+			edit:     func(j *domain.ResumeJob) { delete(j.Profile, "meta") },
+			stage:    StageValidation,
+			sentinel: ErrValidation,
+		},
+		{
+			name:     "unknown template",
+			edit:     func(j *domain.ResumeJob) { j.Template = "nope" },
+			stage:    StageRender,
+			sentinel: ErrRenderFailed,
+		},
+		{
+			name:      "storage",
+			opts:      []ProcessorOption{WithStorage(failingStorage{})},
+			stage:     StageStore,
+			retriable: true,
+			sentinel:  ErrStorage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &savedJobs{}
+			opts := append([]ProcessorOption{WithAIMode(AIModeOff), WithOutputDir(t.TempDir())}, tt.opts...)
+			p := NewProcessor(&blockingRenderer{release: closedChan()}, repo, "English", opts...)
+			job := offlineJob()
+			if tt.edit != nil {
+				tt.edit(job)
+			}
+			err := p.Process(context.Background(), job)
+			checkFailure(t, err, repo.last, tt.stage, tt.retriable, tt.sentinel)
+		})
+	}
+
+	t.Run("save", func(t *testing.T) {
+		repo := &failingSave{}
+		p := NewProcessor(&blockingRenderer{release: closedChan()}, repo, "English", WithAIMode(AIModeOff), WithOutputDir(t.TempDir()))
+		err := p.Process(context.Background(), offlineJob())
+		checkFailure(t, err, repo.last, StageSave, true, ErrStorage)
+	})
+}
+
+func checkFailure(t *testing.T, err error, saved *domain.ResumeJob, stage string, retriable bool, sentinel error) {
+	t.Helper()
+	var pe *ProcessError
+	if !errors.As(err, &pe) {
+		t.Fatalf("Process() = %v, want a ProcessError", err)
+	}
+	if pe.Stage != stage || pe.Retriable != retriable {
+		t.Errorf("ProcessError{Stage: %q, Retriable: %v}, want {%q, %v}", pe.Stage, pe.Retriable, stage, retriable)
+	}
+	if !errors.Is(err, sentinel) {
+		t.Errorf("errors.Is(%v, %v) = false", err, sentinel)
+	}
+	if saved == nil || saved.Status != "failed" {
+		t.Fatalf("saved job = %+v, want a failed job", saved)
+	}
+	for key, want := range map[string]interface{}{
+		"failed_stage": stage,
+		"error_code":   ErrorCode(sentinel),
+		"retriable":    retriable,
+	} {
+		if saved.Metadata[key] != want {
+			t.Errorf("metadata.%s = %v, want %v", key, saved.Metadata[key], want)
+		}
+	}
+	if msg, _ := saved.Metadata["error"].(string); strings.HasPrefix(msg, stage+":") {
+		t.Errorf("metadata.error %q keeps the stage prefix", msg)
+	}
+}
+
+func closedChan() chan struct{} {
+	c := make(chan struct{})
+	close(c)
+	return c
+}
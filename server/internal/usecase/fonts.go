@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"resume-generator/pkg/logctx"
+)
+
+// Templates pick their fonts with meta tags, e.g.
+//
+//	<meta name="resume-fonts" content="Inter, Noto Sans" />
+//	<meta name="resume-fonts-cjk" content="Noto Sans CJK SC" />
+//
+// The CJK families are only embedded when the resume contains CJK text,
+// since those fonts are large.
+var (
+	resumeFontsMeta    = regexp.MustCompile(`<meta\s+name="resume-fonts"\s+content="([^"]*)"`)
+	resumeFontsCJKMeta = regexp.MustCompile(`<meta\s+name="resume-fonts-cjk"\s+content="([^"]*)"`)
+)
+
+// fontCSS returns the @font-face rules for the families the rendered html
+// asks for, plus a --font-family custom property listing them in order, or
+// "" when the template names none. A non-empty font, e.g. from the job's
+// Theme, goes first. Fonts that cannot be loaded are logged and left to the
+// system.
+func (p *Processor) fontCSS(ctx context.Context, html, font string) string {
+	families := metaFamilies(resumeFontsMeta, html)
+	if font != "" {
+		rest := families
+		families = []string{font}
+		for _, f := range rest {
+			if !strings.EqualFold(f, font) {
+				families = append(families, f)
+			}
+		}
+	}
+	if containsCJK(html) {
+		families = append(families, metaFamilies(resumeFontsCJKMeta, html)...)
+	}
+	if len(families) == 0 {
+		return ""
+	}
+
+	var css string
+	if p.fonts != nil {
+		faces, err := p.fonts.FaceCSS(families...)
+		if err != nil {
+			logctx.Warnf(ctx, "processor: load fonts failed: %v", err)
+		}
+		css = faces
+	}
+	quoted := make([]string, len(families))
+	for i, f := range families {
+		quoted[i] = strconv.Quote(f)
+	}
+	return css + ":root{--font-family:" + strings.Join(quoted, ",") + ",sans-serif;}"
+}
+
+// metaFamilies returns the comma separated families of the first meta tag
+// matching re in html.
+func metaFamilies(re *regexp.Regexp, html string) []string {
+	m := re.FindStringSubmatch(html)
+	if m == nil {
+		return nil
+	}
+	var out []string
+	for _, f := range strings.Split(m[1], ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// containsCJK reports whether s has Chinese, Japanese or Korean characters.
+func containsCJK(s string) bool {
+	for _, r := range s {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"resume-generator/internal/domain"
+	infra "resume-generator/pkg/infrastructure"
+)
+
+func TestFontCSS(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"Inter/Inter-Regular.woff2", "Noto Sans CJK SC/NotoSansCJKsc-Regular.otf"} {
+		path := filepath.Join(dir, f)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("font"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := &Processor{fonts: infra.NewFontSet(dir)}
+	head := `<head><meta name="resume-fonts" content="Inter, Georgia" /><meta name="resume-fonts-cjk" content="Noto Sans CJK SC" /></head>`
+
+	tests := []struct {
+		name      string
+		html      string
+		font      string
+		wantVar   string
+		wantFaces []string
+	}{
+		{name: "no meta tag", html: "<head></head><p>Olá</p>"},
+		{
+			name:      "latin text",
+			html:      head + "<p>Olá, João</p>",
+			wantVar:   `:root{--font-family:"Inter","Georgia",sans-serif;}`,
+			wantFaces: []string{`font-family:"Inter"`},
+		},
+		{
+			name:      "cjk text",
+			html:      head + "<p>山田 太郎</p>",
+			wantVar:   `:root{--font-family:"Inter","Georgia","Noto Sans CJK SC",sans-serif;}`,
+			wantFaces: []string{`font-family:"Inter"`, `font-family:"Noto Sans CJK SC"`},
+		},
+		{
+			name:      "theme font first",
+			html:      head,
+			font:      "georgia",
+			wantVar:   `:root{--font-family:"georgia","Inter",sans-serif;}`,
+			wantFaces: []string{`font-family:"Inter"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			css := p.fontCSS(context.Background(), tt.html, tt.font)
+			if !strings.HasSuffix(css, tt.wantVar) {
+				t.Errorf("fontCSS() = %q, want it to end in %q", css, tt.wantVar)
+			}
+			if got := strings.Count(css, "@font-face"); got != len(tt.wantFaces) {
+				t.Errorf("fontCSS() has %d @font-face rules, want %d", got, len(tt.wantFaces))
+			}
+			for _, face := range tt.wantFaces {
+				if !strings.Contains(css, face) {
+					t.Errorf("fontCSS() lacks %s", face)
+				}
+			}
+		})
+	}
+}
+
+func TestInlineStylesInjectsFonts(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "Inter"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "Inter", "Inter-Bold.woff2"), []byte("font"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p := &Processor{fonts: infra.NewFontSet(dir)}
+	html := `<html><head><meta name="resume-fonts" content="Inter" /></head><body></body></html>`
+
+	out := p.inlineStyles(context.Background(), &domain.ResumeJob{}, html, "style.css")
+	i := strings.Index(out, "<head><style>")
+	face := strings.Index(out, `@font-face{font-family:"Inter";font-weight:700`)
+	if i < 0 || face < i || face > strings.Index(out, "</style>") {
+		t.Errorf("font CSS not inlined in the head style block:\n%.300s", out)
+	}
+}
@@ -0,0 +1,61 @@
+package usecase
+
+import (
+	"strings"
+
+	"resume-generator/internal/domain"
+)
+
+// Output formats a job can ask for besides the HTML. The PDF is rendered
+// for every job; DOCX, TXT and TeX only when requested.
+const (
+	FormatPDF  = "pdf"
+	FormatDOCX = "docx"
+	FormatTXT  = "txt"
+	FormatTeX  = "tex"
+)
+
+// Formats lists the accepted output formats.
+var Formats = []string{FormatPDF, FormatDOCX, FormatTXT, FormatTeX}
+
+// ValidFormat reports whether s is one of Formats, ignoring case.
+func ValidFormat(s string) bool {
+	for _, f := range Formats {
+		if strings.EqualFold(f, strings.TrimSpace(s)) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetFormats records the requested formats, lower-cased, in
+// job.Metadata["formats"]; an empty list is not recorded.
+func SetFormats(job *domain.ResumeJob, formats []string) {
+	var out []string
+	for _, f := range formats {
+		if f = strings.ToLower(strings.TrimSpace(f)); f != "" {
+			out = append(out, f)
+		}
+	}
+	if len(out) == 0 {
+		return
+	}
+	if job.Metadata == nil {
+		job.Metadata = map[string]interface{}{}
+	}
+	job.Metadata["formats"] = out
+}
+
+// wantsFormat reports whether the job asked for format, either in
+// metadata.formats or in the older single metadata.format.
+func wantsFormat(job *domain.ResumeJob, format string) bool {
+	if f, _ := job.Metadata["format"].(string); strings.EqualFold(f, format) {
+		return true
+	}
+	for _, f := range metadataStrings(job, "formats") {
+		if strings.EqualFold(f, format) {
+			return true
+		}
+	}
+	return false
+}
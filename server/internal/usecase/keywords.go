@@ -0,0 +1,230 @@
+package usecase
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	repo "resume-generator/internal/adapter/repository"
+	"resume-generator/internal/domain"
+)
+
+// maxJobKeywords caps the keywords taken from a job description.
+const maxJobKeywords = 20
+
+// commonSkills are looked for in job descriptions in addition to the
+// technologies and skills of the user's own data.
+var commonSkills = []string{
+	"Go", "Golang", "Python", "Java", "Kotlin", "Scala", "Rust", "C++", "C#", ".NET",
+	"JavaScript", "TypeScript", "Node.js", "React", "Next.js", "Vue", "Angular", "Svelte",
+	"Ruby", "Rails", "PHP", "Laravel", "Django", "Flask", "FastAPI", "Spring", "Swift",
+	"SQL", "PostgreSQL", "MySQL", "MongoDB", "Redis", "Elasticsearch", "Kafka", "RabbitMQ",
+	"GraphQL", "RESTful", "gRPC", "Microservices", "Docker", "Kubernetes", "Terraform", "Ansible",
+	"AWS", "GCP", "Azure", "Linux", "CI/CD", "Git", "Prometheus", "Grafana",
+	"Machine Learning", "Deep Learning", "PyTorch", "TensorFlow", "Pandas", "Spark", "Airflow",
+	"HTML", "CSS", "Tailwind", "Figma", "Agile", "Scrum", "TDD",
+	"Leadership", "Mentoring", "Communication", "System Design", "Distributed Systems",
+}
+
+// jobDescriptionText joins the job's description and the descriptive
+// fields of its job application row.
+func jobDescriptionText(job *domain.ResumeJob, jobApplication interface{}) string {
+	parts := []string{job.JobDescription}
+	if ja, ok := jobApplication.(map[string]interface{}); ok {
+		for _, k := range []string{"job_title", "title", "position", "description", "job_description", "requirements", "responsibilities", "notes"} {
+			if s, ok := ja[k].(string); ok {
+				parts = append(parts, s)
+			}
+		}
+	}
+	return strings.TrimSpace(strings.Join(parts, "\n"))
+}
+
+// jobKeywords returns the skills named in text: the technologies and
+// skills of the aggregated data first, then commonSkills, in the order
+// they first appear in text. Only whole words match, so "Go" does not
+// match "good"; case is ignored except for names of up to three letters,
+// which keeps "go" and "Go" apart.
+func jobKeywords(text string, agg repo.AggregateResult) []string {
+	if text == "" {
+		return nil
+	}
+	lower := strings.ToLower(text)
+	type hit struct {
+		name string
+		pos  int
+	}
+	var hits []hit
+	seen := map[string]bool{}
+	for _, name := range append(userSkills(agg), commonSkills...) {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		pos := wordIndex(lower, key)
+		if len(key) <= 3 {
+			pos = wordIndex(text, strings.TrimSpace(name))
+		}
+		if pos >= 0 {
+			hits = append(hits, hit{strings.TrimSpace(name), pos})
+		}
+	}
+	// at the same position the longer name wins, so "Vue.js" is not also
+	// reported as "Vue"
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].pos != hits[j].pos {
+			return hits[i].pos < hits[j].pos
+		}
+		return len(hits[i].name) > len(hits[j].name)
+	})
+
+	var out []string
+	for i, h := range hits {
+		if len(out) >= maxJobKeywords {
+			break
+		}
+		if i > 0 && hits[i-1].pos == h.pos {
+			continue
+		}
+		out = append(out, h.name)
+	}
+	return out
+}
+
+// userSkills lists the skill and technology names in the aggregated data.
+func userSkills(agg repo.AggregateResult) []string {
+	var names []string
+	if groups, ok := agg["skills"].([]interface{}); ok {
+		for _, g := range groups {
+			gm, _ := g.(map[string]interface{})
+			items, _ := gm["items"].([]interface{})
+			for _, it := range items {
+				if s := itemTitle(it); s != "" {
+					names = append(names, s)
+				}
+			}
+		}
+	}
+	for _, k := range []string{"technologies", "project_technologies"} {
+		if rows, ok := agg[k].([]interface{}); ok {
+			for _, r := range rows {
+				if s := itemTitle(r); s != "" {
+					names = append(names, s)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// wordIndex returns the index of the first occurrence of word in s that is
+// not part of a longer word, or -1.
+func wordIndex(s, word string) int {
+	for from := 0; from < len(s); {
+		i := strings.Index(s[from:], word)
+		if i < 0 {
+			return -1
+		}
+		i += from
+		end := i + len(word)
+		if !wordRuneAt(s, i-1) && !wordRuneAt(s, end) {
+			return i
+		}
+		from = i + 1
+	}
+	return -1
+}
+
+// wordRuneAt reports whether s has a letter or digit at byte i.
+func wordRuneAt(s string, i int) bool {
+	if i < 0 || i >= len(s) {
+		return false
+	}
+	r := rune(s[i])
+	if r >= 0x80 {
+		// inside a multi-byte letter such as "ç"
+		return true
+	}
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// prioritizeKeywords reorders resume.snapshot.tech so the entries naming
+// one of keywords come first, keeping the rest in place, and returns the
+// keywords found anywhere in the resume.
+func prioritizeKeywords(resume map[string]interface{}, keywords []string) []string {
+	if len(keywords) == 0 {
+		return nil
+	}
+	if snap, ok := resume["snapshot"].(map[string]interface{}); ok {
+		if tech, ok := snap["tech"].(string); ok {
+			snap["tech"] = reorderTech(tech, keywords)
+		}
+	}
+
+	text := strings.ToLower(itemText(resume))
+	matched := []string{}
+	for _, k := range keywords {
+		if mentionsAny(text, []string{k}) {
+			matched = append(matched, k)
+		}
+	}
+	return matched
+}
+
+// reorderTech moves the entries of a comma, semicolon, bullet or pipe
+// separated list that mention a keyword to the front. A string without
+// such separators is returned as is.
+func reorderTech(tech string, keywords []string) string {
+	sep := ""
+	for _, s := range []string{", ", "; ", " · ", " | ", ",", ";", "·", "|"} {
+		if strings.Contains(tech, s) {
+			sep = s
+			break
+		}
+	}
+	if sep == "" {
+		return tech
+	}
+
+	// a closing period stays at the end of the list
+	body := strings.TrimSpace(tech)
+	end := ""
+	if strings.HasSuffix(body, ".") {
+		body, end = strings.TrimSuffix(body, "."), "."
+	}
+	var first, rest []string
+	for _, part := range strings.Split(body, sep) {
+		if mentionsAny(strings.ToLower(part), keywords) {
+			first = append(first, part)
+		} else {
+			rest = append(rest, part)
+		}
+	}
+	if len(first) == 0 {
+		return tech
+	}
+	return strings.Join(append(first, rest...), sep) + end
+}
+
+// mentionsAny reports whether the lower-cased s names one of keywords.
+func mentionsAny(s string, keywords []string) bool {
+	for _, k := range keywords {
+		if wordIndex(s, strings.ToLower(k)) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// recordJobKeywords stores the job's keywords and the ones found in the
+// resume in metadata.job_keywords and metadata.matched_keywords.
+func recordJobKeywords(job *domain.ResumeJob, keywords, matched []string) {
+	if len(keywords) == 0 {
+		delete(job.Metadata, "job_keywords")
+		delete(job.Metadata, "matched_keywords")
+		return
+	}
+	job.Metadata["job_keywords"] = keywords
+	job.Metadata["matched_keywords"] = matched
+}
@@ -0,0 +1,25 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// safeName matches the identifiers that may be joined into a file path:
+// lowercase letters, digits, '_' and '-'.
+var safeName = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// ErrInvalidName is returned for template names and path keys that could
+// escape their directory.
+var ErrInvalidName = errors.New("invalid name: only [a-z0-9_-] is allowed")
+
+// ValidateName rejects externally supplied names before they are joined
+// into a filesystem path. Separators, "..", absolute paths and anything
+// outside [a-z0-9_-] are refused.
+func ValidateName(name string) error {
+	if !safeName.MatchString(name) {
+		return fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	return nil
+}
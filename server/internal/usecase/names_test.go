@@ -0,0 +1,47 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+
+	"resume-generator/internal/domain"
+)
+
+func TestValidateName(t *testing.T) {
+	tests := []struct {
+		name string
+		ok   bool
+	}{
+		{"classic", true},
+		{"two-column_v2", true},
+		{"0b5ad6c4-2d7b-4c7e-9d4f-1a2b3c4d5e6f", true},
+		{"../../etc/passwd", false},
+		{"..", false},
+		{"/etc/passwd", false},
+		{"a/b", false},
+		{`a\b`, false},
+		{"Classic", false},
+		{"", false},
+		{"name.html", false},
+	}
+	for _, tt := range tests {
+		err := ValidateName(tt.name)
+		if tt.ok && err != nil {
+			t.Errorf("ValidateName(%q) = %v, want nil", tt.name, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrInvalidName) {
+			t.Errorf("ValidateName(%q) = %v, want ErrInvalidName", tt.name, err)
+		}
+	}
+}
+
+func TestJobLayoutRejectsPaths(t *testing.T) {
+	for _, name := range []string{"../../etc/passwd", "/etc/passwd", "classic/../../x"} {
+		if _, err := jobLayout(&domain.ResumeJob{Template: name}); err == nil {
+			t.Errorf("jobLayout(%q) accepted the name", name)
+		}
+	}
+	if _, err := jobLayout(&domain.ResumeJob{}); err != nil {
+		t.Errorf("jobLayout(default) = %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"resume-generator/internal/domain"
+)
+
+// DefaultMaxSectionItems caps publications, certifications and projects
+// merged from aggregated data unless job.Metadata["max_<section>"] (e.g.
+// "max_publications") sets another limit.
+const DefaultMaxSectionItems = 8
+
+// sectionCap returns the item limit for section on job.
+func sectionCap(job *domain.ResumeJob, section string) int {
+	switch n := job.Metadata["max_"+section].(type) {
+	case int:
+		if n > 0 {
+			return n
+		}
+	case float64:
+		if n >= 1 {
+			return int(n)
+		}
+	}
+	return DefaultMaxSectionItems
+}
+
+// recencyKeys are date fields checked, in order, to sort items newest
+// first. Dates are compared as strings, which orders ISO 8601 correctly.
+var recencyKeys = []string{"published_at", "publication_date", "date", "issued_at", "issue_date", "end_date", "updated_at", "created_at", "start_date"}
+
+// normalizeSection orders items by relevance/score and then recency when
+// they carry those fields, drops items whose title repeats after
+// normalization and keeps at most max. Items without a title are kept.
+func normalizeSection(items []interface{}, max int) []interface{} {
+	sorted := append([]interface{}(nil), items...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ri, okI := itemRelevance(sorted[i])
+		rj, okJ := itemRelevance(sorted[j])
+		if okI != okJ {
+			return okI
+		}
+		if okI && ri != rj {
+			return ri > rj
+		}
+		di, dj := itemDate(sorted[i]), itemDate(sorted[j])
+		if (di == "") != (dj == "") {
+			return di != ""
+		}
+		return di > dj
+	})
+
+	out := []interface{}{}
+	seen := map[string]bool{}
+	for _, it := range sorted {
+		if len(out) >= max {
+			break
+		}
+		if key := normalizeTitle(itemTitle(it)); key != "" {
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+		out = append(out, it)
+	}
+	return out
+}
+
+func itemTitle(it interface{}) string {
+	switch t := it.(type) {
+	case string:
+		return t
+	case map[string]interface{}:
+		for _, k := range []string{"title", "name", "outline"} {
+			if s, ok := t[k].(string); ok && s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+func itemRelevance(it interface{}) (float64, bool) {
+	m, ok := it.(map[string]interface{})
+	if !ok {
+		return 0, false
+	}
+	for _, k := range []string{"relevance", "score"} {
+		if f, ok := m[k].(float64); ok {
+			return f, true
+		}
+	}
+	return 0, false
+}
+
+func itemDate(it interface{}) string {
+	m, ok := it.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	for _, k := range recencyKeys {
+		switch v := m[k].(type) {
+		case string:
+			if v != "" {
+				return v
+			}
+		case float64:
+			// bare years, e.g. "year": 2021 stored under date
+			return fmt.Sprintf("%04d", int(v))
+		}
+	}
+	return ""
+}
+
+// normalizeTitle lowercases s and collapses punctuation and whitespace so
+// "Go, Concurrency!" and "go concurrency" compare equal.
+func normalizeTitle(s string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}), " ")
+}
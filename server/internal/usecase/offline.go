@@ -0,0 +1,567 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"sort"
+	"strings"
+
+	repo "resume-generator/internal/adapter/repository"
+	"resume-generator/internal/domain"
+	"resume-generator/internal/model"
+	"resume-generator/pkg/ai/formatters"
+	"resume-generator/pkg/logctx"
+)
+
+// generate builds and renders job's resume. With AIModeOff the AI service is
+// never called. With AIModeAuto a failure of the AI or validation stage is
+// followed by the offline builder, so an unreachable AI service degrades the
+// resume instead of failing the job; the AI error is kept in
+// metadata.ai_error. When the offline resume does not validate either, the
+// AI error is returned.
+func (p *Processor) generate(ctx context.Context, job *domain.ResumeJob) error {
+	if p.aiMode == AIModeOff {
+		return p.processOffline(ctx, job)
+	}
+
+	overrides := job.Profile
+	err := p.process(ctx, job)
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+	if stage := failureStage(err); stage != StageAI && stage != StageValidation {
+		return err
+	}
+
+	logctx.Warnf(ctx, "processor: AI pipeline failed, building resume offline: %v", err)
+	job.Profile = overrides
+	if job.Metadata == nil {
+		job.Metadata = map[string]interface{}{}
+	}
+	job.Metadata["ai_error"] = sanitizeError(err)
+	offlineErr := p.processOffline(ctx, job)
+	if offlineErr != nil && failureStage(offlineErr) == StageValidation {
+		logctx.Warnf(ctx, "processor: offline resume failed too: %v", offlineErr)
+		return err
+	}
+	return offlineErr
+}
+
+// processOffline is process without the AI service: the aggregated rows and
+// the caller's overrides are mapped straight into the resume schema by
+// buildOfflineResume, validated and rendered. Labels come from the label
+// cache when the job's language was translated before, else the English
+// defaults. The job's metadata.ai_used is false and ai_skipped true, which
+// tells clients the resume is the plainer one built without enrichment.
+func (p *Processor) processOffline(ctx context.Context, job *domain.ResumeJob) error {
+	if job.Language == "" {
+		job.Language = p.defaultLanguage
+	}
+
+	p.setStatus(ctx, job, domain.StatusAggregating)
+	p.publish(job, EventAggregating, "")
+	agg, err := repo.AggregateForUser(ctx, job.UserID.String())
+	if err != nil {
+		if len(job.Profile) == 0 {
+			return stageErr(StageAggregate, fmt.Errorf("%w: %v", ErrAggregation, err))
+		}
+		// the overrides alone may still make a resume
+		logctx.Warnf(ctx, "processor: aggregate failed, building resume from overrides only: %v", err)
+		agg = repo.AggregateResult{}
+	}
+
+	p.setStatus(ctx, job, domain.StatusFormatting)
+	p.publish(job, EventFormatting, "offline")
+	resumeMap := buildOfflineResume(job, agg, job.Profile)
+	repairCertifications(ctx, resumeMap)
+	dropInvalidPhoto(resumeMap)
+	normalizeMetaContact(resumeMap)
+
+	p.setStatus(ctx, job, domain.StatusValidating)
+	p.publish(job, EventValidating, "")
+	// the offline resume may have fewer snapshot items than the schema asks
+	// for, which the templates render fine; a missing field is fatal
+	verrs, err := model.ValidateMapDetailed(resumeMap)
+	if err == nil {
+		err = missingRequired(verrs)
+	}
+	if err != nil {
+		recordValidationErrors(job, verrs)
+		return stageErr(StageValidation, fmt.Errorf("offline resume validation failed: %w", err))
+	}
+	dedupeMergedSections(resumeMap)
+	compactCertificationDates(resumeMap)
+	labelCertificationURLs(resumeMap)
+	sortExperienceByPeriod(resumeMap, job.Language)
+	tailorSections(job, resumeMap)
+
+	if job.Metadata == nil {
+		job.Metadata = map[string]interface{}{}
+	}
+	keywords := jobKeywords(jobDescriptionText(job, nil), agg)
+	recordJobKeywords(job, keywords, prioritizeKeywords(resumeMap, keywords))
+	synthesizedFields, sourcedFields := classifySections(resumeMap, agg, job.Profile)
+	job.Metadata["ai_used"] = false
+	job.Metadata["ai_skipped"] = true
+	job.Metadata["ai_warnings"] = []string{}
+	job.Metadata["ai_synthesized"] = false
+	job.Metadata["synthesized_fields"] = synthesizedFields
+	job.Metadata["sourced_fields"] = sourcedFields
+	job.Profile = resumeMap
+
+	labels := formatters.GetDefaultLabels()
+	if cached, ok := p.aiClient.LabelCache().Get(ctx, job.Language); ok {
+		for k, v := range cached {
+			if v != "" {
+				labels[k] = v
+			}
+		}
+	}
+	job.Profile["labels"] = labels
+
+	return stageErr(StageRender, p.renderAndSave(ctx, job, labels))
+}
+
+// maxOfflineBullets caps the bullets split from one experience description.
+const maxOfflineBullets = 5
+
+// buildOfflineResume maps the aggregated rows into the resume schema without
+// rewriting anything: meta and summary come from the first profile (or the
+// user row), experience bullets from the description lines, publications
+// from their titles. The snapshot is filled from impact metrics, bullets and
+// project titles. Publications, certifications, extras, education and skills
+// in overrides replace the aggregated ones, as do overrides.summary and the
+// keys of overrides.meta.
+func buildOfflineResume(job *domain.ResumeJob, agg map[string]interface{}, overrides map[string]interface{}) map[string]interface{} {
+	profile := firstRow(agg["profiles"])
+	user, _ := agg["user"].(map[string]interface{})
+
+	resume := map[string]interface{}{}
+	meta := offlineMeta(profile, user)
+	experience := offlineExperience(job, agg["experiences"])
+	if meta["headline"] == "" && len(experience) > 0 {
+		meta["headline"] = experience[0].(map[string]interface{})["title"]
+	}
+	summary := rowString(profile, "bio", "summary", "about", "description")
+	if summary == "" {
+		summary, _ = meta["headline"].(string)
+	}
+	projects := offlineProjects(job, agg["projects"])
+
+	resume["experience"] = experience
+	resume["projects"] = projects
+	resume["publications"] = offlinePublications(job, agg["publications"])
+	resume["certifications"] = offlineCertifications(job, agg["certifications"])
+	resume["extras"] = offlineExtras(agg["extras"])
+	if skills, ok := agg["skills"].([]interface{}); ok {
+		resume["skills"] = skills
+	}
+	if edu := ParseEducation(agg["education"]); len(edu) > 0 {
+		resume["education"] = educationToList(edu)
+	}
+
+	if overrides != nil {
+		ov := NewOverridesFromMap(overrides)
+		if pubs, ok := overrides["publications"].([]interface{}); ok && len(pubs) > 0 {
+			resume["publications"] = offlinePublications(job, pubs)
+		}
+		if len(ov.Certifications) > 0 {
+			certs := make([]interface{}, 0, len(ov.Certifications))
+			for _, c := range ov.Certifications {
+				certs = append(certs, map[string]interface{}{"name": c.Name, "issuer": c.Issuer, "date": c.Date, "url": c.URL, "description": c.Description})
+			}
+			resume["certifications"] = certs
+		}
+		if len(ov.Extras) > 0 {
+			extras := make([]interface{}, 0, len(ov.Extras))
+			for _, e := range ov.Extras {
+				extras = append(extras, map[string]interface{}{"category": e.Category, "text": e.Text})
+			}
+			resume["extras"] = extras
+		}
+		if len(ov.Education) > 0 {
+			resume["education"] = educationToList(ov.Education)
+		}
+		if len(ov.Skills) > 0 {
+			resume["skills"] = skillsToList(ov.Skills)
+		}
+		if s, ok := overrides["summary"].(string); ok && s != "" {
+			summary = s
+		}
+		if m, ok := overrides["meta"].(map[string]interface{}); ok {
+			for k, v := range m {
+				meta[k] = v
+			}
+		}
+	}
+
+	// leave required fields missing rather than empty, so a resume without
+	// a name fails validation
+	for _, k := range []string{"name", "headline"} {
+		if s, _ := meta[k].(string); s == "" {
+			delete(meta, k)
+		}
+	}
+	resume["meta"] = meta
+	resume["summary"] = summary
+	resume["snapshot"] = offlineSnapshot(resume, agg["impact_metrics"])
+	return resume
+}
+
+// missingRequired returns an error naming the required fields verrs
+// reports missing, nil when there are none.
+func missingRequired(verrs []model.ValidationError) error {
+	var missing []string
+	for _, e := range verrs {
+		if e.Type == "required" {
+			missing = append(missing, e.Description)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(missing, "; "))
+}
+
+func offlineMeta(profile, user map[string]interface{}) map[string]interface{} {
+	meta := map[string]interface{}{
+		"name":     firstNonEmpty(rowString(profile, "name", "full_name", "display_name"), rowString(user, "name", "full_name", "username")),
+		"headline": rowString(profile, "headline", "title", "role"),
+	}
+
+	contact := map[string]interface{}{}
+	email := firstNonEmpty(rowString(profile, "email", "contact_email"), rowString(user, "email"))
+	if _, err := mail.ParseAddress(email); err == nil {
+		contact["email"] = email
+	}
+	if loc := firstNonEmpty(rowString(profile, "location", "city"), rowString(user, "location")); loc != "" {
+		contact["location"] = loc
+	}
+	if len(contact) > 0 {
+		meta["contact"] = contact
+	}
+
+	if links, ok := profile["social_links"].(map[string]interface{}); ok {
+		if social := model.NormalizeSocialLinks(links); social != nil {
+			meta["social_links"] = social
+		}
+	}
+	if photo := rowString(profile, "photo_url"); model.IsHTTPURL(photo) {
+		meta["photo_url"] = photo
+	}
+	return meta
+}
+
+// offlineExperience lists current roles first, then the others by start
+// date, newest first. Unlike the other sections, repeated titles are kept:
+// they are usually the same role at different companies.
+func offlineExperience(job *domain.ResumeJob, rows interface{}) []interface{} {
+	arr, _ := rows.([]interface{})
+	var sorted []map[string]interface{}
+	for _, it := range arr {
+		if row, ok := it.(map[string]interface{}); ok {
+			sorted = append(sorted, row)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ei, ej := rowString(sorted[i], "end_date", "ended_at", "end"), rowString(sorted[j], "end_date", "ended_at", "end")
+		if (ei == "") != (ej == "") {
+			return ei == ""
+		}
+		return rowString(sorted[i], "start_date", "started_at", "start") > rowString(sorted[j], "start_date", "started_at", "start")
+	})
+
+	out := []interface{}{}
+	for _, row := range sorted {
+		if len(out) >= sectionCap(job, "experience") {
+			break
+		}
+		company := rowString(row, "company", "company_name", "organization", "employer")
+		title := rowString(row, "title", "position", "role")
+		if company == "" || title == "" {
+			continue
+		}
+		item := map[string]interface{}{"company": company, "title": title}
+		if period := formatPeriod(rowString(row, "start_date", "started_at", "start"), rowString(row, "end_date", "ended_at", "end")); period != "" {
+			item["period"] = period
+		}
+		if bullets := splitBullets(rowString(row, "description", "summary", "responsibilities")); len(bullets) > 0 {
+			item["bullets"] = bullets
+		}
+		out = append(out, item)
+	}
+	return out
+}
+
+func offlineProjects(job *domain.ResumeJob, rows interface{}) []interface{} {
+	arr, _ := rows.([]interface{})
+	out := []interface{}{}
+	for i, it := range normalizeSection(arr, sectionCap(job, "projects")) {
+		row, ok := it.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		title := rowString(row, "title", "name")
+		if title == "" {
+			continue
+		}
+		id := rowString(row, "slug", "id")
+		if id == "" {
+			id = fmt.Sprintf("project-%d", i+1)
+		}
+		item := map[string]interface{}{
+			"id":          id,
+			"title":       title,
+			"description": firstNonEmpty(rowString(row, "description", "summary", "excerpt"), title),
+		}
+		if u := rowString(row, "url", "live_url", "website", "repo_url", "github_url"); model.IsHTTPURL(u) {
+			item["url"] = u
+		}
+		if stack := rowString(row, "stack", "tech_stack"); stack != "" {
+			item["stack"] = stack
+		} else if techs, ok := row["technologies"].([]interface{}); ok {
+			var names []string
+			for _, t := range techs {
+				if s := itemTitle(t); s != "" {
+					names = append(names, s)
+				}
+			}
+			if len(names) > 0 {
+				item["stack"] = strings.Join(names, ", ")
+			}
+		}
+		out = append(out, item)
+	}
+	return out
+}
+
+func offlinePublications(job *domain.ResumeJob, rows interface{}) []interface{} {
+	arr, _ := rows.([]interface{})
+	out := []interface{}{}
+	for _, it := range normalizeSection(arr, sectionCap(job, "publications")) {
+		if title := itemTitle(it); title != "" {
+			out = append(out, title)
+		}
+	}
+	return out
+}
+
+func offlineCertifications(job *domain.ResumeJob, rows interface{}) []interface{} {
+	arr, _ := rows.([]interface{})
+	out := []interface{}{}
+	for _, it := range normalizeSection(arr, sectionCap(job, "certifications")) {
+		row, ok := it.(map[string]interface{})
+		if !ok {
+			if s, ok := it.(string); ok && s != "" {
+				out = append(out, map[string]interface{}{"name": s})
+			}
+			continue
+		}
+		name := rowString(row, "name", "title")
+		if name == "" {
+			continue
+		}
+		cert := map[string]interface{}{"name": name}
+		if s := rowString(row, "issuer", "issuing_organization", "organization"); s != "" {
+			cert["issuer"] = s
+		}
+		if s := rowString(row, "date", "issue_date", "issued_at"); len(s) >= 10 {
+			cert["date"] = s[:10]
+		}
+		if s := rowString(row, "url", "credential_url"); model.IsHTTPURL(s) {
+			cert["url"] = s
+		}
+		if s := rowString(row, "description"); s != "" {
+			cert["description"] = s
+		}
+		out = append(out, cert)
+	}
+	return out
+}
+
+func offlineExtras(rows interface{}) []interface{} {
+	arr, _ := rows.([]interface{})
+	out := []interface{}{}
+	for _, it := range arr {
+		row, ok := it.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		category := rowString(row, "category", "type")
+		text := rowString(row, "text", "description", "content", "name")
+		if category != "" && text != "" {
+			out = append(out, map[string]interface{}{"category": category, "text": text})
+		}
+	}
+	return out
+}
+
+// offlineSnapshot fills the snapshot the schema requires: tech lists the
+// first skills, the three achievements are taken from impact metrics, then
+// experience bullets, and the two selected projects are project titles,
+// then publications. Nothing is invented; when the sources run short the
+// snapshot stays short and the resume fails validation.
+func offlineSnapshot(resume map[string]interface{}, metrics interface{}) map[string]interface{} {
+	var tech []string
+	if groups, ok := resume["skills"].([]interface{}); ok {
+		for _, g := range groups {
+			gm, _ := g.(map[string]interface{})
+			items, _ := gm["items"].([]interface{})
+			for _, it := range items {
+				if s, ok := it.(string); ok && len(tech) < 10 {
+					tech = append(tech, s)
+				}
+			}
+		}
+	}
+	if len(tech) == 0 {
+		for _, pr := range resume["projects"].([]interface{}) {
+			if s, _ := pr.(map[string]interface{})["stack"].(string); s != "" {
+				tech = append(tech, s)
+			}
+		}
+	}
+
+	achievements := pickDistinct(3, metricTexts(metrics), experienceBullets(resume["experience"]))
+	var projectTitles, pubTitles []string
+	for _, pr := range resume["projects"].([]interface{}) {
+		projectTitles = append(projectTitles, itemTitle(pr))
+	}
+	for _, pub := range resume["publications"].([]interface{}) {
+		pubTitles = append(pubTitles, itemTitle(pub))
+	}
+	selected := pickDistinct(2, projectTitles, pubTitles)
+
+	return map[string]interface{}{
+		"tech":              strings.Join(tech, ", "),
+		"achievements":      achievements,
+		"selected_projects": selected,
+	}
+}
+
+// metricTexts returns a line per impact metric, e.g. "Cut p99 latency: 40%".
+func metricTexts(rows interface{}) []string {
+	arr, _ := rows.([]interface{})
+	var out []string
+	for _, it := range arr {
+		row, ok := it.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		text := rowString(row, "description", "title", "name", "metric")
+		if text == "" {
+			continue
+		}
+		if v := rowString(row, "value"); v != "" {
+			text += ": " + v
+		}
+		out = append(out, text)
+	}
+	return out
+}
+
+func experienceBullets(experience interface{}) []string {
+	arr, _ := experience.([]interface{})
+	var out []string
+	for _, it := range arr {
+		bullets, _ := it.(map[string]interface{})["bullets"].([]string)
+		out = append(out, bullets...)
+	}
+	return out
+}
+
+// pickDistinct returns up to n non-empty strings from sources, in order,
+// skipping repeats.
+func pickDistinct(n int, sources ...[]string) []interface{} {
+	out := []interface{}{}
+	seen := map[string]bool{}
+	for _, src := range sources {
+		for _, s := range src {
+			key := normalizeTitle(s)
+			if len(out) >= n {
+				return out
+			}
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			out = append(out, strings.TrimSpace(s))
+		}
+	}
+	return out
+}
+
+var bulletMarker = regexp.MustCompile(`^\s*(?:[-*•·]|\d+[.)])\s*`)
+
+// splitBullets turns a free-text description into bullets: one per line
+// when it has several lines, else one per sentence.
+func splitBullets(desc string) []string {
+	desc = strings.TrimSpace(desc)
+	if desc == "" {
+		return nil
+	}
+	parts := strings.Split(desc, "\n")
+	if len(parts) == 1 {
+		parts = strings.SplitAfter(desc, ". ")
+	}
+	var out []string
+	for _, part := range parts {
+		part = strings.TrimSpace(bulletMarker.ReplaceAllString(part, ""))
+		if part == "" {
+			continue
+		}
+		out = append(out, part)
+		if len(out) == maxOfflineBullets {
+			break
+		}
+	}
+	return out
+}
+
+// formatPeriod renders start and end dates as "2021-03 – Present", keeping
+// the year and month of ISO dates.
+func formatPeriod(start, end string) string {
+	if start == "" {
+		return ""
+	}
+	if end == "" {
+		end = "Present"
+	} else if len(end) > 7 {
+		end = end[:7]
+	}
+	if len(start) > 7 {
+		start = start[:7]
+	}
+	return start + " – " + end
+}
+
+// firstRow returns the first object of rows, or nil.
+func firstRow(rows interface{}) map[string]interface{} {
+	if arr, ok := rows.([]interface{}); ok && len(arr) > 0 {
+		m, _ := arr[0].(map[string]interface{})
+		return m
+	}
+	return nil
+}
+
+// rowString returns the first non-empty string among keys of row.
+func rowString(row map[string]interface{}, keys ...string) string {
+	for _, k := range keys {
+		if s, ok := row[k].(string); ok && strings.TrimSpace(s) != "" {
+			return strings.TrimSpace(s)
+		}
+	}
+	return ""
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
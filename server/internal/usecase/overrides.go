@@ -0,0 +1,50 @@
+package usecase
+
+import (
+	"context"
+
+	ai "resume-generator/pkg/ai"
+	"resume-generator/pkg/logctx"
+)
+
+// overrideFields are the profile override keys expanded by EnrichFields
+// and merged over what the formatters made of the aggregated rows.
+var overrideFields = []string{"publications", "certifications", "extras"}
+
+// enrichOverrides asks the AI to format the publications, certifications
+// and extras the caller posted in the job profile, and merges them into
+// resumeMap so they replace the formatters' versions of those sections.
+// When the EnrichFields call fails the overrides are merged as posted. It
+// reports whether anything was merged.
+func enrichOverrides(ctx context.Context, aiClient *ai.Client, overrides, resumeMap map[string]interface{}) bool {
+	fields := map[string]interface{}{}
+	for _, k := range overrideFields {
+		if v, ok := overrides[k]; ok {
+			fields[k] = v
+		}
+	}
+	if len(fields) == 0 {
+		return false
+	}
+
+	enriched, err := aiClient.EnrichFields(ctx, fields)
+	if err != nil || enriched == nil {
+		logctx.Warnf(ctx, "processor: enrich_fields failed, merging the overrides as posted: %v", err)
+		enriched = fields
+	}
+	// typed round trip: AI answers come back as strings or loose objects
+	typed := NewOverridesFromMap(enriched).ToMap()
+	posted := NewOverridesFromMap(fields).ToMap()
+	merged := false
+	for k := range fields {
+		v, ok := typed[k]
+		if !ok {
+			v, ok = posted[k]
+		}
+		if ok {
+			resumeMap[k] = v
+			merged = true
+		}
+	}
+	return merged
+}
@@ -0,0 +1,86 @@
+package usecase
+
+import (
+	"encoding/json"
+
+	repo "resume-generator/internal/adapter/repository"
+)
+
+// aggregateTrimOrder lists the aggregated sections from the least to the
+// most important for a resume. Sections not listed are trimmed after
+// these, and the job application the resume is for never is.
+var aggregateTrimOrder = []string{
+	"resumes", "job_applications", "testimonials", "case_studies",
+	"impact_metrics", "project_technologies", "extras", "publications",
+	"certifications", "technologies", "projects", "education", "skills",
+	"experiences", "profiles", "user",
+}
+
+// capAggregate returns agg reduced to at most max bytes of JSON, and the
+// sections it cut. Sections are cut in aggregateTrimOrder: a list loses
+// items from its end, which the queries order oldest last, and a section
+// still too large after that is dropped. agg itself is not modified; the
+// full data stays available for merging into the resume.
+func capAggregate(agg repo.AggregateResult, max int) (repo.AggregateResult, []string, error) {
+	if max <= 0 {
+		return agg, nil, nil
+	}
+	b, err := json.Marshal(agg)
+	if err != nil {
+		return nil, nil, err
+	}
+	size := len(b)
+	if size <= max {
+		return agg, nil, nil
+	}
+
+	out := make(repo.AggregateResult, len(agg))
+	for k, v := range agg {
+		out[k] = v
+	}
+	order := append([]string(nil), aggregateTrimOrder...)
+	listed := stringSet(aggregateTrimOrder)
+	for k := range agg {
+		if !listed[k] && k != "job_application" {
+			order = append(order, k)
+		}
+	}
+
+	var cut []string
+	for _, k := range order {
+		if size <= max {
+			break
+		}
+		v, ok := out[k]
+		if !ok {
+			continue
+		}
+		cut = append(cut, k)
+		if items, ok := v.([]interface{}); ok {
+			n := len(items)
+			for n > 0 && size > max {
+				ib, err := json.Marshal(items[n-1])
+				if err != nil {
+					return nil, nil, err
+				}
+				size -= len(ib) + 1 // the item and its comma
+				n--
+			}
+			if n > 0 {
+				out[k] = items[:n]
+				continue
+			}
+		}
+		vb, err := json.Marshal(v)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, isList := v.([]interface{}); isList {
+			// the items are already counted; only "[]" is left
+			vb = []byte("[]")
+		}
+		size -= len(vb) + len(k) + 4 // "key": and the comma
+		delete(out, k)
+	}
+	return out, cut, nil
+}
@@ -0,0 +1,230 @@
+package usecase
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// YearMonth is a date with month precision; Month is 0 when only the year
+// is known.
+type YearMonth struct {
+	Year, Month int
+}
+
+// key orders dates chronologically; a year alone sorts before its months.
+func (d YearMonth) key() int { return d.Year*100 + d.Month }
+
+// Period is an experience period parsed by ParsePeriod. End is the zero
+// YearMonth for an ongoing period, which has Current set.
+type Period struct {
+	Start, End YearMonth
+	Current    bool
+}
+
+var (
+	// periodSeparator splits "Jan 2020 – Mar 2022", "2019 - 2021" and
+	// "2019 to 2021" (or até, hasta, à, bis, al...); a plain hyphen only
+	// counts with spaces around it so "2020-03" stays whole.
+	periodSeparator = regexp.MustCompile(`\s*[–—]\s*|\s+-\s+|\s+(?i:to|until|till|até|a|hasta|à|au|bis|al)\s+`)
+	// periodHyphen splits the unspaced "2019-2021" and "2021-Present".
+	periodHyphen = regexp.MustCompile(`^(.*\d{4})-(\d{4}|\pL.*)$`)
+	// periodLead is a leading "from", "de", "desde"... before the start.
+	periodLead = regexp.MustCompile(`^(?i:from|since|de|desde|dès|seit|von|dal|da)\s+`)
+	periodYear = regexp.MustCompile(`\b(?:19|20)\d{2}\b`)
+	// numericMonth matches "2020-03" and "03/2020" or "3.2020".
+	isoMonth     = regexp.MustCompile(`\b(\d{4})-(\d{1,2})\b`)
+	numericMonth = regexp.MustCompile(`\b(\d{1,2})[/.](\d{4})\b`)
+)
+
+// monthNames lists the month names, without accents, per language.
+var monthNames = map[string][12]string{
+	"en": {"january", "february", "march", "april", "may", "june", "july", "august", "september", "october", "november", "december"},
+	"pt": {"janeiro", "fevereiro", "marco", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+	"es": {"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+	"fr": {"janvier", "fevrier", "mars", "avril", "mai", "juin", "juillet", "aout", "septembre", "octobre", "novembre", "decembre"},
+	"de": {"januar", "februar", "marz", "april", "mai", "juni", "juli", "august", "september", "oktober", "november", "dezember"},
+	"it": {"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+}
+
+// ParsePeriod parses an experience period such as "2021–Present",
+// "Jan 2020 – Mar 2022", "03/2019 - 11/2020", "2019" or "março de 2020 até
+// o momento". Month names are read in language, its base language for
+// regional tags such as "pt-BR", and English. An end without a year, such
+// as "Present" or "Atual", makes the period current. It reports false when
+// no start year is found.
+func ParsePeriod(s, language string) (Period, bool) {
+	s = strings.TrimSpace(periodLead.ReplaceAllString(strings.TrimSpace(s), ""))
+	if s == "" {
+		return Period{}, false
+	}
+	parts := periodSeparator.Split(s, 2)
+	if len(parts) == 1 {
+		if m := periodHyphen.FindStringSubmatch(s); m != nil {
+			parts = []string{m[1], m[2]}
+		}
+	}
+
+	var p Period
+	start, ok := parseYearMonth(parts[0], language)
+	if !ok {
+		return Period{}, false
+	}
+	p.Start = start
+	if len(parts) == 1 {
+		p.End = start
+		return p, true
+	}
+	end, ok := parseYearMonth(parts[1], language)
+	switch {
+	case ok:
+		p.End = end
+	case strings.IndexFunc(parts[1], unicode.IsLetter) >= 0:
+		p.Current = true
+	default:
+		p.End = start
+	}
+	return p, true
+}
+
+// parseYearMonth reads a single date: "2020", "2020-03", "03/2020",
+// "Mar 2020", "March, 2020" or "marzo de 2020".
+func parseYearMonth(s, language string) (YearMonth, bool) {
+	if m := isoMonth.FindStringSubmatch(s); m != nil {
+		if d, ok := yearMonth(m[1], m[2]); ok {
+			return d, true
+		}
+	}
+	if m := numericMonth.FindStringSubmatch(s); m != nil {
+		if d, ok := yearMonth(m[2], m[1]); ok {
+			return d, true
+		}
+	}
+	year := periodYear.FindString(s)
+	if year == "" {
+		return YearMonth{}, false
+	}
+	d, _ := yearMonth(year, "")
+	for _, w := range strings.FieldsFunc(foldMonth(s), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		if month := monthNumber(w, language); month > 0 {
+			d.Month = month
+			break
+		}
+	}
+	return d, true
+}
+
+func yearMonth(year, month string) (YearMonth, bool) {
+	y, err := strconv.Atoi(year)
+	if err != nil || y < 1900 || y > 2099 {
+		return YearMonth{}, false
+	}
+	if month == "" {
+		return YearMonth{Year: y}, true
+	}
+	m, err := strconv.Atoi(month)
+	if err != nil || m < 1 || m > 12 {
+		return YearMonth{}, false
+	}
+	return YearMonth{Year: y, Month: m}, true
+}
+
+// monthNumber returns the month w names, in full or abbreviated to at
+// least three letters, or 0. The names of language and English are tried
+// first, then the other languages; an abbreviation matching two different
+// months in the same pass ("jui" for juin and juillet) names none.
+func monthNumber(w, language string) int {
+	if len(w) < 3 {
+		return 0
+	}
+	base := strings.ToLower(language)
+	if i := strings.IndexAny(base, "-_"); i > 0 {
+		base = base[:i]
+	}
+	preferred := []string{base, "en"}
+	others := make([]string, 0, len(monthNames))
+	for lang := range monthNames {
+		if lang != base && lang != "en" {
+			others = append(others, lang)
+		}
+	}
+	sort.Strings(others)
+	for _, langs := range [][]string{preferred, others} {
+		found := 0
+		for _, lang := range langs {
+			names, ok := monthNames[lang]
+			if !ok {
+				continue
+			}
+			for i, name := range names {
+				if strings.HasPrefix(name, w) {
+					if found != 0 && found != i+1 {
+						return 0
+					}
+					found = i + 1
+				}
+			}
+		}
+		if found > 0 {
+			return found
+		}
+	}
+	return 0
+}
+
+// foldMonth lowercases s and strips its accents, so "Março" and "März"
+// match the monthNames spelling.
+func foldMonth(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(strings.ToLower(s)) {
+		if !unicode.Is(unicode.Mn, r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// sortExperienceByPeriod orders resume.experience newest first: current
+// roles by start date, then the others by end and start date. Entries
+// whose period ParsePeriod cannot read keep their relative order after
+// the dated ones.
+func sortExperienceByPeriod(resume map[string]interface{}, language string) {
+	arr, ok := resume["experience"].([]interface{})
+	if !ok || len(arr) < 2 {
+		return
+	}
+	type dated struct {
+		item   interface{}
+		period Period
+	}
+	var withPeriod []dated
+	var without []interface{}
+	for _, it := range arr {
+		m, _ := it.(map[string]interface{})
+		s, _ := m["period"].(string)
+		if p, ok := ParsePeriod(s, language); ok {
+			withPeriod = append(withPeriod, dated{it, p})
+		} else {
+			without = append(without, it)
+		}
+	}
+	sort.SliceStable(withPeriod, func(i, j int) bool {
+		a, b := withPeriod[i].period, withPeriod[j].period
+		if a.Current != b.Current {
+			return a.Current
+		}
+		if !a.Current && a.End.key() != b.End.key() {
+			return a.End.key() > b.End.key()
+		}
+		return a.Start.key() > b.Start.key()
+	})
+	out := make([]interface{}, 0, len(arr))
+	for _, d := range withPeriod {
+		out = append(out, d.item)
+	}
+	resume["experience"] = append(out, without...)
+}
@@ -0,0 +1,120 @@
+package usecase
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePeriod(t *testing.T) {
+	ym := func(y, m int) YearMonth { return YearMonth{Year: y, Month: m} }
+	tests := []struct {
+		in, language string
+		want         Period
+		ok           bool
+	}{
+		{"2021–Present", "English", Period{Start: ym(2021, 0), Current: true}, true},
+		{"2021-Present", "English", Period{Start: ym(2021, 0), Current: true}, true},
+		{"Mar. 2019 to Present", "English", Period{Start: ym(2019, 3), Current: true}, true},
+		{"Jan 2020 – Mar 2022", "English", Period{Start: ym(2020, 1), End: ym(2022, 3)}, true},
+		{"Sept 2017 - Jun 2019", "English", Period{Start: ym(2017, 9), End: ym(2019, 6)}, true},
+		{"2019", "English", Period{Start: ym(2019, 0), End: ym(2019, 0)}, true},
+		{"2019-2021", "English", Period{Start: ym(2019, 0), End: ym(2021, 0)}, true},
+		{"03/2019 - 11/2020", "English", Period{Start: ym(2019, 3), End: ym(2020, 11)}, true},
+		{"2020-03 — 2021-11", "English", Period{Start: ym(2020, 3), End: ym(2021, 11)}, true},
+		{"from 2016 until 2018", "English", Period{Start: ym(2016, 0), End: ym(2018, 0)}, true},
+		{"Summer 2018", "English", Period{Start: ym(2018, 0), End: ym(2018, 0)}, true},
+		{"2019-13", "English", Period{Start: ym(2019, 0), End: ym(2019, 0)}, true},
+
+		// localized month names
+		{"março de 2020 até o momento", "Portuguese", Period{Start: ym(2020, 3), Current: true}, true},
+		{"Fevereiro 2018 até Dezembro 2019", "pt-BR", Period{Start: ym(2018, 2), End: ym(2019, 12)}, true},
+		{"out/2019 – atual", "Portuguese", Period{Start: ym(2019, 10), Current: true}, true},
+		{"enero 2021 hasta la fecha", "Spanish", Period{Start: ym(2021, 1), Current: true}, true},
+		{"März 2020 bis heute", "German", Period{Start: ym(2020, 3), Current: true}, true},
+		{"juil. 2020 – août 2021", "French", Period{Start: ym(2020, 7), End: ym(2021, 8)}, true},
+		{"jui 2020", "French", Period{Start: ym(2020, 0), End: ym(2020, 0)}, true},
+
+		// unreadable
+		{"", "English", Period{}, false},
+		{"Present", "English", Period{}, false},
+		{"a summer long ago", "English", Period{}, false},
+		{"1899 – 1901", "English", Period{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, ok := ParsePeriod(tt.in, tt.language)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("ParsePeriod(%q, %q) = %+v, %v; want %+v, %v", tt.in, tt.language, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestSortExperienceByPeriod(t *testing.T) {
+	role := func(name, period string) map[string]interface{} {
+		m := map[string]interface{}{"company": name}
+		if period != "" {
+			m["period"] = period
+		}
+		return m
+	}
+	companies := func(arr []interface{}) []string {
+		var out []string
+		for _, it := range arr {
+			out = append(out, it.(map[string]interface{})["company"].(string))
+		}
+		return out
+	}
+
+	tests := []struct {
+		name  string
+		roles []interface{}
+		want  []string
+	}{
+		{
+			name: "current first, then by end and start, undated last",
+			roles: []interface{}{
+				role("A", "2017 – 2019"),
+				role("B", "2021 – Present"),
+				role("C", "sometime"),
+				role("D", "Jan 2020 – Mar 2021"),
+				role("E", "2022 – Atual"),
+				role("F", ""),
+				role("G", "2019"),
+				role("H", "Jun 2019 – Dec 2019"),
+			},
+			want: []string{"E", "B", "D", "H", "G", "A", "C", "F"},
+		},
+		{
+			name:  "already sorted",
+			roles: []interface{}{role("A", "2021 – Present"), role("B", "2018 – 2021")},
+			want:  []string{"A", "B"},
+		},
+		{
+			name:  "same period keeps order",
+			roles: []interface{}{role("A", "2020"), role("B", "2020"), role("C", "2021")},
+			want:  []string{"C", "A", "B"},
+		},
+		{
+			name:  "nothing dated",
+			roles: []interface{}{role("A", "early career"), role("B", "")},
+			want:  []string{"A", "B"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resume := map[string]interface{}{"experience": tt.roles}
+			sortExperienceByPeriod(resume, "English")
+			if got := companies(resume["experience"].([]interface{})); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// not an array: left alone
+	resume := map[string]interface{}{"experience": "PayCo"}
+	sortExperienceByPeriod(resume, "English")
+	if resume["experience"] != "PayCo" {
+		t.Errorf("experience = %v", resume["experience"])
+	}
+}
@@ -0,0 +1,49 @@
+package usecase
+
+import (
+	"context"
+	"html/template"
+	"strings"
+
+	"resume-generator/internal/domain"
+	"resume-generator/internal/model"
+	"resume-generator/pkg/logctx"
+)
+
+// dropInvalidPhoto removes meta.photo_url from resume unless it is an
+// http(s) URL, so a bad link from the AI does not fail the schema.
+func dropInvalidPhoto(resume map[string]interface{}) {
+	meta, ok := resume["meta"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	if v, has := meta["photo_url"]; has {
+		if s, _ := v.(string); !model.IsHTTPURL(s) {
+			delete(meta, "photo_url")
+		}
+	}
+}
+
+// photoSource returns the src of the header photo for job.Profile, or ""
+// when it has none. With photo embedding enabled the image is inlined as a
+// data: URI; if fetching fails the remote URL is used and the error is
+// recorded in metadata.photo_embed_error.
+func (p *Processor) photoSource(ctx context.Context, job *domain.ResumeJob) template.URL {
+	meta, _ := job.Profile["meta"].(map[string]interface{})
+	raw, _ := meta["photo_url"].(string)
+	if !model.IsHTTPURL(raw) {
+		return ""
+	}
+	raw = strings.TrimSpace(raw)
+	if p.images == nil {
+		return template.URL(raw)
+	}
+	uri, err := p.images.FetchDataURI(ctx, raw)
+	if err != nil {
+		logctx.Warnf(ctx, "processor: embed photo failed: %v", err)
+		job.Metadata["photo_embed_error"] = sanitizeError(err)
+		return template.URL(raw)
+	}
+	delete(job.Metadata, "photo_embed_error")
+	return template.URL(uri)
+}
@@ -3,13 +3,15 @@ package usecase
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"io/ioutil"
-	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	repo "resume-generator/internal/adapter/repository"
@@ -17,56 +19,496 @@ import (
 	"resume-generator/internal/model"
 	ai "resume-generator/pkg/ai"
 	"resume-generator/pkg/ai/formatters"
+	"resume-generator/pkg/export"
+	infra "resume-generator/pkg/infrastructure"
+	"resume-generator/pkg/metrics"
+	"resume-generator/pkg/retry"
+	"resume-generator/pkg/storage"
+	"resume-generator/templates"
 
 	"github.com/google/uuid"
-	"golang.org/x/net/publicsuffix"
+
+	"resume-generator/pkg/logctx"
 )
 
 type Renderer interface {
 	RenderHTMLToPDF(ctx context.Context, html string) ([]byte, error)
+	RenderHTMLToPDFWithOptions(ctx context.Context, html string, opts infra.RenderOptions) ([]byte, error)
+	RenderHTMLToPNG(ctx context.Context, html string, width int) ([]byte, error)
+}
+
+// DocxRenderer converts rendered resume HTML into a .docx document.
+type DocxRenderer interface {
+	RenderHTMLToDOCX(ctx context.Context, html string) ([]byte, error)
+}
+
+// LaTeXCompiler compiles the .tex export into a PDF.
+type LaTeXCompiler interface {
+	CompileLaTeX(ctx context.Context, src string) ([]byte, error)
 }
 
 type JobsRepo interface {
 	Save(ctx context.Context, j *domain.ResumeJob) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.ResumeJob, error)
+	FindByResumeID(ctx context.Context, resumeID uuid.UUID) (*domain.ResumeJob, error)
+	ListByUser(ctx context.Context, userID uuid.UUID, filter domain.JobFilter, page domain.Page) ([]*domain.ResumeJob, error)
+	FindByIdempotencyKey(ctx context.Context, userID uuid.UUID, key string) (*domain.ResumeJob, error)
+	ReleaseIdempotencyKey(ctx context.Context, id uuid.UUID) error
+	DeleteJobsByUser(ctx context.Context, userID uuid.UUID) (int64, error)
+	FindStale(ctx context.Context, before time.Time, limit int) ([]*domain.ResumeJob, error)
+	ClaimStale(ctx context.Context, id uuid.UUID, instance string, before time.Time) (bool, error)
+	ClaimRetry(ctx context.Context, id uuid.UUID, from string) (bool, error)
+	UpdateStatus(ctx context.Context, id uuid.UUID, status string, progress map[string]interface{}) error
+	SetMetadata(ctx context.Context, id uuid.UUID, key string, value interface{}) error
+}
+
+// ResumesRepo reads generated resumes.
+type ResumesRepo interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Resume, error)
+	ListByUser(ctx context.Context, userID uuid.UUID, filter domain.ResumeFilter, page domain.Page) ([]*domain.Resume, error)
+	GetJSON(ctx context.Context, id uuid.UUID) (map[string]interface{}, error)
+	DeleteResumesByUser(ctx context.Context, userID uuid.UUID) (int64, error)
 }
 
 type Processor struct {
 	renderer        Renderer
 	repo            JobsRepo
-	tplDir          string
 	aiClient        *ai.Client
 	defaultLanguage string
+	docxRenderer    DocxRenderer
+	latexCompiler   LaTeXCompiler
+	events          *EventBroker
+	previewWidth    int
+	storage         storage.Storage
+	jobTimeout      time.Duration
+	stageAttempts   int
+	outputDir       string
+	images          *infra.ImageFetcher
+	fonts           *infra.FontSet
+	dryRun          bool
+	aiMode          string
+	splitFlow       bool
+	textWidth       int
+	renderRetry     retry.Policy
+	maxAIPayload    int
+	active          jobRegistry
+
+	// progressMu guards job metadata updates made by concurrently
+	// running stages.
+	progressMu sync.Mutex
+	// saves orders the stage progress saves of each job.
+	saves jobSaves
+}
+
+// ProcessorOption customizes optional Processor dependencies.
+type ProcessorOption func(*Processor)
+
+// WithDocxRenderer enables .docx output for jobs requesting the
+// FormatDOCX format.
+func WithDocxRenderer(r DocxRenderer) ProcessorOption {
+	return func(p *Processor) { p.docxRenderer = r }
+}
+
+// WithLaTeXCompiler compiles the .tex output of jobs requesting the
+// FormatTeX format to PDF as well; without it only the .tex is written.
+func WithLaTeXCompiler(c LaTeXCompiler) ProcessorOption {
+	return func(p *Processor) { p.latexCompiler = c }
+}
+
+// DefaultPreviewWidth is the width in pixels of preview.png unless
+// WithPreviewWidth is used.
+const DefaultPreviewWidth = 800
+
+// WithPreviewWidth sets the width in pixels of the PNG preview.
+func WithPreviewWidth(width int) ProcessorOption {
+	return func(p *Processor) {
+		if width > 0 {
+			p.previewWidth = width
+		}
+	}
+}
+
+// WithTextWidth sets the column the .txt output wraps at;
+// export.DefaultTextWidth when 0, no wrapping when negative.
+func WithTextWidth(width int) ProcessorOption {
+	return func(p *Processor) { p.textWidth = width }
+}
+
+// WithRenderRetry sets how often and with which backoff a failed PDF
+// render is retried; retry.DefaultPolicy unless set.
+func WithRenderRetry(policy retry.Policy) ProcessorOption {
+	return func(p *Processor) { p.renderRetry = policy }
+}
+
+// DefaultMaxAIPayloadBytes caps the JSON size of the aggregated data sent
+// to the AI unless WithMaxAIPayloadBytes is used.
+const DefaultMaxAIPayloadBytes = 256 << 10
+
+// WithMaxAIPayloadBytes sets the cap on the JSON size of the aggregated
+// data sent to the AI; a negative n removes it.
+func WithMaxAIPayloadBytes(n int) ProcessorOption {
+	return func(p *Processor) {
+		if n != 0 {
+			p.maxAIPayload = n
+		}
+	}
+}
+
+// WithStorage sets where the per-user copies of generated HTML and PDF are
+// stored. The default is local disk under <output dir>/resumes.
+func WithStorage(s storage.Storage) ProcessorOption {
+	return func(p *Processor) {
+		if s != nil {
+			p.storage = s
+		}
+	}
+}
+
+// DefaultJobTimeout bounds a single Process call unless WithJobTimeout is
+// used.
+const DefaultJobTimeout = 5 * time.Minute
+
+// WithJobTimeout sets the deadline of each Process call. AI requests and
+// renders derive from the job context, so they abort when it expires.
+func WithJobTimeout(d time.Duration) ProcessorOption {
+	return func(p *Processor) {
+		if d > 0 {
+			p.jobTimeout = d
+		}
+	}
+}
+
+// DefaultStageAttempts is how often a stage's enrichment is tried while the
+// stage stays invalid, unless WithStageAttempts is used.
+const DefaultStageAttempts = 2
+
+// WithStageAttempts bounds the enrichment calls made per invalid stage.
+func WithStageAttempts(n int) ProcessorOption {
+	return func(p *Processor) {
+		if n > 0 {
+			p.stageAttempts = n
+		}
+	}
+}
+
+// DefaultOutputDir is the base directory of generated files unless
+// WithOutputDir is used.
+const DefaultOutputDir = "resume-data"
+
+// WithOutputDir sets the base directory of everything the processor writes
+// to disk: rendered artifacts under generated/ and, without WithStorage,
+// the per-user copies under resumes/<user>.
+func WithOutputDir(dir string) ProcessorOption {
+	return func(p *Processor) {
+		if dir != "" {
+			p.outputDir = dir
+		}
+	}
+}
+
+// WithPhotoEmbedding makes the renderer fetch meta.photo_url through f and
+// inline it as a data: URI, so the PDF does not depend on the remote image.
+// Without it the template links the URL directly.
+func WithPhotoEmbedding(f *infra.ImageFetcher) ProcessorOption {
+	return func(p *Processor) { p.images = f }
+}
+
+// WithFonts sets where the fonts named by templates are loaded from. The
+// default is the fonts directory next to the templates.
+func WithFonts(f *infra.FontSet) ProcessorOption {
+	return func(p *Processor) {
+		if f != nil {
+			p.fonts = f
+		}
+	}
+}
+
+// WithDryRun makes every job stop after the HTML artifact, as if it was
+// started with dry_run: no PDF or preview is rendered, so Chrome is never
+// launched.
+func WithDryRun(enabled bool) ProcessorOption {
+	return func(p *Processor) { p.dryRun = enabled }
+}
+
+// AI modes accepted by WithAIMode.
+const (
+	// AIModeAuto formats resumes with the AI service and falls back to the
+	// offline builder when the AI or validation stage fails.
+	AIModeAuto = "auto"
+	// AIModeOff never calls the AI service; resumes are built from the
+	// aggregated rows alone.
+	AIModeOff = "off"
+)
+
+// WithAIMode sets whether jobs use the AI service, AIModeAuto by default.
+// Unknown modes are ignored.
+func WithAIMode(mode string) ProcessorOption {
+	return func(p *Processor) {
+		if mode == AIModeAuto || mode == AIModeOff {
+			p.aiMode = mode
+		}
+	}
+}
+
+// AI flows a job can ask for with job.Metadata["ai_flow"].
+const (
+	// FlowSplit formats the resume with one AI call per section stage.
+	FlowSplit = "split"
+	// FlowSingle formats the whole resume with a single AI call.
+	FlowSingle = "single"
+)
+
+// WithSplitFlow sets the AI flow of jobs that do not choose one: the split
+// per-section flow when enabled, which is the default, else a single call.
+func WithSplitFlow(enabled bool) ProcessorOption {
+	return func(p *Processor) { p.splitFlow = enabled }
+}
+
+// useSplitFlow reports whether job is formatted with the split flow, as
+// asked by job.Metadata["ai_flow"] or else the processor default.
+func (p *Processor) useSplitFlow(job *domain.ResumeJob) bool {
+	switch job.Metadata["ai_flow"] {
+	case FlowSplit:
+		return true
+	case FlowSingle:
+		return false
+	}
+	return p.splitFlow
+}
+
+// isDryRun reports whether job skips PDF rendering, either because the
+// processor runs in dry-run mode or the job asked for it.
+func (p *Processor) isDryRun(job *domain.ResumeJob) bool {
+	dry, _ := job.Metadata["dry_run"].(bool)
+	return p.dryRun || dry
+}
+
+// NewProcessor builds a Processor rendering the templates of package
+// templates. defaultLanguage is used for jobs that do not set ResumeJob.Language, both
+// for the AI formatters and the translated labels.
+func NewProcessor(r Renderer, repo JobsRepo, defaultLanguage string, opts ...ProcessorOption) *Processor {
+	p := &Processor{renderer: r, repo: repo, aiClient: ai.NewClient(), defaultLanguage: defaultLanguage, events: NewEventBroker(), previewWidth: DefaultPreviewWidth, jobTimeout: DefaultJobTimeout, stageAttempts: DefaultStageAttempts, outputDir: DefaultOutputDir, aiMode: AIModeAuto, splitFlow: true, maxAIPayload: DefaultMaxAIPayloadBytes, renderRetry: retry.DefaultPolicy()}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.storage == nil {
+		p.storage = storage.NewLocalStorage(filepath.Join(p.outputDir, "resumes"))
+	}
+	if p.fonts == nil {
+		p.fonts = infra.NewFontSet(filepath.Join(templates.Dir(), "fonts"))
+	}
+	return p
+}
+
+// WithResponseCache makes the processor's AI clients answer repeated
+// formatter calls from c.
+func WithResponseCache(c *ai.ResponseCache) ProcessorOption {
+	return func(p *Processor) { p.aiClient.Responses = c }
+}
+
+// WithLabelCache makes the processor's AI clients share c for translated
+// labels.
+func WithLabelCache(c *ai.LabelCache) ProcessorOption {
+	return func(p *Processor) { p.aiClient.Labels = c }
+}
+
+// LabelCache returns the cache used for translated labels.
+func (p *Processor) LabelCache() *ai.LabelCache {
+	return p.aiClient.LabelCache()
+}
+
+// AIBreakerState returns the state of the circuit breaker guarding the AI
+// service.
+func (p *Processor) AIBreakerState() ai.BreakerState {
+	return p.aiClient.BreakerState()
+}
+
+// DefaultTemplate is the layout rendered for jobs that do not set Template.
+const DefaultTemplate = templates.DefaultLayout
+
+// jobLayout returns the layout the job renders with. Unknown names are an
+// error rather than a silent fallback, since the caller asked for them.
+func jobLayout(job *domain.ResumeJob) (templates.Layout, error) {
+	name := job.Template
+	if name == "" {
+		name = DefaultTemplate
+	}
+	layout, ok := templates.Lookup(name)
+	if !ok {
+		return templates.Layout{}, fmt.Errorf("unknown template %q", name)
+	}
+	return layout, nil
+}
+
+// OutputDir returns the base directory of generated files.
+func (p *Processor) OutputDir() string {
+	return p.outputDir
+}
+
+// Storage returns where users' copies are stored.
+func (p *Processor) Storage() storage.Storage {
+	return p.storage
+}
+
+// GeneratedDir returns the directory where rendered HTML/PDF artifacts are
+// written.
+func (p *Processor) GeneratedDir() string {
+	return filepath.Join(p.outputDir, "generated")
+}
+
+// Events returns the broker that receives job progress events.
+func (p *Processor) Events() *EventBroker {
+	return p.events
+}
+
+// publish emits a progress event for the job.
+func (p *Processor) publish(job *domain.ResumeJob, stage, detail string) {
+	p.events.Publish(JobEvent{JobID: job.ID, Stage: stage, Detail: detail})
 }
 
-func NewProcessor(r Renderer, repo JobsRepo, tplDir string, defaultLanguage string) *Processor {
-	return &Processor{renderer: r, repo: repo, tplDir: tplDir, aiClient: ai.NewClient(), defaultLanguage: defaultLanguage}
+// setStatus records the phase the job is in, finishing the previous phase
+// in metadata.progress, and persists just the status and progress, so
+// GET /jobs/:id and the DB show where a running job is. Failing to persist
+// it only loses visibility and is logged.
+func (p *Processor) setStatus(ctx context.Context, job *domain.ResumeJob, status string) {
+	p.progressMu.Lock()
+	if job.Status != status && job.Status != domain.StatusPending && domain.IsActiveStatus(job.Status) {
+		trackProgress(job, job.Status, true)
+	}
+	var progress map[string]interface{}
+	if domain.IsActiveStatus(status) && status != domain.StatusPending {
+		progress = copyMap(trackProgress(job, status, false))
+	}
+	job.Status = status
+	job.UpdatedAt = time.Now()
+	p.progressMu.Unlock()
+
+	if p.repo == nil {
+		return
+	}
+	if err := p.repo.UpdateStatus(ctx, job.ID, status, progress); err != nil {
+		logctx.Warnf(ctx, "processor: failed to update status of job %s to %s: %v", job.ID.String(), status, err)
+	}
 }
 
+// Process runs the full generation pipeline for a job and publishes a
+// terminal done/failed/cancelled/timeout event when it returns. The job can
+// be aborted with Cancel while Process runs, and ends with status and
+// failed_stage "timeout" once it runs longer than the job timeout or ctx's
+// deadline. Depending on the AI mode the resume is built without the AI
+// service; see generate.
 func (p *Processor) Process(ctx context.Context, job *domain.ResumeJob) error {
-	// Create AI client with the job's language
-	aiClient := ai.NewClientWithLanguage(job.Language)
+	ctx = jobContext(ctx, job)
+	ctx, cancel := context.WithTimeout(ctx, p.jobTimeout)
+	defer cancel()
+	entry := p.active.add(job.ID, cancel)
+	defer p.active.remove(job.ID)
+
+	metrics.JobsStarted.Inc()
+	err := p.generate(ctx, job)
+	if err != nil && p.active.wasCancelled(entry) {
+		metrics.JobsCancelled.Inc()
+		p.markCancelled(job)
+	} else if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		metrics.JobsFailed.Inc()
+		p.progressMu.Lock()
+		if job.Metadata == nil {
+			job.Metadata = map[string]interface{}{}
+		}
+		job.Metadata["timed_out_during"] = job.Status
+		p.progressMu.Unlock()
+		p.finish(job, domain.StatusTimeout, EventTimeout, StageTimeout, "job exceeded its processing timeout", true)
+	} else if err != nil {
+		metrics.JobsFailed.Inc()
+		p.fail(job, failureStage(err), sanitizeError(err), failureRetriable(err))
+	} else {
+		metrics.JobsCompleted.Inc()
+		p.progressMu.Lock()
+		result := eventResult(job)
+		p.progressMu.Unlock()
+		p.events.Publish(JobEvent{JobID: job.ID, Stage: EventDone, Terminal: true, Result: result})
+	}
+	return err
+}
+
+// jobContext attaches a logger carrying the job and user ids and, when the
+// job was started over HTTP, its request id.
+func jobContext(ctx context.Context, job *domain.ResumeJob) context.Context {
+	args := []any{"job_id", job.ID.String(), "user_id", job.UserID.String()}
+	if rid, ok := job.Metadata["request_id"].(string); ok && rid != "" {
+		args = append(args, "request_id", rid)
+	}
+	return logctx.With(ctx, args...)
+}
+
+// fail marks job as failed with reason, the stage it failed in and whether
+// a retry may help, persists it best-effort and publishes the terminal
+// event.
+func (p *Processor) fail(job *domain.ResumeJob, stage, reason string, retriable bool) {
+	p.finish(job, "failed", EventFailed, stage, reason, retriable)
+}
+
+// finish ends job unsuccessfully with status, records reason, stage and
+// retriable, counts the failure by stage, persists the job, including the
+// metadata gathered so far, and publishes the terminal event.
+func (p *Processor) finish(job *domain.ResumeJob, status, event, stage, reason string, retriable bool) {
+	ctx := jobContext(context.Background(), job)
+	metrics.JobFailures.WithLabelValues(stage).Inc()
+	p.progressMu.Lock()
+	if job.Metadata == nil {
+		job.Metadata = map[string]interface{}{}
+	}
+	job.Status = status
+	job.Metadata["error"] = reason
+	job.Metadata["failed_stage"] = stage
+	job.Metadata["error_code"] = stageErrorCode(stage)
+	job.Metadata["retriable"] = retriable
+	job.UpdatedAt = time.Now()
+	result := eventResult(job)
+	p.progressMu.Unlock()
+
+	if p.repo != nil {
+		if err := p.repo.Save(ctx, job); err != nil {
+			logctx.Warnf(ctx, "processor: failed to save failed job %s: %v", job.ID.String(), err)
+		}
+	}
+	p.events.Publish(JobEvent{JobID: job.ID, Stage: event, Error: reason, Terminal: true, Result: result})
+}
+
+func (p *Processor) process(ctx context.Context, job *domain.ResumeJob) error {
+	// Use the job's language, falling back to the process default; the
+	// resolved value is stored on the job so it is persisted with it.
+	if job.Language == "" {
+		job.Language = p.defaultLanguage
+	}
+	aiClient := p.aiClient.WithLanguage(job.Language)
 	
 	// aggregate data from DBs to provide a rich payload for the AI
 	var rawForAI interface{} = job.Profile
 	var aggregated interface{}
+	var keywords []string
 	if aiClient != nil {
+		p.setStatus(ctx, job, domain.StatusAggregating)
+		p.publish(job, EventAggregating, "")
 		agg, err := repo.AggregateForUser(ctx, job.UserID.String())
 		if err == nil {
 			// keep the aggregated result for later merging if needed
 			aggregated = agg
 			// If a job_application_id was provided on the job, fetch that
 			// specific job application and include it in the aggregated payload
+			var jobApplication interface{}
 			if job.Metadata != nil {
 				if jaidRaw, ok := job.Metadata["job_application_id"]; ok {
 					if jaid, ok2 := jaidRaw.(string); ok2 && jaid != "" {
 						if ja, err := repo.GetJobApplicationByID(ctx, jaid); err == nil {
+							jobApplication = ja
 							// ensure agg is a map-like structure
 							if ar, ok := aggregated.(repo.AggregateResult); ok {
 								ar["job_application"] = ja
 								aggregated = ar
 							}
 						} else {
-							fmt.Printf("processor: failed to fetch job_application %s: %v\n", jaid, err)
+							logctx.Warnf(ctx, "processor: failed to fetch job_application %s: %v", jaid, err)
 						}
 					}
 				}
@@ -82,20 +524,44 @@ func (p *Processor) Process(ctx context.Context, job *domain.ResumeJob) error {
 
 			// overrides is already normalized by NewOverridesFromMap
 
-			// overrides is already normalized by NewOverridesFromMap
-
+			// the AI gets the aggregated data cut down to maxAIPayload;
+			// aggregated keeps all of it for the merge
+			forAI, cut, err := capAggregate(agg, p.maxAIPayload)
+			if err != nil {
+				logctx.Warnf(ctx, "processor: measuring aggregated payload failed: %v", err)
+				forAI, cut = agg, nil
+			}
+			if job.Metadata == nil {
+				job.Metadata = map[string]interface{}{}
+			}
+			delete(job.Metadata, "ai_payload_trimmed")
+			if len(cut) > 0 {
+				logctx.Printf(ctx, "processor: aggregated payload over %d bytes, trimmed %v", p.maxAIPayload, cut)
+				job.Metadata["ai_payload_trimmed"] = cut
+			}
 			payload := map[string]interface{}{
-				"aggregated": agg,
+				"aggregated": forAI,
 				"overrides":  overrides.ToMap(),
 			}
+			// the experience and profile formatters put what the job asks
+			// for first
+			keywords = jobKeywords(jobDescriptionText(job, jobApplication), agg)
+			if len(keywords) > 0 {
+				payload["prioritize_keywords"] = keywords
+			}
 			rawForAI = payload
+		} else if len(job.Profile) == 0 {
+			// without the profile data or overrides the AI would have to
+			// make the whole resume up
+			return stageErr(StageAggregate, fmt.Errorf("%w: %v", ErrAggregation, err))
 		} else {
 			// fallback to whatever profile was provided
+			logctx.Warnf(ctx, "processor: aggregate failed, using the provided profile only: %v", err)
 			rawForAI = job.Profile
 		}
 
 		// debug: inspect the payload we'll send to the AI service
-		fmt.Printf("processor: rawForAI type=%T\n", rawForAI)
+		logctx.Debugf(ctx, "processor: rawForAI type=%T", rawForAI)
 		if m, ok := rawForAI.(map[string]interface{}); ok {
 			if agg, ok := m["aggregated"]; ok {
 				switch at := agg.(type) {
@@ -104,61 +570,61 @@ func (p *Processor) Process(ctx context.Context, job *domain.ResumeJob) error {
 					for k := range at {
 						keys = append(keys, k)
 					}
-					fmt.Printf("processor: aggregated keys=%v\n", keys)
+					logctx.Debugf(ctx, "processor: aggregated keys=%v", keys)
 					if pubs, ok := at["publications"]; ok {
 						if s, ok := pubs.([]interface{}); ok {
-							fmt.Printf("processor: aggregated.publications count=%d\n", len(s))
+							logctx.Debugf(ctx, "processor: aggregated.publications count=%d", len(s))
 						} else {
-							fmt.Printf("processor: aggregated.publications type=%T\n", pubs)
+							logctx.Debugf(ctx, "processor: aggregated.publications type=%T", pubs)
 						}
 					} else {
-						fmt.Printf("processor: aggregated.publications missing\n")
+						logctx.Debugf(ctx, "processor: aggregated.publications missing")
 					}
 					if certs, ok := at["certifications"]; ok {
 						if s, ok := certs.([]interface{}); ok {
-							fmt.Printf("processor: aggregated.certifications count=%d\n", len(s))
+							logctx.Debugf(ctx, "processor: aggregated.certifications count=%d", len(s))
 						} else {
-							fmt.Printf("processor: aggregated.certifications type=%T\n", certs)
+							logctx.Debugf(ctx, "processor: aggregated.certifications type=%T", certs)
 						}
 					} else {
-						fmt.Printf("processor: aggregated.certifications missing\n")
+						logctx.Debugf(ctx, "processor: aggregated.certifications missing")
 					}
 					if extras, ok := at["extras"]; ok {
-						fmt.Printf("processor: aggregated.extras type=%T value=%v\n", extras, extras)
+						logctx.Debugf(ctx, "processor: aggregated.extras type=%T value=%v", extras, extras)
 					} else {
-						fmt.Printf("processor: aggregated.extras missing\n")
+						logctx.Debugf(ctx, "processor: aggregated.extras missing")
 					}
 				case map[string]interface{}:
 					keys := []string{}
 					for k := range at {
 						keys = append(keys, k)
 					}
-					fmt.Printf("processor: aggregated keys=%v\n", keys)
+					logctx.Debugf(ctx, "processor: aggregated keys=%v", keys)
 				default:
-					fmt.Printf("processor: aggregated type=%T\n", agg)
+					logctx.Debugf(ctx, "processor: aggregated type=%T", agg)
 				}
 			} else {
-				fmt.Printf("processor: rawForAI has no aggregated key\n")
+				logctx.Debugf(ctx, "processor: rawForAI has no aggregated key")
 			}
 			if ov, ok := m["overrides"]; ok {
 				if ovm, ok := ov.(map[string]interface{}); ok {
 					if _, ok := ovm["publications"]; ok {
-						fmt.Printf("processor: overrides contains publications\n")
+						logctx.Debugf(ctx, "processor: overrides contains publications")
 					} else {
-						fmt.Printf("processor: overrides missing publications\n")
+						logctx.Debugf(ctx, "processor: overrides missing publications")
 					}
 					if _, ok := ovm["certifications"]; ok {
-						fmt.Printf("processor: overrides contains certifications\n")
+						logctx.Debugf(ctx, "processor: overrides contains certifications")
 					} else {
-						fmt.Printf("processor: overrides missing certifications\n")
+						logctx.Debugf(ctx, "processor: overrides missing certifications")
 					}
 					if _, ok := ovm["extras"]; ok {
-						fmt.Printf("processor: overrides contains extras\n")
+						logctx.Debugf(ctx, "processor: overrides contains extras")
 					} else {
-						fmt.Printf("processor: overrides missing extras\n")
+						logctx.Debugf(ctx, "processor: overrides missing extras")
 					}
 				} else {
-					fmt.Printf("processor: overrides type=%T\n", ov)
+					logctx.Debugf(ctx, "processor: overrides type=%T", ov)
 				}
 			}
 		}
@@ -170,7 +636,16 @@ func (p *Processor) Process(ctx context.Context, job *domain.ResumeJob) error {
 
 		// Staged AI flow: sequential validation and enrichment
 		// Each stage depends on previous stage success for context
-		if os.Getenv("AI_SPLIT_FLOW") != "false" {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		p.setStatus(ctx, job, domain.StatusFormatting)
+		// collect the formatters answered from the response cache; a job
+		// flagged no_cache asks the AI again
+		noCache, _ := job.Metadata["no_cache"].(bool)
+		cacheTrace := &ai.CacheTrace{Bypass: noCache}
+		ctx := ai.WithCacheTrace(ctx, cacheTrace)
+		if p.useSplitFlow(job) {
 			// prepare payload containing aggregated and overrides
 			payload := map[string]interface{}{}
 			if m, ok := rawForAI.(map[string]interface{}); ok {
@@ -180,73 +655,22 @@ func (p *Processor) Process(ctx context.Context, job *domain.ResumeJob) error {
 			}
 
 			if aiClient != nil {
-				// Stage 1: Foundation (Meta)
-				fmt.Printf("processor: Stage 1 - Foundation (meta)\n")
-				val1 := Stage1Validator(resumeMap)
-				if !val1.Valid {
-					if err := Stage1Enrich(ctx, aiClient, payload, resumeMap, val1); err != nil {
-						fmt.Printf("processor: Stage 1 enrichment failed (non-fatal): %v\n", err)
-						// Stage 1 is critical; if it fails, we can't proceed with confidence
-					}
-				}
-				val1 = Stage1Validator(resumeMap)
-				if val1.Valid {
-					fmt.Printf("processor: Stage 1 validated ✓\n")
-				} else {
-					fmt.Printf("processor: Stage 1 still invalid after enrichment: %v\n", val1.Missing)
-				}
-
-				// Stage 2: Professional History (Experience)
-				fmt.Printf("processor: Stage 2 - Professional History (experience)\n")
-				val2 := Stage2Validator(resumeMap)
-				if !val2.Valid {
-					if err := Stage2Enrich(ctx, aiClient, payload, resumeMap, val2); err != nil {
-						fmt.Printf("processor: Stage 2 enrichment failed (non-fatal): %v\n", err)
-					}
-				}
-				val2 = Stage2Validator(resumeMap)
-				if val2.Valid {
-					fmt.Printf("processor: Stage 2 validated ✓\n")
-				} else {
-					fmt.Printf("processor: Stage 2 still invalid after enrichment: %v\n", val2.Missing)
-				}
-
-				// Stage 3: Showcase Content (Projects, Publications, Certifications)
-				fmt.Printf("processor: Stage 3 - Showcase Content (projects, publications, certs)\n")
-				val3 := Stage3Validator(resumeMap)
-				if !val3.Valid {
-					if err := Stage3Enrich(ctx, aiClient, payload, resumeMap, val3); err != nil {
-						fmt.Printf("processor: Stage 3 enrichment failed (non-fatal): %v\n", err)
-					}
-				}
-				val3 = Stage3Validator(resumeMap)
-				if val3.Valid {
-					fmt.Printf("processor: Stage 3 validated ✓\n")
-				} else {
-					fmt.Printf("processor: Stage 3 still invalid after enrichment: %v\n", val3.Missing)
+				stageNames := make([]string, 0, len(splitFlowStages))
+				for _, st := range splitFlowStages {
+					stageNames = append(stageNames, st.Name)
 				}
+				initStageProgress(job, stageNames)
 
-				// Stage 4: Synthesis (Summary, Extras, Final Polish)
-				fmt.Printf("processor: Stage 4 - Synthesis (summary, extras)\n")
-				val4 := Stage4Validator(resumeMap)
-				if !val4.Valid {
-					if err := Stage4Enrich(ctx, aiClient, payload, resumeMap, val4); err != nil {
-						fmt.Printf("processor: Stage 4 enrichment failed (non-fatal): %v\n", err)
-					}
-				}
-				val4 = Stage4Validator(resumeMap)
-				if val4.Valid {
-					fmt.Printf("processor: Stage 4 validated ✓\n")
-				} else {
-					fmt.Printf("processor: Stage 4 still invalid after enrichment: %v\n", val4.Missing)
+				allValid, err := p.runStages(ctx, job, aiClient, payload, resumeMap, splitFlowStages)
+				if err != nil {
+					return err
 				}
 
 				// Log overall completion status
-				allValid := val1.Valid && val2.Valid && val3.Valid && val4.Valid
 				if allValid {
-					fmt.Printf("processor: All stages validated successfully\n")
+					logctx.Printf(ctx, "processor: All stages validated successfully")
 				} else {
-					fmt.Printf("processor: WARNING - Some stages failed validation\n")
+					logctx.Warnf(ctx, "processor: WARNING - Some stages failed validation")
 				}
 			}
 			// keep baseResume as a snapshot for targeted merges later
@@ -254,106 +678,47 @@ func (p *Processor) Process(ctx context.Context, job *domain.ResumeJob) error {
 			for k, v := range resumeMap {
 				baseResume[k] = v
 			}
+		} else {
+			p.publish(job, EventFormatting, "resume")
+			resumeMap, warnings, synthesized, err = aiClient.FormatResume(ctx, rawForAI)
+			if err != nil {
+				return stageErr(StageAI, err)
+			}
+			// the single-call output goes through the same stage
+			// validators and enrichers as the split flow, one stage at a
+			// time since they all work on the same map
+			payload := map[string]interface{}{}
+			if m, ok := rawForAI.(map[string]interface{}); ok {
+				payload = m
 			} else {
-				resumeMap, warnings, synthesized, err = aiClient.FormatResume(ctx, rawForAI)
-				if err != nil {
-					return err
-				}
-				// Keep a copy of the base resume returned from the first AI call.
-				baseResume = map[string]interface{}{}
-				for k, v := range resumeMap {
-					baseResume[k] = v
-				}
+				payload["aggregated"] = rawForAI
 			}
-
-		// If overrides supplied short lists, ask AI for a focused enrichment step
-		if m, ok := rawForAI.(map[string]interface{}); ok {
-			if ov, ok := m["overrides"]; ok {
-				if ovm, ok := ov.(map[string]interface{}); ok {
-					if _, hasPubs := ovm["publications"]; hasPubs {
-						// Focused enrichment: request only the override fields and hard-merge
-						fields, err := aiClient.EnrichFields(ctx, ovm)
-						if err != nil {
-							// fallback to broader EnrichResume if focused call fails
-							fmt.Printf("processor: enrich_fields failed: %v, falling back\n", err)
-							enriched, err2 := aiClient.EnrichResume(ctx, resumeMap, ovm)
-							if err2 != nil {
-								fmt.Printf("processor: enrich step failed: %v\n", err2)
-							} else if enriched != nil {
-								fields = map[string]interface{}{}
-								for _, k := range []string{"publications", "certifications", "extras"} {
-									if v, ok := enriched[k]; ok {
-										fields[k] = v
-									}
-								}
-							}
-						}
-						if fields != nil {
-							// convert AI-provided fields into typed Overrides for safer handling
-							// fields is already a map[string]interface{} from the AI client
-							fieldsMap := fields
-							ofields := NewOverridesFromMap(fieldsMap)
-							// merge into copy of baseResume
-							merged := map[string]interface{}{}
-							for k, v := range baseResume {
-								merged[k] = v
-							}
-                            
-							// merge typed fields into the resume map
-							if len(ofields.Publications) > 0 {
-								pubs := make([]interface{}, 0, len(ofields.Publications))
-								for _, s := range ofields.Publications {
-									pubs = append(pubs, s)
-								}
-								merged["publications"] = pubs
-							}
-							if len(ofields.Certifications) > 0 {
-								certs := make([]interface{}, 0, len(ofields.Certifications))
-								for _, c := range ofields.Certifications {
-									m := map[string]interface{}{"name": c.Name}
-									if c.Issuer != "" {
-										m["issuer"] = c.Issuer
-									}
-									if c.Date != "" {
-										m["date"] = c.Date
-									}
-									if c.URL != "" {
-										m["url"] = c.URL
-									}
-									if c.Description != "" {
-										m["description"] = c.Description
-									}
-									certs = append(certs, m)
-								}
-								merged["certifications"] = certs
-							}
-							if len(ofields.Extras) > 0 {
-								extras := make([]interface{}, 0, len(ofields.Extras))
-								for _, e := range ofields.Extras {
-									extras = append(extras, map[string]interface{}{"category": e.Category, "text": e.Text})
-								}
-								merged["extras"] = extras
-							}
-
-							// ensure publications meet minLength
-							if arr, ok := merged["publications"].([]interface{}); ok {
-								for i, it := range arr {
-									if s, ok := it.(string); ok {
-										// leave short publications as-is; we'll ask the AI to
-										// expand them via EnrichFields instead of synthesizing here.
-										_ = s
-									} else {
-										arr[i] = fmt.Sprintf("%v", it)
-									}
-								}
-								merged["publications"] = arr
-							}
-							resumeMap = merged
-							fmt.Printf("processor: resumeMap enriched (hard-merge of override keys)\n")
-						}
-					}
-				}
+			stageNames := make([]string, 0, len(splitFlowStages))
+			for _, st := range splitFlowStages {
+				stageNames = append(stageNames, st.Name)
+			}
+			initStageProgress(job, stageNames)
+			for i, st := range splitFlowStages {
+				p.runStage(ctx, job, aiClient, payload, resumeMap, i, st)
+			}
+			if err := ctx.Err(); err != nil {
+				return err
 			}
+			// Keep a copy of the base resume returned from the first AI call.
+			baseResume = map[string]interface{}{}
+			for k, v := range resumeMap {
+				baseResume[k] = v
+			}
+		}
+		// the caller's publications, certifications and extras win over
+		// what the formatters made of the aggregated rows
+		if enrichOverrides(ctx, aiClient, job.Profile, resumeMap) {
+			logctx.Printf(ctx, "processor: merged enriched profile overrides")
+		}
+		if hits := cacheTrace.Hits(); len(hits) > 0 {
+			job.Metadata["ai_cache_hits"] = hits
+		} else {
+			delete(job.Metadata, "ai_cache_hits")
 		}
 
 		// Validate AI output; if enrichment broke other fields, try merging only
@@ -462,8 +827,17 @@ func (p *Processor) Process(ctx context.Context, job *domain.ResumeJob) error {
 			return m
 		}
 
-		if err := model.ValidateMap(normalizeForSchema(resumeMap)); err != nil {
-			fmt.Printf("processor: ai validation failed: %v - attempting targeted merge\n", err)
+		p.setStatus(ctx, job, domain.StatusValidating)
+		p.publish(job, EventValidating, "")
+		dropInvalidPhoto(resumeMap)
+		normalizeMetaContact(resumeMap)
+		repairCertifications(ctx, resumeMap)
+		verrs, verr := model.ValidateMapDetailed(normalizeForSchema(resumeMap))
+		if verr != nil || len(verrs) > 0 {
+			if verr == nil {
+				verr = fmt.Errorf("%d schema violations", len(verrs))
+			}
+			logctx.Warnf(ctx, "processor: ai validation failed: %v - attempting targeted merge", verr)
 			// ensure tryMerge uses normalized types before re-validating
 			// attempt to merge only publications/certifications/extras from the
 			// enriched result into the original baseResume and re-validate.
@@ -478,20 +852,22 @@ func (p *Processor) Process(ctx context.Context, job *domain.ResumeJob) error {
 			if merged {
 				if err2 := model.ValidateMap(normalizeForSchema(tryMerge)); err2 == nil {
 					resumeMap = tryMerge
-					fmt.Printf("processor: targeted merge succeeded\n")
+					logctx.Printf(ctx, "processor: targeted merge succeeded")
 				} else {
-					fmt.Printf("processor: targeted merge still invalid: %v - using base resume\n", err2)
+					logctx.Warnf(ctx, "processor: targeted merge still invalid: %v - using base resume", err2)
 					resumeMap = baseResume
+					recordValidationErrors(job, verrs)
 				}
 			} else {
 				// nothing to merge; fall back to baseResume
 				resumeMap = baseResume
+				recordValidationErrors(job, verrs)
 			}
 		}
 
-		// validate against schema
+		// validate against schema; the AI may well answer validly on a retry
 		if err := model.ValidateMap(resumeMap); err != nil {
-			return fmt.Errorf("ai response validation failed: %w", err)
+			return &ProcessError{Stage: StageValidation, Retriable: true, Err: fmt.Errorf("ai response validation failed: %w", err)}
 		}
 
 		// HARD-MERGE: ensure meta and social_links are present from aggregated
@@ -512,7 +888,7 @@ func (p *Processor) Process(ctx context.Context, job *domain.ResumeJob) error {
 								} else {
 									profileMeta = map[string]interface{}{}
 									// copy some common fields if present (include social_links)
-									for _, k := range []string{"name", "headline", "contact", "website", "bio", "social_links"} {
+									for _, k := range []string{"name", "headline", "contact", "website", "bio", "social_links", "photo_url"} {
 										if v, ok := first[k]; ok {
 											profileMeta[k] = v
 										}
@@ -544,6 +920,11 @@ func (p *Processor) Process(ctx context.Context, job *domain.ResumeJob) error {
 							metaObj["contact"] = c
 						}
 					}
+					if photo, ok := profileMeta["photo_url"].(string); ok && model.IsHTTPURL(photo) {
+						if s, _ := metaObj["photo_url"].(string); s == "" {
+							metaObj["photo_url"] = photo
+						}
+					}
 					// ensure social_links
 					if sl, ok := profileMeta["social_links"]; ok {
 						has := false
@@ -559,6 +940,9 @@ func (p *Processor) Process(ctx context.Context, job *domain.ResumeJob) error {
 							metaObj["social_links"] = sl
 						}
 					}
+					// the aggregated contact and links have not been checked yet
+					model.NormalizeContact(metaObj)
+					model.NormalizeMetaSocialLinks(metaObj)
 					resumeMap["meta"] = metaObj
 				}
 			}
@@ -567,7 +951,7 @@ func (p *Processor) Process(ctx context.Context, job *domain.ResumeJob) error {
 		// ensure important aggregated sections are present if AI omitted them
 		if aggregated != nil {
 			if aggMap, ok := aggregated.(repo.AggregateResult); ok {
-				fmt.Printf("processor: agg keys=%v\n", aggMap)
+				logctx.Debugf(ctx, "processor: agg keys=%v", aggMap)
 				// publications
 				mergePubs := func(pubsRaw interface{}) []interface{} {
 					out := []interface{}{}
@@ -576,7 +960,9 @@ func (p *Processor) Process(ctx context.Context, job *domain.ResumeJob) error {
 					}
 					switch t := pubsRaw.(type) {
 					case []interface{}:
-						for _, itm := range t {
+						// dedup, order and cap on the raw rows, which still
+						// carry their dates
+						for _, itm := range normalizeSection(t, sectionCap(job, "publications")) {
 							switch it := itm.(type) {
 							case string:
 								out = append(out, it)
@@ -613,117 +999,98 @@ func (p *Processor) Process(ctx context.Context, job *domain.ResumeJob) error {
 				if v, exists := resumeMap["publications"]; !exists {
 					if pubs, ok := aggMap["publications"]; ok {
 						resumeMap["publications"] = mergePubs(pubs)
-						fmt.Printf("processor: merged publications from agg, count=%d\n", len(resumeMap["publications"].([]interface{})))
+						logctx.Debugf(ctx, "processor: merged publications from agg, count=%d", len(resumeMap["publications"].([]interface{})))
 					} else {
-						fmt.Printf("processor: agg has no publications\n")
+						logctx.Debugf(ctx, "processor: agg has no publications")
 					}
 				} else {
 					// replace if empty
 					if arr, ok := v.([]interface{}); ok && len(arr) == 0 {
 						if pubs, ok := aggMap["publications"]; ok {
 							resumeMap["publications"] = mergePubs(pubs)
-							fmt.Printf("processor: replaced empty publications with agg, count=%d\n", len(resumeMap["publications"].([]interface{})))
+							logctx.Debugf(ctx, "processor: replaced empty publications with agg, count=%d", len(resumeMap["publications"].([]interface{})))
 						} else {
-							fmt.Printf("processor: resumeMap has empty publications but agg has none\n")
+							logctx.Debugf(ctx, "processor: resumeMap has empty publications but agg has none")
 						}
 					} else {
-						fmt.Printf("processor: resumeMap publications present and non-empty or not array: %T\n", v)
+						logctx.Debugf(ctx, "processor: resumeMap publications present and non-empty or not array: %T", v)
 					}
 				}
+				mergeCerts := func(certsRaw interface{}) interface{} {
+					if arr, ok := certsRaw.([]interface{}); ok {
+						return normalizeSection(arr, sectionCap(job, "certifications"))
+					}
+					return certsRaw
+				}
 				// certifications (sometimes called certifications or certs)
 				if v, exists := resumeMap["certifications"]; !exists {
 					if certs, ok := aggMap["certifications"]; ok {
-						resumeMap["certifications"] = certs
-						fmt.Printf("processor: merged certifications from agg\n")
+						resumeMap["certifications"] = mergeCerts(certs)
+						logctx.Debugf(ctx, "processor: merged certifications from agg")
 					} else {
-						fmt.Printf("processor: agg has no certifications\n")
+						logctx.Debugf(ctx, "processor: agg has no certifications")
 					}
 				} else {
 					if arr, ok := v.([]interface{}); ok && len(arr) == 0 {
 						if certs, ok := aggMap["certifications"]; ok {
-							resumeMap["certifications"] = certs
-							fmt.Printf("processor: replaced empty certifications with agg\n")
+							resumeMap["certifications"] = mergeCerts(certs)
+							logctx.Debugf(ctx, "processor: replaced empty certifications with agg")
 						} else {
-							fmt.Printf("processor: resumeMap has empty certifications but agg has none\n")
+							logctx.Debugf(ctx, "processor: resumeMap has empty certifications but agg has none")
 						}
 					} else {
-						fmt.Printf("processor: resumeMap certifications present and non-empty or not array: %T\n", v)
+						logctx.Debugf(ctx, "processor: resumeMap certifications present and non-empty or not array: %T", v)
 					}
 				}
+				// projects are built from the aggregated rows as well
+				if arr, ok := resumeMap["projects"].([]interface{}); ok && len(arr) > 0 {
+					resumeMap["projects"] = normalizeSection(arr, sectionCap(job, "projects"))
+				}
 			}
 		}
 
-		// Compact certification dates to year-only for compact display
-		if certsRaw, ok := resumeMap["certifications"]; ok {
-			if certsArr, ok := certsRaw.([]interface{}); ok {
-				for i, it := range certsArr {
-					switch c := it.(type) {
-					case map[string]interface{}:
-						if d, ok := c["date"].(string); ok && len(d) >= 4 {
-							// prefer first 4 characters as year when possible
-							c["date"] = d[:4]
-						}
-						certsArr[i] = c
-					default:
-						// leave as-is
-					}
+		// education and skills: caller overrides win, then whatever the AI
+		// produced, then the rows aggregated from the management DB
+		if job.Profile != nil {
+			ov := NewOverridesFromMap(job.Profile)
+			if len(ov.Education) > 0 {
+				resumeMap["education"] = educationToList(ov.Education)
+			}
+			if len(ov.Skills) > 0 {
+				resumeMap["skills"] = skillsToList(ov.Skills)
+			}
+		}
+		if arr, ok := resumeMap["skills"].([]interface{}); !ok || len(arr) == 0 {
+			if aggMap, ok := aggregated.(repo.AggregateResult); ok {
+				if skills, ok := aggMap["skills"].([]interface{}); ok && len(skills) > 0 {
+					resumeMap["skills"] = skills
+					logctx.Debugf(ctx, "processor: seeded skills from agg, groups=%d", len(skills))
+				}
+			}
+		}
+		if arr, ok := resumeMap["education"].([]interface{}); !ok || len(arr) == 0 {
+			if aggMap, ok := aggregated.(repo.AggregateResult); ok {
+				if edu := ParseEducation(aggMap["education"]); len(edu) > 0 {
+					resumeMap["education"] = educationToList(edu)
+					logctx.Debugf(ctx, "processor: merged education from agg, count=%d", len(edu))
 				}
-				resumeMap["certifications"] = certsArr
 			}
 		}
 
+		// certifications merged from the aggregated rows skipped the schema
+		// check above
+		repairCertifications(ctx, resumeMap)
+
+		dedupeMergedSections(resumeMap)
+		compactCertificationDates(resumeMap)
+		sortExperienceByPeriod(resumeMap, job.Language)
+		tailorSections(job, resumeMap)
+		recordJobKeywords(job, keywords, prioritizeKeywords(resumeMap, keywords))
+
 		// replace job.Profile with validated and merged resumeMap for template rendering
 		job.Profile = resumeMap
 
-		// compute short URL label for certifications (used by template to show domain-only)
-		if certsRaw, ok := resumeMap["certifications"]; ok {
-			if certsArr, ok := certsRaw.([]interface{}); ok {
-				for i, it := range certsArr {
-					switch c := it.(type) {
-					case map[string]interface{}:
-						label := ""
-						if uRaw, ok := c["url"]; ok {
-							if us, ok := uRaw.(string); ok && us != "" {
-								// ensure scheme present for parsing
-								candidate := us
-								if !strings.HasPrefix(candidate, "http://") && !strings.HasPrefix(candidate, "https://") {
-									candidate = "https://" + candidate
-								}
-								if parsed, err := url.Parse(candidate); err == nil {
-									host := parsed.Hostname()
-									// attempt eTLD+1 extraction for tidy labels
-									if etld, err2 := publicsuffix.EffectiveTLDPlusOne(host); err2 == nil {
-										label = strings.TrimPrefix(etld, "www.")
-									} else {
-										// fallback to hostname without port and www
-										if host == "" {
-											label = candidate
-										} else {
-											label = strings.TrimPrefix(host, "www.")
-										}
-									}
-								} else {
-									label = us
-								}
-							}
-						}
-						if label == "" {
-							// prefer issuer if present for human-friendly label
-							if iss, ok := c["issuer"].(string); ok && iss != "" {
-								label = iss
-							} else {
-								label = "link"
-							}
-						}
-						c["url_label"] = label
-						certsArr[i] = c
-					default:
-						// leave non-object entries as-is
-					}
-				}
-				resumeMap["certifications"] = certsArr
-			}
-		}
+		labelCertificationURLs(resumeMap)
 
 		// All per-experience summaries must be produced by the AI.
 		// The processor no longer synthesizes role summaries locally; if the
@@ -732,71 +1099,137 @@ func (p *Processor) Process(ctx context.Context, job *domain.ResumeJob) error {
 		if job.Metadata == nil {
 			job.Metadata = map[string]interface{}{}
 		}
+		// a section with no source data at all was invented by the AI, even
+		// when the AI did not flag it
+		var aggMap map[string]interface{}
+		switch at := aggregated.(type) {
+		case repo.AggregateResult:
+			aggMap = at
+		case map[string]interface{}:
+			aggMap = at
+		}
+		overridesMap, _ := job.Metadata["profile_overrides"].(map[string]interface{})
+		synthesizedFields, sourcedFields := classifySections(resumeMap, aggMap, overridesMap)
+		job.Metadata["ai_used"] = true
+		job.Metadata["ai_skipped"] = false
 		job.Metadata["ai_warnings"] = warnings
-		job.Metadata["ai_synthesized"] = synthesized
+		job.Metadata["ai_synthesized"] = synthesized || len(synthesizedFields) > 0
+		job.Metadata["synthesized_fields"] = synthesizedFields
+		job.Metadata["sourced_fields"] = sourcedFields
+	}
 
-		// Format UI labels in the specified language
-		labels, labErr := aiClient.FormatLabels(ctx)
-		if labErr != nil {
-			fmt.Printf("processor: FormatLabels failed: %v, using defaults\n", labErr)
-			labels = formatters.GetDefaultLabels()
-		}
-		if labels != nil {
-			resumeMap["labels"] = labels
-			fmt.Printf("processor: formatted labels in %s\n", job.Language)
+	// Section headings in the job's language; kept on the profile too for
+	// the text export and older templates
+	labels := resolveLabels(ctx, aiClient, job.Language)
+	if job.Profile != nil {
+		job.Profile["labels"] = labels
+	}
+
+	return stageErr(StageRender, p.renderAndSave(ctx, job, labels))
+}
+
+// maxPDFKeywords bounds the skills listed as PDF keywords.
+const maxPDFKeywords = 12
+
+// pdfMetadata describes the rendered resume in the PDF's information
+// dictionary: the person's name as title and author, the headline as
+// subject and the first skills as keywords.
+func pdfMetadata(job *domain.ResumeJob) infra.PDFMetadata {
+	meta, _ := job.Profile["meta"].(map[string]interface{})
+	name := rowString(meta, "name")
+	md := infra.PDFMetadata{
+		Title:   strings.TrimSpace(name + " Resume"),
+		Author:  name,
+		Subject: rowString(meta, "headline"),
+		Created: job.CreatedAt,
+	}
+	var keywords []string
+	groups, _ := job.Profile["skills"].([]interface{})
+	for _, g := range groups {
+		group, _ := g.(map[string]interface{})
+		items, _ := group["items"].([]interface{})
+		for _, it := range items {
+			if s, ok := it.(string); ok && s != "" && len(keywords) < maxPDFKeywords {
+				keywords = append(keywords, s)
+			}
 		}
 	}
+	md.Keywords = strings.Join(keywords, ", ")
+	return md
+}
+
+// inlineStyles returns html with the stylesheet cssFile of the templates FS
+// inlined, so saved HTML shows styling.
+func (p *Processor) inlineStyles(ctx context.Context, job *domain.ResumeJob, html, cssFile string) string {
+	var cssContent string
+	if b, err := templates.ReadFile(cssFile); err == nil {
+		cssContent = string(b)
+	}
+	// embed the template's fonts so the PDF looks the same wherever Chrome
+	// runs; the rules and the job's theme go after the stylesheet, whose
+	// custom properties they override
+	theme := jobTheme(job)
+	cssContent += p.fontCSS(ctx, html, theme.Font) + theme.css()
+	if cssContent == "" {
+		logctx.Debugf(ctx, "processor: no cssContent found while attempting to inline")
+		return html
+	}
+	cssBlock := "<style>" + cssContent + "</style>"
+	logctx.Debugf(ctx, "processor: inlined CSS, len=%d", len(cssContent))
+	// inject stylesheet at top of head so saved HTML shows styles
+	if strings.Contains(strings.ToLower(html), "<head>") {
+		return strings.Replace(html, "<head>", "<head>"+cssBlock, 1)
+	}
+	return cssBlock + html
+}
+
+// renderAndSave renders job.Profile through the template into HTML, text,
+// optional DOCX, PDF and preview artifacts, stores the user's copy, marks
+// the job completed and saves it. It is the tail of process and is reused by
+// Rerender.
+func (p *Processor) renderAndSave(ctx context.Context, job *domain.ResumeJob, labels map[string]string) error {
+	// keep the validated map that is about to be rendered; Save stores it
+	// on the resumes row for GET /resumes/:id/json
+	job.Resume = job.Profile
+	// the AI or a rerender may hand back links that are handles or lack
+	// a scheme; templates link them as they are
+	if meta, ok := job.Profile["meta"].(map[string]interface{}); ok {
+		model.NormalizeMetaSocialLinks(meta)
+	}
 
 	// render HTML
-	tplPath := filepath.Join(p.tplDir, "template.html")
-	tpl, err := template.ParseFiles(tplPath)
-	if err != nil {
+	if err := ctx.Err(); err != nil {
 		return err
 	}
+	p.setStatus(ctx, job, domain.StatusRendering)
+	p.publish(job, EventRendering, "")
+	// a broken or unknown template fails every retry the same way
+	layout, err := jobLayout(job)
+	if err != nil {
+		return permanentErr(StageRender, err)
+	}
+	tpl, err := template.New(layout.HTML).Funcs(templates.FuncMap()).ParseFS(templates.FS(), layout.HTML)
+	if err != nil {
+		return permanentErr(StageRender, err)
+	}
 
 	var buf bytes.Buffer
 	data := map[string]interface{}{
 		"Profile": job.Profile,
+		"Labels":  labels,
+		"Photo":   p.photoSource(ctx, job),
 	}
 	if err := tpl.Execute(&buf, data); err != nil {
-		return err
+		return permanentErr(StageRender, err)
 	}
 
 	html := buf.String()
 
-	// Inline local stylesheet from templates so saved HTML shows styling
-	// try several candidate locations for the stylesheet file
-	candidates := []string{
-		filepath.Join(p.tplDir, "style.css"),
-		filepath.Join(".", p.tplDir, "style.css"),
-		"/app/templates/style.css",
-		"./style.css",
-		"style.css",
-	}
-	var cssContent string
-	for _, c := range candidates {
-		if b, err := ioutil.ReadFile(c); err == nil {
-			cssContent = string(b)
-			break
-		}
-	}
-	if cssContent != "" {
-		cssBlock := "<style>" + cssContent + "</style>"
-		// inject stylesheet at top of head so saved HTML shows styles
-		if strings.Contains(strings.ToLower(html), "<head>") {
-			html = strings.Replace(html, "<head>", "<head>"+cssBlock, 1)
-		} else {
-			html = cssBlock + html
-		}
-		fmt.Printf("processor: inlined CSS, len=%d\n", len(cssContent))
-	}
-	if cssContent == "" {
-		fmt.Printf("processor: no cssContent found while attempting to inline\n")
-	}
+	html = p.inlineStyles(ctx, job, html, layout.CSS)
 
 	// save HTML artifact before rendering so it's preserved even if rendering fails
 	ts := time.Now().Format("20060102T150405")
-	genDir := filepath.Join("resume-data", "generated")
+	genDir := p.GeneratedDir()
 	if err := os.MkdirAll(genDir, 0o755); err != nil {
 		return err
 	}
@@ -806,63 +1239,201 @@ func (p *Processor) Process(ctx context.Context, job *domain.ResumeJob) error {
 		return err
 	}
 
-	// produce PDF with retry and validation
+	// the validated resume the artifacts were rendered from, for diffing
+	// and re-rendering without the AI
+	resumeJSON, err := json.MarshalIndent(job.Resume, "", "  ")
+	if err != nil {
+		return err
+	}
+	jsonName := fmt.Sprintf("resume_%s.json", ts)
+	if err := ioutil.WriteFile(filepath.Join(genDir, jsonName), resumeJSON, 0o644); err != nil {
+		return err
+	}
+	job.Metadata["generated_json"] = filepath.Join(genDir, jsonName)
+
+	// plain-text (ATS-friendly) rendering of the same resume; accented text
+	// is kept unless the job asked for ascii_text
+	if wantsFormat(job, FormatTXT) {
+		textOpts := export.TextOptions{Width: p.textWidth, ASCII: job.Metadata["ascii_text"] == true}
+		if txt, err := export.RenderResumeTextWithOptions(job.Profile, labels, textOpts); err != nil {
+			logctx.Warnf(ctx, "processor: text export failed: %v", err)
+			job.Metadata["txt_render_error"] = err.Error()
+		} else {
+			txtName := fmt.Sprintf("resume_%s.txt", ts)
+			if err := ioutil.WriteFile(filepath.Join(genDir, txtName), []byte(txt), 0o644); err != nil {
+				return err
+			}
+			job.Metadata["generated_txt"] = filepath.Join(genDir, txtName)
+		}
+	}
+
+	// moderncv LaTeX source, compiled to PDF when pdflatex is available
+	if wantsFormat(job, FormatTeX) {
+		paper, _ := job.Metadata["paper_size"].(string)
+		if tex, err := export.RenderResumeLaTeX(job.Profile, labels, export.LaTeXOptions{Paper: paper}); err != nil {
+			logctx.Warnf(ctx, "processor: latex export failed: %v", err)
+			job.Metadata["tex_render_error"] = err.Error()
+		} else {
+			texName := fmt.Sprintf("resume_%s.tex", ts)
+			if err := ioutil.WriteFile(filepath.Join(genDir, texName), []byte(tex), 0o644); err != nil {
+				return err
+			}
+			job.Metadata["generated_tex"] = filepath.Join(genDir, texName)
+			if p.latexCompiler != nil {
+				if texPDF, err := p.latexCompiler.CompileLaTeX(ctx, tex); err != nil {
+					logctx.Warnf(ctx, "processor: latex compile failed: %v", err)
+					job.Metadata["tex_compile_error"] = err.Error()
+				} else {
+					texPDFName := fmt.Sprintf("resume_%s.tex.pdf", ts)
+					if err := ioutil.WriteFile(filepath.Join(genDir, texPDFName), texPDF, 0o644); err != nil {
+						return err
+					}
+					job.Metadata["generated_tex_pdf"] = filepath.Join(genDir, texPDFName)
+				}
+			}
+		}
+	}
+
+	// optional DOCX export next to the HTML; PDF remains the default output
+	if wantsFormat(job, FormatDOCX) {
+		if p.docxRenderer == nil {
+			logctx.Warnf(ctx, "processor: docx requested but no docx renderer configured")
+		} else if docxBytes, err := p.docxRenderer.RenderHTMLToDOCX(ctx, html); err != nil {
+			logctx.Warnf(ctx, "processor: docx render failed: %v", err)
+			job.Metadata["docx_render_error"] = err.Error()
+		} else {
+			docxName := fmt.Sprintf("resume_%s.docx", ts)
+			if err := ioutil.WriteFile(filepath.Join(genDir, docxName), docxBytes, 0o644); err != nil {
+				return err
+			}
+			job.Metadata["generated_docx"] = filepath.Join(genDir, docxName)
+		}
+	}
+
+	// paper size comes from job metadata ("A4", "Letter", "Legal"); A4 default
+	renderOpts := infra.DefaultRenderOptions()
+	if ps, _ := job.Metadata["paper_size"].(string); ps != "" {
+		if o, ok := infra.RenderOptionsForPaper(ps); ok {
+			renderOpts = o
+		} else {
+			logctx.Warnf(ctx, "processor: unknown paper_size %q, using A4", ps)
+		}
+	}
+
+	renderOpts.Metadata = pdfMetadata(job)
+
+	// produce PDF with retry and validation; dry runs stop at the HTML
 	var pdfBytes []byte
 	var renderErr error
-	attempts := 3
-	for i := 0; i < attempts; i++ {
-		pdfBytes, renderErr = p.renderer.RenderHTMLToPDF(ctx, html)
-		if renderErr == nil {
+	dryRun := p.isDryRun(job)
+	if dryRun {
+		job.Metadata["dry_run"] = true
+		logctx.Printf(ctx, "processor: dry run, skipping PDF and preview rendering")
+	}
+	var stats retry.Stats
+	renderFull := func() {
+		stats, renderErr = p.renderRetry.Do(ctx, func(attempt int) error {
+			out, err := p.renderer.RenderHTMLToPDFWithOptions(ctx, html, renderOpts)
 			// validate basic PDF signature
-			if len(pdfBytes) > 0 && strings.HasPrefix(string(pdfBytes), "%PDF") {
-				renderErr = nil
-				break
+			if err == nil && (len(out) == 0 || !strings.HasPrefix(string(out), "%PDF")) {
+				err = fmt.Errorf("invalid PDF output (len=%d)", len(out))
 			}
-			renderErr = fmt.Errorf("invalid PDF output (len=%d)", len(pdfBytes))
-		}
-		fmt.Printf("processor: render attempt %d failed: %v\n", i+1, renderErr)
-		// exponential backoff before retrying
-		if i < attempts-1 {
-			backoff := time.Duration(1<<i) * time.Second
-			select {
-			case <-time.After(backoff):
-			case <-ctx.Done():
-				return ctx.Err()
+			if err != nil {
+				logctx.Warnf(ctx, "processor: render attempt %d failed: %v", attempt, err)
+				return err
 			}
+			pdfBytes = out
+			return nil
+		})
+	}
+	switch {
+	case dryRun:
+	case job.Metadata["preview"] == true:
+		// the full render runs in the background while page 1 is rendered
+		// and published, so the preview does not delay the full PDF
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			defer func() {
+				if r := recover(); r != nil {
+					renderErr = fmt.Errorf("render panicked: %v", r)
+				}
+			}()
+			renderFull()
+		}()
+		p.renderPreviewPDF(ctx, job, html, renderOpts, filepath.Join(genDir, fmt.Sprintf("resume_%s_page1.pdf", ts)))
+		<-done
+	default:
+		renderFull()
+	}
+	if !dryRun {
+		job.Metadata["render_attempts"] = stats.Attempts
+		job.Metadata["render_retry_wait_ms"] = stats.Waited.Milliseconds()
+		if err := ctx.Err(); err != nil {
+			return err
 		}
 	}
 
-	if renderErr != nil {
+	switch {
+	case dryRun:
+	case renderErr != nil:
 		// log and continue; preserve HTML and record metadata
-		fmt.Printf("processor: rendering failed after %d attempts: %v\n", attempts, renderErr)
-	} else {
+		logctx.Warnf(ctx, "processor: rendering failed after %d attempts: %v", job.Metadata["render_attempts"], renderErr)
+	default:
 		if err := ioutil.WriteFile(filepath.Join(genDir, pdfName), pdfBytes, 0o644); err != nil {
 			return err
 		}
 	}
 
-	// copy to per-user folder
-	userDir := filepath.Join("resume-data", "resumes", job.UserID.String())
-	if err := os.MkdirAll(userDir, 0o755); err != nil {
-		return err
+	// thumbnail preview from the same HTML; produced even when the PDF failed
+	if !dryRun {
+		if png, err := p.renderer.RenderHTMLToPNG(ctx, html, p.previewWidth); err != nil {
+			logctx.Warnf(ctx, "processor: preview render failed: %v", err)
+			job.Metadata["preview_render_error"] = err.Error()
+		} else {
+			previewName := fmt.Sprintf("preview_%s.png", ts)
+			if err := ioutil.WriteFile(filepath.Join(genDir, previewName), png, 0o644); err != nil {
+				return err
+			}
+			job.Metadata["generated_preview"] = filepath.Join(genDir, previewName)
+		}
+		p.renderCoverLetter(ctx, job, renderOpts, filepath.Join(genDir, fmt.Sprintf("cover_letter_%s.pdf", ts)))
 	}
-	// copy PDF to per-user folder if rendering succeeded
+
+	p.setStatus(ctx, job, domain.StatusSaving)
+	p.publish(job, EventSaving, "")
+
+	// store the user's copy through p.storage (<output dir>/resumes/<user>
+	// locally, or the configured bucket)
+	copyID := uuid.New().String()
+	userKey := job.UserID.String()
+	if err := ValidateName(userKey); err != nil {
+		return stageErr(StageStore, err)
+	}
+	htmlURL, err := p.storage.Put(ctx, userKey+"/"+copyID+".html", []byte(html), "text/html; charset=utf-8")
+	if err != nil {
+		return stageErr(StageStore, fmt.Errorf("store html: %w", err))
+	}
+	job.Metadata["html_url"] = htmlURL
 	if renderErr == nil && len(pdfBytes) > 0 {
-		destName := uuid.New().String() + ".pdf"
-		if err := ioutil.WriteFile(filepath.Join(userDir, destName), pdfBytes, 0o644); err != nil {
-			return err
+		pdfURL, err := p.storage.Put(ctx, userKey+"/"+copyID+".pdf", pdfBytes, "application/pdf")
+		if err != nil {
+			return stageErr(StageStore, fmt.Errorf("store pdf: %w", err))
 		}
-		job.Metadata["user_copy"] = filepath.Join(userDir, destName)
+		job.Metadata["user_copy"] = pdfURL
+		job.Metadata["pdf_url"] = pdfURL
+	} else if dryRun {
+		job.Metadata["user_copy"] = ""
 	} else {
 		job.Metadata["user_copy"] = ""
 		job.Metadata["pdf_render_error"] = fmt.Sprintf("render failed: %v", renderErr)
 	}
 
 	// update job metadata and status
+	p.progressMu.Lock()
+	trackProgress(job, domain.StatusSaving, true)["percent"] = 100
 	job.Status = "completed"
-	if job.Metadata == nil {
-		job.Metadata = map[string]interface{}{}
-	}
+	DiscardCheckpoints(job)
 	job.Metadata["generated_html"] = filepath.Join(genDir, htmlName)
 	if renderErr == nil && len(pdfBytes) > 0 {
 		job.Metadata["generated_pdf"] = filepath.Join(genDir, pdfName)
@@ -870,12 +1441,71 @@ func (p *Processor) Process(ctx context.Context, job *domain.ResumeJob) error {
 		job.Metadata["generated_pdf"] = ""
 	}
 	job.UpdatedAt = time.Now()
+	p.progressMu.Unlock()
 
 	if p.repo != nil {
 		if err := p.repo.Save(ctx, job); err != nil {
-			return err
+			return stageErr(StageSave, err)
 		}
 	}
 
 	return nil
 }
+
+// renderPreviewPDF renders only the first page of html to path for jobs
+// started in preview mode, so clients can show it while the full document
+// renders alongside. It records the file as preview_pdf right away and
+// publishes EventPreview; a failed preview is logged and the full render
+// goes on.
+func (p *Processor) renderPreviewPDF(ctx context.Context, job *domain.ResumeJob, html string, opts infra.RenderOptions, path string) {
+	opts.PageRanges = "1"
+	pdfBytes, err := p.renderer.RenderHTMLToPDFWithOptions(ctx, html, opts)
+	if err == nil && !strings.HasPrefix(string(pdfBytes), "%PDF") {
+		err = fmt.Errorf("invalid PDF output (len=%d)", len(pdfBytes))
+	}
+	if err == nil {
+		err = ioutil.WriteFile(path, pdfBytes, 0o644)
+	}
+	if err != nil {
+		logctx.Warnf(ctx, "processor: preview render failed: %v", err)
+		return
+	}
+	job.Metadata["preview_pdf"] = path
+	if p.repo != nil {
+		if err := p.repo.SetMetadata(ctx, job.ID, "preview_pdf", path); err != nil {
+			logctx.Warnf(ctx, "processor: failed to record preview of job %s: %v", job.ID.String(), err)
+		}
+	}
+	p.publish(job, EventPreview, "")
+}
+
+// recordValidationErrors stores the schema violations of the discarded AI
+// output on the job so clients can see which fields were rejected.
+func recordValidationErrors(job *domain.ResumeJob, verrs []model.ValidationError) {
+	if len(verrs) == 0 {
+		return
+	}
+	if job.Metadata == nil {
+		job.Metadata = map[string]interface{}{}
+	}
+	job.Metadata["validation_errors"] = verrs
+}
+
+// resolveLabels returns the section labels translated into language. Keys
+// the translation lacks, or all of them when the AI call fails, fall back to
+// the English defaults.
+func resolveLabels(ctx context.Context, aiClient *ai.Client, language string) map[string]string {
+	labels := formatters.GetDefaultLabels()
+	translated, err := aiClient.FormatLabels(ctx)
+	if err != nil {
+		logctx.Warnf(ctx, "processor: FormatLabels failed: %v, using defaults", err)
+		return labels
+	}
+	for k, v := range translated {
+		if v != "" {
+			labels[k] = v
+		}
+	}
+	logctx.Printf(ctx, "processor: formatted labels in %s", language)
+	return labels
+}
@@ -0,0 +1,462 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"resume-generator/internal/domain"
+	"resume-generator/pkg/ai"
+	infra "resume-generator/pkg/infrastructure"
+	"resume-generator/pkg/retry"
+	"resume-generator/templates"
+
+	"github.com/google/uuid"
+)
+
+func TestPDFMetadata(t *testing.T) {
+	created := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	many := make([]interface{}, 20)
+	for i := range many {
+		many[i] = string(rune('a' + i))
+	}
+	tests := []struct {
+		name    string
+		profile map[string]interface{}
+		want    infra.PDFMetadata
+	}{
+		{
+			name: "name, headline and skills",
+			profile: map[string]interface{}{
+				"meta": map[string]interface{}{"name": "Ada Lovelace", "headline": "Engineer"},
+				"skills": []interface{}{
+					map[string]interface{}{"items": []interface{}{"Go", "", "SQL"}},
+					map[string]interface{}{"items": []interface{}{"Docker"}},
+				},
+			},
+			want: infra.PDFMetadata{Title: "Ada Lovelace Resume", Author: "Ada Lovelace", Subject: "Engineer", Keywords: "Go, SQL, Docker", Created: created},
+		},
+		{
+			name:    "no name",
+			profile: map[string]interface{}{},
+			want:    infra.PDFMetadata{Title: "Resume", Created: created},
+		},
+		{
+			name: "keywords are capped",
+			profile: map[string]interface{}{
+				"skills": []interface{}{map[string]interface{}{"items": many}},
+			},
+			want: infra.PDFMetadata{Title: "Resume", Keywords: "a, b, c, d, e, f, g, h, i, j, k, l", Created: created},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pdfMetadata(&domain.ResumeJob{Profile: tt.profile, CreatedAt: created})
+			if got != tt.want {
+				t.Errorf("pdfMetadata() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveLabelsRendersPortuguese(t *testing.T) {
+	portuguese := map[string]string{
+		"professional_summary": "Resumo Profissional",
+		"experience":           "Experiência",
+		"education":            "",
+	}
+	tests := []struct {
+		name   string
+		status int
+		want   map[string]string
+	}{
+		{
+			name:   "translated",
+			status: http.StatusOK,
+			want:   map[string]string{"professional_summary": "Resumo Profissional", "experience": "Experiência", "education": "Education"},
+		},
+		{
+			name:   "ai-service down",
+			status: http.StatusBadGateway,
+			want:   map[string]string{"professional_summary": "Professional Summary", "experience": "Experience", "education": "Education"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.status != http.StatusOK {
+					w.WriteHeader(tt.status)
+					return
+				}
+				out, _ := json.Marshal(portuguese)
+				json.NewEncoder(w).Encode(map[string]string{"agent": "labels", "output": string(out)})
+			}))
+			defer srv.Close()
+
+			client := &ai.Client{
+				BaseURL:         srv.URL,
+				HTTP:            srv.Client(),
+				DefaultLanguage: "Portuguese",
+				Labels:          ai.NewLabelCache(time.Minute, nil),
+			}
+			labels := resolveLabels(context.Background(), client, "Portuguese")
+			for k, v := range tt.want {
+				if labels[k] != v {
+					t.Errorf("labels[%q] = %q, want %q", k, labels[k], v)
+				}
+			}
+
+			layout, err := jobLayout(&domain.ResumeJob{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			tpl, err := template.New(layout.HTML).Funcs(templates.FuncMap()).ParseFS(templates.FS(), layout.HTML)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			data := map[string]interface{}{
+				"Profile": map[string]interface{}{"meta": map[string]interface{}{"name": "Ada"}, "summary": "Builds things."},
+				"Labels":  labels,
+			}
+			if err := tpl.Execute(&buf, data); err != nil {
+				t.Fatal(err)
+			}
+			if heading := tt.want["professional_summary"]; !strings.Contains(buf.String(), heading) {
+				t.Errorf("rendered template lacks heading %q", heading)
+			}
+		})
+	}
+}
+
+// languageAI stands in for the ai-service. It translates the labels into
+// Portuguese or Spanish, answers every formatter with resume, and records
+// the prompts it received.
+type languageAI struct {
+	resume string
+
+	mu     sync.Mutex
+	inputs []string
+}
+
+func (a *languageAI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Input string `json:"input"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	a.mu.Lock()
+	a.inputs = append(a.inputs, req.Input)
+	a.mu.Unlock()
+
+	output := a.resume
+	switch {
+	case strings.HasPrefix(req.Input, "Translate UI labels to Portuguese"):
+		output = `{"professional_summary": "Resumo Profissional", "experience": "Experiência"}`
+	case strings.HasPrefix(req.Input, "Translate UI labels to Spanish"):
+		output = `{"professional_summary": "Resumen Profesional", "experience": "Experiencia"}`
+	}
+	json.NewEncoder(w).Encode(map[string]string{"agent": "auto", "output": output})
+}
+
+// TestProcessLanguages generates the same profile in two languages through
+// Process and checks each job's language reaches the formatter prompts and
+// the rendered headings.
+func TestProcessLanguages(t *testing.T) {
+	resume, err := os.ReadFile(filepath.Join("..", "..", "templates", "testdata", "resume.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// no source databases: the AI works from the profile alone
+	for _, env := range []string{"AUTH_DATABASE_URL", "JOBS_DATABASE_URL", "POSTS_DATABASE_URL", "MGMT_DATABASE_URL"} {
+		t.Setenv(env, "")
+	}
+
+	tests := []struct {
+		language string
+		headings []string
+		other    string
+	}{
+		{"Portuguese", []string{"Resumo Profissional", "Experiência"}, "Spanish"},
+		{"Spanish", []string{"Resumen Profesional", "Experiencia"}, "Portuguese"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.language, func(t *testing.T) {
+			mock := &languageAI{resume: string(resume)}
+			srv := httptest.NewServer(mock)
+			defer srv.Close()
+			p := NewProcessor(nil, nil, "English", WithDryRun(true), WithOutputDir(t.TempDir()))
+			p.aiClient = &ai.Client{BaseURL: srv.URL, HTTP: srv.Client(), Labels: ai.NewLabelCache(time.Minute, nil)}
+			job := offlineJob()
+			job.Language = tt.language
+
+			if err := p.Process(context.Background(), job); err != nil {
+				t.Fatal(err)
+			}
+			if job.Metadata["ai_used"] != true {
+				t.Fatalf("metadata.ai_used = %v, ai_error = %v; want the AI flow", job.Metadata["ai_used"], job.Metadata["ai_error"])
+			}
+
+			formatters := 0
+			for _, in := range mock.inputs {
+				if !strings.HasPrefix(in, "Format ") && !strings.HasPrefix(in, "Polish ") {
+					continue
+				}
+				formatters++
+				if !strings.Contains(in, "LANGUAGE: You MUST format ALL output in "+tt.language) {
+					t.Errorf("formatter prompt %.40q lacks the %s instruction", in, tt.language)
+				}
+				if strings.Contains(in, tt.other) {
+					t.Errorf("formatter prompt %.40q mentions %s", in, tt.other)
+				}
+			}
+			if formatters == 0 {
+				t.Fatal("no formatter was called")
+			}
+
+			html, err := os.ReadFile(job.Metadata["generated_html"].(string))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, h := range tt.headings {
+				if !strings.Contains(string(html), h) {
+					t.Errorf("rendered resume lacks the heading %q", h)
+				}
+			}
+			if strings.Contains(string(html), "Professional Summary") {
+				t.Error("rendered resume kept the English heading")
+			}
+		})
+	}
+}
+
+func TestProcessDefaultLanguage(t *testing.T) {
+	tests := []struct {
+		job  string
+		want string
+	}{
+		{"", "Portuguese"},
+		{"Spanish", "Spanish"},
+	}
+	for _, tt := range tests {
+		p := NewProcessor(nil, nil, "Portuguese", WithAIMode(AIModeOff), WithDryRun(true), WithOutputDir(t.TempDir()))
+		job := &domain.ResumeJob{
+			ID:       uuid.New(),
+			Language: tt.job,
+			Metadata: map[string]interface{}{},
+			Profile: map[string]interface{}{
+				"meta":    map[string]interface{}{"name": "Ada Lovelace", "headline": "Backend Engineer"},
+				"summary": "Backend engineer building reliable Go services, data pipelines and the tooling around them.",
+			},
+		}
+		if err := p.Process(context.Background(), job); err != nil {
+			t.Fatal(err)
+		}
+		if job.Language != tt.want {
+			t.Errorf("job language %q processed as %q, want %q", tt.job, job.Language, tt.want)
+		}
+	}
+}
+
+func TestProcessOutputDir(t *testing.T) {
+	dir := t.TempDir()
+	r := &blockingRenderer{release: make(chan struct{})}
+	close(r.release)
+	p := NewProcessor(r, nil, "English", WithAIMode(AIModeOff), WithOutputDir(dir))
+	job := offlineJob()
+	if err := p.Process(context.Background(), job); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, under := range map[string]string{
+		"generated_html": filepath.Join(dir, "generated"),
+		"generated_pdf":  filepath.Join(dir, "generated"),
+		"generated_json": filepath.Join(dir, "generated"),
+		"html_url":       filepath.Join(dir, "resumes", job.UserID.String()),
+		"pdf_url":        filepath.Join(dir, "resumes", job.UserID.String()),
+	} {
+		path, _ := job.Metadata[key].(string)
+		if filepath.Dir(path) != under {
+			t.Errorf("%s = %q, want a file in %s", key, path, under)
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s: %v", key, err)
+		}
+	}
+}
+
+// savedJobs is a JobsRepo keeping the last saved copy of each job's
+// status and metadata keys.
+type savedJobs struct {
+	JobsRepo
+
+	mu   sync.Mutex
+	last *domain.ResumeJob
+}
+
+func (r *savedJobs) Save(ctx context.Context, j *domain.ResumeJob) error {
+	cp := *j
+	cp.Metadata = copyMap(j.Metadata)
+	r.mu.Lock()
+	r.last = &cp
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *savedJobs) UpdateStatus(ctx context.Context, id uuid.UUID, status string, progress map[string]interface{}) error {
+	return nil
+}
+
+func TestProcessTimesOutOnSlowAI(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the server notices the client hanging up once the body is read
+		io.Copy(io.Discard, r.Body)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	repo := &savedJobs{}
+	p := NewProcessor(nil, repo, "English", WithJobTimeout(100*time.Millisecond), WithOutputDir(t.TempDir()))
+	p.aiClient = &ai.Client{BaseURL: srv.URL, HTTP: srv.Client(), Labels: ai.NewLabelCache(time.Minute, nil)}
+	job := offlineJob()
+	events, unsubscribe := p.Events().Subscribe(job.ID)
+	defer unsubscribe()
+
+	start := time.Now()
+	err := p.Process(context.Background(), job)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Process() = %v, want a deadline error", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Process() took %v with a 100ms timeout", elapsed)
+	}
+
+	saved := repo.last
+	if saved == nil || saved.Status != domain.StatusTimeout {
+		t.Fatalf("saved job = %+v, want status %q", saved, domain.StatusTimeout)
+	}
+	for key, want := range map[string]interface{}{
+		"timed_out_during": domain.StatusFormatting,
+		"failed_stage":     StageTimeout,
+		"retriable":        true,
+	} {
+		if saved.Metadata[key] != want {
+			t.Errorf("metadata.%s = %v, want %v", key, saved.Metadata[key], want)
+		}
+	}
+	if _, ok := saved.Metadata["stage_progress"]; !ok {
+		t.Error("partial stage_progress was not persisted")
+	}
+
+	for ev := range events {
+		if ev.Terminal {
+			if ev.Stage != EventTimeout {
+				t.Errorf("terminal event %q, want %q", ev.Stage, EventTimeout)
+			}
+			break
+		}
+	}
+}
+
+// flakyRenderer fails the first failures PDF renders, calling onFail after
+// each, and then returns a PDF.
+type flakyRenderer struct {
+	failures int
+	onFail   func()
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (r *flakyRenderer) RenderHTMLToPDF(ctx context.Context, html string) ([]byte, error) {
+	return r.RenderHTMLToPDFWithOptions(ctx, html, infra.RenderOptions{})
+}
+
+func (r *flakyRenderer) RenderHTMLToPDFWithOptions(ctx context.Context, html string, opts infra.RenderOptions) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls++
+	if r.calls <= r.failures {
+		if r.onFail != nil {
+			r.onFail()
+		}
+		return nil, errors.New("chrome crashed")
+	}
+	return []byte("%PDF-1.4\n%%EOF\n"), nil
+}
+
+func (r *flakyRenderer) RenderHTMLToPNG(ctx context.Context, html string, width int) ([]byte, error) {
+	return nil, errors.New("no previews")
+}
+
+func TestProcessRenderRetryPolicy(t *testing.T) {
+	policy := retry.Policy{Attempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+	tests := []struct {
+		name      string
+		failures  int
+		wantCalls int
+		wantPDF   bool
+		wantWait  int64
+	}{
+		{"first attempt", 0, 1, true, 0},
+		{"recovers on the last attempt", 2, 3, true, 3},
+		{"gives up after the attempts", 5, 3, false, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &flakyRenderer{failures: tt.failures}
+			p := NewProcessor(r, nil, "English", WithAIMode(AIModeOff), WithOutputDir(t.TempDir()), WithRenderRetry(policy))
+			job := offlineJob()
+			if err := p.Process(context.Background(), job); err != nil {
+				t.Fatal(err)
+			}
+			if r.calls != tt.wantCalls || job.Metadata["render_attempts"] != tt.wantCalls {
+				t.Errorf("%d renders, render_attempts %v, want %d", r.calls, job.Metadata["render_attempts"], tt.wantCalls)
+			}
+			if got := job.Metadata["render_retry_wait_ms"]; got != tt.wantWait {
+				t.Errorf("render_retry_wait_ms = %v, want %d", got, tt.wantWait)
+			}
+			pdf, _ := job.Metadata["generated_pdf"].(string)
+			if (pdf != "") != tt.wantPDF {
+				t.Errorf("generated_pdf = %q, want a PDF: %v", pdf, tt.wantPDF)
+			}
+			if _, failed := job.Metadata["pdf_render_error"]; failed == tt.wantPDF {
+				t.Errorf("pdf_render_error = %v", job.Metadata["pdf_render_error"])
+			}
+		})
+	}
+}
+
+func TestProcessCancelInterruptsRenderBackoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := &flakyRenderer{failures: 1, onFail: cancel}
+	policy := retry.Policy{Attempts: 3, InitialBackoff: time.Hour}
+	p := NewProcessor(r, nil, "English", WithAIMode(AIModeOff), WithOutputDir(t.TempDir()), WithRenderRetry(policy))
+
+	start := time.Now()
+	err := p.Process(ctx, offlineJob())
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Process() = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Process() returned after %v, want the hour-long backoff interrupted", elapsed)
+	}
+	if r.calls != 1 {
+		t.Errorf("%d renders, want 1", r.calls)
+	}
+}
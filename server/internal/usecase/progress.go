@@ -0,0 +1,356 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"resume-generator/internal/domain"
+	ai "resume-generator/pkg/ai"
+
+	"github.com/google/uuid"
+
+	"resume-generator/pkg/logctx"
+	"resume-generator/pkg/metrics"
+)
+
+// Stage progress statuses recorded under job.Metadata["stage_progress"].
+const (
+	StagePending   = "pending"
+	StageRunning   = "running"
+	StageCompleted = "completed"
+	StageFailed    = "failed"
+)
+
+// initStageProgress records every named stage as pending so a polling
+// client can see the full pipeline before it starts.
+func initStageProgress(job *domain.ResumeJob, stages []string) {
+	if job.Metadata == nil {
+		job.Metadata = map[string]interface{}{}
+	}
+	progress := map[string]interface{}{}
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, s := range stages {
+		progress[s] = map[string]interface{}{"status": StagePending, "updated_at": now}
+	}
+	job.Metadata["stage_progress"] = progress
+}
+
+// markStage updates a single stage entry in job.Metadata["stage_progress"],
+// persists a snapshot of the job so pollers observe incremental progress,
+// without holding progressMu during the save, and publishes an
+// EventStageProgress for SSE subscribers. stageErr is recorded as last_error
+// when non-nil and details are copied into the entry. Persistence is
+// best-effort.
+func (p *Processor) markStage(ctx context.Context, job *domain.ResumeJob, stage, status string, stageErr error, details map[string]interface{}) {
+	ev := JobEvent{JobID: job.ID, Stage: EventStageProgress, Detail: stage, Status: status}
+	if stageErr != nil {
+		ev.Error = stageErr.Error()
+	}
+	defer p.events.Publish(ev)
+
+	p.progressMu.Lock()
+	switch status {
+	case StageRunning:
+		trackProgress(job, stage, false)
+	case StageCompleted, StageFailed:
+		trackProgress(job, stage, true)
+	}
+
+	if job.Metadata == nil {
+		job.Metadata = map[string]interface{}{}
+	}
+	progress, ok := job.Metadata["stage_progress"].(map[string]interface{})
+	if !ok {
+		progress = map[string]interface{}{}
+		job.Metadata["stage_progress"] = progress
+	}
+	entry := map[string]interface{}{
+		"status":     status,
+		"updated_at": time.Now().UTC().Format(time.RFC3339),
+	}
+	if stageErr != nil {
+		entry["last_error"] = stageErr.Error()
+	}
+	for k, v := range details {
+		entry[k] = v
+	}
+	progress[stage] = entry
+	job.UpdatedAt = time.Now()
+	// save a snapshot outside progressMu so sibling stages are not held up
+	// by the database; the ticket, taken in metadata order, drops snapshots
+	// that lost the race to a newer one
+	snapshot := *job
+	snapshot.Metadata = copyMap(job.Metadata)
+	save := p.saves.ticket(job.ID)
+	p.progressMu.Unlock()
+
+	save(func() {
+		if p.repo == nil {
+			return
+		}
+		if err := p.repo.Save(ctx, &snapshot); err != nil {
+			logctx.Warnf(ctx, "processor: failed to persist stage progress for %s: %v", stage, err)
+		}
+	})
+}
+
+// jobSaves orders the snapshot saves of each job. An entry lives while a
+// save of its job is pending.
+type jobSaves struct {
+	mu   sync.Mutex
+	jobs map[uuid.UUID]*jobSave
+}
+
+type jobSave struct {
+	mu          sync.Mutex
+	next, saved uint64
+	pending     int
+}
+
+// ticket numbers a snapshot of job id and returns the function running its
+// save. The save runs unless a later-numbered snapshot of the job has been
+// saved already; saves of one job never overlap.
+func (s *jobSaves) ticket(id uuid.UUID) func(save func()) {
+	s.mu.Lock()
+	if s.jobs == nil {
+		s.jobs = map[uuid.UUID]*jobSave{}
+	}
+	js, ok := s.jobs[id]
+	if !ok {
+		js = &jobSave{}
+		s.jobs[id] = js
+	}
+	js.next++
+	js.pending++
+	seq := js.next
+	s.mu.Unlock()
+
+	return func(save func()) {
+		js.mu.Lock()
+		if seq > js.saved {
+			save()
+			js.saved = seq
+		}
+		js.mu.Unlock()
+
+		s.mu.Lock()
+		if js.pending--; js.pending == 0 {
+			delete(s.jobs, id)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// runStage validates a single stage against resumeMap and, while it is
+// invalid, invokes its enrichment up to p.stageAttempts times. The outcome,
+// attempt count and fields still missing are recorded in stage_progress. A
+// stage checkpointed by an earlier run of the job is restored instead of
+// run, and a stage that validates is checkpointed. It returns whether the
+// stage validated.
+func (p *Processor) runStage(ctx context.Context, job *domain.ResumeJob, aiClient *ai.Client, payload, resumeMap map[string]interface{}, idx int, st pipelineStage) bool {
+	ctx = logctx.With(ctx, "stage", st.Name)
+	logctx.Printf(ctx, "processor: Stage %d - %s", idx+1, st.Label)
+	p.publish(job, EventFormatting, st.Name)
+	p.markStage(ctx, job, st.Name, StageRunning, nil, nil)
+
+	if p.restoreCheckpoint(job, st, resumeMap) {
+		logctx.Printf(ctx, "processor: Stage %d restored from checkpoint ✓", idx+1)
+		p.markStage(ctx, job, st.Name, StageCompleted, nil, map[string]interface{}{"attempts": 0, "restored": true})
+		return true
+	}
+
+	var stageErr error
+	attempts := 0
+	val := st.Validate(resumeMap)
+	for !val.Valid && attempts < p.stageAttempts && ctx.Err() == nil {
+		attempts++
+		start := time.Now()
+		err := st.Enrich(ctx, aiClient, payload, resumeMap, val)
+		metrics.AIStageDuration.WithLabelValues(st.Name).Observe(time.Since(start).Seconds())
+		if err != nil {
+			logctx.Warnf(ctx, "processor: Stage %d enrichment attempt %d failed (non-fatal): %v", idx+1, attempts, err)
+			stageErr = err
+		}
+		val = st.Validate(resumeMap)
+	}
+	details := map[string]interface{}{"attempts": attempts}
+	if val.Valid {
+		logctx.Printf(ctx, "processor: Stage %d validated ✓", idx+1)
+		p.saveCheckpoint(job, st, resumeMap)
+		p.markStage(ctx, job, st.Name, StageCompleted, nil, details)
+		return true
+	}
+
+	logctx.Warnf(ctx, "processor: Stage %d still invalid after %d enrichment attempts: %v", idx+1, attempts, val.Missing)
+	if stageErr == nil {
+		stageErr = fmt.Errorf("still invalid after enrichment: %v", val.Missing)
+	}
+	details["missing"] = val.Missing
+	p.markStage(ctx, job, st.Name, StageFailed, stageErr, details)
+	return false
+}
+
+// runStages runs stages against resumeMap and reports whether all of them
+// validated. Independent stages run concurrently on scratch maps and are
+// merged under mergeMu, so formatting takes about as long as the slowest
+// stage plus summary/meta instead of the sum of all four. A failed stage
+// does not cancel its siblings; sequential stages (summary/meta) need the
+// assembled result and run afterwards. It returns ctx.Err() when ctx ends
+// while the independent stages run.
+func (p *Processor) runStages(ctx context.Context, job *domain.ResumeJob, aiClient *ai.Client, payload, resumeMap map[string]interface{}, stages []pipelineStage) (bool, error) {
+	allValid := true
+	var wg sync.WaitGroup
+	var mergeMu sync.Mutex
+	for i, st := range stages {
+		if st.Sequential {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, st pipelineStage) {
+			defer wg.Done()
+			scratch := map[string]interface{}{}
+			valid := p.runStageIsolated(ctx, job, aiClient, payload, scratch, i, st)
+			mergeMu.Lock()
+			defer mergeMu.Unlock()
+			for _, k := range st.Keys {
+				if v, ok := scratch[k]; ok {
+					resumeMap[k] = v
+				}
+			}
+			if !valid {
+				allValid = false
+			}
+		}(i, st)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	for i, st := range stages {
+		if !st.Sequential {
+			continue
+		}
+		if !p.runStage(ctx, job, aiClient, payload, resumeMap, i, st) {
+			allValid = false
+		}
+	}
+	return allValid, nil
+}
+
+// runStageIsolated is runStage for stages running on their own goroutine:
+// a panic fails only that stage instead of taking the process down, and the
+// sibling stages keep running.
+func (p *Processor) runStageIsolated(ctx context.Context, job *domain.ResumeJob, aiClient *ai.Client, payload, resumeMap map[string]interface{}, idx int, st pipelineStage) (valid bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			logctx.Errorf(ctx, "processor: stage %s panicked: %v\n%s", st.Name, r, debug.Stack())
+			p.markStage(ctx, job, st.Name, StageFailed, fmt.Errorf("panic: %v", r), nil)
+			valid = false
+		}
+	}()
+	return p.runStage(ctx, job, aiClient, payload, resumeMap, idx, st)
+}
+
+// progressWeights is the share of the whole job, in percent, of each phase
+// and split-flow stage. The four split stages make up the formatting phase;
+// jobs not using the split flow get formattingWeight when formatting ends.
+var progressWeights = map[string]int{
+	domain.StatusAggregating:    10,
+	"profile_snapshot":          15,
+	"experience_projects":       15,
+	"publications_certs_extras": 15,
+	"summary_meta":              10,
+	domain.StatusValidating:     10,
+	domain.StatusRendering:      20,
+	domain.StatusSaving:         5,
+}
+
+// formattingWeight is the weight of domain.StatusFormatting, shared with
+// the split-flow stages.
+const formattingWeight = 55
+
+// trackProgress records that phase started (done false) or finished in
+// job.Metadata["progress"]:
+//
+//	{stage, percent, started_at, finished_at, phases: {name: {started_at, finished_at}}}
+//
+// stage is the phase last started or finished and percent the weighted
+// share of finished phases. The caller holds progressMu and returns the
+// progress map for persisting.
+func trackProgress(job *domain.ResumeJob, phase string, done bool) map[string]interface{} {
+	if job.Metadata == nil {
+		job.Metadata = map[string]interface{}{}
+	}
+	progress, ok := job.Metadata["progress"].(map[string]interface{})
+	if !ok {
+		progress = map[string]interface{}{}
+		job.Metadata["progress"] = progress
+	}
+	phases, ok := progress["phases"].(map[string]interface{})
+	if !ok {
+		phases = map[string]interface{}{}
+		progress["phases"] = phases
+	}
+	entry, ok := phases[phase].(map[string]interface{})
+	if !ok {
+		entry = map[string]interface{}{}
+		phases[phase] = entry
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	if done {
+		entry["finished_at"] = now
+	} else {
+		entry["started_at"] = now
+		delete(entry, "finished_at")
+	}
+
+	progress["stage"] = phase
+	progress["started_at"] = entry["started_at"]
+	progress["finished_at"] = entry["finished_at"]
+	progress["percent"] = progressPercent(phases)
+	return progress
+}
+
+// progressPercent sums the weights of the finished phases, capped at 100.
+func progressPercent(phases map[string]interface{}) int {
+	finished := func(name string) bool {
+		e, ok := phases[name].(map[string]interface{})
+		return ok && e["finished_at"] != nil
+	}
+	total, split := 0, 0
+	for name := range phases {
+		if !finished(name) {
+			continue
+		}
+		w := progressWeights[name]
+		total += w
+		if name != domain.StatusAggregating && name != domain.StatusValidating && name != domain.StatusRendering && name != domain.StatusSaving {
+			split += w
+		}
+	}
+	if finished(domain.StatusFormatting) && split < formattingWeight {
+		total += formattingWeight - split
+	}
+	if total > 100 {
+		total = 100
+	}
+	return total
+}
+
+// copyMap deep-copies nested maps so a snapshot can be persisted outside
+// progressMu while stages keep updating the original.
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if nested, ok := v.(map[string]interface{}); ok {
+			v = copyMap(nested)
+		}
+		out[k] = v
+	}
+	return out
+}
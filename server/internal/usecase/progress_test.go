@@ -0,0 +1,250 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"resume-generator/internal/domain"
+	ai "resume-generator/pkg/ai"
+
+	"github.com/google/uuid"
+)
+
+func TestJobSavesDropsSupersededSnapshots(t *testing.T) {
+	tests := []struct {
+		name  string
+		order []int // tickets, by issue index, in the order their saves run
+		want  []int
+	}{
+		{"in order", []int{0, 1, 2}, []int{0, 1, 2}},
+		{"newest first", []int{2, 1, 0}, []int{2}},
+		{"one overtaken", []int{1, 0, 2}, []int{1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s jobSaves
+			id := uuid.New()
+			tickets := make([]func(func()), len(tt.order))
+			for i := range tickets {
+				tickets[i] = s.ticket(id)
+			}
+			var saved []int
+			for _, i := range tt.order {
+				tickets[i](func() { saved = append(saved, i) })
+			}
+			if !reflect.DeepEqual(saved, tt.want) {
+				t.Errorf("saved %v, want %v", saved, tt.want)
+			}
+			if len(s.jobs) != 0 {
+				t.Errorf("%d job entries left after every save ran", len(s.jobs))
+			}
+		})
+	}
+}
+
+// testStage owns resumeMap["summary"]; its enrichment fills the summary on
+// the repairOn-th call, never when repairOn is 0, and returns err.
+func testStage(repairOn int, err error) (pipelineStage, *int) {
+	calls := 0
+	return pipelineStage{
+		Name: "summary_meta",
+		Keys: []string{"summary"},
+		Validate: func(m map[string]interface{}) *StageValidationResult {
+			if s, _ := m["summary"].(string); s != "" {
+				return &StageValidationResult{Valid: true}
+			}
+			return &StageValidationResult{Missing: []string{"summary"}}
+		},
+		Enrich: func(ctx context.Context, _ *ai.Client, _, m map[string]interface{}, _ *StageValidationResult) error {
+			calls++
+			if calls == repairOn {
+				m["summary"] = "repaired"
+			}
+			return err
+		},
+	}, &calls
+}
+
+func TestRunStage(t *testing.T) {
+	enrichErr := errors.New("ai-service returned non-200 status")
+	tests := []struct {
+		name       string
+		summary    string
+		repairOn   int
+		enrichErr  error
+		wantValid  bool
+		wantCalls  int
+		wantStatus string
+		wantError  string
+	}{
+		{name: "already valid", summary: "ok", wantValid: true, wantStatus: StageCompleted},
+		{name: "repaired on the first try", repairOn: 1, wantValid: true, wantCalls: 1, wantStatus: StageCompleted},
+		{name: "fails once then repaired", repairOn: 2, wantValid: true, wantCalls: 2, wantStatus: StageCompleted},
+		{name: "fails permanently", wantCalls: 3, wantStatus: StageFailed, wantError: "still invalid after enrichment: [summary]"},
+		{name: "enrichment errors", enrichErr: enrichErr, wantCalls: 3, wantStatus: StageFailed, wantError: enrichErr.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Processor{events: NewEventBroker(), stageAttempts: 3}
+			job := &domain.ResumeJob{ID: uuid.New(), Metadata: map[string]interface{}{}}
+			resume := map[string]interface{}{}
+			if tt.summary != "" {
+				resume["summary"] = tt.summary
+			}
+			st, calls := testStage(tt.repairOn, tt.enrichErr)
+
+			valid := p.runStage(context.Background(), job, nil, nil, resume, 3, st)
+			if valid != tt.wantValid || *calls != tt.wantCalls {
+				t.Fatalf("runStage() = %v after %d enrichments, want %v after %d", valid, *calls, tt.wantValid, tt.wantCalls)
+			}
+			entry := job.Metadata["stage_progress"].(map[string]interface{})[st.Name].(map[string]interface{})
+			if entry["status"] != tt.wantStatus || entry["attempts"] != tt.wantCalls {
+				t.Errorf("stage_progress entry = %v, want status %s after %d attempts", entry, tt.wantStatus, tt.wantCalls)
+			}
+			if got, _ := entry["last_error"].(string); got != tt.wantError {
+				t.Errorf("last_error = %q, want %q", got, tt.wantError)
+			}
+			checkpoints, _ := job.Metadata[stageCheckpointsKey].(map[string]interface{})
+			_, checkpointed := checkpoints[st.Name]
+			if checkpointed != tt.wantValid {
+				t.Errorf("checkpointed = %v, want %v", checkpointed, tt.wantValid)
+			}
+		})
+	}
+}
+
+// slowAI is a mock ai-service answering every request after delay. It
+// records the most requests it served at once.
+type slowAI struct {
+	delay time.Duration
+
+	mu             sync.Mutex
+	inFlight, peak int
+}
+
+func (s *slowAI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.inFlight++
+	s.peak = max(s.peak, s.inFlight)
+	s.mu.Unlock()
+	time.Sleep(s.delay)
+	s.mu.Lock()
+	s.inFlight--
+	s.mu.Unlock()
+	w.Write([]byte(`{"output":"ok"}`))
+}
+
+// aiStage is a stage owning key whose enrichment asks the AI service for
+// it, like the split-flow formatters. A sequential stage also requires the
+// keys of the stages before it, which it must see already merged.
+func aiStage(key string, sequential bool, needs ...string) pipelineStage {
+	return pipelineStage{
+		Name:       key,
+		Keys:       []string{key},
+		Sequential: sequential,
+		Validate: func(m map[string]interface{}) *StageValidationResult {
+			for _, k := range append(needs, key) {
+				if m[k] == nil {
+					return &StageValidationResult{Missing: []string{k}}
+				}
+			}
+			return &StageValidationResult{Valid: true}
+		},
+		Enrich: func(ctx context.Context, c *ai.Client, _, m map[string]interface{}, _ *StageValidationResult) error {
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/v1/chat", nil)
+			if err != nil {
+				return err
+			}
+			resp, err := c.HTTP.Do(req)
+			if err != nil {
+				return err
+			}
+			resp.Body.Close()
+			m[key] = "formatted"
+			return nil
+		},
+	}
+}
+
+func splitStages(sequential bool) []pipelineStage {
+	return []pipelineStage{
+		aiStage("meta", sequential),
+		aiStage("experience", sequential),
+		aiStage("projects", sequential),
+		aiStage("summary", true, "meta", "experience", "projects"),
+	}
+}
+
+func TestRunStages(t *testing.T) {
+	panicking := aiStage("projects", false)
+	panicking.Enrich = func(context.Context, *ai.Client, map[string]interface{}, map[string]interface{}, *StageValidationResult) error {
+		panic("formatter bug")
+	}
+	tests := []struct {
+		name      string
+		stages    []pipelineStage
+		wantValid bool
+		wantPeak  int
+		wantKeys  []string
+	}{
+		{"independent stages overlap", splitStages(false), true, 3, []string{"meta", "experience", "projects", "summary"}},
+		{"a panicking stage fails alone", []pipelineStage{aiStage("meta", false), aiStage("experience", false), panicking}, false, 2, []string{"meta", "experience"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &slowAI{delay: 20 * time.Millisecond}
+			srv := httptest.NewServer(mock)
+			defer srv.Close()
+			p := &Processor{events: NewEventBroker(), stageAttempts: 1}
+			job := &domain.ResumeJob{ID: uuid.New(), Metadata: map[string]interface{}{}}
+			resume := map[string]interface{}{}
+
+			valid, err := p.runStages(context.Background(), job, &ai.Client{BaseURL: srv.URL, HTTP: srv.Client()}, nil, resume, tt.stages)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if valid != tt.wantValid || mock.peak != tt.wantPeak {
+				t.Errorf("runStages() = %v with %d concurrent AI calls, want %v with %d", valid, mock.peak, tt.wantValid, tt.wantPeak)
+			}
+			for _, k := range tt.wantKeys {
+				if resume[k] != "formatted" {
+					t.Errorf("resume[%q] = %v, want it merged", k, resume[k])
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkRunStages compares the split flow against running its four
+// stages one after another, with each AI call taking 10ms. Concurrent runs
+// take about two calls (the slowest independent stage, then summary/meta),
+// sequential ones four:
+//
+//	BenchmarkRunStages/concurrent   ~21ms/op
+//	BenchmarkRunStages/sequential   ~42ms/op
+func BenchmarkRunStages(b *testing.B) {
+	srv := httptest.NewServer(&slowAI{delay: 10 * time.Millisecond})
+	defer srv.Close()
+	client := &ai.Client{BaseURL: srv.URL, HTTP: srv.Client()}
+	for _, bm := range []struct {
+		name       string
+		sequential bool
+	}{{"concurrent", false}, {"sequential", true}} {
+		b.Run(bm.name, func(b *testing.B) {
+			stages := splitStages(bm.sequential)
+			p := &Processor{events: NewEventBroker(), stageAttempts: 1}
+			for i := 0; i < b.N; i++ {
+				job := &domain.ResumeJob{ID: uuid.New(), Metadata: map[string]interface{}{}}
+				if valid, err := p.runStages(context.Background(), job, client, nil, map[string]interface{}{}, stages); err != nil || !valid {
+					b.Fatalf("runStages() = %v, %v", valid, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package usecase
+
+import "sort"
+
+// sectionSources maps each resume section to the aggregated or override
+// keys whose data the AI formats into it.
+var sectionSources = map[string][]string{
+	"meta":           {"user", "profiles", "meta"},
+	"summary":        {"profiles", "summary"},
+	"snapshot":       {"technologies", "project_technologies", "impact_metrics", "snapshot"},
+	"skills":         {"skills", "technologies"},
+	"experience":     {"experiences", "experience"},
+	"education":      {"education"},
+	"projects":       {"projects", "case_studies"},
+	"publications":   {"publications"},
+	"certifications": {"certifications"},
+	"extras":         {"extras"},
+}
+
+// classifySections splits the non-empty sections of resume into those
+// backed by the user's data in any of sources and those the AI produced
+// without any, both sorted.
+func classifySections(resume map[string]interface{}, sources ...map[string]interface{}) (synthesized, sourced []string) {
+	synthesized, sourced = []string{}, []string{}
+	for section, keys := range sectionSources {
+		if isEmptyValue(resume[section]) {
+			continue
+		}
+		found := false
+		for _, src := range sources {
+			for _, k := range keys {
+				if !isEmptyValue(src[k]) {
+					found = true
+				}
+			}
+		}
+		if found {
+			sourced = append(sourced, section)
+		} else {
+			synthesized = append(synthesized, section)
+		}
+	}
+	sort.Strings(synthesized)
+	sort.Strings(sourced)
+	return synthesized, sourced
+}
+
+// isEmptyValue reports whether v is nil, an empty string or an empty
+// JSON array or object.
+func isEmptyValue(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return true
+	case string:
+		return t == ""
+	case []interface{}:
+		return len(t) == 0
+	case []map[string]interface{}:
+		return len(t) == 0
+	case map[string]interface{}:
+		return len(t) == 0
+	}
+	return false
+}
@@ -0,0 +1,220 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"resume-generator/internal/domain"
+	"resume-generator/pkg/storage"
+
+	"github.com/google/uuid"
+)
+
+// ErrUserHasActiveJobs is returned by PurgeUserData while one of the user's
+// jobs is still queued or running and has been updated recently.
+var ErrUserHasActiveJobs = errors.New("user has jobs in progress")
+
+// artifactKeys are the job metadata keys holding paths of generated files.
+var artifactKeys = []string{"generated_html", "generated_pdf", "generated_txt", "generated_tex", "generated_tex_pdf", "generated_json", "generated_docx", "generated_preview", "preview_pdf", "generated_cover_letter", "user_copy", "html_url", "pdf_url"}
+
+// PurgeResult reports what PurgeUserData removed. Objects counts the
+// users' copies deleted from the Storage, Files the other local files.
+type PurgeResult struct {
+	Jobs            int64    `json:"jobs"`
+	Resumes         int64    `json:"resumes"`
+	Files           int      `json:"files"`
+	Objects         int      `json:"objects"`
+	CancelledJobIDs []string `json:"cancelledJobIds,omitempty"`
+	ActiveJobIDs    []string `json:"activeJobIds,omitempty"`
+}
+
+// PurgeUserData deletes the user's jobs and resumes rows, the files
+// referenced by them and <dataRoot>/resumes/<userID>, and the user's
+// copies in store. Only files inside dataRoot, the processor's output dir,
+// are deleted locally. It refuses with ErrUserHasActiveJobs (listing them
+// in the result) while a job is still unfinished; unfinished jobs not
+// updated for staleAfter were abandoned by their instance and are
+// cancelled instead of blocking the purge. Running it again for the same
+// user removes nothing and succeeds.
+func PurgeUserData(ctx context.Context, jobs JobsRepo, resumes ResumesRepo, store storage.Storage, dataRoot string, userID uuid.UUID, staleAfter time.Duration) (*PurgeResult, error) {
+	res := &PurgeResult{}
+
+	active, err := jobs.ListByUser(ctx, userID, domain.JobFilter{Active: true}, domain.Page{Limit: 100})
+	if err != nil {
+		return nil, err
+	}
+	staleBefore := time.Now().Add(-staleAfter)
+	var stale []*domain.ResumeJob
+	for _, j := range active {
+		if j.UpdatedAt.Before(staleBefore) {
+			stale = append(stale, j)
+		} else {
+			res.ActiveJobIDs = append(res.ActiveJobIDs, j.ID.String())
+		}
+	}
+	if len(res.ActiveJobIDs) > 0 {
+		return res, ErrUserHasActiveJobs
+	}
+	for _, j := range stale {
+		if err := cancelAbandoned(ctx, jobs, j); err != nil {
+			return nil, err
+		}
+		res.CancelledJobIDs = append(res.CancelledJobIDs, j.ID.String())
+	}
+
+	// collect the files first; the rows pointing at them go away below
+	paths := map[string]struct{}{}
+	keys := map[string]struct{}{}
+	const pageSize = 100
+	for offset := 0; ; offset += pageSize {
+		page, err := jobs.ListByUser(ctx, userID, domain.JobFilter{}, domain.Page{Limit: pageSize, Offset: offset})
+		if err != nil {
+			return nil, err
+		}
+		for _, j := range page {
+			for _, k := range artifactKeys {
+				if p, ok := j.Metadata[k].(string); ok && p != "" {
+					paths[p] = struct{}{}
+				}
+			}
+			for _, k := range storedCopyKeys {
+				if ref, ok := j.Metadata[k].(string); ok {
+					if key, ok := storageKey(userID, ref); ok {
+						keys[key] = struct{}{}
+					}
+				}
+			}
+		}
+		if len(page) < pageSize {
+			break
+		}
+	}
+	for offset := 0; ; offset += pageSize {
+		page, err := resumes.ListByUser(ctx, userID, domain.ResumeFilter{}, domain.Page{Limit: pageSize, Offset: offset})
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range page {
+			if r.FilePath != "" {
+				paths[r.FilePath] = struct{}{}
+			}
+		}
+		if len(page) < pageSize {
+			break
+		}
+	}
+
+	if res.Jobs, err = jobs.DeleteJobsByUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	if res.Resumes, err = resumes.DeleteResumesByUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	// deleting a local copy through store first counts it once, as an object
+	if store != nil {
+		for key := range keys {
+			if err := store.Delete(ctx, key); err != nil {
+				return res, err
+			}
+			res.Objects++
+		}
+	}
+	for p := range paths {
+		if !underDataRoot(dataRoot, p) {
+			continue
+		}
+		if err := os.Remove(p); err == nil {
+			res.Files++
+		} else if !os.IsNotExist(err) {
+			return res, err
+		}
+	}
+
+	userDir := filepath.Join(dataRoot, "resumes", userID.String())
+	n, err := removeTree(userDir)
+	res.Files += n
+	if err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// cancelAbandoned marks an unfinished job nobody has updated for a while as
+// cancelled, so the purge can go ahead without waiting for it.
+func cancelAbandoned(ctx context.Context, jobs JobsRepo, job *domain.ResumeJob) error {
+	if job.Metadata == nil {
+		job.Metadata = map[string]interface{}{}
+	}
+	job.Status = "cancelled"
+	job.Metadata["cancelled_at"] = time.Now().UTC().Format(time.RFC3339)
+	job.Metadata["error"] = "abandoned before it finished; cancelled by a user data purge"
+	job.Metadata["error_code"] = CodeCancelled
+	job.UpdatedAt = time.Now()
+	return jobs.Save(ctx, job)
+}
+
+// storedCopyKeys are the job metadata keys holding the URLs of the user's
+// copies written through the processor's Storage.
+var storedCopyKeys = []string{"html_url", "pdf_url", "user_copy"}
+
+// storageKey recovers the Storage key "<userID>/<name>" the processor
+// stored a user's copy under from its URL or local path.
+func storageKey(userID uuid.UUID, ref string) (string, bool) {
+	if u, err := url.Parse(ref); err == nil && u.Scheme != "" && u.Host != "" {
+		ref = u.Path
+	} else {
+		ref = filepath.ToSlash(ref)
+	}
+	dir, name := path.Split(ref)
+	if name == "" || path.Base(dir) != userID.String() {
+		return "", false
+	}
+	return userID.String() + "/" + name, true
+}
+
+// underDataRoot reports whether path is a local file inside dataRoot. URLs
+// of remotely stored objects never are.
+func underDataRoot(dataRoot, path string) bool {
+	absRoot, err := filepath.Abs(dataRoot)
+	if err != nil {
+		return false
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(absRoot, absPath)
+	if err != nil {
+		return false
+	}
+	return rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// removeTree deletes dir and returns how many regular files it contained.
+// A missing dir is not an error.
+func removeTree(dir string) (int, error) {
+	n := 0
+	err := filepath.WalkDir(dir, func(_ string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			n++
+		}
+		return nil
+	})
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return n, os.RemoveAll(dir)
+}
@@ -0,0 +1,51 @@
+package usecase
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestStorageKey(t *testing.T) {
+	uid := uuid.MustParse("5f1d7c1e-8a8e-4d5e-9a55-0d8b9c1f2e3a")
+	tests := []struct {
+		name string
+		ref  string
+		want string
+		ok   bool
+	}{
+		{"local path", filepath.Join("data", "resumes", uid.String(), "a.pdf"), uid.String() + "/a.pdf", true},
+		{"path-style s3 url", "https://minio:9000/bucket/" + uid.String() + "/a.html", uid.String() + "/a.html", true},
+		{"public url", "https://cdn.example.com/" + uid.String() + "/a.pdf", uid.String() + "/a.pdf", true},
+		{"other user", "https://cdn.example.com/" + uuid.NewString() + "/a.pdf", "", false},
+		{"no directory", "a.pdf", "", false},
+		{"empty", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := storageKey(uid, tt.ref)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("storageKey(%q) = %q, %v, want %q, %v", tt.ref, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestUnderDataRoot(t *testing.T) {
+	root := t.TempDir()
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{filepath.Join(root, "generated", "a.pdf"), true},
+		{root, false},
+		{filepath.Join(root, "..", "a.pdf"), false},
+		{"https://cdn.example.com/a.pdf", false},
+	}
+	for _, tt := range tests {
+		if got := underDataRoot(root, tt.path); got != tt.want {
+			t.Errorf("underDataRoot(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"resume-generator/internal/domain"
+
+	"resume-generator/pkg/logctx"
+)
+
+// DefaultStaleAfter is the least time an unfinished job may go without an
+// update before RequeueStale treats it as abandoned by a crashed process;
+// see WorkerPool.StaleAfter.
+const DefaultStaleAfter = 15 * time.Minute
+
+// staleBatchSize bounds how many jobs one RequeueStale call looks at.
+const staleBatchSize = 100
+
+// RequeueResult reports the outcome of RequeueStale.
+type RequeueResult struct {
+	Found    int      `json:"found"`
+	Requeued []string `json:"requeued"`
+	// Skipped counts jobs another instance claimed first and jobs still
+	// queued or running on this instance.
+	Skipped int `json:"skipped"`
+}
+
+// RequeueStale finds unfinished jobs not updated for olderThan, claims each one
+// for instance and submits it to the pool again. Jobs this pool still holds
+// are skipped. Queued jobs are not updated until a worker picks them up, so
+// olderThan should be at least wp.StaleAfter() for other instances' queues
+// to drain. The sweep stops early when the queue is full; the remaining
+// jobs are picked up by a later sweep.
+func (wp *WorkerPool) RequeueStale(ctx context.Context, instance string, olderThan time.Duration) (*RequeueResult, error) {
+	res := &RequeueResult{Requeued: []string{}}
+	repo := wp.processor.repo
+	if repo == nil {
+		return res, nil
+	}
+
+	before := time.Now().Add(-olderThan)
+	jobs, err := repo.FindStale(ctx, before, staleBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	res.Found = len(jobs)
+
+	for _, job := range jobs {
+		if wp.holds(job.ID) {
+			res.Skipped++
+			continue
+		}
+		claimed, err := repo.ClaimStale(ctx, job.ID, instance, before)
+		if err != nil {
+			return res, err
+		}
+		if !claimed {
+			res.Skipped++
+			continue
+		}
+
+		for _, k := range failureMetadataKeys {
+			delete(job.Metadata, k)
+		}
+		if ov, ok := job.Metadata["profile_overrides"].(map[string]interface{}); ok {
+			job.Profile = ov
+		}
+		job.Metadata["requeued_at"] = time.Now().UTC().Format(time.RFC3339)
+		job.Metadata["requeued_by"] = instance
+		job.Status = domain.StatusPending
+		job.UpdatedAt = time.Now()
+		if err := repo.Save(ctx, job); err != nil {
+			return res, err
+		}
+
+		if err := wp.Submit(job); err != nil {
+			if errors.Is(err, ErrQueueFull) {
+				logctx.Warnf(ctx, "processor: queue full, leaving %d stale jobs for the next sweep", len(jobs)-len(res.Requeued)-res.Skipped)
+				return res, nil
+			}
+			return res, err
+		}
+		res.Requeued = append(res.Requeued, job.ID.String())
+	}
+	return res, nil
+}
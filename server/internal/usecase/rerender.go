@@ -0,0 +1,101 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"resume-generator/internal/domain"
+	"resume-generator/pkg/ai/formatters"
+
+	"github.com/google/uuid"
+)
+
+// ErrJobInProgress is returned by Rerender while the resume's job is still
+// being processed.
+var ErrJobInProgress = errors.New("resume is still being generated")
+
+// renderMetadataKeys are cleared before a rerender so the job only reports
+// artifacts and errors of the new render.
+var renderMetadataKeys = []string{
+	"error",
+	"failed_stage",
+	"error_code",
+	"generated_txt",
+	"generated_docx",
+	"generated_tex",
+	"generated_tex_pdf",
+	"generated_preview",
+	"preview_pdf",
+	"cover_letter_error",
+	"pdf_render_error",
+	"docx_render_error",
+	"txt_render_error",
+	"tex_render_error",
+	"tex_compile_error",
+	"preview_render_error",
+}
+
+// Rerender renders an edited resume map into fresh artifacts for the job
+// that produced resumeID, without calling the AI. The caller validates
+// resume first. Labels stored in resume["labels"] are reused; otherwise they
+// are resolved for the job's language as in Process.
+func (p *Processor) Rerender(ctx context.Context, resumeID uuid.UUID, resume map[string]interface{}) (*domain.ResumeJob, error) {
+	if p.repo == nil {
+		return nil, domain.ErrResumeNotFound
+	}
+	job, err := p.repo.FindByResumeID(ctx, resumeID)
+	if err != nil {
+		if errors.Is(err, domain.ErrJobNotFound) {
+			return nil, domain.ErrResumeNotFound
+		}
+		return nil, err
+	}
+	if domain.IsActiveStatus(job.Status) {
+		return nil, ErrJobInProgress
+	}
+
+	ctx = jobContext(ctx, job)
+	if job.Language == "" {
+		job.Language = p.defaultLanguage
+	}
+	labels := labelsFromResume(resume)
+	if labels == nil {
+		labels = resolveLabels(ctx, p.aiClient.WithLanguage(job.Language), job.Language)
+		resume["labels"] = labels
+	}
+
+	if job.Metadata == nil {
+		job.Metadata = map[string]interface{}{}
+	}
+	for _, k := range renderMetadataKeys {
+		delete(job.Metadata, k)
+	}
+	job.Metadata["rerendered_at"] = time.Now().UTC().Format(time.RFC3339)
+	job.Profile = resume
+
+	// renderAndSave moves the job through rendering/saving; put the old
+	// status back if it does not get to completed
+	prevStatus := job.Status
+	if err := p.renderAndSave(ctx, job, labels); err != nil {
+		p.setStatus(ctx, job, prevStatus)
+		return nil, err
+	}
+	return job, nil
+}
+
+// labelsFromResume overlays resume["labels"] on the default labels, or
+// returns nil when the resume carries none.
+func labelsFromResume(resume map[string]interface{}) map[string]string {
+	raw, ok := resume["labels"].(map[string]interface{})
+	if !ok || len(raw) == 0 {
+		return nil
+	}
+	labels := formatters.GetDefaultLabels()
+	for k, v := range raw {
+		if s, ok := v.(string); ok && s != "" {
+			labels[k] = s
+		}
+	}
+	return labels
+}
@@ -0,0 +1,94 @@
+package usecase
+
+import (
+	"errors"
+	"time"
+
+	"resume-generator/internal/domain"
+)
+
+// MaxJobRetries caps how often a single job may be retried.
+const MaxJobRetries = 3
+
+var (
+	// ErrJobNotRetryable is returned by ResetForRetry for jobs that have
+	// neither failed nor timed out. Completed jobs are not retried; start
+	// a new job instead.
+	ErrJobNotRetryable = errors.New("only failed or timed out jobs can be retried")
+	// ErrJobCancelled is returned by ResetForRetry for cancelled jobs: the
+	// caller stopped them on purpose, so they are not restarted in place.
+	ErrJobCancelled = errors.New("cancelled jobs cannot be retried; start a new job instead")
+	// ErrRetryLimitReached is returned once a job has been retried
+	// MaxJobRetries times.
+	ErrRetryLimitReached = errors.New("retry limit reached")
+	// ErrFailureNotRetryable is returned for jobs whose failure would
+	// repeat, such as a resume that does not validate; metadata.retriable
+	// is false for them.
+	ErrFailureNotRetryable = errors.New("job failed with an error a retry will not fix")
+)
+
+// failureMetadataKeys are cleared when a job is retried.
+var failureMetadataKeys = []string{
+	"error",
+	"failed_stage",
+	"error_code",
+	"retriable",
+	"timed_out_during",
+	"ai_error",
+	"pdf_render_error",
+	"docx_render_error",
+	"txt_render_error",
+	"tex_render_error",
+	"tex_compile_error",
+	"preview_render_error",
+	"cover_letter_error",
+	"validation_errors",
+	"stage_progress",
+	"progress",
+	"cancelled_at",
+}
+
+// ResetForRetry puts a failed or timed out job back into the pending
+// state: it clears the failure metadata, bumps metadata.retry_count and
+// restores the caller's original profile overrides so the job reprocesses
+// with the same input. The job keeps its stage checkpoints and resumes
+// after the last validated stage. Cancelled jobs get ErrJobCancelled.
+func ResetForRetry(job *domain.ResumeJob) error {
+	if job.Status == "cancelled" {
+		return ErrJobCancelled
+	}
+	if job.Status != "failed" && job.Status != domain.StatusTimeout {
+		return ErrJobNotRetryable
+	}
+	if job.Metadata == nil {
+		job.Metadata = map[string]interface{}{}
+	}
+
+	retries := 0
+	switch n := job.Metadata["retry_count"].(type) {
+	case int:
+		retries = n
+	case float64:
+		retries = int(n)
+	}
+	if retries >= MaxJobRetries {
+		return ErrRetryLimitReached
+	}
+	if job.Status == "failed" && job.Metadata["retriable"] == false {
+		return ErrFailureNotRetryable
+	}
+
+	for _, k := range failureMetadataKeys {
+		delete(job.Metadata, k)
+	}
+	job.Metadata["retry_count"] = retries + 1
+	job.Metadata["retried_at"] = time.Now().UTC().Format(time.RFC3339)
+
+	job.Profile = nil
+	if ov, ok := job.Metadata["profile_overrides"].(map[string]interface{}); ok {
+		job.Profile = ov
+	}
+	job.Status = "pending"
+	job.UpdatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,46 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+
+	"resume-generator/internal/domain"
+)
+
+func TestResetForRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   string
+		metadata map[string]interface{}
+		want     error
+	}{
+		{"failed", "failed", nil, nil},
+		{"timed out", domain.StatusTimeout, nil, nil},
+		{"cancelled", "cancelled", nil, ErrJobCancelled},
+		{"completed", "completed", nil, ErrJobNotRetryable},
+		{"pending", "pending", nil, ErrJobNotRetryable},
+		{"retry limit", "failed", map[string]interface{}{"retry_count": float64(MaxJobRetries)}, ErrRetryLimitReached},
+		{"not retriable", "failed", map[string]interface{}{"retriable": false}, ErrFailureNotRetryable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &domain.ResumeJob{Status: tt.status, Metadata: tt.metadata}
+			err := ResetForRetry(job)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("ResetForRetry() = %v, want %v", err, tt.want)
+			}
+			if err != nil {
+				if job.Status != tt.status {
+					t.Errorf("status = %q after a rejected retry, want %q", job.Status, tt.status)
+				}
+				return
+			}
+			if job.Status != "pending" {
+				t.Errorf("status = %q, want pending", job.Status)
+			}
+			if job.Metadata["retry_count"] != 1 {
+				t.Errorf("retry_count = %v, want 1", job.Metadata["retry_count"])
+			}
+		})
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"resume-generator/internal/model"
 	ai "resume-generator/pkg/ai"
+
+	"resume-generator/pkg/logctx"
 )
 
 // StageValidationResult holds validation state for a stage
@@ -15,6 +17,28 @@ type StageValidationResult struct {
 	Error      string
 }
 
+// pipelineStage pairs a stage validator with the AI enrichment step that
+// repairs it. Keys lists the top-level resume keys the stage owns; only those
+// are merged back when the stage runs on a scratch map. Sequential stages
+// depend on the output of the others and run after them.
+type pipelineStage struct {
+	Name       string
+	Label      string
+	Keys       []string
+	Sequential bool
+	Validate   func(resumeMap map[string]interface{}) *StageValidationResult
+	Enrich     func(ctx context.Context, aiClient *ai.Client, payload map[string]interface{}, resumeMap map[string]interface{}, validation *StageValidationResult) error
+}
+
+// splitFlowStages lists the split AI flow stages in execution order. Names
+// are used as keys in job.Metadata["stage_progress"].
+var splitFlowStages = []pipelineStage{
+	{Name: "profile_snapshot", Label: "Foundation (meta, skills, education)", Keys: []string{"meta", "snapshot", "skills", "education"}, Validate: Stage1Validator, Enrich: Stage1Enrich},
+	{Name: "experience_projects", Label: "Professional History (experience)", Keys: []string{"experience"}, Validate: Stage2Validator, Enrich: Stage2Enrich},
+	{Name: "publications_certs_extras", Label: "Showcase Content (projects, publications, certs)", Keys: []string{"projects", "publications", "certifications"}, Validate: Stage3Validator, Enrich: Stage3Enrich},
+	{Name: "summary_meta", Label: "Synthesis (summary, extras)", Keys: []string{"summary", "extras", "meta"}, Sequential: true, Validate: Stage4Validator, Enrich: Stage4Enrich},
+}
+
 // Stage1Validator validates Foundation stage: meta.name, meta.headline, meta.contact
 func Stage1Validator(resumeMap map[string]interface{}) *StageValidationResult {
 	result := &StageValidationResult{
@@ -196,12 +220,12 @@ func Stage1Enrich(ctx context.Context, aiClient *ai.Client, payload map[string]i
 		return nil
 	}
 
-	fmt.Printf("processor: Stage 1 enriching: %v\n", validation.Missing)
+	logctx.Printf(ctx, "processor: Stage 1 enriching: %v", validation.Missing)
 
 	// Call AI to generate meta
 	out, err := aiClient.FormatProfileSnapshot(ctx, payload)
 	if err != nil {
-		fmt.Printf("processor: Stage1Enrich FormatProfileSnapshot failed: %v\n", err)
+		logctx.Warnf(ctx, "processor: Stage1Enrich FormatProfileSnapshot failed: %v", err)
 		return err
 	}
 
@@ -210,8 +234,8 @@ func Stage1Enrich(ctx context.Context, aiClient *ai.Client, payload map[string]i
 	}
 
 	// Validate against schema
-	if err := model.ValidateMapWithSchema("templates/schema/profile.schema.json", out); err != nil {
-		fmt.Printf("processor: Stage1Enrich validation failed: %v, attempting EnrichFields\n", err)
+	if err := model.ValidateMapWithSchema("schema/profile.schema.json", out); err != nil {
+		logctx.Warnf(ctx, "processor: Stage1Enrich validation failed: %v, attempting EnrichFields", err)
 		
 		// Try targeted enrichment
 		fields, err := aiClient.EnrichFields(ctx, map[string]interface{}{
@@ -234,6 +258,15 @@ func Stage1Enrich(ctx context.Context, aiClient *ai.Client, payload map[string]i
 	if meta, ok := out["meta"].(map[string]interface{}); ok {
 		resumeMap["meta"] = meta
 	}
+	if snapshot, ok := out["snapshot"].(map[string]interface{}); ok {
+		resumeMap["snapshot"] = snapshot
+	}
+	if skills, ok := out["skills"].([]interface{}); ok && len(skills) > 0 {
+		resumeMap["skills"] = skills
+	}
+	if edu, ok := out["education"].([]interface{}); ok && len(edu) > 0 {
+		resumeMap["education"] = edu
+	}
 
 	// Validate again
 	revalidation := Stage1Validator(resumeMap)
@@ -250,12 +283,12 @@ func Stage2Enrich(ctx context.Context, aiClient *ai.Client, payload map[string]i
 		return nil
 	}
 
-	fmt.Printf("processor: Stage 2 enriching: %v\n", validation.Missing)
+	logctx.Printf(ctx, "processor: Stage 2 enriching: %v", validation.Missing)
 
 	// Call AI to generate experience
 	out, err := aiClient.FormatExperienceProjects(ctx, payload)
 	if err != nil {
-		fmt.Printf("processor: Stage2Enrich FormatExperienceProjects failed: %v\n", err)
+		logctx.Warnf(ctx, "processor: Stage2Enrich FormatExperienceProjects failed: %v", err)
 		return err
 	}
 
@@ -264,8 +297,8 @@ func Stage2Enrich(ctx context.Context, aiClient *ai.Client, payload map[string]i
 	}
 
 	// Validate against schema
-	if err := model.ValidateMapWithSchema("templates/schema/experience.schema.json", out); err != nil {
-		fmt.Printf("processor: Stage2Enrich validation failed: %v, attempting enrichment\n", err)
+	if err := model.ValidateMapWithSchema("schema/experience.schema.json", out); err != nil {
+		logctx.Warnf(ctx, "processor: Stage2Enrich validation failed: %v, attempting enrichment", err)
 		
 		// Fallback to broad enrichment with context
 		enriched, err := aiClient.EnrichResume(ctx, resumeMap, out)
@@ -295,22 +328,23 @@ func Stage3Enrich(ctx context.Context, aiClient *ai.Client, payload map[string]i
 		return nil
 	}
 
-	fmt.Printf("processor: Stage 3 enriching: %v\n", validation.Missing)
+	logctx.Printf(ctx, "processor: Stage 3 enriching: %v", validation.Missing)
 
 	// Call AI to generate showcase content
 	out, err := aiClient.FormatPublicationsCertsExtras(ctx, payload)
 	if err != nil {
-		fmt.Printf("processor: Stage3Enrich FormatPublicationsCertsExtras failed: %v\n", err)
+		logctx.Warnf(ctx, "processor: Stage3Enrich FormatPublicationsCertsExtras failed: %v", err)
 		return err
 	}
 
 	if out == nil {
 		return fmt.Errorf("Stage3Enrich: no output from FormatPublicationsCertsExtras")
 	}
+	repairCertifications(ctx, out)
 
 	// Validate against schema
-	if err := model.ValidateMapWithSchema("templates/schema/publications.schema.json", out); err != nil {
-		fmt.Printf("processor: Stage3Enrich validation failed: %v, attempting enrichment\n", err)
+	if err := model.ValidateMapWithSchema("schema/publications.schema.json", out); err != nil {
+		logctx.Warnf(ctx, "processor: Stage3Enrich validation failed: %v, attempting enrichment", err)
 		
 		// Fallback to broad enrichment
 		enriched, err := aiClient.EnrichResume(ctx, resumeMap, out)
@@ -342,7 +376,7 @@ func Stage4Enrich(ctx context.Context, aiClient *ai.Client, payload map[string]i
 		return nil
 	}
 
-	fmt.Printf("processor: Stage 4 enriching: %v\n", validation.Missing)
+	logctx.Printf(ctx, "processor: Stage 4 enriching: %v", validation.Missing)
 
 	// Build assembled payload with validated stages
 	assembled := map[string]interface{}{
@@ -353,7 +387,7 @@ func Stage4Enrich(ctx context.Context, aiClient *ai.Client, payload map[string]i
 	// Call AI to generate summary and polish meta
 	out, err := aiClient.FormatSummaryMeta(ctx, assembled)
 	if err != nil {
-		fmt.Printf("processor: Stage4Enrich FormatSummaryMeta failed: %v\n", err)
+		logctx.Warnf(ctx, "processor: Stage4Enrich FormatSummaryMeta failed: %v", err)
 		return err
 	}
 
@@ -0,0 +1,225 @@
+package usecase
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"resume-generator/internal/domain"
+)
+
+// Orders accepted in job.Metadata["order"] for the experience and projects
+// sections. Without one the sections keep the order they were built in.
+const (
+	// OrderRecent puts current roles first, then the most recently ended.
+	OrderRecent = "recent"
+	// OrderRelevance puts the items sharing the most words with the job
+	// description first, or, without a description, those with the
+	// highest relevance/score.
+	OrderRelevance = "relevance"
+)
+
+// ValidOrder reports whether s is "", OrderRecent or OrderRelevance.
+func ValidOrder(s string) bool {
+	return s == "" || s == OrderRecent || s == OrderRelevance
+}
+
+// SetTailoring records the project ids to include or exclude and the
+// section order in the job's metadata; empty values are not recorded.
+func SetTailoring(job *domain.ResumeJob, include, exclude []string, order string) {
+	if job.Metadata == nil {
+		job.Metadata = map[string]interface{}{}
+	}
+	if len(include) > 0 {
+		job.Metadata["include_project_ids"] = include
+	}
+	if len(exclude) > 0 {
+		job.Metadata["exclude_project_ids"] = exclude
+	}
+	if order != "" {
+		job.Metadata["order"] = order
+	}
+}
+
+// tailorSections applies the job's include_project_ids and
+// exclude_project_ids to resume.projects and its order to experience and
+// projects. A filter that would leave no project is ignored, so the
+// section falls back to all projects.
+func tailorSections(job *domain.ResumeJob, resume map[string]interface{}) {
+	if arr, ok := resume["projects"].([]interface{}); ok && len(arr) > 0 {
+		if kept := filterByID(arr, metadataStrings(job, "include_project_ids"), metadataStrings(job, "exclude_project_ids")); len(kept) > 0 {
+			resume["projects"] = kept
+		}
+	}
+
+	order, _ := job.Metadata["order"].(string)
+	for _, section := range []string{"experience", "projects"} {
+		arr, ok := resume[section].([]interface{})
+		if !ok || len(arr) < 2 {
+			continue
+		}
+		switch order {
+		case OrderRecent:
+			resume[section] = sortByRecency(arr)
+		case OrderRelevance:
+			resume[section] = sortByRelevance(arr, job.JobDescription)
+		}
+	}
+}
+
+// metadataStrings reads a string list from job metadata, which holds
+// []interface{} once it went through JSON.
+func metadataStrings(job *domain.ResumeJob, key string) []string {
+	switch v := job.Metadata[key].(type) {
+	case []string:
+		return v
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, it := range v {
+			if s, ok := it.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// filterByID keeps the items whose "id" is in include, when include is
+// set, and not in exclude.
+func filterByID(items []interface{}, include, exclude []string) []interface{} {
+	if len(include) == 0 && len(exclude) == 0 {
+		return items
+	}
+	in, out := stringSet(include), stringSet(exclude)
+	kept := []interface{}{}
+	for _, it := range items {
+		m, _ := it.(map[string]interface{})
+		id, _ := m["id"].(string)
+		if len(in) > 0 && !in[id] || out[id] {
+			continue
+		}
+		kept = append(kept, it)
+	}
+	return kept
+}
+
+func stringSet(ss []string) map[string]bool {
+	set := make(map[string]bool, len(ss))
+	for _, s := range ss {
+		if s = strings.TrimSpace(s); s != "" {
+			set[s] = true
+		}
+	}
+	return set
+}
+
+var (
+	yearRe    = regexp.MustCompile(`\b(?:19|20)\d{2}\b`)
+	ongoingRe = regexp.MustCompile(`[-–—]\s*\pL`)
+)
+
+// itemRecency returns a key that sorts items newest first: the item's date
+// fields, else the end year of its period, with a period such as
+// "2021 – Present" sorting before any year.
+func itemRecency(it interface{}) string {
+	if d := itemDate(it); d != "" {
+		return d
+	}
+	m, _ := it.(map[string]interface{})
+	period, _ := m["period"].(string)
+	years := yearRe.FindAllStringIndex(period, -1)
+	if len(years) == 0 {
+		return ""
+	}
+	last := years[len(years)-1]
+	start := period[years[0][0]:years[0][1]]
+	if ongoingRe.MatchString(period[last[1]:]) {
+		return "9999 " + start
+	}
+	return period[last[0]:last[1]] + " " + start
+}
+
+func sortByRecency(items []interface{}) []interface{} {
+	sorted := append([]interface{}(nil), items...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ri, rj := itemRecency(sorted[i]), itemRecency(sorted[j])
+		if (ri == "") != (rj == "") {
+			return ri != ""
+		}
+		return ri > rj
+	})
+	return sorted
+}
+
+// sortByRelevance orders items by how many distinct words of the job
+// description they mention, falling back to their relevance/score fields
+// when there is no description.
+func sortByRelevance(items []interface{}, jobDescription string) []interface{} {
+	sorted := append([]interface{}(nil), items...)
+	words := relevanceWords(jobDescription)
+	if len(words) == 0 {
+		sort.SliceStable(sorted, func(i, j int) bool {
+			ri, _ := itemRelevance(sorted[i])
+			rj, _ := itemRelevance(sorted[j])
+			return ri > rj
+		})
+		return sorted
+	}
+
+	scores := make(map[int]int, len(sorted))
+	for i, it := range sorted {
+		for w := range relevanceWords(itemText(it)) {
+			if words[w] {
+				scores[i]++
+			}
+		}
+	}
+	idx := make([]int, len(sorted))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return scores[idx[a]] > scores[idx[b]] })
+	out := make([]interface{}, len(sorted))
+	for i, k := range idx {
+		out[i] = sorted[k]
+	}
+	return out
+}
+
+// relevanceWords returns the normalized words of s longer than two
+// letters, which skips most articles and prepositions.
+func relevanceWords(s string) map[string]bool {
+	words := map[string]bool{}
+	for _, w := range strings.Fields(normalizeTitle(s)) {
+		if len([]rune(w)) > 2 {
+			words[w] = true
+		}
+	}
+	return words
+}
+
+// itemText joins the string fields of an item, including its bullets.
+func itemText(it interface{}) string {
+	var b strings.Builder
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch t := v.(type) {
+		case string:
+			b.WriteString(t)
+			b.WriteByte(' ')
+		case []interface{}:
+			for _, e := range t {
+				walk(e)
+			}
+		case map[string]interface{}:
+			for k, e := range t {
+				if k != "id" && k != "url" {
+					walk(e)
+				}
+			}
+		}
+	}
+	walk(it)
+	return b.String()
+}
@@ -0,0 +1,127 @@
+package usecase
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"resume-generator/internal/domain"
+)
+
+// Theme overrides the accent color and font of a layout. It is stored in
+// job metadata under "theme" so retries and rerenders look the same.
+type Theme struct {
+	// AccentColor is a hex color such as "#0a66c2".
+	AccentColor string `json:"accentColor,omitempty"`
+	// Font is one of ThemeFonts.
+	Font string `json:"font,omitempty"`
+}
+
+// ThemeFonts are the families a theme may pick. Only listed names are
+// accepted since the font is written into the page's CSS; they are embedded
+// like template fonts when FONTS_DIR has them.
+var ThemeFonts = []string{
+	"Inter", "Noto Sans", "Noto Serif", "Roboto", "Open Sans", "Lato",
+	"Source Sans 3", "Merriweather", "Georgia", "Arial", "Helvetica",
+}
+
+var hexColor = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// ValidAccentColor reports whether s is a #rgb or #rrggbb color.
+func ValidAccentColor(s string) bool {
+	return hexColor.MatchString(s)
+}
+
+// LookupThemeFont returns the ThemeFonts entry matching name, ignoring
+// case.
+func LookupThemeFont(name string) (string, bool) {
+	for _, f := range ThemeFonts {
+		if strings.EqualFold(f, strings.TrimSpace(name)) {
+			return f, true
+		}
+	}
+	return "", false
+}
+
+// Normalize validates t and returns it with the font's canonical name.
+func (t Theme) Normalize() (Theme, error) {
+	if t.AccentColor != "" && !ValidAccentColor(t.AccentColor) {
+		return Theme{}, fmt.Errorf("accentColor %q is not a hex color", t.AccentColor)
+	}
+	if t.Font != "" {
+		f, ok := LookupThemeFont(t.Font)
+		if !ok {
+			return Theme{}, fmt.Errorf("font %q is not supported", t.Font)
+		}
+		t.Font = f
+	}
+	return t, nil
+}
+
+// IsZero reports whether t changes nothing.
+func (t Theme) IsZero() bool {
+	return t.AccentColor == "" && t.Font == ""
+}
+
+// SetTheme records t in the job's metadata; a zero theme is not recorded.
+func SetTheme(job *domain.ResumeJob, t Theme) {
+	if t.IsZero() {
+		return
+	}
+	if job.Metadata == nil {
+		job.Metadata = map[string]interface{}{}
+	}
+	m := map[string]interface{}{}
+	if t.AccentColor != "" {
+		m["accentColor"] = t.AccentColor
+	}
+	if t.Font != "" {
+		m["font"] = t.Font
+	}
+	job.Metadata["theme"] = m
+}
+
+// jobTheme reads the theme recorded by SetTheme. Values that no longer
+// validate, e.g. a font dropped from ThemeFonts, are ignored.
+func jobTheme(job *domain.ResumeJob) Theme {
+	m, _ := job.Metadata["theme"].(map[string]interface{})
+	var t Theme
+	if s, _ := m["accentColor"].(string); ValidAccentColor(s) {
+		t.AccentColor = s
+	}
+	if s, _ := m["font"].(string); s != "" {
+		t.Font, _ = LookupThemeFont(s)
+	}
+	return t
+}
+
+// css returns the custom properties overriding the layout's accent colors,
+// placed after its stylesheet. The darker and lighter accent variants used
+// by the classic layout are derived from the accent.
+func (t Theme) css() string {
+	if t.AccentColor == "" {
+		return ""
+	}
+	r, g, b := parseHex(t.AccentColor)
+	return fmt.Sprintf(":root{--accent:%s;--accent-700:%s;--accent-300:%s;}",
+		t.AccentColor, mixHex(r, g, b, 0, 0.3), mixHex(r, g, b, 255, 0.4))
+}
+
+// parseHex splits a color accepted by ValidAccentColor into its channels.
+func parseHex(s string) (r, g, b int) {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	v, _ := strconv.ParseUint(s, 16, 32)
+	return int(v >> 16 & 0xff), int(v >> 8 & 0xff), int(v & 0xff)
+}
+
+// mixHex mixes the color with the gray level to by weight.
+func mixHex(r, g, b, to int, weight float64) string {
+	mix := func(c int) int {
+		return int(float64(c) + (float64(to)-float64(c))*weight + 0.5)
+	}
+	return fmt.Sprintf("#%02x%02x%02x", mix(r), mix(g), mix(b))
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"resume-generator/internal/model"
 )
 
 // Section-specific typed outputs for AI responses. These are intentionally
@@ -43,6 +45,8 @@ type Overrides struct {
     Publications   []string               `json:"publications"`
     Certifications []Certification       `json:"certifications"`
     Extras         []ExtraItem           `json:"extras"`
+    Education      []model.EducationEntry `json:"education"`
+    Skills         []model.SkillGroup     `json:"skills"`
     Other          map[string]interface{} `json:"-"`
 }
 
@@ -99,6 +103,12 @@ func (o *Overrides) ToMap() map[string]interface{} {
         }
         out["extras"] = extras
     }
+    if len(o.Education) > 0 {
+        out["education"] = educationToList(o.Education)
+    }
+    if len(o.Skills) > 0 {
+        out["skills"] = skillsToList(o.Skills)
+    }
     for k, v := range o.Other {
         if _, exists := out[k]; !exists {
             out[k] = v
@@ -242,9 +252,64 @@ func NewOverridesFromMap(m map[string]interface{}) *Overrides {
         }
     }
 
+    if e, ok := m["education"]; ok {
+        out.Education = ParseEducation(e)
+    }
+
+    if sk, ok := m["skills"]; ok {
+        // split "Go, Rust" style strings into items
+        splitItems := func(s string) []string {
+            var items []string
+            for _, it := range strings.Split(s, ",") {
+                if it = strings.TrimSpace(it); it != "" {
+                    items = append(items, it)
+                }
+            }
+            return items
+        }
+        misc := model.SkillGroup{Category: "misc"}
+        switch t := sk.(type) {
+        case string:
+            misc.Items = append(misc.Items, splitItems(t)...)
+        case []interface{}:
+            for _, it := range t {
+                switch v := it.(type) {
+                case string:
+                    misc.Items = append(misc.Items, splitItems(v)...)
+                case map[string]interface{}:
+                    cat := "misc"
+                    if c, ok := v["category"].(string); ok && c != "" {
+                        cat = c
+                    }
+                    group := model.SkillGroup{Category: cat}
+                    switch items := v["items"].(type) {
+                    case []interface{}:
+                        for _, x := range items {
+                            if s := strings.TrimSpace(fmt.Sprintf("%v", x)); s != "" {
+                                group.Items = append(group.Items, s)
+                            }
+                        }
+                    case string:
+                        group.Items = splitItems(items)
+                    }
+                    if len(group.Items) > 0 {
+                        out.Skills = append(out.Skills, group)
+                    }
+                default:
+                    misc.Items = append(misc.Items, fmt.Sprintf("%v", v))
+                }
+            }
+        default:
+            misc.Items = append(misc.Items, fmt.Sprintf("%v", t))
+        }
+        if len(misc.Items) > 0 {
+            out.Skills = append(out.Skills, misc)
+        }
+    }
+
     // preserve other keys
     for k, v := range m {
-        if k == "publications" || k == "certifications" || k == "extras" {
+        if k == "publications" || k == "certifications" || k == "extras" || k == "education" || k == "skills" {
             continue
         }
         out.Other[k] = v
@@ -252,3 +317,97 @@ func NewOverridesFromMap(m map[string]interface{}) *Overrides {
 
     return out
 }
+
+// ParseEducation normalizes education input (override objects or rows from
+// the management DB's education table) into entries. Common column aliases
+// such as school/field_of_study are accepted; entries without an
+// institution are dropped.
+func ParseEducation(v interface{}) []model.EducationEntry {
+    str := func(m map[string]interface{}, keys ...string) string {
+        for _, k := range keys {
+            switch t := m[k].(type) {
+            case string:
+                if s := strings.TrimSpace(t); s != "" {
+                    return s
+                }
+            case nil:
+            default:
+                return fmt.Sprintf("%v", t)
+            }
+        }
+        return ""
+    }
+
+    var items []interface{}
+    switch t := v.(type) {
+    case []interface{}:
+        items = t
+    case map[string]interface{}:
+        items = []interface{}{t}
+    case string:
+        items = []interface{}{t}
+    }
+
+    var out []model.EducationEntry
+    for _, it := range items {
+        switch e := it.(type) {
+        case string:
+            if s := strings.TrimSpace(e); s != "" {
+                out = append(out, model.EducationEntry{Institution: s})
+            }
+        case map[string]interface{}:
+            entry := model.EducationEntry{
+                Institution: str(e, "institution", "school", "university"),
+                Degree:      str(e, "degree"),
+                Field:       str(e, "field", "field_of_study", "major"),
+                StartDate:   str(e, "start_date", "start", "started_at"),
+                EndDate:     str(e, "end_date", "end", "ended_at", "graduation_date"),
+                GPA:         str(e, "gpa"),
+            }
+            if entry.Institution != "" {
+                out = append(out, entry)
+            }
+        }
+    }
+    return out
+}
+
+// educationToList converts entries into the []interface{} shape used by the
+// resume map and template.
+func educationToList(entries []model.EducationEntry) []interface{} {
+    out := make([]interface{}, 0, len(entries))
+    for _, e := range entries {
+        m := map[string]interface{}{"institution": e.Institution}
+        if e.Degree != "" {
+            m["degree"] = e.Degree
+        }
+        if e.Field != "" {
+            m["field"] = e.Field
+        }
+        if e.StartDate != "" {
+            m["start_date"] = e.StartDate
+        }
+        if e.EndDate != "" {
+            m["end_date"] = e.EndDate
+        }
+        if e.GPA != "" {
+            m["gpa"] = e.GPA
+        }
+        out = append(out, m)
+    }
+    return out
+}
+
+// skillsToList converts skill groups into the []interface{} shape used by
+// the resume map and template.
+func skillsToList(groups []model.SkillGroup) []interface{} {
+    out := make([]interface{}, 0, len(groups))
+    for _, g := range groups {
+        items := make([]interface{}, 0, len(g.Items))
+        for _, it := range g.Items {
+            items = append(items, it)
+        }
+        out = append(out, map[string]interface{}{"category": g.Category, "items": items})
+    }
+    return out
+}
@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"resume-generator/internal/domain"
+
+	"github.com/google/uuid"
+
+	"resume-generator/pkg/logctx"
+)
+
+// ErrQueueFull is returned by WorkerPool.Submit when every worker is busy
+// and the queue has no room left.
+var ErrQueueFull = errors.New("job queue is full")
+
+// ErrPoolClosed is returned by WorkerPool.Submit after Close.
+var ErrPoolClosed = errors.New("job pool is closed")
+
+// WorkerPool runs jobs on a fixed number of workers, so at most that many
+// jobs (each with its AI calls and Chrome render) run at once. The pool owns
+// the context of every job it runs. A panic inside a job is recovered and
+// recorded on the job instead of crashing the process.
+type WorkerPool struct {
+	processor *Processor
+	workers   int
+	queue     chan *domain.ResumeJob
+	wg        sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+
+	// held lists the jobs queued or running on this pool.
+	heldMu sync.Mutex
+	held   map[uuid.UUID]struct{}
+}
+
+// NewWorkerPool starts workers goroutines processing jobs from a queue that
+// holds up to queueSize pending jobs.
+func NewWorkerPool(p *Processor, workers, queueSize int) *WorkerPool {
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+	wp := &WorkerPool{processor: p, workers: workers, queue: make(chan *domain.ResumeJob, queueSize), held: map[uuid.UUID]struct{}{}}
+	wp.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go wp.worker(i)
+	}
+	return wp
+}
+
+// Submit queues job without blocking. It returns ErrQueueFull when the pool
+// is saturated.
+func (wp *WorkerPool) Submit(job *domain.ResumeJob) error {
+	wp.mu.RLock()
+	defer wp.mu.RUnlock()
+	if wp.closed {
+		return ErrPoolClosed
+	}
+	wp.heldMu.Lock()
+	defer wp.heldMu.Unlock()
+	select {
+	case wp.queue <- job:
+		wp.held[job.ID] = struct{}{}
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// holds reports whether job id is queued or running on this pool.
+func (wp *WorkerPool) holds(id uuid.UUID) bool {
+	wp.heldMu.Lock()
+	defer wp.heldMu.Unlock()
+	_, ok := wp.held[id]
+	return ok
+}
+
+func (wp *WorkerPool) release(id uuid.UUID) {
+	wp.heldMu.Lock()
+	defer wp.heldMu.Unlock()
+	delete(wp.held, id)
+}
+
+// StaleAfter is how long a job of this pool can go without an update: it
+// may wait behind a full queue, a job timeout per queued job a worker
+// runs before it, and then run for one job timeout itself. It is at least
+// DefaultStaleAfter.
+func (wp *WorkerPool) StaleAfter() time.Duration {
+	ahead := (cap(wp.queue) + wp.workers - 1) / wp.workers
+	d := time.Duration(ahead+1) * wp.processor.jobTimeout
+	return max(d, DefaultStaleAfter)
+}
+
+// Close stops accepting jobs and waits until queued and running jobs finish
+// or ctx is done.
+func (wp *WorkerPool) Close(ctx context.Context) error {
+	wp.mu.Lock()
+	if !wp.closed {
+		wp.closed = true
+		close(wp.queue)
+	}
+	wp.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		wp.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (wp *WorkerPool) worker(id int) {
+	defer wp.wg.Done()
+	for job := range wp.queue {
+		wp.run(id, job)
+	}
+}
+
+// run processes a single job on worker id, turning a panic into a failed
+// job. Log lines of the job carry the worker id.
+func (wp *WorkerPool) run(id int, job *domain.ResumeJob) {
+	ctx := jobContext(logctx.With(context.Background(), "worker", id), job)
+	defer wp.release(job.ID)
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		logctx.Errorf(ctx, "processor: job %s panicked: %v\n%s", job.ID.String(), r, debug.Stack())
+		wp.processor.fail(job, StageInternal, fmt.Sprintf("panic: %v", r), true)
+	}()
+
+	if err := wp.processor.Process(ctx, job); err != nil {
+		logctx.Errorf(ctx, "processor: job %s failed: %v", job.ID.String(), err)
+	}
+}
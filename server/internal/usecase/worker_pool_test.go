@@ -0,0 +1,181 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"resume-generator/internal/domain"
+	infra "resume-generator/pkg/infrastructure"
+
+	"github.com/google/uuid"
+)
+
+func TestWorkerPoolStaleAfter(t *testing.T) {
+	tests := []struct {
+		name       string
+		workers    int
+		queueSize  int
+		jobTimeout time.Duration
+		want       time.Duration
+	}{
+		{"floor", 4, 16, time.Minute, DefaultStaleAfter},
+		{"queue ahead of one worker", 1, 4, 5 * time.Minute, 25 * time.Minute},
+		{"queue spread over workers", 4, 16, 5 * time.Minute, 25 * time.Minute},
+		{"partial round", 3, 4, 10 * time.Minute, 30 * time.Minute},
+		{"no queue", 2, 0, 20 * time.Minute, 20 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wp := &WorkerPool{
+				processor: &Processor{jobTimeout: tt.jobTimeout},
+				workers:   tt.workers,
+				queue:     make(chan *domain.ResumeJob, tt.queueSize),
+			}
+			if got := wp.StaleAfter(); got != tt.want {
+				t.Errorf("StaleAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// blockingRenderer counts the PDF renders in progress and holds each one
+// until release is closed.
+type blockingRenderer struct {
+	release chan struct{}
+
+	mu      sync.Mutex
+	running int
+	peak    int
+	done    int
+}
+
+func (r *blockingRenderer) RenderHTMLToPDF(ctx context.Context, html string) ([]byte, error) {
+	return r.RenderHTMLToPDFWithOptions(ctx, html, infra.RenderOptions{})
+}
+
+func (r *blockingRenderer) RenderHTMLToPDFWithOptions(ctx context.Context, html string, opts infra.RenderOptions) ([]byte, error) {
+	r.mu.Lock()
+	r.running++
+	r.peak = max(r.peak, r.running)
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		r.running--
+		r.done++
+		r.mu.Unlock()
+	}()
+	select {
+	case <-r.release:
+		return []byte("%PDF-1.4\n%%EOF\n"), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (r *blockingRenderer) RenderHTMLToPNG(ctx context.Context, html string, width int) ([]byte, error) {
+	return nil, errors.New("no previews")
+}
+
+func (r *blockingRenderer) stats() (running, peak, done int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.running, r.peak, r.done
+}
+
+// offlineJob is a job the processor can complete without the AI service
+// or the databases.
+func offlineJob() *domain.ResumeJob {
+	return &domain.ResumeJob{
+		ID:       uuid.New(),
+		UserID:   uuid.New(),
+		Metadata: map[string]interface{}{},
+		Profile: map[string]interface{}{
+			"meta":    map[string]interface{}{"name": "Ada Lovelace", "headline": "Backend Engineer"},
+			"summary": "Backend engineer building reliable Go services, data pipelines and the tooling around them.",
+		},
+	}
+}
+
+func TestWorkerPoolBoundsConcurrency(t *testing.T) {
+	const workers, jobs = 2, 6
+	r := &blockingRenderer{release: make(chan struct{})}
+	p := NewProcessor(r, nil, "English", WithAIMode(AIModeOff), WithOutputDir(t.TempDir()))
+	wp := NewWorkerPool(p, workers, jobs)
+
+	for i := 0; i < jobs; i++ {
+		if err := wp.Submit(offlineJob()); err != nil {
+			t.Fatalf("Submit %d: %v", i, err)
+		}
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if running, _, _ := r.stats(); running == workers {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("workers never all started")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// give a wrongly unbounded pool the chance to start more jobs
+	time.Sleep(50 * time.Millisecond)
+	if running, _, _ := r.stats(); running != workers {
+		t.Fatalf("%d jobs running, want %d", running, workers)
+	}
+
+	close(r.release)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := wp.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, peak, done := r.stats(); peak != workers || done != jobs {
+		t.Errorf("peak %d jobs at once over %d renders, want %d over %d", peak, done, workers, jobs)
+	}
+}
+
+func TestWorkerPoolSubmit(t *testing.T) {
+	r := &blockingRenderer{release: make(chan struct{})}
+	p := NewProcessor(r, nil, "English", WithAIMode(AIModeOff), WithOutputDir(t.TempDir()))
+	wp := NewWorkerPool(p, 1, 1)
+
+	running := offlineJob()
+	if err := wp.Submit(running); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		if n, _, _ := r.stats(); n == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("job never started")
+		}
+	}
+	queued := offlineJob()
+	if err := wp.Submit(queued); err != nil {
+		t.Fatal(err)
+	}
+	if err := wp.Submit(offlineJob()); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Submit on a full queue = %v, want ErrQueueFull", err)
+	}
+	if !wp.holds(running.ID) || !wp.holds(queued.ID) {
+		t.Error("pool does not hold its running and queued jobs")
+	}
+
+	close(r.release)
+	if err := wp.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := wp.Submit(offlineJob()); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Submit after Close = %v, want ErrPoolClosed", err)
+	}
+	if wp.holds(running.ID) || wp.holds(queued.ID) {
+		t.Error("pool still holds finished jobs")
+	}
+	if running.Status != "completed" || queued.Status != "completed" {
+		t.Errorf("job statuses %q and %q, want completed", running.Status, queued.Status)
+	}
+}
@@ -0,0 +1,151 @@
+package ai
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned instead of calling the ai-service while its
+// circuit breaker is open.
+var ErrCircuitOpen = errors.New("ai-service circuit breaker is open")
+
+// BreakerState is the state of a Breaker.
+type BreakerState string
+
+const (
+	// BreakerClosed lets every request through.
+	BreakerClosed BreakerState = "closed"
+	// BreakerOpen fails requests fast until the cooldown has passed.
+	BreakerOpen BreakerState = "open"
+	// BreakerHalfOpen lets a single probe through; its outcome closes or
+	// reopens the breaker.
+	BreakerHalfOpen BreakerState = "half-open"
+)
+
+// Defaults of NewBreaker, overridden by AI_BREAKER_THRESHOLD and
+// AI_BREAKER_COOLDOWN.
+const (
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = 30 * time.Second
+)
+
+// Breaker stops calling the ai-service after Threshold consecutive failed
+// requests, so jobs do not spend their whole retry budget on a service that
+// is down. After Cooldown one probe request is let through; it closes the
+// breaker when it succeeds and reopens it when it fails. Transport errors
+// and 5xx responses count as failures; requests aborted by their context
+// count as neither.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewBreaker opens after threshold consecutive failures and probes again
+// after cooldown.
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	if threshold <= 0 {
+		threshold = DefaultBreakerThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = DefaultBreakerCooldown
+	}
+	return &Breaker{threshold: threshold, cooldown: cooldown, state: BreakerClosed}
+}
+
+// breakerFromEnv builds the breaker of NewClient from AI_BREAKER_THRESHOLD
+// and AI_BREAKER_COOLDOWN (a duration such as "30s").
+func breakerFromEnv() *Breaker {
+	threshold, _ := strconv.Atoi(os.Getenv("AI_BREAKER_THRESHOLD"))
+	cooldown, _ := time.ParseDuration(os.Getenv("AI_BREAKER_COOLDOWN"))
+	return NewBreaker(threshold, cooldown)
+}
+
+// State returns the current state. An open breaker whose cooldown has passed
+// reports half-open, since the next request will probe.
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.cooldown {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// allow reports whether a request may be sent, claiming the probe when the
+// cooldown of an open breaker has passed.
+func (b *Breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return nil
+	case BreakerHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// done records the outcome of an allowed request. ignored releases a probe
+// without judging the service, e.g. when the caller gave up.
+func (b *Breaker) done(failed, ignored bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	switch {
+	case ignored:
+	case !failed:
+		b.state = BreakerClosed
+		b.failures = 0
+	case b.state == BreakerHalfOpen:
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+	default:
+		b.failures++
+		if b.failures >= b.threshold {
+			b.state = BreakerOpen
+			b.openedAt = time.Now()
+		}
+	}
+}
+
+// Transport wraps next so every request through it passes b. A nil next
+// uses http.DefaultTransport.
+func (b *Breaker) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &breakerTransport{breaker: b, next: next}
+}
+
+type breakerTransport struct {
+	breaker *Breaker
+	next    http.RoundTripper
+}
+
+func (t *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.breaker.allow(); err != nil {
+		return nil, err
+	}
+	resp, err := t.next.RoundTrip(req)
+	failed := err != nil || resp.StatusCode >= http.StatusInternalServerError
+	t.breaker.done(failed, err != nil && req.Context().Err() != nil)
+	return resp, err
+}
@@ -0,0 +1,141 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	const cooldown = 30 * time.Millisecond
+	// each step sends one request while the service answers status (0 means
+	// the request is cancelled first) and optionally waits out the cooldown
+	type step struct {
+		status  int
+		wait    bool
+		wantErr error
+		wantHit bool
+		after   BreakerState
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "sustained failures open the breaker",
+			steps: []step{
+				{status: 500, wantHit: true, after: BreakerClosed},
+				{status: 503, wantHit: true, after: BreakerClosed},
+				{status: 500, wantHit: true, after: BreakerOpen},
+				{status: 200, wantErr: ErrCircuitOpen, after: BreakerOpen},
+			},
+		},
+		{
+			name: "a success resets the count",
+			steps: []step{
+				{status: 500, wantHit: true, after: BreakerClosed},
+				{status: 500, wantHit: true, after: BreakerClosed},
+				{status: 404, wantHit: true, after: BreakerClosed},
+				{status: 500, wantHit: true, after: BreakerClosed},
+				{status: 500, wantHit: true, after: BreakerClosed},
+			},
+		},
+		{
+			name: "recovery after the cooldown",
+			steps: []step{
+				{status: 500, wantHit: true},
+				{status: 500, wantHit: true},
+				{status: 500, wantHit: true, after: BreakerOpen},
+				{status: 200, wait: true, wantHit: true, after: BreakerClosed},
+				{status: 200, wantHit: true, after: BreakerClosed},
+			},
+		},
+		{
+			name: "a failed probe reopens",
+			steps: []step{
+				{status: 500, wantHit: true},
+				{status: 500, wantHit: true},
+				{status: 500, wantHit: true, after: BreakerOpen},
+				{status: 502, wait: true, wantHit: true, after: BreakerOpen},
+				{status: 200, wantErr: ErrCircuitOpen, after: BreakerOpen},
+			},
+		},
+		{
+			name: "cancelled requests are not failures",
+			steps: []step{
+				{status: 500, wantHit: true},
+				{status: 500, wantHit: true},
+				{status: 0, wantErr: context.Canceled, after: BreakerClosed},
+				{status: 0, wantErr: context.Canceled, after: BreakerClosed},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var status, hits atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hits.Add(1)
+				w.WriteHeader(int(status.Load()))
+			}))
+			defer srv.Close()
+			b := NewBreaker(3, cooldown)
+			client := &http.Client{Transport: b.Transport(nil)}
+
+			for i, s := range tt.steps {
+				if s.wait {
+					time.Sleep(cooldown)
+					if got := b.State(); got != BreakerHalfOpen {
+						t.Fatalf("step %d: state after cooldown = %s, want half-open", i, got)
+					}
+				}
+				ctx, cancel := context.WithCancel(context.Background())
+				if s.status == 0 {
+					cancel()
+				}
+				status.Store(int32(s.status))
+				before := hits.Load()
+				req, _ := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL, nil)
+				resp, err := client.Do(req)
+				cancel()
+				if err == nil {
+					resp.Body.Close()
+				}
+				if !errors.Is(err, s.wantErr) {
+					t.Fatalf("step %d: error = %v, want %v", i, err, s.wantErr)
+				}
+				if hit := hits.Load() > before; hit != s.wantHit {
+					t.Fatalf("step %d: reached the service = %v, want %v", i, hit, s.wantHit)
+				}
+				if s.after != "" && b.State() != s.after {
+					t.Fatalf("step %d: state = %s, want %s", i, b.State(), s.after)
+				}
+			}
+		})
+	}
+}
+
+func TestBreakerSingleProbe(t *testing.T) {
+	b := NewBreaker(1, time.Millisecond)
+	b.done(true, false)
+	time.Sleep(2 * time.Millisecond)
+	if err := b.allow(); err != nil {
+		t.Fatalf("probe refused: %v", err)
+	}
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("second request during the probe = %v, want ErrCircuitOpen", err)
+	}
+	b.done(false, true)
+	if err := b.allow(); err != nil {
+		t.Errorf("probe after an ignored one refused: %v", err)
+	}
+}
+
+func TestClientBreakerState(t *testing.T) {
+	if got := (&Client{}).BreakerState(); got != BreakerClosed {
+		t.Errorf("BreakerState() without a breaker = %s, want closed", got)
+	}
+}
@@ -12,6 +12,11 @@ import (
 	"time"
 
 	"resume-generator/pkg/ai/formatters"
+
+	"resume-generator/pkg/logctx"
+	"resume-generator/pkg/metrics"
+	"resume-generator/pkg/retry"
+	"resume-generator/templates"
 )
 
 // Client calls the internal ai-service to format raw profile data into the
@@ -20,6 +25,29 @@ type Client struct {
 	BaseURL         string
 	HTTP            *http.Client
 	DefaultLanguage string
+	// Labels caches translated section labels per language. When nil the
+	// package-wide in-memory cache is used.
+	Labels *LabelCache
+	// Responses caches the section formatter responses. Nil disables
+	// caching.
+	Responses *ResponseCache
+	// Breaker guards HTTP; clients built with NewClient share it with
+	// their WithLanguage copies.
+	Breaker *Breaker
+	// Retry is how doPostWithRetry retries failed requests; the zero
+	// Policy makes a single attempt.
+	Retry retry.Policy
+}
+
+// defaultLabelCache is shared by clients without their own Labels cache.
+var defaultLabelCache = NewLabelCache(DefaultLabelCacheTTL, nil)
+
+// LabelCache returns the label cache used by c.
+func (c *Client) LabelCache() *LabelCache {
+	if c.Labels != nil {
+		return c.Labels
+	}
+	return defaultLabelCache
 }
 
 func NewClient() *Client {
@@ -27,15 +55,46 @@ func NewClient() *Client {
 	if base == "" {
 		base = "http://ai-service:8000"
 	}
-	return &Client{BaseURL: base, HTTP: &http.Client{Timeout: 60 * time.Second}}
+	breaker := breakerFromEnv()
+	return &Client{
+		BaseURL: base,
+		HTTP:    &http.Client{Timeout: 60 * time.Second, Transport: breaker.Transport(nil)},
+		Breaker: breaker,
+		Retry:   retry.FromEnv("AI_RETRY", retry.DefaultPolicy()),
+	}
 }
 
 func NewClientWithLanguage(language string) *Client {
-	base := os.Getenv("AI_SERVICE_URL")
-	if base == "" {
-		base = "http://ai-service:8000"
+	c := NewClient()
+	c.DefaultLanguage = language
+	return c
+}
+
+// BreakerState reports whether the ai-service is currently called, for
+// readiness checks. Clients without a breaker are always closed.
+func (c *Client) BreakerState() BreakerState {
+	if c.Breaker == nil {
+		return BreakerClosed
 	}
-	return &Client{BaseURL: base, HTTP: &http.Client{Timeout: 60 * time.Second}, DefaultLanguage: language}
+	return c.Breaker.State()
+}
+
+// WithLanguage returns a copy of c that asks the formatters for output in
+// language. The copy shares c's HTTP client.
+func (c *Client) WithLanguage(language string) *Client {
+	cp := *c
+	cp.DefaultLanguage = language
+	return &cp
+}
+
+// languageInstruction is prepended to the prompts of the non-formatter
+// calls so they answer in the client's language. It is empty when no
+// language is set.
+func (c *Client) languageInstruction() string {
+	if c.DefaultLanguage == "" {
+		return ""
+	}
+	return fmt.Sprintf("LANGUAGE: Write every string value in %s.\n\n", c.DefaultLanguage)
 }
 
 // Formatter interface for the four specialized formatters
@@ -60,38 +119,52 @@ func (c *Client) NewSummaryFormatter() Formatter {
 	return formatters.NewSummaryFormatter(c.HTTP, c.BaseURL, c.DefaultLanguage)
 }
 
+func (c *Client) NewCoverLetterFormatter() Formatter {
+	return formatters.NewCoverLetterFormatter(c.HTTP, c.BaseURL, c.DefaultLanguage)
+}
+
+// FormatLabels returns the section labels translated into the client's
+// language, asking the AI service only when the label cache has no entry.
 func (c *Client) FormatLabels(ctx context.Context) (map[string]string, error) {
+	cache := c.LabelCache()
+	if labels, ok := cache.Get(ctx, c.DefaultLanguage); ok {
+		return labels, nil
+	}
 	lf := formatters.NewLabelsFormatter(c.HTTP, c.BaseURL, c.DefaultLanguage)
-	return lf.Format(ctx)
+	labels, err := lf.Format(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cache.Set(ctx, c.DefaultLanguage, labels)
+	return labels, nil
 }
 
-// doPostWithRetry performs an HTTP POST to the given path with retry/backoff.
+// doPostWithRetry performs an HTTP POST to the given path, retrying
+// transport errors as c.Retry allows. An open circuit breaker is not
+// retried.
 func (c *Client) doPostWithRetry(ctx context.Context, path string, body []byte) (*http.Response, error) {
-	attempts := 3
-	var lastErr error
-	for i := 0; i < attempts; i++ {
+	var resp *http.Response
+	_, err := c.Retry.Do(ctx, func(attempt int) error {
+		if attempt > 1 {
+			metrics.AIRetries.WithLabelValues(path).Inc()
+		}
 		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+path, bytes.NewReader(body))
 		if err != nil {
-			return nil, err
+			return retry.Permanent(err)
 		}
 		req.Header.Set("Content-Type", "application/json")
 
-		resp, err := c.HTTP.Do(req)
-		if err == nil {
-			return resp, nil
-		}
-		lastErr = err
-		// exponential backoff before retrying
-		if i < attempts-1 {
-			backoff := time.Duration(1<<i) * time.Second
-			select {
-			case <-time.After(backoff):
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			}
+		resp, err = c.HTTP.Do(req)
+		if errors.Is(err, ErrCircuitOpen) {
+			// retrying cannot help until the breaker probes again
+			return retry.Permanent(err)
 		}
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
-	return nil, lastErr
+	return resp, nil
 }
 
 // FormatResume sends rawProfile to the ai-service and attempts to obtain a
@@ -101,12 +174,12 @@ func (c *Client) doPostWithRetry(ctx context.Context, path string, body []byte)
 func (c *Client) FormatResume(ctx context.Context, rawProfile interface{}) (map[string]interface{}, []string, bool, error) {
 	// Build a userContext that includes strict instructions asking
 	// the ai-service to return only a JSON object matching our schema.
-	instructions := "Respond with ONLY a single JSON object that conforms to the resume JSON Schema. Do NOT include any explanatory text, backticks, or code fences. If you include anything else, the caller will fail."
+	instructions := c.languageInstruction() + "Respond with ONLY a single JSON object that conforms to the resume JSON Schema. Do NOT include any explanatory text, backticks, or code fences. If you include anything else, the caller will fail."
 
 	// Try to load the JSON schema file and append it to the instructions
 	// to make the requirement explicit to the LLM. If the file isn't
 	// available, fall back to the brief instruction above.
-	if schemaBytes, err := os.ReadFile("templates/resume.schema.json"); err == nil {
+	if schemaBytes, err := templates.ReadFile("resume.schema.json"); err == nil {
 		instructions = instructions + "\n\nJSON-SCHEMA:\n" + string(schemaBytes)
 	}
 
@@ -167,7 +240,7 @@ Example JSON skeleton (use this structure and follow the length limits):
 	}
 
 	// Debug: log outgoing request payload
-	fmt.Printf("ai.client: POST %s/v1/chat payload=%s\n", c.BaseURL, string(b))
+	logctx.Debugf(ctx, "ai.client: POST %s/v1/chat payload=%s", c.BaseURL, string(b))
 
 	resp, err := c.doPostWithRetry(ctx, "/v1/chat", b)
 	if err != nil {
@@ -180,7 +253,7 @@ Example JSON skeleton (use this structure and follow the length limits):
 	if err != nil {
 		return nil, nil, false, err
 	}
-	fmt.Printf("ai.client: response status=%d body=%s\n", resp.StatusCode, string(respBytes))
+	logctx.Debugf(ctx, "ai.client: response status=%d body=%s", resp.StatusCode, string(respBytes))
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, nil, false, errors.New("ai-service returned non-200 status")
@@ -194,31 +267,11 @@ Example JSON skeleton (use this structure and follow the length limits):
 		return nil, nil, false, err
 	}
 
-	// Try to parse the chat output as JSON; if it fails, attempt robust extraction
+	// Parse the chat output as JSON, asking the model once to repair it if
+	// it is not
 	var resumeMap map[string]interface{}
-	if err := json.Unmarshal([]byte(chatResp.Output), &resumeMap); err != nil {
-		s := chatResp.Output
-		start := -1
-		end := -1
-		for i, r := range s {
-			if r == '{' {
-				start = i
-				break
-			}
-		}
-		for i := len(s) - 1; i >= 0; i-- {
-			if s[i] == '}' {
-				end = i
-				break
-			}
-		}
-		if start >= 0 && end > start {
-			sub := s[start : end+1]
-			if err2 := json.Unmarshal([]byte(sub), &resumeMap); err2 == nil {
-				return resumeMap, nil, false, nil
-			}
-		}
-		return nil, nil, false, fmt.Errorf("ai-service returned non-json content: %w", err)
+	if err := formatters.DecodeJSONResponse(ctx, c.HTTP, c.BaseURL, chatResp.Output, &resumeMap); err != nil {
+		return nil, nil, false, err
 	}
 
 	// This endpoint doesn't return structured warnings/synthesized flags.
@@ -235,7 +288,7 @@ func (c *Client) EnrichResume(ctx context.Context, baseResume map[string]interfa
 	payloadObj := map[string]interface{}{
 		"base_resume":  baseResume,
 		"overrides":    overrides,
-		"instructions": instr,
+		"instructions": c.languageInstruction() + instr,
 	}
 	b, err := json.Marshal(map[string]interface{}{"userContext": payloadObj})
 	if err != nil {
@@ -251,7 +304,7 @@ func (c *Client) EnrichResume(ctx context.Context, baseResume map[string]interfa
 		return nil, err
 	}
 
-	fmt.Printf("ai.client: ENRICH POST %s/v1/chat payload=%s\n", c.BaseURL, string(rb))
+	logctx.Debugf(ctx, "ai.client: ENRICH POST %s/v1/chat payload=%s", c.BaseURL, string(rb))
 
 	resp, err := c.doPostWithRetry(ctx, "/v1/chat", rb)
 	if err != nil {
@@ -263,7 +316,7 @@ func (c *Client) EnrichResume(ctx context.Context, baseResume map[string]interfa
 	if err != nil {
 		return nil, err
 	}
-	fmt.Printf("ai.client: enrich response status=%d body=%s\n", resp.StatusCode, string(respBytes))
+	logctx.Debugf(ctx, "ai.client: enrich response status=%d body=%s", resp.StatusCode, string(respBytes))
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("ai-service returned non-200 status: %d", resp.StatusCode)
@@ -278,30 +331,8 @@ func (c *Client) EnrichResume(ctx context.Context, baseResume map[string]interfa
 	}
 
 	var enriched map[string]interface{}
-	if err := json.Unmarshal([]byte(chatResp.Output), &enriched); err != nil {
-		// try to extract JSON substring
-		s := chatResp.Output
-		start := -1
-		end := -1
-		for i, r := range s {
-			if r == '{' {
-				start = i
-				break
-			}
-		}
-		for i := len(s) - 1; i >= 0; i-- {
-			if s[i] == '}' {
-				end = i
-				break
-			}
-		}
-		if start >= 0 && end > start {
-			sub := s[start : end+1]
-			if err2 := json.Unmarshal([]byte(sub), &enriched); err2 == nil {
-				return enriched, nil
-			}
-		}
-		return nil, fmt.Errorf("ai-service returned non-json content: %w", err)
+	if err := formatters.DecodeJSONObject(chatResp.Output, &enriched); err != nil {
+		return nil, err
 	}
 
 	return enriched, nil
@@ -316,7 +347,7 @@ func (c *Client) EnrichFields(ctx context.Context, overrides map[string]interfac
 
 	payloadObj := map[string]interface{}{
 		"overrides":    overrides,
-		"instructions": instr,
+		"instructions": c.languageInstruction() + instr,
 	}
 	b, err := json.Marshal(map[string]interface{}{"userContext": payloadObj})
 	if err != nil {
@@ -332,7 +363,7 @@ func (c *Client) EnrichFields(ctx context.Context, overrides map[string]interfac
 		return nil, err
 	}
 
-	fmt.Printf("ai.client: ENRICH_FIELDS POST %s/v1/chat payload=%s\n", c.BaseURL, string(rb))
+	logctx.Debugf(ctx, "ai.client: ENRICH_FIELDS POST %s/v1/chat payload=%s", c.BaseURL, string(rb))
 
 	resp, err := c.doPostWithRetry(ctx, "/v1/chat", rb)
 	if err != nil {
@@ -344,7 +375,7 @@ func (c *Client) EnrichFields(ctx context.Context, overrides map[string]interfac
 	if err != nil {
 		return nil, err
 	}
-	fmt.Printf("ai.client: enrich_fields response status=%d body=%s\n", resp.StatusCode, string(respBytes))
+	logctx.Debugf(ctx, "ai.client: enrich_fields response status=%d body=%s", resp.StatusCode, string(respBytes))
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("ai-service returned non-200 status: %d", resp.StatusCode)
@@ -359,30 +390,8 @@ func (c *Client) EnrichFields(ctx context.Context, overrides map[string]interfac
 	}
 
 	var fields map[string]interface{}
-	if err := json.Unmarshal([]byte(chatResp.Output), &fields); err != nil {
-		// try substring extraction
-		s := chatResp.Output
-		start := -1
-		end := -1
-		for i, r := range s {
-			if r == '{' {
-				start = i
-				break
-			}
-		}
-		for i := len(s) - 1; i >= 0; i-- {
-			if s[i] == '}' {
-				end = i
-				break
-			}
-		}
-		if start >= 0 && end > start {
-			sub := s[start : end+1]
-			if err2 := json.Unmarshal([]byte(sub), &fields); err2 == nil {
-				return fields, nil
-			}
-		}
-		return nil, fmt.Errorf("ai-service returned non-json content: %w", err)
+	if err := formatters.DecodeJSONObject(chatResp.Output, &fields); err != nil {
+		return nil, err
 	}
 
 	return fields, nil
@@ -392,29 +401,39 @@ func (c *Client) EnrichFields(ctx context.Context, overrides map[string]interfac
 // projects sections. It returns a map with keys "experience" and "projects".
 // This now delegates to the ExperienceFormatter.
 func (c *Client) FormatExperienceProjects(ctx context.Context, payload map[string]interface{}) (map[string]interface{}, error) {
-	formatter := c.NewExperienceFormatter()
-	return formatter.Format(ctx, payload)
+	return c.cachedFormat(ctx, "experience", payload, c.NewExperienceFormatter())
 }
 
 // FormatProfileSnapshot returns profile/meta/summary and snapshot fields.
 // This now delegates to the ProfileFormatter.
 func (c *Client) FormatProfileSnapshot(ctx context.Context, payload map[string]interface{}) (map[string]interface{}, error) {
-	formatter := c.NewProfileFormatter()
-	return formatter.Format(ctx, payload)
+	return c.cachedFormat(ctx, "profile", payload, c.NewProfileFormatter())
 }
 
 // FormatPublicationsCertsExtras returns publications/certifications/extras only.
 // This now delegates to the PublicationsFormatter.
 func (c *Client) FormatPublicationsCertsExtras(ctx context.Context, payload map[string]interface{}) (map[string]interface{}, error) {
-	formatter := c.NewPublicationsFormatter()
-	return formatter.Format(ctx, payload)
+	return c.cachedFormat(ctx, "publications", payload, c.NewPublicationsFormatter())
 }
 
 // FormatSummaryMeta returns a short polished summary and headline only.
 // This now delegates to the SummaryFormatter.
 func (c *Client) FormatSummaryMeta(ctx context.Context, payload map[string]interface{}) (map[string]interface{}, error) {
-	formatter := c.NewSummaryFormatter()
-	return formatter.Format(ctx, payload)
+	return c.cachedFormat(ctx, "summary", payload, c.NewSummaryFormatter())
+}
+
+// FormatCoverLetter writes a cover letter from payload's "resume" for its
+// "job_application", returning salutation, body_paragraphs and closing.
+func (c *Client) FormatCoverLetter(ctx context.Context, payload map[string]interface{}) (map[string]interface{}, error) {
+	return c.cachedFormat(ctx, "cover_letter", payload, c.NewCoverLetterFormatter())
+}
+
+// cachedFormat runs formatter through the response cache when one is set.
+func (c *Client) cachedFormat(ctx context.Context, name string, payload map[string]interface{}, formatter Formatter) (map[string]interface{}, error) {
+	if c.Responses == nil {
+		return formatter.Format(ctx, payload)
+	}
+	return c.Responses.format(ctx, name, c.DefaultLanguage, payload, formatter.Format)
 }
 
 // mustMarshal is a tiny helper for embedding example payloads in prompts.
@@ -423,10 +442,10 @@ func mustMarshal(v interface{}) string {
 	return string(b)
 }
 
-// sanitizeCertifications enforces date formats and description length for
+// SanitizeCertifications enforces date formats and description length for
 // the 'certifications' field produced by the AI. It mutates the provided
 // map in-place.
-func sanitizeCertifications(m map[string]interface{}) {
+func SanitizeCertifications(m map[string]interface{}) {
 	if m == nil {
 		return
 	}
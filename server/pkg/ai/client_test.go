@@ -0,0 +1,169 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"resume-generator/pkg/retry"
+)
+
+// chatServer answers /v1/chat with a resume whose summary names the
+// language the prompt asked for, and records the prompts it received.
+func chatServer(t *testing.T, inputs *[]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		*inputs = append(*inputs, req.Input)
+		summary := "default"
+		for _, lang := range []string{"Portuguese", "Spanish"} {
+			if strings.Contains(req.Input, "Write every string value in "+lang) {
+				summary = lang
+			}
+		}
+		out, _ := json.Marshal(map[string]interface{}{"summary": summary})
+		json.NewEncoder(w).Encode(map[string]string{"agent": "auto", "output": string(out)})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestFormatResumeLanguage(t *testing.T) {
+	var inputs []string
+	srv := chatServer(t, &inputs)
+	base := &Client{BaseURL: srv.URL, HTTP: srv.Client()}
+	profile := map[string]interface{}{"name": "Ada", "headline": "Engineer"}
+
+	tests := []struct {
+		language string
+		want     string
+	}{
+		{"", "default"},
+		{"Portuguese", "Portuguese"},
+		{"Spanish", "Spanish"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			inputs = nil
+			resume, _, _, err := base.WithLanguage(tt.language).FormatResume(context.Background(), profile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := resume["summary"]; got != tt.want {
+				t.Errorf("summary = %v, want %q", got, tt.want)
+			}
+			if len(inputs) != 1 {
+				t.Fatalf("ai-service called %d times, want 1", len(inputs))
+			}
+			if hasInstr := strings.Contains(inputs[0], "LANGUAGE:"); hasInstr != (tt.language != "") {
+				t.Errorf("prompt has language instruction = %v, want %v", hasInstr, tt.language != "")
+			}
+		})
+	}
+	if base.DefaultLanguage != "" {
+		t.Errorf("WithLanguage changed the original client's language to %q", base.DefaultLanguage)
+	}
+}
+
+func TestEnrichLanguage(t *testing.T) {
+	var inputs []string
+	srv := chatServer(t, &inputs)
+	c := (&Client{BaseURL: srv.URL, HTTP: srv.Client()}).WithLanguage("Portuguese")
+	overrides := map[string]interface{}{"publications": []interface{}{"Paper"}}
+
+	if _, err := c.EnrichResume(context.Background(), map[string]interface{}{"summary": "x"}, overrides); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.EnrichFields(context.Background(), overrides); err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) != 2 {
+		t.Fatalf("ai-service called %d times, want 2", len(inputs))
+	}
+	for i, in := range inputs {
+		if !strings.Contains(in, "Write every string value in Portuguese") {
+			t.Errorf("prompt %d lacks the language instruction", i)
+		}
+	}
+}
+
+// droppingServer hangs up on the first drops requests without answering
+// and answers the rest with 200. It counts the requests it saw.
+func droppingServer(t *testing.T, drops int32, calls *atomic.Int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= drops {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Errorf("hijack: %v", err)
+				return
+			}
+			conn.Close()
+			return
+		}
+		w.Write([]byte(`{"output":"{}"}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestDoPostWithRetry(t *testing.T) {
+	policy := retry.Policy{Attempts: 3, InitialBackoff: time.Millisecond}
+	tests := []struct {
+		name      string
+		policy    retry.Policy
+		drops     int32
+		wantCalls int32
+		wantErr   bool
+	}{
+		{"no failures", policy, 0, 1, false},
+		{"recovers", policy, 2, 3, false},
+		{"attempts exhausted", policy, 5, 3, true},
+		{"zero policy tries once", retry.Policy{}, 1, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := droppingServer(t, tt.drops, &calls)
+			c := &Client{BaseURL: srv.URL, HTTP: srv.Client(), Retry: tt.policy}
+			resp, err := c.doPostWithRetry(context.Background(), "/v1/chat", []byte(`{}`))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("doPostWithRetry() error = %v, want error: %v", err, tt.wantErr)
+			}
+			if resp != nil {
+				resp.Body.Close()
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("%d requests, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestDoPostWithRetryStopsOnOpenBreaker(t *testing.T) {
+	var calls atomic.Int32
+	srv := droppingServer(t, 0, &calls)
+	b := NewBreaker(1, time.Hour)
+	c := &Client{BaseURL: srv.URL, HTTP: &http.Client{Transport: b.Transport(srv.Client().Transport)}, Breaker: b, Retry: retry.Policy{Attempts: 3, InitialBackoff: time.Hour}}
+	b.allow()
+	b.done(true, false)
+
+	start := time.Now()
+	if _, err := c.doPostWithRetry(context.Background(), "/v1/chat", []byte(`{}`)); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("doPostWithRetry() = %v, want ErrCircuitOpen", err)
+	}
+	if time.Since(start) > time.Second || calls.Load() != 0 {
+		t.Errorf("an open breaker was retried: %d requests in %v", calls.Load(), time.Since(start))
+	}
+}
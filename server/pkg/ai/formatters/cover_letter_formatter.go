@@ -0,0 +1,103 @@
+package formatters
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"resume-generator/pkg/logctx"
+	"resume-generator/templates"
+)
+
+// CoverLetterFormatter writes a cover letter tailored to a job application
+// from the assembled resume. Its payload has the keys "resume" and
+// "job_application"; the result is {salutation, body_paragraphs, closing}.
+type CoverLetterFormatter struct {
+	client   *http.Client
+	baseURL  string
+	language string
+}
+
+func NewCoverLetterFormatter(httpClient *http.Client, baseURL string, language string) *CoverLetterFormatter {
+	return &CoverLetterFormatter{client: httpClient, baseURL: baseURL, language: language}
+}
+
+func (cf *CoverLetterFormatter) Format(ctx context.Context, payload map[string]interface{}) (map[string]interface{}, error) {
+	schemaBytes := []byte{}
+	if b, err := templates.ReadFile("schema/cover_letter.schema.json"); err == nil {
+		schemaBytes = b
+	}
+
+	instr := fmt.Sprintf("LANGUAGE: You MUST write ALL output in %s.\n\nWrite a cover letter for the job application in payload.job_application, using ONLY facts from payload.resume. Return ONLY a single JSON object with keys 'salutation', 'body_paragraphs' and 'closing'.\n\nCRITICAL:\n- salutation: address the hiring manager or company named in the job application; never invent a person's name\n- body_paragraphs: 3 or 4 paragraphs of plain text: why this role and company, the most relevant experience and results, how the skills match the requirements, and a short call to action\n- closing: a sign-off phrase such as 'Kind regards', without the candidate's name\n- Do NOT invent employers, titles, dates or metrics that are not in the resume\n- No markdown, no placeholders like [Company]\n\nJSON-SCHEMA:\n", cf.language) + string(schemaBytes)
+
+	userCtx := map[string]interface{}{"payload": payload, "instructions": instr}
+	reqObj := map[string]interface{}{"agent": "auto", "input": "Write cover letter:\n" + mustMarshal(userCtx)}
+	b, _ := json.Marshal(reqObj)
+
+	logctx.Debugf(ctx, "ai.client: FormatCoverLetter POST %s/v1/chat payload=%s", cf.baseURL, string(b))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cf.baseURL+"/v1/chat", bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := cf.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	rb, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	logctx.Debugf(ctx, "ai.client: FormatCoverLetter response status=%d body=%s", resp.StatusCode, string(rb))
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ai-service returned non-200 status: %d", resp.StatusCode)
+	}
+
+	var chatResp struct {
+		Agent  string `json:"agent"`
+		Output string `json:"output"`
+	}
+	if err := json.Unmarshal(rb, &chatResp); err != nil {
+		return nil, err
+	}
+
+	var out map[string]interface{}
+	if err := DecodeJSONResponse(ctx, cf.client, cf.baseURL, chatResp.Output, &out); err != nil {
+		return nil, err
+	}
+	sanitizeCoverLetter(out)
+	return out, nil
+}
+
+// sanitizeCoverLetter turns a body given as one string into a paragraph
+// list and drops empty paragraphs.
+func sanitizeCoverLetter(m map[string]interface{}) {
+	var paragraphs []interface{}
+	switch v := m["body_paragraphs"].(type) {
+	case string:
+		for _, p := range strings.Split(v, "\n\n") {
+			if s := strings.TrimSpace(p); s != "" {
+				paragraphs = append(paragraphs, s)
+			}
+		}
+	case []interface{}:
+		for _, it := range v {
+			if s, ok := it.(string); ok && s != "" {
+				paragraphs = append(paragraphs, s)
+			}
+		}
+	default:
+		return
+	}
+	m["body_paragraphs"] = paragraphs
+}
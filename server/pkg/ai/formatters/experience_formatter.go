@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
+
+	"resume-generator/pkg/logctx"
+	"resume-generator/templates"
 )
 
 type ExperienceFormatter struct {
@@ -23,17 +25,17 @@ func NewExperienceFormatter(httpClient *http.Client, baseURL string, language st
 func (ef *ExperienceFormatter) Format(ctx context.Context, payload map[string]interface{}) (map[string]interface{}, error) {
 	// Load the focused schema for experience + projects only
 	schemaBytes := []byte{}
-	if b, err := os.ReadFile("templates/schema/experience.schema.json"); err == nil {
+	if b, err := templates.ReadFile("schema/experience.schema.json"); err == nil {
 		schemaBytes = b
 	}
 	
-	instr := fmt.Sprintf("LANGUAGE: You MUST format ALL output in %s. Translate every single field and string value into %s. Every piece of text must be in %s.\n\nReturn ONLY a single JSON object with keys 'experience' and 'projects' that conform to the provided schema. For each experience entry include an optional 'summary' field: a meaningful paragraph (aim for 100-300 characters) describing the role and impact.\n\nIMPORTANT: For projects that do NOT have a 'url' field or have a null/empty url, use the user's GitHub link provided in the payload (aggregated.profiles[0].social_links.github). This is the default link for projects without their own URL.\n\nDo NOT include any extra text beyond the JSON.\n\nREMEMBER: ALL content MUST be in %s. Do NOT include any English text. Prioritize meaningful content.\n\nJSON-SCHEMA:\n", ef.language, ef.language, ef.language, ef.language) + string(schemaBytes)
+	instr := fmt.Sprintf("LANGUAGE: You MUST format ALL output in %s. Translate every single field and string value into %s. Every piece of text must be in %s.\n\nReturn ONLY a single JSON object with keys 'experience' and 'projects' that conform to the provided schema. For each experience entry include an optional 'summary' field: a meaningful paragraph (aim for 100-300 characters) describing the role and impact.\n\nIMPORTANT: For projects that do NOT have a 'url' field or have a null/empty url, use the user's GitHub link provided in the payload (aggregated.profiles[0].social_links.github). This is the default link for projects without their own URL.\n\nTAILORING: When payload.prioritize_keywords is present it lists the skills the target job asks for. Put the bullets and projects that demonstrate them first and name those skills explicitly where the data supports it. NEVER claim a keyword the payload does not back up.\n\nDo NOT include any extra text beyond the JSON.\n\nREMEMBER: ALL content MUST be in %s. Do NOT include any English text. Prioritize meaningful content.\n\nJSON-SCHEMA:\n", ef.language, ef.language, ef.language, ef.language) + string(schemaBytes)
 	
 	userCtx := map[string]interface{}{"payload": payload, "instructions": instr}
 	reqObj := map[string]interface{}{"agent": "auto", "input": "Format experience and projects:\n" + mustMarshal(userCtx)}
 	b, _ := json.Marshal(reqObj)
 	
-	fmt.Printf("ai.client: FormatExperienceProjects POST %s/v1/chat payload=%s\n", ef.baseURL, string(b))
+	logctx.Debugf(ctx, "ai.client: FormatExperienceProjects POST %s/v1/chat payload=%s", ef.baseURL, string(b))
 	
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ef.baseURL+"/v1/chat", bytes.NewReader(b))
 	if err != nil {
@@ -52,7 +54,7 @@ func (ef *ExperienceFormatter) Format(ctx context.Context, payload map[string]in
 		return nil, err
 	}
 	
-	fmt.Printf("ai.client: FormatExperienceProjects response status=%d body=%s\n", resp.StatusCode, string(rb))
+	logctx.Debugf(ctx, "ai.client: FormatExperienceProjects response status=%d body=%s", resp.StatusCode, string(rb))
 	
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("ai-service returned non-200 status: %d", resp.StatusCode)
@@ -67,30 +69,8 @@ func (ef *ExperienceFormatter) Format(ctx context.Context, payload map[string]in
 	}
 	
 	var out map[string]interface{}
-	if err := json.Unmarshal([]byte(chatResp.Output), &out); err != nil {
-		// Try extracting JSON from the response if wrapped in markdown
-		s := chatResp.Output
-		start := -1
-		end := -1
-		for i, r := range s {
-			if r == '{' {
-				start = i
-				break
-			}
-		}
-		for i := len(s) - 1; i >= 0; i-- {
-			if s[i] == '}' {
-				end = i
-				break
-			}
-		}
-		if start >= 0 && end > start {
-			sub := s[start : end+1]
-			if err2 := json.Unmarshal([]byte(sub), &out); err2 == nil {
-				return out, nil
-			}
-		}
-		return nil, fmt.Errorf("ai-service returned non-json content: %w", err)
+	if err := DecodeJSONResponse(ctx, ef.client, ef.baseURL, chatResp.Output, &out); err != nil {
+		return nil, err
 	}
 	
 	return out, nil
@@ -0,0 +1,158 @@
+package formatters
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"resume-generator/pkg/logctx"
+	"resume-generator/pkg/metrics"
+)
+
+// ErrNoJSONObject is returned when a response contains no valid JSON object.
+var ErrNoJSONObject = errors.New("no JSON object found")
+
+// ExtractJSONObject recovers the JSON object from a model response that may
+// wrap it in markdown code fences or surround it with prose. When the
+// response holds several objects, e.g. an example before the real answer,
+// the largest valid one is returned.
+func ExtractJSONObject(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "{") && json.Valid([]byte(s)) {
+		return []byte(s), nil
+	}
+
+	var best string
+	for i := 0; i < len(s); i++ {
+		if s[i] != '{' {
+			continue
+		}
+		end := matchingBrace(s, i)
+		if end < 0 {
+			continue
+		}
+		candidate := s[i : end+1]
+		if !json.Valid([]byte(candidate)) {
+			// a nested object may still be valid
+			continue
+		}
+		if len(candidate) > len(best) {
+			best = candidate
+		}
+		// skip the objects nested in this one; they are smaller
+		i = end
+	}
+	if best == "" {
+		return nil, ErrNoJSONObject
+	}
+	return []byte(best), nil
+}
+
+// matchingBrace returns the index of the '}' closing the '{' at start,
+// ignoring braces inside JSON strings, or -1 when it is never closed.
+func matchingBrace(s string, start int) int {
+	depth := 0
+	inString, escaped := false, false
+	for i := start; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// DecodeJSONObject extracts the JSON object from a model response with
+// ExtractJSONObject and unmarshals it into v.
+func DecodeJSONObject(s string, v interface{}) error {
+	b, err := ExtractJSONObject(s)
+	if err == nil {
+		err = json.Unmarshal(b, v)
+	}
+	if err != nil {
+		return fmt.Errorf("ai-service returned non-json content: %w", err)
+	}
+	return nil
+}
+
+// jsonRepairInstruction prefixes the invalid output sent back to the model.
+const jsonRepairInstruction = "Return ONLY valid JSON, fixing the following. Keep every key and value as it is; only repair the syntax (quotes, commas, brackets, escaping). Do not add any text, markdown or code fences.\n\n"
+
+// DecodeJSONResponse decodes output like DecodeJSONObject. When output holds
+// no valid JSON object, the model behind baseURL is asked once to repair it
+// and the answer is decoded instead; a single attempt bounds the extra cost.
+// The original error is returned when the repair request itself fails.
+func DecodeJSONResponse(ctx context.Context, client *http.Client, baseURL, output string, v interface{}) error {
+	err := DecodeJSONObject(output, v)
+	if err == nil {
+		return nil
+	}
+
+	logctx.Warnf(ctx, "ai.client: %v, asking ai-service to repair the JSON", err)
+	repaired, rerr := requestJSONRepair(ctx, client, baseURL, output)
+	if rerr != nil {
+		metrics.AIJSONRepairs.WithLabelValues("failed").Inc()
+		logctx.Warnf(ctx, "ai.client: JSON repair request failed: %v", rerr)
+		return err
+	}
+	if err := DecodeJSONObject(repaired, v); err != nil {
+		metrics.AIJSONRepairs.WithLabelValues("failed").Inc()
+		return fmt.Errorf("after JSON repair: %w", err)
+	}
+	metrics.AIJSONRepairs.WithLabelValues("repaired").Inc()
+	return nil
+}
+
+// requestJSONRepair sends output back to the chat endpoint with
+// jsonRepairInstruction and returns the model's answer.
+func requestJSONRepair(ctx context.Context, client *http.Client, baseURL, output string) (string, error) {
+	b, err := json.Marshal(map[string]interface{}{"agent": "auto", "input": jsonRepairInstruction + output})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/v1/chat", bytes.NewReader(b))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	rb, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	logctx.Debugf(ctx, "ai.client: JSON repair response status=%d body=%s", resp.StatusCode, string(rb))
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("ai-service returned non-200 status: %d", resp.StatusCode)
+	}
+
+	var chatResp struct {
+		Output string `json:"output"`
+	}
+	if err := json.Unmarshal(rb, &chatResp); err != nil {
+		return "", err
+	}
+	return chatResp.Output, nil
+}
@@ -0,0 +1,49 @@
+package formatters
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExtractJSONObject(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+		err  error
+	}{
+		{name: "bare object", in: ` {"a":1} `, want: `{"a":1}`},
+		{name: "json fence", in: "```json\n{\"a\":1}\n```", want: `{"a":1}`},
+		{name: "plain fence", in: "```\n{\"a\":1}\n```", want: `{"a":1}`},
+		{name: "prose around", in: `Here is the resume: {"a":1} Let me know!`, want: `{"a":1}`},
+		{name: "example before the answer", in: `For example {"a":1}. The answer: {"summary":"longer","b":[1,2]}`, want: `{"summary":"longer","b":[1,2]}`},
+		{name: "braces in strings", in: `Sure: {"text":"use } and { freely","n":{"x":"\"}"}}`, want: `{"text":"use } and { freely","n":{"x":"\"}"}}`},
+		{name: "invalid outer, valid nested", in: `{"a": {"b":1}, oops}`, want: `{"b":1}`},
+		{name: "unbalanced", in: `{"a": {"b":1}`, want: `{"b":1}`},
+		{name: "non-ascii", in: "Resposta:\n```json\n{\"título\":\"Visão Geral — São Paulo\"}\n```", want: `{"título":"Visão Geral — São Paulo"}`},
+		{name: "array only", in: `[1,2,3]`, err: ErrNoJSONObject},
+		{name: "no json", in: "Sorry, I can't help with that.", err: ErrNoJSONObject},
+		{name: "empty", in: "", err: ErrNoJSONObject},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractJSONObject(tt.in)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ExtractJSONObject() error = %v, want %v", err, tt.err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ExtractJSONObject() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeJSONObject(t *testing.T) {
+	var v map[string]string
+	if err := DecodeJSONObject("```json\n{\"k\":\"v\"}\n```", &v); err != nil || v["k"] != "v" {
+		t.Errorf("DecodeJSONObject() = %v, %v", v, err)
+	}
+	if err := DecodeJSONObject("no object", &v); !errors.Is(err, ErrNoJSONObject) {
+		t.Errorf("DecodeJSONObject(no object) = %v, want ErrNoJSONObject", err)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+
+	"resume-generator/pkg/logctx"
 )
 
 type LabelsFormatter struct {
@@ -35,7 +37,9 @@ REQUIRED OUTPUT FORMAT (with all 12 keys):
   "tech_snapshot": "<translated heading>",
   "top_achievements": "<translated heading>",
   "selected_projects": "<translated heading>",
+  "skills": "<translated heading>",
   "experience": "<translated heading>",
+  "education": "<translated heading>",
   "projects_case_studies": "<translated heading>",
   "publications": "<translated heading>",
   "certifications": "<translated heading>",
@@ -51,7 +55,9 @@ Example for Portuguese:
   "tech_snapshot": "Visão Geral Técnica",
   "top_achievements": "Principais Conquistas",
   "selected_projects": "Projetos Selecionados",
+  "skills": "Competências",
   "experience": "Experiência",
+  "education": "Formação Acadêmica",
   "projects_case_studies": "Projetos — Estudos de Caso",
   "publications": "Publicações",
   "certifications": "Certificações",
@@ -66,7 +72,7 @@ NOW translate to %s. Return ONLY JSON with all 13 keys.`, lf.language, lf.langua
 	reqObj := map[string]interface{}{"agent": "auto", "input": "Translate UI labels to " + lf.language + ":\n" + instr}
 	b, _ := json.Marshal(reqObj)
 
-	fmt.Printf("ai.client: FormatLabels POST %s/v1/chat payload=%s\n", lf.baseURL, string(b))
+	logctx.Debugf(ctx, "ai.client: FormatLabels POST %s/v1/chat payload=%s", lf.baseURL, string(b))
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, lf.baseURL+"/v1/chat", bytes.NewReader(b))
 	if err != nil {
@@ -85,7 +91,7 @@ NOW translate to %s. Return ONLY JSON with all 13 keys.`, lf.language, lf.langua
 		return nil, err
 	}
 
-	fmt.Printf("ai.client: FormatLabels response status=%d body=%s\n", resp.StatusCode, string(rb))
+	logctx.Debugf(ctx, "ai.client: FormatLabels response status=%d body=%s", resp.StatusCode, string(rb))
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("ai-service returned non-200 status: %d", resp.StatusCode)
@@ -100,30 +106,8 @@ NOW translate to %s. Return ONLY JSON with all 13 keys.`, lf.language, lf.langua
 	}
 
 	var out map[string]string
-	if err := json.Unmarshal([]byte(chatResp.Output), &out); err != nil {
-		// Try extracting JSON from the response if wrapped in markdown
-		s := chatResp.Output
-		start := -1
-		end := -1
-		for i, r := range s {
-			if r == '{' {
-				start = i
-				break
-			}
-		}
-		for i := len(s) - 1; i >= 0; i-- {
-			if s[i] == '}' {
-				end = i
-				break
-			}
-		}
-		if start >= 0 && end > start {
-			sub := s[start : end+1]
-			if err2 := json.Unmarshal([]byte(sub), &out); err2 == nil {
-				return out, nil
-			}
-		}
-		return nil, fmt.Errorf("ai-service returned non-json content: %w", err)
+	if err := DecodeJSONObject(chatResp.Output, &out); err != nil {
+		return nil, err
 	}
 
 	return out, nil
@@ -136,7 +120,9 @@ func GetDefaultLabels() map[string]string {
 		"tech_snapshot":            "Tech Snapshot",
 		"top_achievements":         "Top Achievements",
 		"selected_projects":        "Selected Projects",
+		"skills":                   "Skills",
 		"experience":               "Experience",
+		"education":                "Education",
 		"projects_case_studies":    "Projects — Case Studies",
 		"publications":             "Publications",
 		"certifications":           "Certifications",
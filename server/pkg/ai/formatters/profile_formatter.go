@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
+
+	"resume-generator/pkg/logctx"
+	"resume-generator/templates"
 )
 
 type ProfileFormatter struct {
@@ -23,17 +25,17 @@ func NewProfileFormatter(httpClient *http.Client, baseURL string, language strin
 func (pf *ProfileFormatter) Format(ctx context.Context, payload map[string]interface{}) (map[string]interface{}, error) {
 	// Load the focused schema for profile/snapshot only
 	schemaBytes := []byte{}
-	if b, err := os.ReadFile("templates/schema/profile.schema.json"); err == nil {
+	if b, err := templates.ReadFile("schema/profile.schema.json"); err == nil {
 		schemaBytes = b
 	}
 	
-	instr := fmt.Sprintf("LANGUAGE: You MUST format ALL output in %s. Translate every single field and string value into %s. Every piece of text must be in %s.\n\nReturn ONLY a single JSON object with keys 'meta', 'summary', 'snapshot'.\n\nCRITICAL CONSTRAINTS:\n1. selected_projects: MUST be exactly 2 items, EACH item should be 40-200 characters (aim for quality over strict length). MUST be in %s.\n2. achievements: MUST be 3+ items, each 40+ characters. MUST be in %s.\n3. snapshot.tech: aim for 150-250 characters, prioritize meaningful content. MUST be in %s.\n4. meta.contact: MUST be an object {email: string, location: string}.\n\nREMEMBER: ALL content MUST be in %s. Do NOT include any English text. Prioritize meaningful content.\n\nJSON-SCHEMA:\n", pf.language, pf.language, pf.language, pf.language, pf.language, pf.language, pf.language) + string(schemaBytes)
+	instr := fmt.Sprintf("LANGUAGE: You MUST format ALL output in %s. Translate every single field and string value into %s. Every piece of text must be in %s.\n\nReturn ONLY a single JSON object with keys 'meta', 'summary', 'snapshot', 'skills', 'education'.\n\nCRITICAL CONSTRAINTS:\n1. selected_projects: MUST be exactly 2 items, EACH item should be 40-200 characters (aim for quality over strict length). MUST be in %s.\n2. achievements: MUST be 3+ items, each 40+ characters. MUST be in %s.\n3. snapshot.tech: aim for 150-250 characters, prioritize meaningful content. MUST be in %s.\n4. meta.contact: MUST be an object {email: string, location: string}.\n5. education: array of {institution, degree, field, start_date, end_date, gpa} built ONLY from education entries in the payload; omit gpa when unknown and return [] when there is no education data. Do NOT invent institutions or degrees.\n6. skills: array of {category, items[]} grouping the technologies and competencies from the payload into categories such as languages, frameworks, tools and soft skills; category names MUST be in %s, each group needs at least 1 item.\n7. TAILORING: when payload.prioritize_keywords is present it lists the skills the target job asks for; name the ones the payload supports first in snapshot.tech, achievements and the headline, and list them first in their skills group. NEVER claim a keyword the payload does not back up.\n\nREMEMBER: ALL content MUST be in %s. Do NOT include any English text. Prioritize meaningful content.\n\nJSON-SCHEMA:\n", pf.language, pf.language, pf.language, pf.language, pf.language, pf.language, pf.language, pf.language) + string(schemaBytes)
 	
 	userCtx := map[string]interface{}{"payload": payload, "instructions": instr}
 	reqObj := map[string]interface{}{"agent": "auto", "input": "Format profile and snapshot:\n" + mustMarshal(userCtx)}
 	b, _ := json.Marshal(reqObj)
 	
-	fmt.Printf("ai.client: FormatProfileSnapshot POST %s/v1/chat payload=%s\n", pf.baseURL, string(b))
+	logctx.Debugf(ctx, "ai.client: FormatProfileSnapshot POST %s/v1/chat payload=%s", pf.baseURL, string(b))
 	
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, pf.baseURL+"/v1/chat", bytes.NewReader(b))
 	if err != nil {
@@ -52,7 +54,7 @@ func (pf *ProfileFormatter) Format(ctx context.Context, payload map[string]inter
 		return nil, err
 	}
 	
-	fmt.Printf("ai.client: FormatProfileSnapshot response status=%d body=%s\n", resp.StatusCode, string(rb))
+	logctx.Debugf(ctx, "ai.client: FormatProfileSnapshot response status=%d body=%s", resp.StatusCode, string(rb))
 	
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("ai-service returned non-200 status: %d", resp.StatusCode)
@@ -67,30 +69,8 @@ func (pf *ProfileFormatter) Format(ctx context.Context, payload map[string]inter
 	}
 	
 	var out map[string]interface{}
-	if err := json.Unmarshal([]byte(chatResp.Output), &out); err != nil {
-		// Try extracting JSON from the response if wrapped in markdown
-		s := chatResp.Output
-		start := -1
-		end := -1
-		for i, r := range s {
-			if r == '{' {
-				start = i
-				break
-			}
-		}
-		for i := len(s) - 1; i >= 0; i-- {
-			if s[i] == '}' {
-				end = i
-				break
-			}
-		}
-		if start >= 0 && end > start {
-			sub := s[start : end+1]
-			if err2 := json.Unmarshal([]byte(sub), &out); err2 == nil {
-				return out, nil
-			}
-		}
-		return nil, fmt.Errorf("ai-service returned non-json content: %w", err)
+	if err := DecodeJSONResponse(ctx, pf.client, pf.baseURL, chatResp.Output, &out); err != nil {
+		return nil, err
 	}
 	
 	return out, nil
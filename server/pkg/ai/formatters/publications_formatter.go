@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
+
+	"resume-generator/pkg/logctx"
+	"resume-generator/templates"
 )
 
 type PublicationsFormatter struct {
@@ -23,7 +25,7 @@ func NewPublicationsFormatter(httpClient *http.Client, baseURL string, language
 func (pf *PublicationsFormatter) Format(ctx context.Context, payload map[string]interface{}) (map[string]interface{}, error) {
 	// Load the focused schema for publications/certifications/extras only
 	schemaBytes := []byte{}
-	if b, err := os.ReadFile("templates/schema/publications.schema.json"); err == nil {
+	if b, err := templates.ReadFile("schema/publications.schema.json"); err == nil {
 		schemaBytes = b
 	}
 	
@@ -33,7 +35,7 @@ func (pf *PublicationsFormatter) Format(ctx context.Context, payload map[string]
 	reqObj := map[string]interface{}{"agent": "auto", "input": "Format publications/certifications/extras:\n" + mustMarshal(userCtx)}
 	b, _ := json.Marshal(reqObj)
 	
-	fmt.Printf("ai.client: FormatPublicationsCertsExtras POST %s/v1/chat payload=%s\n", pf.baseURL, string(b))
+	logctx.Debugf(ctx, "ai.client: FormatPublicationsCertsExtras POST %s/v1/chat payload=%s", pf.baseURL, string(b))
 	
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, pf.baseURL+"/v1/chat", bytes.NewReader(b))
 	if err != nil {
@@ -52,7 +54,7 @@ func (pf *PublicationsFormatter) Format(ctx context.Context, payload map[string]
 		return nil, err
 	}
 	
-	fmt.Printf("ai.client: FormatPublicationsCertsExtras response status=%d body=%s\n", resp.StatusCode, string(rb))
+	logctx.Debugf(ctx, "ai.client: FormatPublicationsCertsExtras response status=%d body=%s", resp.StatusCode, string(rb))
 	
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("ai-service returned non-200 status: %d", resp.StatusCode)
@@ -67,30 +69,8 @@ func (pf *PublicationsFormatter) Format(ctx context.Context, payload map[string]
 	}
 	
 	var out map[string]interface{}
-	if err := json.Unmarshal([]byte(chatResp.Output), &out); err != nil {
-		// Try extracting JSON from the response if wrapped in markdown
-		s := chatResp.Output
-		start := -1
-		end := -1
-		for i, r := range s {
-			if r == '{' {
-				start = i
-				break
-			}
-		}
-		for i := len(s) - 1; i >= 0; i-- {
-			if s[i] == '}' {
-				end = i
-				break
-			}
-		}
-		if start >= 0 && end > start {
-			sub := s[start : end+1]
-			if err2 := json.Unmarshal([]byte(sub), &out); err2 == nil {
-				return out, nil
-			}
-		}
-		return nil, fmt.Errorf("ai-service returned non-json content: %w", err)
+	if err := DecodeJSONResponse(ctx, pf.client, pf.baseURL, chatResp.Output, &out); err != nil {
+		return nil, err
 	}
 	
 	return out, nil
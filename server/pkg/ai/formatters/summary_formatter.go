@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
+
+	"resume-generator/pkg/logctx"
+	"resume-generator/templates"
 )
 
 type SummaryFormatter struct {
@@ -23,7 +25,7 @@ func NewSummaryFormatter(httpClient *http.Client, baseURL string, language strin
 func (sf *SummaryFormatter) Format(ctx context.Context, payload map[string]interface{}) (map[string]interface{}, error) {
 	// Load the focused schema for summary and meta only
 	schemaBytes := []byte{}
-	if b, err := os.ReadFile("templates/schema/summary_meta.schema.json"); err == nil {
+	if b, err := templates.ReadFile("schema/summary_meta.schema.json"); err == nil {
 		schemaBytes = b
 	}
 	
@@ -33,7 +35,7 @@ func (sf *SummaryFormatter) Format(ctx context.Context, payload map[string]inter
 	reqObj := map[string]interface{}{"agent": "auto", "input": "Polish summary and meta:\n" + mustMarshal(userCtx)}
 	b, _ := json.Marshal(reqObj)
 	
-	fmt.Printf("ai.client: FormatSummaryMeta POST %s/v1/chat payload=%s\n", sf.baseURL, string(b))
+	logctx.Debugf(ctx, "ai.client: FormatSummaryMeta POST %s/v1/chat payload=%s", sf.baseURL, string(b))
 	
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sf.baseURL+"/v1/chat", bytes.NewReader(b))
 	if err != nil {
@@ -52,7 +54,7 @@ func (sf *SummaryFormatter) Format(ctx context.Context, payload map[string]inter
 		return nil, err
 	}
 	
-	fmt.Printf("ai.client: FormatSummaryMeta response status=%d body=%s\n", resp.StatusCode, string(rb))
+	logctx.Debugf(ctx, "ai.client: FormatSummaryMeta response status=%d body=%s", resp.StatusCode, string(rb))
 	
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("ai-service returned non-200 status: %d", resp.StatusCode)
@@ -67,31 +69,8 @@ func (sf *SummaryFormatter) Format(ctx context.Context, payload map[string]inter
 	}
 	
 	var out map[string]interface{}
-	if err := json.Unmarshal([]byte(chatResp.Output), &out); err != nil {
-		// Try extracting JSON from the response if wrapped in markdown
-		s := chatResp.Output
-		start := -1
-		end := -1
-		for i, r := range s {
-			if r == '{' {
-				start = i
-				break
-			}
-		}
-		for i := len(s) - 1; i >= 0; i-- {
-			if s[i] == '}' {
-				end = i
-				break
-			}
-		}
-		if start >= 0 && end > start {
-			sub := s[start : end+1]
-			if err2 := json.Unmarshal([]byte(sub), &out); err2 == nil {
-				sanitizeSummaryMeta(out)
-				return out, nil
-			}
-		}
-		return nil, fmt.Errorf("ai-service returned non-json content: %w", err)
+	if err := DecodeJSONResponse(ctx, sf.client, sf.baseURL, chatResp.Output, &out); err != nil {
+		return nil, err
 	}
 	
 	// Ensure meta.contact is an object (coerce simple string emails to {"email": "..."})
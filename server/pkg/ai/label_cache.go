@@ -0,0 +1,112 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"resume-generator/pkg/logctx"
+)
+
+// LabelStore persists translated labels so they survive restarts.
+type LabelStore interface {
+	LoadLabels(ctx context.Context, language string) (map[string]string, bool, error)
+	SaveLabels(ctx context.Context, language string, labels map[string]string) error
+	// DeleteLabels removes the stored translation for language, or all of
+	// them when language is empty.
+	DeleteLabels(ctx context.Context, language string) error
+}
+
+type cachedLabels struct {
+	labels  map[string]string
+	expires time.Time
+}
+
+// LabelCache keeps translated section labels per language in memory for ttl
+// and, when a store is set, reads through to and writes to it.
+type LabelCache struct {
+	ttl   time.Duration
+	store LabelStore
+
+	mu      sync.RWMutex
+	entries map[string]cachedLabels
+}
+
+// DefaultLabelCacheTTL is the in-memory lifetime of a cached translation.
+const DefaultLabelCacheTTL = 24 * time.Hour
+
+// NewLabelCache creates an in-memory cache. A nil store disables
+// persistence.
+func NewLabelCache(ttl time.Duration, store LabelStore) *LabelCache {
+	if ttl <= 0 {
+		ttl = DefaultLabelCacheTTL
+	}
+	return &LabelCache{ttl: ttl, store: store, entries: map[string]cachedLabels{}}
+}
+
+func labelKey(language string) string {
+	return strings.ToLower(strings.TrimSpace(language))
+}
+
+// Get returns the cached labels for language, consulting the store on an
+// in-memory miss.
+func (c *LabelCache) Get(ctx context.Context, language string) (map[string]string, bool) {
+	key := labelKey(language)
+	c.mu.RLock()
+	e, ok := c.entries[key]
+	c.mu.RUnlock()
+	if ok && time.Now().Before(e.expires) {
+		return e.labels, true
+	}
+
+	if c.store == nil {
+		return nil, false
+	}
+	labels, ok, err := c.store.LoadLabels(ctx, key)
+	if err != nil {
+		logctx.Warnf(ctx, "ai.client: label store load failed for %s: %v", key, err)
+		return nil, false
+	}
+	if !ok || len(labels) == 0 {
+		return nil, false
+	}
+	c.remember(key, labels)
+	return labels, true
+}
+
+// Set caches labels for language and persists them best-effort.
+func (c *LabelCache) Set(ctx context.Context, language string, labels map[string]string) {
+	key := labelKey(language)
+	c.remember(key, labels)
+	if c.store != nil {
+		if err := c.store.SaveLabels(ctx, key, labels); err != nil {
+			logctx.Warnf(ctx, "ai.client: label store save failed for %s: %v", key, err)
+		}
+	}
+}
+
+func (c *LabelCache) remember(key string, labels map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = cachedLabels{labels: labels, expires: time.Now().Add(c.ttl)}
+}
+
+// Invalidate drops the cached labels for language, or every entry when
+// language is empty, from memory and the store so the next job asks the AI
+// again.
+func (c *LabelCache) Invalidate(ctx context.Context, language string) error {
+	key := labelKey(language)
+	c.mu.Lock()
+	if key == "" {
+		c.entries = map[string]cachedLabels{}
+	} else {
+		delete(c.entries, key)
+	}
+	c.mu.Unlock()
+
+	if c.store != nil {
+		return c.store.DeleteLabels(ctx, key)
+	}
+	return nil
+}
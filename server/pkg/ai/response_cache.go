@@ -0,0 +1,222 @@
+package ai
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/fs"
+	"sort"
+	"sync"
+	"time"
+
+	"resume-generator/pkg/logctx"
+	"resume-generator/templates"
+)
+
+// ResponseStore holds encoded formatter responses by key. It must be safe
+// for concurrent use; MemoryResponseStore is the default and a shared store
+// such as Redis can implement it to reuse responses across instances.
+type ResponseStore interface {
+	GetResponse(ctx context.Context, key string) ([]byte, bool, error)
+	SetResponse(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// ResponseCache answers formatter calls whose payload, language and schemas
+// are unchanged from a previous call without asking the AI service again.
+type ResponseCache struct {
+	ttl   time.Duration
+	store ResponseStore
+}
+
+// DefaultResponseCacheSize is the number of responses kept by the default
+// in-memory store.
+const DefaultResponseCacheSize = 512
+
+// DefaultResponseCacheTTL is how long responses are kept when the cache is
+// enabled without a TTL.
+const DefaultResponseCacheTTL = time.Hour
+
+// NewResponseCache caches responses in store for ttl. A nil store uses an
+// in-memory LRU of DefaultResponseCacheSize entries.
+func NewResponseCache(ttl time.Duration, store ResponseStore) *ResponseCache {
+	if store == nil {
+		store = NewMemoryResponseStore(DefaultResponseCacheSize)
+	}
+	return &ResponseCache{ttl: ttl, store: store}
+}
+
+// responseKey hashes the formatter name, language, schema version and the
+// payload. encoding/json sorts map keys, so equal payloads encode equally.
+func responseKey(formatter, language string, payload map[string]interface{}) (string, error) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	for _, part := range []string{formatter, labelKey(language), schemaVersion()} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	h.Write(b)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+var (
+	schemaVersionOnce sync.Once
+	schemaVersionHash string
+)
+
+// schemaVersion is a hash of the resume schemas the formatters answer to,
+// so editing a schema invalidates the responses cached against it.
+func schemaVersion() string {
+	schemaVersionOnce.Do(func() {
+		files, _ := fs.Glob(templates.FS(), "schema/*.json")
+		files = append(files, "resume.schema.json")
+		sort.Strings(files)
+		h := sha256.New()
+		for _, f := range files {
+			if b, err := templates.ReadFile(f); err == nil {
+				h.Write(b)
+			}
+		}
+		schemaVersionHash = hex.EncodeToString(h.Sum(nil))
+	})
+	return schemaVersionHash
+}
+
+// format returns the cached response of formatter for payload or calls fn
+// and caches its result. Hits are recorded on the context's CacheTrace,
+// whose Bypass skips the lookup but still stores the fresh response.
+func (c *ResponseCache) format(ctx context.Context, formatter, language string, payload map[string]interface{}, fn func(context.Context, map[string]interface{}) (map[string]interface{}, error)) (map[string]interface{}, error) {
+	key, err := responseKey(formatter, language, payload)
+	if err != nil {
+		return fn(ctx, payload)
+	}
+
+	trace := cacheTraceFrom(ctx)
+	if trace == nil || !trace.Bypass {
+		b, ok, err := c.store.GetResponse(ctx, key)
+		if err != nil {
+			logctx.Warnf(ctx, "ai.client: response cache load failed for %s: %v", formatter, err)
+		}
+		var out map[string]interface{}
+		if ok && json.Unmarshal(b, &out) == nil {
+			logctx.Debugf(ctx, "ai.client: response cache hit for %s", formatter)
+			trace.hit(formatter)
+			return out, nil
+		}
+	}
+
+	out, err := fn(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+	if b, err := json.Marshal(out); err == nil {
+		if err := c.store.SetResponse(ctx, key, b, c.ttl); err != nil {
+			logctx.Warnf(ctx, "ai.client: response cache save failed for %s: %v", formatter, err)
+		}
+	}
+	return out, nil
+}
+
+// CacheTrace is carried by a job's context to bypass the response cache and
+// collect the formatters it answered.
+type CacheTrace struct {
+	// Bypass forces fresh AI calls, e.g. after the user's data changed.
+	Bypass bool
+
+	mu   sync.Mutex
+	hits []string
+}
+
+type cacheTraceKey struct{}
+
+// WithCacheTrace attaches t to ctx.
+func WithCacheTrace(ctx context.Context, t *CacheTrace) context.Context {
+	return context.WithValue(ctx, cacheTraceKey{}, t)
+}
+
+func cacheTraceFrom(ctx context.Context) *CacheTrace {
+	t, _ := ctx.Value(cacheTraceKey{}).(*CacheTrace)
+	return t
+}
+
+func (t *CacheTrace) hit(formatter string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.hits = append(t.hits, formatter)
+}
+
+// Hits returns the formatters answered from the cache, in call order.
+func (t *CacheTrace) Hits() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.hits...)
+}
+
+// MemoryResponseStore is an in-memory ResponseStore evicting the least
+// recently used entry beyond its capacity.
+type MemoryResponseStore struct {
+	size int
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type memoryResponse struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryResponseStore keeps at most size responses.
+func NewMemoryResponseStore(size int) *MemoryResponseStore {
+	if size <= 0 {
+		size = DefaultResponseCacheSize
+	}
+	return &MemoryResponseStore{size: size, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+func (s *MemoryResponseStore) GetResponse(_ context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	el, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	e := el.Value.(*memoryResponse)
+	if !e.expires.IsZero() && time.Now().After(e.expires) {
+		s.order.Remove(el)
+		delete(s.entries, key)
+		return nil, false, nil
+	}
+	s.order.MoveToFront(el)
+	return e.value, true, nil
+}
+
+func (s *MemoryResponseStore) SetResponse(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var expires time.Time
+	if ttl > 0 {
+		expires = time.Now().Add(ttl)
+	}
+	if el, ok := s.entries[key]; ok {
+		el.Value = &memoryResponse{key: key, value: value, expires: expires}
+		s.order.MoveToFront(el)
+		return nil
+	}
+	s.entries[key] = s.order.PushFront(&memoryResponse{key: key, value: value, expires: expires})
+	for s.order.Len() > s.size {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*memoryResponse).key)
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+// Package auth verifies the bearer JWTs of the HTTP and gRPC APIs and
+// carries the authenticated caller through a context.
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config configures bearer JWT authentication of the HTTP and gRPC APIs.
+type Config struct {
+	// Algorithm is "HS256", verified with Secret, or "RS256", verified
+	// with PublicKey. Tokens signed with any other algorithm are rejected.
+	Algorithm string
+	Secret    []byte
+	PublicKey *rsa.PublicKey
+	// Issuer and Audience, when set, must match the iss and aud claims.
+	Issuer   string
+	Audience string
+	// AdminRole in the role or roles claim, or "admin": true, lets the
+	// caller act for every user and use the admin endpoints.
+	AdminRole string
+}
+
+// defaultAdminRole is the AdminRole unless JWT_ADMIN_ROLE is set.
+const defaultAdminRole = "admin"
+
+// jwtLeeway absorbs clock skew when checking exp and nbf.
+const jwtLeeway = 30 * time.Second
+
+// ConfigFromEnv reads the auth settings. Auth is off, and a nil config
+// returned, unless AUTH_ENABLED is true, which lets local setups run
+// without tokens. JWT_ALGORITHM picks HS256 (the default, with JWT_SECRET)
+// or RS256 (with a PEM public key in JWT_PUBLIC_KEY or the file
+// JWT_PUBLIC_KEY_FILE); JWT_ISSUER, JWT_AUDIENCE and JWT_ADMIN_ROLE are
+// optional.
+func ConfigFromEnv() (*Config, error) {
+	if on, _ := strconv.ParseBool(os.Getenv("AUTH_ENABLED")); !on {
+		return nil, nil
+	}
+	cfg := &Config{
+		Algorithm: strings.ToUpper(os.Getenv("JWT_ALGORITHM")),
+		Issuer:    os.Getenv("JWT_ISSUER"),
+		Audience:  os.Getenv("JWT_AUDIENCE"),
+		AdminRole: os.Getenv("JWT_ADMIN_ROLE"),
+	}
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = "HS256"
+	}
+	if cfg.AdminRole == "" {
+		cfg.AdminRole = defaultAdminRole
+	}
+
+	switch cfg.Algorithm {
+	case "HS256":
+		cfg.Secret = []byte(os.Getenv("JWT_SECRET"))
+		if len(cfg.Secret) == 0 {
+			return nil, errors.New("auth: JWT_SECRET is required for HS256")
+		}
+	case "RS256":
+		pemBytes := []byte(os.Getenv("JWT_PUBLIC_KEY"))
+		if path := os.Getenv("JWT_PUBLIC_KEY_FILE"); len(pemBytes) == 0 && path != "" {
+			b, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("auth: read JWT_PUBLIC_KEY_FILE: %w", err)
+			}
+			pemBytes = b
+		}
+		key, err := parseRSAPublicKey(pemBytes)
+		if err != nil {
+			return nil, err
+		}
+		cfg.PublicKey = key
+	default:
+		return nil, fmt.Errorf("auth: unsupported JWT_ALGORITHM %q", cfg.Algorithm)
+	}
+	return cfg, nil
+}
+
+// parseRSAPublicKey reads a PKIX ("PUBLIC KEY") or PKCS#1 ("RSA PUBLIC
+// KEY") PEM block.
+func parseRSAPublicKey(pemBytes []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("auth: JWT_PUBLIC_KEY is not a PEM public key")
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("auth: parse public key: %w", err)
+	}
+	key, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("auth: public key is not an RSA key")
+	}
+	return key, nil
+}
+
+// Principal is the authenticated caller.
+type Principal struct {
+	// UserID is the token's sub claim.
+	UserID string
+	Admin  bool
+}
+
+// Allows reports whether p may act for userID: the token's subject and
+// admins may. A nil p, meaning auth is off, allows everyone.
+func (p *Principal) Allows(userID string) bool {
+	return p == nil || p.Admin || strings.EqualFold(p.UserID, userID)
+}
+
+type principalKey struct{}
+
+// NewContext returns ctx carrying p.
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the caller stored by NewContext, or nil.
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// Verify checks the token's signature, algorithm, time claims, issuer and
+// audience, and returns the caller it names. Tokens without an exp claim
+// are rejected, so a leaked token cannot stay valid forever.
+func (cfg *Config) Verify(token string, now time.Time) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("header: %w", err)
+	}
+	// the configured algorithm is the only one accepted, so a token cannot
+	// pick "none" or verify an RS256 key as an HMAC secret
+	if header.Alg != cfg.Algorithm {
+		return nil, fmt.Errorf("unexpected alg %q", header.Alg)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("signature: %w", err)
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+	switch cfg.Algorithm {
+	case "HS256":
+		mac := hmac.New(sha256.New, cfg.Secret)
+		mac.Write(signed)
+		if !hmac.Equal(sig, mac.Sum(nil)) {
+			return nil, errors.New("bad signature")
+		}
+	case "RS256":
+		sum := sha256.Sum256(signed)
+		if err := rsa.VerifyPKCS1v15(cfg.PublicKey, crypto.SHA256, sum[:], sig); err != nil {
+			return nil, errors.New("bad signature")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported alg %q", cfg.Algorithm)
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("claims: %w", err)
+	}
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return nil, errors.New("missing exp")
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(jwtLeeway)) {
+		return nil, errors.New("token expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(jwtLeeway).Before(time.Unix(int64(nbf), 0)) {
+		return nil, errors.New("token not valid yet")
+	}
+	if cfg.Issuer != "" && claims["iss"] != cfg.Issuer {
+		return nil, errors.New("unexpected issuer")
+	}
+	if cfg.Audience != "" && !hasClaimValue(claims["aud"], cfg.Audience) {
+		return nil, errors.New("unexpected audience")
+	}
+	sub, _ := claims["sub"].(string)
+	if sub == "" {
+		return nil, errors.New("missing sub")
+	}
+
+	admin := claims["admin"] == true || hasClaimValue(claims["role"], cfg.AdminRole) || hasClaimValue(claims["roles"], cfg.AdminRole)
+	return &Principal{UserID: sub, Admin: admin}, nil
+}
+
+// decodeSegment decodes a base64url JSON segment of a token into v.
+func decodeSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// hasClaimValue reports whether a string or string-list claim holds want.
+func hasClaimValue(claim interface{}, want string) bool {
+	switch v := claim.(type) {
+	case string:
+		return v == want
+	case []interface{}:
+		for _, it := range v {
+			if it == want {
+				return true
+			}
+		}
+	}
+	return false
+}
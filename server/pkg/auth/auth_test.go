@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testNow = time.Unix(1_700_000_000, 0)
+
+// sign returns a token with the given header alg and claims, signed with
+// HS256 under secret or RS256 under key, whichever is set. An unsigned
+// token has an empty signature.
+func sign(t *testing.T, alg string, claims map[string]interface{}, secret []byte, key *rsa.PrivateKey) string {
+	t.Helper()
+	seg := func(v interface{}) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	signed := seg(map[string]string{"alg": alg, "typ": "JWT"}) + "." + seg(claims)
+	var sig []byte
+	switch {
+	case secret != nil:
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	case key != nil:
+		sum := sha256.Sum256([]byte(signed))
+		s, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig = s
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// claimsWith returns valid claims for user "ana" with the changes applied;
+// a nil value deletes the claim.
+func claimsWith(changes map[string]interface{}) map[string]interface{} {
+	claims := map[string]interface{}{
+		"sub": "ana",
+		"iss": "portfolio",
+		"aud": "resume-generator",
+		"exp": testNow.Add(time.Hour).Unix(),
+	}
+	for k, v := range changes {
+		if v == nil {
+			delete(claims, k)
+			continue
+		}
+		claims[k] = v
+	}
+	return claims
+}
+
+func TestVerify(t *testing.T) {
+	secret := []byte("s3cret")
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hs := &Config{Algorithm: "HS256", Secret: secret, Issuer: "portfolio", Audience: "resume-generator", AdminRole: "admin"}
+	rs := &Config{Algorithm: "RS256", PublicKey: &key.PublicKey, AdminRole: "admin"}
+
+	tests := []struct {
+		name    string
+		cfg     *Config
+		token   string
+		want    *Principal
+		wantErr string
+	}{
+		{name: "hs256", cfg: hs, token: sign(t, "HS256", claimsWith(nil), secret, nil), want: &Principal{UserID: "ana"}},
+		{name: "rs256", cfg: rs, token: sign(t, "RS256", claimsWith(nil), nil, key), want: &Principal{UserID: "ana"}},
+		{name: "aud list", cfg: hs, token: sign(t, "HS256", claimsWith(map[string]interface{}{"aud": []string{"billing", "resume-generator"}}), secret, nil), want: &Principal{UserID: "ana"}},
+		{name: "admin claim", cfg: hs, token: sign(t, "HS256", claimsWith(map[string]interface{}{"admin": true}), secret, nil), want: &Principal{UserID: "ana", Admin: true}},
+		{name: "admin role", cfg: hs, token: sign(t, "HS256", claimsWith(map[string]interface{}{"roles": []string{"user", "admin"}}), secret, nil), want: &Principal{UserID: "ana", Admin: true}},
+		{name: "other role", cfg: hs, token: sign(t, "HS256", claimsWith(map[string]interface{}{"role": "user"}), secret, nil), want: &Principal{UserID: "ana"}},
+
+		{name: "malformed", cfg: hs, token: "not-a-jwt", wantErr: "malformed token"},
+		{name: "alg none", cfg: hs, token: sign(t, "none", claimsWith(nil), nil, nil), wantErr: `unexpected alg "none"`},
+		{name: "alg mismatch", cfg: hs, token: sign(t, "RS256", claimsWith(nil), nil, key), wantErr: `unexpected alg "RS256"`},
+		// an HMAC over the token keyed with the public key must not pass
+		// an RS256 verifier
+		{name: "hs256 against rs256", cfg: rs, token: sign(t, "HS256", claimsWith(nil), []byte("public key bytes"), nil), wantErr: `unexpected alg "HS256"`},
+		{name: "bad hs signature", cfg: hs, token: sign(t, "HS256", claimsWith(nil), []byte("guessed"), nil), wantErr: "bad signature"},
+		{name: "bad rs signature", cfg: rs, token: sign(t, "RS256", claimsWith(nil), nil, otherKey), wantErr: "bad signature"},
+		{name: "tampered claims", cfg: hs, token: tamper(sign(t, "HS256", claimsWith(nil), secret, nil), claimsWith(map[string]interface{}{"sub": "bob"})), wantErr: "bad signature"},
+
+		{name: "no exp", cfg: hs, token: sign(t, "HS256", claimsWith(map[string]interface{}{"exp": nil}), secret, nil), wantErr: "missing exp"},
+		{name: "exp not a number", cfg: hs, token: sign(t, "HS256", claimsWith(map[string]interface{}{"exp": "tomorrow"}), secret, nil), wantErr: "missing exp"},
+		{name: "expired", cfg: hs, token: sign(t, "HS256", claimsWith(map[string]interface{}{"exp": testNow.Add(-time.Minute).Unix()}), secret, nil), wantErr: "token expired"},
+		{name: "expired within leeway", cfg: hs, token: sign(t, "HS256", claimsWith(map[string]interface{}{"exp": testNow.Add(-10 * time.Second).Unix()}), secret, nil), want: &Principal{UserID: "ana"}},
+		{name: "nbf in the future", cfg: hs, token: sign(t, "HS256", claimsWith(map[string]interface{}{"nbf": testNow.Add(time.Minute).Unix()}), secret, nil), wantErr: "token not valid yet"},
+		{name: "nbf within leeway", cfg: hs, token: sign(t, "HS256", claimsWith(map[string]interface{}{"nbf": testNow.Add(10 * time.Second).Unix()}), secret, nil), want: &Principal{UserID: "ana"}},
+
+		{name: "wrong iss", cfg: hs, token: sign(t, "HS256", claimsWith(map[string]interface{}{"iss": "evil"}), secret, nil), wantErr: "unexpected issuer"},
+		{name: "no iss", cfg: hs, token: sign(t, "HS256", claimsWith(map[string]interface{}{"iss": nil}), secret, nil), wantErr: "unexpected issuer"},
+		{name: "wrong aud", cfg: hs, token: sign(t, "HS256", claimsWith(map[string]interface{}{"aud": "billing"}), secret, nil), wantErr: "unexpected audience"},
+		{name: "wrong aud list", cfg: hs, token: sign(t, "HS256", claimsWith(map[string]interface{}{"aud": []string{"billing"}}), secret, nil), wantErr: "unexpected audience"},
+		{name: "missing sub", cfg: hs, token: sign(t, "HS256", claimsWith(map[string]interface{}{"sub": nil}), secret, nil), wantErr: "missing sub"},
+		{name: "empty sub", cfg: hs, token: sign(t, "HS256", claimsWith(map[string]interface{}{"sub": ""}), secret, nil), wantErr: "missing sub"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.cfg.Verify(tt.token, testNow)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Verify() = %+v, %v; want error %q", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if *got != *tt.want {
+				t.Errorf("Verify() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// tamper replaces the claims segment of token, keeping its signature.
+func tamper(token string, claims map[string]interface{}) string {
+	parts := strings.Split(token, ".")
+	b, _ := json.Marshal(claims)
+	parts[1] = base64.RawURLEncoding.EncodeToString(b)
+	return strings.Join(parts, ".")
+}
+
+func TestPrincipalAllows(t *testing.T) {
+	tests := []struct {
+		name string
+		p    *Principal
+		user string
+		want bool
+	}{
+		{"auth off", nil, "ana", true},
+		{"subject", &Principal{UserID: "Ana"}, "ana", true},
+		{"other user", &Principal{UserID: "ana"}, "bob", false},
+		{"admin", &Principal{UserID: "ana", Admin: true}, "bob", true},
+	}
+	for _, tt := range tests {
+		if got := tt.p.Allows(tt.user); got != tt.want {
+			t.Errorf("%s: Allows(%q) = %v, want %v", tt.name, tt.user, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,560 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Europass is a resume converted to the Europass CV (SkillsPassport v3)
+// profile, ready to be written as JSON or XML.
+type Europass struct {
+	doc europassPassport
+	// Unmapped names the resume sections and fields that had content but
+	// no Europass counterpart, e.g. "summary" or "meta.photo_url", so
+	// users know what to fill in by hand.
+	Unmapped []string
+}
+
+// ToEuropass converts a validated resume map into a Europass CV:
+//
+//   - meta becomes the identification (name, contact details, websites)
+//     and the headline
+//   - experience becomes work experience and education becomes education
+//   - skill groups and extras whose category names languages, such as
+//     "Languages" or "Idiomas", become language skills; "native" entries
+//     are mother tongues and a CEFR level (A1–C2) sets every proficiency
+//     field of a foreign language
+//   - the other skill groups become job-related skills; projects,
+//     publications and certifications become achievements
+//
+// Fields the resume does not have are omitted rather than guessed, and
+// everything left out is listed in Unmapped. locale is the document's
+// language, "en" when empty.
+func ToEuropass(resume map[string]interface{}, locale string) (*Europass, error) {
+	if resume == nil {
+		return nil, errors.New("export: resume is nil")
+	}
+	if locale == "" {
+		locale = "en"
+	}
+	e := &Europass{doc: europassPassport{
+		Locale:       locale,
+		DocumentInfo: europassDocumentInfo{DocumentType: "ECV", XSDVersion: "V3.3"},
+	}}
+	l := &e.doc.LearnerInfo
+	meta := asMap(resume["meta"])
+	l.Identification = europassIdentification(meta)
+	if headline := asString(meta["headline"]); headline != "" {
+		l.Headline = &europassHeadline{
+			Type:        europassCode{Code: "position"},
+			Description: europassLabel{Label: headline},
+		}
+	}
+	if asString(meta["photo_url"]) != "" {
+		// Europass embeds the photo's bytes, not a link
+		e.unmapped("meta.photo_url")
+	}
+
+	for _, r := range asSlice(resume["experience"]) {
+		role := asMap(r)
+		if role == nil {
+			continue
+		}
+		l.WorkExperience = append(l.WorkExperience, europassWork{
+			Period:     europassPeriodOf(asString(role["period"])),
+			Position:   optLabel(asString(role["title"])),
+			Activities: europassActivities(asString(role["summary"]), asStrings(role["bullets"])),
+			Employer:   optName(asString(role["company"])),
+		})
+	}
+
+	for _, ed := range asSlice(resume["education"]) {
+		em := asMap(ed)
+		if em == nil {
+			continue
+		}
+		item := europassEducation{
+			Title:        asString(em["degree"]),
+			Field:        optLabel(asString(em["field"])),
+			Organisation: optName(asString(em["institution"])),
+		}
+		from := europassDateOf(jsonResumeDate(asString(em["start_date"])))
+		to := europassDateOf(jsonResumeDate(asString(em["end_date"])))
+		if from != nil || to != nil {
+			item.Period = &europassPeriod{From: from, To: to}
+		}
+		if asString(em["gpa"]) != "" {
+			e.unmapped("education.gpa")
+		}
+		l.Education = append(l.Education, item)
+	}
+
+	e.skills(resume)
+	e.achievements(resume)
+
+	if asString(resume["summary"]) != "" {
+		e.unmapped("summary")
+	}
+	snap := asMap(resume["snapshot"])
+	for _, k := range []string{"achievements", "selected_projects"} {
+		if len(asSlice(snap[k])) > 0 || len(asStrings(snap[k])) > 0 {
+			e.unmapped("snapshot." + k)
+		}
+	}
+	return e, nil
+}
+
+// JSON encodes the CV as Europass JSON. The HTML of the rich-text fields
+// is written as is rather than \u-escaped.
+func (e *Europass) JSON() ([]byte, error) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(map[string]interface{}{"SkillsPassport": e.doc}); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// XML encodes the CV as a Europass XML document.
+func (e *Europass) XML() ([]byte, error) {
+	b, err := xml.MarshalIndent(e.doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), b...), nil
+}
+
+func (e *Europass) unmapped(field string) {
+	for _, f := range e.Unmapped {
+		if f == field {
+			return
+		}
+	}
+	e.Unmapped = append(e.Unmapped, field)
+}
+
+func europassIdentification(meta map[string]interface{}) europassIdentity {
+	var id europassIdentity
+	if first, last := splitName(asString(meta["name"])); first != "" {
+		id.PersonName = &europassPersonName{FirstName: first, Surname: last}
+	}
+
+	contact := asMap(meta["contact"])
+	info := &europassContactInfo{}
+	if loc := asString(contact["location"]); loc != "" {
+		parts := strings.Split(loc, ",")
+		addr := &europassAddressContact{Municipality: strings.TrimSpace(parts[0])}
+		if len(parts) > 1 {
+			addr.Country = optLabel(strings.TrimSpace(parts[len(parts)-1]))
+		}
+		info.Address = &europassAddress{Contact: addr}
+	}
+	if email := asString(contact["email"]); email != "" {
+		info.Email = &europassContact{Contact: email}
+	}
+	if phone := asString(contact["phone"]); phone != "" {
+		info.Telephone = []europassContact{{Contact: phone}}
+	}
+	var sites []string
+	for _, v := range []interface{}{contact["website"], contact["github"]} {
+		sites = appendURL(sites, asString(v))
+	}
+	for _, p := range socialProfiles(meta, contact) {
+		sites = appendURL(sites, asString(asMap(p)["url"]))
+	}
+	for _, s := range sites {
+		info.Website = append(info.Website, europassContact{Contact: s})
+	}
+	if info.Address != nil || info.Email != nil || len(info.Telephone) > 0 || len(info.Website) > 0 {
+		id.ContactInfo = info
+	}
+	return id
+}
+
+// appendURL appends an absolute URL to list unless it is already there.
+func appendURL(list []string, s string) []string {
+	if !absoluteURL(s) {
+		return list
+	}
+	for _, it := range list {
+		if strings.EqualFold(strings.TrimSuffix(it, "/"), strings.TrimSuffix(s, "/")) {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+// skills maps language groups to linguistic skills and the other groups,
+// or snapshot.tech without any, to job-related skills.
+func (e *Europass) skills(resume map[string]interface{}) {
+	var languages, jobRelated []string
+	for _, g := range asSlice(resume["skills"]) {
+		gm := asMap(g)
+		cat, items := asString(gm["category"]), asStrings(gm["items"])
+		if len(items) == 0 {
+			continue
+		}
+		if languageCategory(cat) {
+			languages = append(languages, items...)
+			continue
+		}
+		line := strings.Join(items, ", ")
+		if cat != "" {
+			line = cat + ": " + line
+		}
+		jobRelated = append(jobRelated, line)
+	}
+	if len(jobRelated) == 0 {
+		if tech := splitList(asString(asMap(resume["snapshot"])["tech"])); len(tech) > 0 {
+			jobRelated = append(jobRelated, strings.Join(tech, ", "))
+		}
+	}
+
+	otherExtras := false
+	for _, x := range asSlice(resume["extras"]) {
+		xm := asMap(x)
+		text := asString(xm["text"])
+		if text == "" {
+			continue
+		}
+		if languageCategory(asString(xm["category"])) {
+			languages = append(languages, splitList(text)...)
+		} else {
+			otherExtras = true
+		}
+	}
+	if otherExtras {
+		e.unmapped("extras")
+	}
+
+	ling := &europassLinguistic{}
+	for _, s := range languages {
+		name, level, native := parseLanguage(s)
+		if name == "" {
+			continue
+		}
+		desc := europassLabel{Label: name}
+		if native {
+			ling.MotherTongue = append(ling.MotherTongue, europassLanguage{Description: desc})
+			continue
+		}
+		lang := europassLanguage{Description: desc}
+		if level != "" {
+			lang.ProficiencyLevel = &europassProficiency{level, level, level, level, level}
+		}
+		ling.ForeignLanguage = append(ling.ForeignLanguage, lang)
+	}
+
+	var sk europassSkills
+	if len(ling.MotherTongue) > 0 || len(ling.ForeignLanguage) > 0 {
+		sk.Linguistic = ling
+	}
+	if len(jobRelated) > 0 {
+		sk.JobRelated = &europassDescription{Description: htmlList(jobRelated)}
+	}
+	if sk.Linguistic != nil || sk.JobRelated != nil {
+		e.doc.LearnerInfo.Skills = &sk
+	}
+}
+
+// achievements maps projects, publications and certifications to
+// achievements with the matching Europass title code.
+func (e *Europass) achievements(resume map[string]interface{}) {
+	add := func(code, label, description string) {
+		e.doc.LearnerInfo.Achievement = append(e.doc.LearnerInfo.Achievement, europassAchievement{
+			Title:       europassCode{Code: code, Label: label},
+			Description: description,
+		})
+	}
+	for _, p := range asSlice(resume["projects"]) {
+		pm := asMap(p)
+		title := asString(pm["title"])
+		if title == "" {
+			continue
+		}
+		var parts []string
+		for _, s := range []string{asString(pm["description"]), asString(pm["stack"]), asString(pm["url"])} {
+			if s != "" {
+				parts = append(parts, s)
+			}
+		}
+		add("projects", title, europassActivities(strings.Join(parts, " · "), asStrings(pm["bullets"])))
+	}
+	for _, pub := range asStrings(resume["publications"]) {
+		add("publications", "", html.EscapeString(pub))
+	}
+	for _, c := range asSlice(resume["certifications"]) {
+		cm := asMap(c)
+		if cm == nil {
+			if s := asString(c); s != "" {
+				add("certifications", "", html.EscapeString(s))
+			}
+			continue
+		}
+		var parts []string
+		for _, k := range []string{"name", "issuer", "date", "url"} {
+			if s := asString(cm[k]); s != "" {
+				parts = append(parts, s)
+			}
+		}
+		if len(parts) > 0 {
+			add("certifications", "", europassActivities(strings.Join(parts, " · "), nil))
+		}
+	}
+}
+
+// languageWords mark a skills or extras category as spoken languages; the
+// programmingWords exclude groups such as "Programming Languages".
+var (
+	languageWords    = []string{"language", "idioma", "langue", "sprache", "lingua", "lingue"}
+	programmingWords = []string{"programming", "programacao", "programacion", "programmation", "coding", "computer"}
+)
+
+func languageCategory(cat string) bool {
+	c := strings.ToLower(foldASCII(cat))
+	for _, w := range programmingWords {
+		if strings.Contains(c, w) {
+			return false
+		}
+	}
+	for _, w := range languageWords {
+		if strings.Contains(c, w) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	cefrLevel      = regexp.MustCompile(`(?i)\b([ABC][12])\b`)
+	nativeLanguage = regexp.MustCompile(`(?i)\b(nativ\w*|mother tongue|materna|maternelle|muttersprache)\b`)
+	languageEnd    = regexp.MustCompile(`\s*[(:–—]|\s+-\s+`)
+)
+
+// parseLanguage reads an entry such as "English (C1)" or "Português –
+// nativo" into the language name, its CEFR level and whether it is a
+// mother tongue. Levels given in words, such as "fluent", are not
+// converted.
+func parseLanguage(s string) (name, level string, native bool) {
+	name = s
+	if loc := languageEnd.FindStringIndex(s); loc != nil {
+		name = s[:loc[0]]
+	}
+	name = strings.TrimSpace(name)
+	if m := cefrLevel.FindStringSubmatch(s); m != nil {
+		level = strings.ToUpper(m[1])
+	}
+	return name, level, nativeLanguage.MatchString(s)
+}
+
+// europassActivities renders a summary and bullets as the HTML the
+// Europass rich-text fields hold.
+func europassActivities(summary string, bullets []string) string {
+	var b strings.Builder
+	if summary != "" {
+		b.WriteString("<p>" + html.EscapeString(summary) + "</p>")
+	}
+	b.WriteString(htmlList(bullets))
+	return b.String()
+}
+
+func htmlList(items []string) string {
+	if len(items) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("<ul>")
+	for _, it := range items {
+		b.WriteString("<li>" + html.EscapeString(it) + "</li>")
+	}
+	b.WriteString("</ul>")
+	return b.String()
+}
+
+func europassPeriodOf(period string) *europassPeriod {
+	start, end, current := splitPeriod(period)
+	p := &europassPeriod{From: europassDateOf(start), To: europassDateOf(end), Current: current}
+	if p.From == nil && p.To == nil && !current {
+		return nil
+	}
+	return p
+}
+
+// europassDateOf converts a jsonResumeDate date; it returns nil for "".
+func europassDateOf(d string) *europassDate {
+	if d == "" {
+		return nil
+	}
+	var out europassDate
+	fmt.Sscanf(strings.ReplaceAll(d, "-", " "), "%d %d %d", &out.Year, &out.Month, &out.Day)
+	return &out
+}
+
+func optLabel(s string) *europassLabel {
+	if s == "" {
+		return nil
+	}
+	return &europassLabel{Label: s}
+}
+
+func optName(s string) *europassName {
+	if s == "" {
+		return nil
+	}
+	return &europassName{Name: s}
+}
+
+// The types below follow the element names of the Europass XML schema;
+// Europass JSON uses the same names, with lists as arrays instead of
+// *List wrapper elements.
+
+type europassPassport struct {
+	XMLName      xml.Name             `xml:"http://europass.cedefop.europa.eu/Europass SkillsPassport" json:"-"`
+	Locale       string               `xml:"locale,attr" json:"Locale"`
+	DocumentInfo europassDocumentInfo `xml:"DocumentInfo" json:"DocumentInfo"`
+	LearnerInfo  europassLearnerInfo  `xml:"LearnerInfo" json:"LearnerInfo"`
+}
+
+type europassDocumentInfo struct {
+	DocumentType string `xml:"DocumentType" json:"DocumentType"`
+	XSDVersion   string `xml:"XSDVersion" json:"XSDVersion"`
+}
+
+type europassLearnerInfo struct {
+	Identification europassIdentity      `xml:"Identification" json:"Identification"`
+	Headline       *europassHeadline     `xml:"Headline,omitempty" json:"Headline,omitempty"`
+	WorkExperience []europassWork        `xml:"WorkExperienceList>WorkExperience,omitempty" json:"WorkExperience,omitempty"`
+	Education      []europassEducation   `xml:"EducationList>Education,omitempty" json:"Education,omitempty"`
+	Skills         *europassSkills       `xml:"Skills,omitempty" json:"Skills,omitempty"`
+	Achievement    []europassAchievement `xml:"AchievementList>Achievement,omitempty" json:"Achievement,omitempty"`
+}
+
+type europassIdentity struct {
+	PersonName  *europassPersonName  `xml:"PersonName,omitempty" json:"PersonName,omitempty"`
+	ContactInfo *europassContactInfo `xml:"ContactInfo,omitempty" json:"ContactInfo,omitempty"`
+}
+
+type europassPersonName struct {
+	FirstName string `xml:"FirstName,omitempty" json:"FirstName,omitempty"`
+	Surname   string `xml:"Surname,omitempty" json:"Surname,omitempty"`
+}
+
+type europassContactInfo struct {
+	Address   *europassAddress  `xml:"Address,omitempty" json:"Address,omitempty"`
+	Email     *europassContact  `xml:"Email,omitempty" json:"Email,omitempty"`
+	Telephone []europassContact `xml:"TelephoneList>Telephone,omitempty" json:"Telephone,omitempty"`
+	Website   []europassContact `xml:"WebsiteList>Website,omitempty" json:"Website,omitempty"`
+}
+
+type europassAddress struct {
+	Contact *europassAddressContact `xml:"Contact" json:"Contact"`
+}
+
+type europassAddressContact struct {
+	Municipality string         `xml:"Municipality,omitempty" json:"Municipality,omitempty"`
+	Country      *europassLabel `xml:"Country,omitempty" json:"Country,omitempty"`
+}
+
+type europassContact struct {
+	Contact string `xml:"Contact" json:"Contact"`
+}
+
+type europassHeadline struct {
+	Type        europassCode  `xml:"Type" json:"Type"`
+	Description europassLabel `xml:"Description" json:"Description"`
+}
+
+type europassCode struct {
+	Code  string `xml:"Code,omitempty" json:"Code,omitempty"`
+	Label string `xml:"Label,omitempty" json:"Label,omitempty"`
+}
+
+type europassLabel struct {
+	Label string `xml:"Label" json:"Label"`
+}
+
+type europassName struct {
+	Name string `xml:"Name" json:"Name"`
+}
+
+type europassWork struct {
+	Period     *europassPeriod `xml:"Period,omitempty" json:"Period,omitempty"`
+	Position   *europassLabel  `xml:"Position,omitempty" json:"Position,omitempty"`
+	Activities string          `xml:"Activities,omitempty" json:"Activities,omitempty"`
+	Employer   *europassName   `xml:"Employer,omitempty" json:"Employer,omitempty"`
+}
+
+type europassEducation struct {
+	Period       *europassPeriod `xml:"Period,omitempty" json:"Period,omitempty"`
+	Title        string          `xml:"Title,omitempty" json:"Title,omitempty"`
+	Organisation *europassName   `xml:"Organisation,omitempty" json:"Organisation,omitempty"`
+	Field        *europassLabel  `xml:"Field,omitempty" json:"Field,omitempty"`
+}
+
+type europassPeriod struct {
+	From    *europassDate `xml:"From,omitempty" json:"From,omitempty"`
+	To      *europassDate `xml:"To,omitempty" json:"To,omitempty"`
+	Current bool          `xml:"Current,omitempty" json:"Current,omitempty"`
+}
+
+// europassDate is a partial date: Month and Day are 0 when unknown.
+type europassDate struct {
+	Year  int `json:"Year"`
+	Month int `json:"Month,omitempty"`
+	Day   int `json:"Day,omitempty"`
+}
+
+// MarshalXML writes the date as attributes in the schema's XML date
+// types: year="2021" month="--03" day="---09".
+func (d europassDate) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "year"}, Value: fmt.Sprintf("%04d", d.Year)})
+	if d.Month > 0 {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "month"}, Value: fmt.Sprintf("--%02d", d.Month)})
+	}
+	if d.Day > 0 {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "day"}, Value: fmt.Sprintf("---%02d", d.Day)})
+	}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	return enc.EncodeToken(start.End())
+}
+
+type europassSkills struct {
+	Linguistic *europassLinguistic  `xml:"Linguistic,omitempty" json:"Linguistic,omitempty"`
+	JobRelated *europassDescription `xml:"JobRelated,omitempty" json:"JobRelated,omitempty"`
+}
+
+type europassLinguistic struct {
+	MotherTongue    []europassLanguage `xml:"MotherTongueList>MotherTongue,omitempty" json:"MotherTongue,omitempty"`
+	ForeignLanguage []europassLanguage `xml:"ForeignLanguageList>ForeignLanguage,omitempty" json:"ForeignLanguage,omitempty"`
+}
+
+type europassLanguage struct {
+	Description      europassLabel        `xml:"Description" json:"Description"`
+	ProficiencyLevel *europassProficiency `xml:"ProficiencyLevel,omitempty" json:"ProficiencyLevel,omitempty"`
+}
+
+type europassProficiency struct {
+	Listening         string `xml:"Listening" json:"Listening"`
+	Reading           string `xml:"Reading" json:"Reading"`
+	SpokenInteraction string `xml:"SpokenInteraction" json:"SpokenInteraction"`
+	SpokenProduction  string `xml:"SpokenProduction" json:"SpokenProduction"`
+	Writing           string `xml:"Writing" json:"Writing"`
+}
+
+type europassDescription struct {
+	Description string `xml:"Description" json:"Description"`
+}
+
+type europassAchievement struct {
+	Title       europassCode `xml:"Title" json:"Title"`
+	Description string       `xml:"Description,omitempty" json:"Description,omitempty"`
+}
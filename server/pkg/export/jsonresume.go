@@ -0,0 +1,391 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// JSONResumeSchemaURL is the published JSON Resume schema the export
+// follows; it is recorded in the output's "$schema".
+const JSONResumeSchemaURL = "https://raw.githubusercontent.com/jsonresume/resume-schema/v1.0.0/schema.json"
+
+// ToJSONResume converts a validated resume map into the JSON Resume
+// (jsonresume.org) format:
+//
+//   - meta becomes basics, with social_links as profiles
+//   - skills become skills, one per category; without skills the
+//     comma-separated snapshot.tech becomes a single "Technologies" entry
+//   - experience becomes work, with period split into startDate/endDate
+//   - education, projects, publications and certifications map to the
+//     sections of the same meaning; extras become interests by category
+//
+// Dates are written as YYYY-MM-DD, YYYY-MM or YYYY. Periods that cannot be
+// read are left out rather than guessed. snapshot.achievements and
+// snapshot.selected_projects have no JSON Resume counterpart and are not
+// exported. Empty sections are omitted.
+func ToJSONResume(resume map[string]interface{}) (map[string]interface{}, error) {
+	if resume == nil {
+		return nil, errors.New("export: resume is nil")
+	}
+	out := map[string]interface{}{
+		"$schema": JSONResumeSchemaURL,
+		"basics":  jsonResumeBasics(asMap(resume["meta"]), asString(resume["summary"])),
+		"meta":    map[string]interface{}{"version": "v1.0.0"},
+	}
+	set := func(key string, items []interface{}) {
+		if len(items) > 0 {
+			out[key] = items
+		}
+	}
+
+	var work []interface{}
+	for _, r := range asSlice(resume["experience"]) {
+		role := asMap(r)
+		if role == nil {
+			continue
+		}
+		item := map[string]interface{}{}
+		putString(item, "name", role["company"])
+		putString(item, "position", role["title"])
+		putString(item, "summary", role["summary"])
+		putPeriod(item, asString(role["period"]))
+		if hl := asStrings(role["bullets"]); len(hl) > 0 {
+			item["highlights"] = hl
+		}
+		work = append(work, item)
+	}
+	set("work", work)
+
+	var education []interface{}
+	for _, e := range asSlice(resume["education"]) {
+		em := asMap(e)
+		if em == nil {
+			continue
+		}
+		item := map[string]interface{}{}
+		putString(item, "institution", em["institution"])
+		putString(item, "area", em["field"])
+		putString(item, "studyType", em["degree"])
+		putString(item, "score", em["gpa"])
+		if d := jsonResumeDate(asString(em["start_date"])); d != "" {
+			item["startDate"] = d
+		}
+		if d := jsonResumeDate(asString(em["end_date"])); d != "" {
+			item["endDate"] = d
+		}
+		education = append(education, item)
+	}
+	set("education", education)
+
+	set("skills", jsonResumeSkills(resume))
+
+	var projects []interface{}
+	for _, pr := range asSlice(resume["projects"]) {
+		proj := asMap(pr)
+		if proj == nil {
+			continue
+		}
+		item := map[string]interface{}{}
+		putString(item, "name", proj["title"])
+		putString(item, "description", proj["description"])
+		putURL(item, "url", proj["url"])
+		if hl := asStrings(proj["bullets"]); len(hl) > 0 {
+			item["highlights"] = hl
+		}
+		if kw := splitList(asString(proj["stack"])); len(kw) > 0 {
+			item["keywords"] = kw
+		}
+		projects = append(projects, item)
+	}
+	set("projects", projects)
+
+	var publications []interface{}
+	for _, pub := range asStrings(resume["publications"]) {
+		publications = append(publications, map[string]interface{}{"name": pub})
+	}
+	set("publications", publications)
+
+	var certificates []interface{}
+	for _, ce := range asSlice(resume["certifications"]) {
+		item := map[string]interface{}{}
+		if cm := asMap(ce); cm != nil {
+			putString(item, "name", cm["name"])
+			putString(item, "issuer", cm["issuer"])
+			putURL(item, "url", cm["url"])
+			if d := jsonResumeDate(asString(cm["date"])); d != "" {
+				item["date"] = d
+			}
+		} else {
+			putString(item, "name", ce)
+		}
+		if len(item) > 0 {
+			certificates = append(certificates, item)
+		}
+	}
+	set("certificates", certificates)
+
+	set("interests", jsonResumeInterests(asSlice(resume["extras"])))
+	return out, nil
+}
+
+func jsonResumeBasics(meta map[string]interface{}, summary string) map[string]interface{} {
+	basics := map[string]interface{}{}
+	putString(basics, "name", meta["name"])
+	putString(basics, "label", meta["headline"])
+	putURL(basics, "image", meta["photo_url"])
+	putString(basics, "summary", summary)
+
+	contact := asMap(meta["contact"])
+	putString(basics, "email", contact["email"])
+	putString(basics, "phone", contact["phone"])
+	putURL(basics, "url", contact["website"])
+	if loc := asString(contact["location"]); loc != "" {
+		// "City, Region, Country": the schema has no free-text field, so
+		// the first part is the city and the rest the region
+		parts := strings.SplitN(loc, ",", 2)
+		location := map[string]interface{}{"city": strings.TrimSpace(parts[0])}
+		if len(parts) == 2 && strings.TrimSpace(parts[1]) != "" {
+			location["region"] = strings.TrimSpace(parts[1])
+		}
+		basics["location"] = location
+	}
+
+	if profiles := socialProfiles(meta, contact); len(profiles) > 0 {
+		basics["profiles"] = profiles
+	}
+	return basics
+}
+
+// socialProfiles lists meta.social_links, plus contact.github when it is
+// not among them, as profiles sorted by network.
+func socialProfiles(meta, contact map[string]interface{}) []interface{} {
+	links := map[string]string{}
+	for k, v := range asMap(meta["social_links"]) {
+		if s := asString(v); s != "" {
+			links[k] = s
+		}
+	}
+	if gh := asString(contact["github"]); gh != "" && links["github"] == "" {
+		links["github"] = gh
+	}
+	networks := make([]string, 0, len(links))
+	for k := range links {
+		networks = append(networks, k)
+	}
+	sort.Strings(networks)
+	var profiles []interface{}
+	for _, network := range networks {
+		u, err := url.Parse(links[network])
+		if err != nil || u.Host == "" {
+			continue
+		}
+		profile := map[string]interface{}{"network": networkName(network), "url": links[network]}
+		if segs := strings.Split(strings.Trim(u.Path, "/"), "/"); segs[len(segs)-1] != "" {
+			profile["username"] = strings.TrimPrefix(segs[len(segs)-1], "@")
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles
+}
+
+// networkNames spells the social_links keys whose name is not just the
+// key capitalized.
+var networkNames = map[string]string{
+	"github":        "GitHub",
+	"gitlab":        "GitLab",
+	"linkedin":      "LinkedIn",
+	"x":             "X",
+	"devto":         "DEV",
+	"dev":           "DEV",
+	"stackoverflow": "Stack Overflow",
+	"youtube":       "YouTube",
+}
+
+func networkName(key string) string {
+	if n, ok := networkNames[strings.ToLower(key)]; ok {
+		return n
+	}
+	r := []rune(key)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+// jsonResumeSkills maps the skill groups, or snapshot.tech when there are
+// none, to skills entries.
+func jsonResumeSkills(resume map[string]interface{}) []interface{} {
+	var skills []interface{}
+	for _, g := range asSlice(resume["skills"]) {
+		gm := asMap(g)
+		items := asStrings(gm["items"])
+		if len(items) == 0 {
+			continue
+		}
+		skill := map[string]interface{}{"keywords": items}
+		putString(skill, "name", gm["category"])
+		skills = append(skills, skill)
+	}
+	if len(skills) > 0 {
+		return skills
+	}
+	if kw := splitList(asString(asMap(resume["snapshot"])["tech"])); len(kw) > 0 {
+		skills = append(skills, map[string]interface{}{"name": "Technologies", "keywords": kw})
+	}
+	return skills
+}
+
+// jsonResumeInterests groups extras by category, keeping the order in
+// which categories first appear.
+func jsonResumeInterests(extras []interface{}) []interface{} {
+	var order []string
+	byCategory := map[string][]string{}
+	for _, e := range extras {
+		cat, text := "", asString(e)
+		if em := asMap(e); em != nil {
+			cat, text = asString(em["category"]), asString(em["text"])
+		}
+		if text == "" {
+			continue
+		}
+		if _, seen := byCategory[cat]; !seen {
+			order = append(order, cat)
+		}
+		byCategory[cat] = append(byCategory[cat], text)
+	}
+	var interests []interface{}
+	for _, cat := range order {
+		interest := map[string]interface{}{"keywords": byCategory[cat]}
+		putString(interest, "name", cat)
+		interests = append(interests, interest)
+	}
+	return interests
+}
+
+func putString(m map[string]interface{}, key string, v interface{}) {
+	if s := asString(v); s != "" {
+		m[key] = s
+	}
+}
+
+// putURL sets key only for absolute URLs, which the schema requires.
+func putURL(m map[string]interface{}, key string, v interface{}) {
+	if s := asString(v); absoluteURL(s) {
+		m[key] = s
+	}
+}
+
+func absoluteURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+// splitList splits a comma, semicolon, pipe or bullet separated list.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool { return strings.ContainsRune(",;|·•", r) }) {
+		if part = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(part), ".")); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+var (
+	// periodSeparator splits "Jan 2020 – Present" and "2019 to 2021";
+	// a plain hyphen only counts with spaces around it so ISO dates stay
+	// whole.
+	periodSeparator = regexp.MustCompile(`\s+[-–—]\s+|\s*[–—]\s*|\s+(?i:to|até|a)\s+`)
+	isoDate         = regexp.MustCompile(`^(\d{4})(?:-(\d{1,2}))?(?:-(\d{1,2}))?$`)
+	monthYear       = regexp.MustCompile(`^(\d{1,2})[/.](\d{4})$`)
+	namedMonthYear  = regexp.MustCompile(`^(\pL+)\.?,?\s+(\d{4})$`)
+)
+
+// monthPrefixes maps the first three letters of month names in English,
+// Portuguese and Spanish to the month number.
+var monthPrefixes = map[string]int{
+	"jan": 1, "ene": 1, "feb": 2, "fev": 2, "mar": 3, "apr": 4, "abr": 4,
+	"may": 5, "mai": 5, "jun": 6, "jul": 7, "aug": 8, "ago": 8,
+	"sep": 9, "set": 9, "oct": 10, "out": 10, "nov": 11,
+	"dec": 12, "dez": 12, "dic": 12,
+}
+
+// putPeriod sets startDate and endDate from a period such as
+// "Mar 2021 – Present"; an ongoing role has no endDate.
+func putPeriod(m map[string]interface{}, period string) {
+	start, end, _ := splitPeriod(period)
+	if start != "" {
+		m["startDate"] = start
+	}
+	if end != "" {
+		m["endDate"] = end
+	}
+}
+
+// splitPeriod returns the start and end of a period as jsonResumeDate
+// dates. current is set when the period has an end that is not a date,
+// such as "Present".
+func splitPeriod(period string) (start, end string, current bool) {
+	if strings.TrimSpace(period) == "" {
+		return "", "", false
+	}
+	parts := periodSeparator.Split(period, 2)
+	start = jsonResumeDate(parts[0])
+	if len(parts) == 2 {
+		end = jsonResumeDate(parts[1])
+		current = end == "" && strings.TrimSpace(parts[1]) != ""
+	}
+	return start, end, current
+}
+
+// jsonResumeDate returns s as YYYY-MM-DD, YYYY-MM or YYYY, or "" when s
+// is not a date, e.g. "Present".
+func jsonResumeDate(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 10 && s[4] == '-' && strings.ContainsAny(s[10:], "T ") {
+		// a timestamp: keep the date
+		s = s[:10]
+	}
+	if m := isoDate.FindStringSubmatch(s); m != nil {
+		out := m[1]
+		if m[2] != "" {
+			if !validMonth(m[2]) {
+				return m[1]
+			}
+			out += "-" + twoDigits(m[2])
+			if m[3] != "" {
+				out += "-" + twoDigits(m[3])
+			}
+		}
+		return out
+	}
+	if m := monthYear.FindStringSubmatch(s); m != nil && validMonth(m[1]) {
+		return m[2] + "-" + twoDigits(m[1])
+	}
+	if m := namedMonthYear.FindStringSubmatch(s); m != nil {
+		name := []rune(strings.ToLower(foldASCII(m[1])))
+		if len(name) >= 3 {
+			if month, ok := monthPrefixes[string(name[:3])]; ok {
+				return fmt.Sprintf("%s-%02d", m[2], month)
+			}
+		}
+		return m[2]
+	}
+	return ""
+}
+
+func validMonth(s string) bool {
+	var n int
+	_, err := fmt.Sscanf(s, "%d", &n)
+	return err == nil && n >= 1 && n <= 12
+}
+
+func twoDigits(s string) string {
+	if len(s) == 1 {
+		return "0" + s
+	}
+	return s
+}
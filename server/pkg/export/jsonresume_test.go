@@ -0,0 +1,167 @@
+package export
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+func exportResume() map[string]interface{} {
+	return map[string]interface{}{
+		"meta": map[string]interface{}{
+			"name":      "Ana Souza",
+			"headline":  "Staff Engineer",
+			"photo_url": "/photos/ana.png",
+			"contact": map[string]interface{}{
+				"email":    "ana@example.com",
+				"phone":    "+55 11 99999-0000",
+				"location": "São Paulo, SP, Brazil",
+				"website":  "ana.example.com",
+				"github":   "https://github.com/anasouza",
+			},
+			"social_links": map[string]interface{}{
+				"linkedin": "https://www.linkedin.com/in/anasouza/",
+				"medium":   "https://medium.com/@ana",
+				"broken":   "not a url",
+			},
+		},
+		"summary": "Builds payment platforms in Go.",
+		"snapshot": map[string]interface{}{
+			"tech":              "Go, PostgreSQL; Kafka",
+			"achievements":      []interface{}{"a", "b", "c"},
+			"selected_projects": []interface{}{"x", "y"},
+		},
+		"experience": []interface{}{
+			map[string]interface{}{"company": "PayCo", "title": "Staff Engineer", "period": "Mar 2021 – Present", "bullets": []interface{}{"Designed the ledger"}},
+			map[string]interface{}{"company": "ShipFast", "title": "Engineer", "period": "2017 - 2021-02"},
+			map[string]interface{}{"company": "Acme", "title": "Intern", "period": "a summer long ago"},
+		},
+		"education": []interface{}{
+			map[string]interface{}{"institution": "USP", "degree": "BSc", "field": "Computer Science", "start_date": "2012", "end_date": "2016-12-15T00:00:00Z"},
+		},
+		"projects": []interface{}{
+			map[string]interface{}{"title": "Ledger", "url": "https://github.com/anasouza/ledger", "stack": "Go · PostgreSQL", "description": "Double-entry ledger."},
+			map[string]interface{}{"title": "PIX Client", "url": "github.com/anasouza/pix"},
+		},
+		"publications": []interface{}{"Scaling ledgers — 2023"},
+		"certifications": []interface{}{
+			map[string]interface{}{"name": "CKA", "issuer": "CNCF", "date": "05/2022", "url": "https://www.cncf.io/certification/cka/"},
+			"AWS Solutions Architect",
+		},
+		"extras": []interface{}{
+			map[string]interface{}{"category": "Community", "text": "Go meetup organizer"},
+			map[string]interface{}{"category": "Languages", "text": "Portuguese"},
+			map[string]interface{}{"category": "Community", "text": "Mentor"},
+		},
+	}
+}
+
+func TestToJSONResumeValidatesAgainstSchema(t *testing.T) {
+	schemaPath, err := filepath.Abs("testdata/jsonresume.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewReferenceLoader("file://" + schemaPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, resume := range map[string]map[string]interface{}{
+		"full":    exportResume(),
+		"minimal": {"meta": map[string]interface{}{"name": "Ana"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			out, err := ToJSONResume(resume)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// validate what clients receive: the JSON encoding
+			b, err := json.Marshal(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := schema.Validate(gojsonschema.NewBytesLoader(b))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, e := range res.Errors() {
+				t.Errorf("schema violation: %s", e)
+			}
+		})
+	}
+}
+
+func TestToJSONResumeMapping(t *testing.T) {
+	out, err := ToJSONResume(exportResume())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := json.Marshal(out)
+	var got map[string]interface{}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		got  interface{}
+		want string
+	}{
+		{"basics", got["basics"], `{"email":"ana@example.com","label":"Staff Engineer","location":{"city":"São Paulo","region":"SP, Brazil"},"name":"Ana Souza","phone":"+55 11 99999-0000","profiles":[{"network":"GitHub","url":"https://github.com/anasouza","username":"anasouza"},{"network":"LinkedIn","url":"https://www.linkedin.com/in/anasouza/","username":"anasouza"},{"network":"Medium","url":"https://medium.com/@ana","username":"ana"}],"summary":"Builds payment platforms in Go."}`},
+		{"work", got["work"], `[{"highlights":["Designed the ledger"],"name":"PayCo","position":"Staff Engineer","startDate":"2021-03"},{"endDate":"2021-02","name":"ShipFast","position":"Engineer","startDate":"2017"},{"name":"Acme","position":"Intern"}]`},
+		{"education", got["education"], `[{"area":"Computer Science","endDate":"2016-12-15","institution":"USP","startDate":"2012","studyType":"BSc"}]`},
+		{"skills", got["skills"], `[{"keywords":["Go","PostgreSQL","Kafka"],"name":"Technologies"}]`},
+		{"projects", got["projects"], `[{"description":"Double-entry ledger.","keywords":["Go","PostgreSQL"],"name":"Ledger","url":"https://github.com/anasouza/ledger"},{"name":"PIX Client"}]`},
+		{"publications", got["publications"], `[{"name":"Scaling ledgers — 2023"}]`},
+		{"certificates", got["certificates"], `[{"date":"2022-05","issuer":"CNCF","name":"CKA","url":"https://www.cncf.io/certification/cka/"},{"name":"AWS Solutions Architect"}]`},
+		{"interests", got["interests"], `[{"keywords":["Go meetup organizer","Mentor"],"name":"Community"},{"keywords":["Portuguese"],"name":"Languages"}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			var want interface{}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tt.got, want) {
+				b, _ := json.Marshal(tt.got)
+				t.Errorf("%s =\n%s\nwant\n%s", tt.path, b, tt.want)
+			}
+		})
+	}
+
+	if _, err := ToJSONResume(nil); err == nil {
+		t.Error("ToJSONResume(nil) succeeded")
+	}
+}
+
+func TestSplitPeriod(t *testing.T) {
+	tests := []struct {
+		period     string
+		start, end string
+		current    bool
+	}{
+		{"", "", "", false},
+		{"2019", "2019", "", false},
+		{"2021 – Present", "2021", "", true},
+		{"Jan 2020 – Mar 2022", "2020-01", "2022-03", false},
+		{"jan. 2020 — atual", "2020-01", "", true},
+		{"Fevereiro 2018 até Dezembro 2019", "2018-02", "2019-12", false},
+		{"03/2019 - 11/2020", "2019-03", "2020-11", false},
+		{"2019-04-01 to 2020-01-31", "2019-04-01", "2020-01-31", false},
+		{"2019-13", "2019", "", false},
+		{"13/2019", "", "", false},
+		{"Brumaire 1799", "1799", "", false},
+		{"sometime", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.period, func(t *testing.T) {
+			start, end, current := splitPeriod(tt.period)
+			if start != tt.start || end != tt.end || current != tt.current {
+				t.Errorf("splitPeriod(%q) = %q, %q, %v; want %q, %q, %v", tt.period, start, end, current, tt.start, tt.end, tt.current)
+			}
+		})
+	}
+}
@@ -0,0 +1,316 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+
+	"resume-generator/pkg/ai/formatters"
+	"resume-generator/templates"
+)
+
+// LaTeXTemplate is the moderncv template RenderResumeLaTeX executes, read
+// from the templates directory so it can be customized.
+const LaTeXTemplate = "resume.tex.tmpl"
+
+// LaTeXOptions tune RenderResumeLaTeX.
+type LaTeXOptions struct {
+	// Paper is "A4" (the default), "Letter" or "Legal".
+	Paper string
+}
+
+// RenderResumeLaTeX renders the resume as a moderncv LaTeX document.
+// Every value is escaped with EscapeLaTeX, and URLs for \href, before the
+// template sees it, so AI output cannot inject commands. Sections and
+// labels follow RenderResumeText.
+func RenderResumeLaTeX(resume map[string]interface{}, labels map[string]string, opts LaTeXOptions) (string, error) {
+	if resume == nil {
+		return "", errors.New("export: resume is nil")
+	}
+	src, err := templates.ReadFile(LaTeXTemplate)
+	if err != nil {
+		return "", err
+	}
+	tpl, err := template.New(LaTeXTemplate).Delims("<<", ">>").Option("missingkey=zero").Parse(string(src))
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, latexData(resume, labels, opts)); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// latexDoc is the template data. Every string is already escaped.
+type latexDoc struct {
+	Paper                            string
+	FirstName, LastName              string
+	Headline, Location, Phone, Email string
+	Homepage, ExtraInfo              string
+	Social                           []latexSocial
+	Labels                           map[string]string
+	Summary, Tech                    string
+	Achievements, SelectedProjects   []string
+	Skills, Extras                   []latexItem
+	Experience, Education, Projects  []latexEntry
+	Publications                     []string
+	Certifications                   []latexEntry
+}
+
+type latexSocial struct {
+	Network, Account string
+}
+
+type latexItem struct {
+	Label, Text string
+}
+
+// latexEntry fills a moderncv \cventry: Period, Title and Organization,
+// then Detail and Grade, then Summary and Bullets as the description.
+type latexEntry struct {
+	Period, Title, Organization, Detail, Grade string
+	URL, URLText                               string
+	Summary                                    string
+	Bullets                                    []string
+}
+
+// latexPapers maps the paper sizes to moderncv class options.
+var latexPapers = map[string]string{"a4": "a4paper", "letter": "letterpaper", "legal": "legalpaper"}
+
+// moderncvNetworks maps social_links keys to the \social networks
+// moderncv links by account name.
+var moderncvNetworks = map[string]string{
+	"github": "github", "gitlab": "gitlab", "linkedin": "linkedin", "twitter": "twitter", "x": "twitter",
+}
+
+// latexAccount matches account names safe to pass to \social, which
+// builds a URL from them and typesets them unescaped, so an underscore
+// would break the document.
+var latexAccount = regexp.MustCompile(`^[A-Za-z0-9.-]+$`)
+
+func latexData(resume map[string]interface{}, labels map[string]string, opts LaTeXOptions) latexDoc {
+	esc := EscapeLaTeX
+	d := latexDoc{Paper: latexPapers[strings.ToLower(opts.Paper)], Labels: map[string]string{}}
+	if d.Paper == "" {
+		d.Paper = "a4paper"
+	}
+	for k, v := range formatters.GetDefaultLabels() {
+		if l := labels[k]; l != "" {
+			v = l
+		}
+		d.Labels[k] = esc(v)
+	}
+
+	meta := asMap(resume["meta"])
+	first, last := splitName(asString(meta["name"]))
+	d.FirstName, d.LastName = esc(first), esc(last)
+	d.Headline = esc(asString(meta["headline"]))
+	contact := asMap(meta["contact"])
+	d.Location = esc(asString(contact["location"]))
+	d.Phone = esc(asString(contact["phone"]))
+	d.Email = esc(asString(contact["email"]))
+	if site := asString(contact["website"]); absoluteURL(site) {
+		// moderncv prefixes the link with http:// itself
+		d.Homepage = EscapeLaTeXURL(strings.TrimPrefix(strings.TrimPrefix(site, "https://"), "http://"))
+	}
+	var extra []string
+	for _, p := range socialProfiles(meta, contact) {
+		pm := asMap(p)
+		network := moderncvNetworks[strings.ToLower(asString(pm["network"]))]
+		account := asString(pm["username"])
+		if network != "" && latexAccount.MatchString(account) {
+			d.Social = append(d.Social, latexSocial{network, account})
+		} else {
+			extra = append(extra, esc(asString(pm["url"])))
+		}
+	}
+	d.ExtraInfo = strings.Join(extra, `, `)
+
+	d.Summary = esc(asString(resume["summary"]))
+	snap := asMap(resume["snapshot"])
+	d.Tech = esc(asString(snap["tech"]))
+	d.Achievements = escapeAll(asStrings(snap["achievements"]))
+	d.SelectedProjects = escapeAll(asStrings(snap["selected_projects"]))
+
+	for _, g := range asSlice(resume["skills"]) {
+		gm := asMap(g)
+		if items := asStrings(gm["items"]); len(items) > 0 {
+			d.Skills = append(d.Skills, latexItem{esc(asString(gm["category"])), esc(strings.Join(items, ", "))})
+		}
+	}
+
+	for _, r := range asSlice(resume["experience"]) {
+		role := asMap(r)
+		if role == nil {
+			continue
+		}
+		d.Experience = append(d.Experience, latexEntry{
+			Period:       esc(asString(role["period"])),
+			Title:        esc(asString(role["title"])),
+			Organization: esc(asString(role["company"])),
+			Summary:      esc(asString(role["summary"])),
+			Bullets:      escapeAll(asStrings(role["bullets"])),
+		})
+	}
+
+	for _, e := range asSlice(resume["education"]) {
+		em := asMap(e)
+		if em == nil {
+			continue
+		}
+		d.Education = append(d.Education, latexEntry{
+			Period:       esc(strings.Trim(asString(em["start_date"])+" – "+asString(em["end_date"]), " –")),
+			Title:        esc(asString(em["degree"])),
+			Organization: esc(asString(em["institution"])),
+			Detail:       esc(asString(em["field"])),
+			Grade:        esc(asString(em["gpa"])),
+		})
+	}
+
+	for _, pr := range asSlice(resume["projects"]) {
+		proj := asMap(pr)
+		if proj == nil {
+			continue
+		}
+		entry := latexEntry{
+			Title:   esc(asString(proj["title"])),
+			Detail:  esc(asString(proj["stack"])),
+			Summary: esc(asString(proj["description"])),
+			Bullets: escapeAll(asStrings(proj["bullets"])),
+		}
+		entry.URL, entry.URLText = latexLink(asString(proj["url"]))
+		d.Projects = append(d.Projects, entry)
+	}
+
+	d.Publications = escapeAll(asStrings(resume["publications"]))
+
+	for _, x := range asSlice(resume["extras"]) {
+		if xm := asMap(x); xm != nil {
+			if text := asString(xm["text"]); text != "" {
+				d.Extras = append(d.Extras, latexItem{esc(asString(xm["category"])), esc(text)})
+			}
+		} else if s := asString(x); s != "" {
+			d.Extras = append(d.Extras, latexItem{"", esc(s)})
+		}
+	}
+
+	for _, c := range asSlice(resume["certifications"]) {
+		cm := asMap(c)
+		if cm == nil {
+			if s := asString(c); s != "" {
+				d.Certifications = append(d.Certifications, latexEntry{Title: esc(s)})
+			}
+			continue
+		}
+		entry := latexEntry{
+			Period:       esc(asString(cm["date"])),
+			Title:        esc(asString(cm["name"])),
+			Organization: esc(asString(cm["issuer"])),
+			Summary:      esc(asString(cm["description"])),
+		}
+		entry.URL, entry.URLText = latexLink(asString(cm["url"]))
+		d.Certifications = append(d.Certifications, entry)
+	}
+	return d
+}
+
+// latexLink returns the \href target and the escaped text shown for an
+// http(s) URL, or two empty strings.
+func latexLink(s string) (string, string) {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", ""
+	}
+	text := strings.TrimSuffix(u.Host+u.EscapedPath(), "/")
+	return EscapeLaTeXURL(s), EscapeLaTeX(text)
+}
+
+func escapeAll(items []string) []string {
+	out := make([]string, 0, len(items))
+	for _, it := range items {
+		if s := EscapeLaTeX(it); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// latexReplacer escapes the characters LaTeX treats specially. Brackets
+// are braced so text such as "[1] ..." after \item is not read as its
+// optional argument.
+var latexReplacer = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`{`, `\{`, `}`, `\}`,
+	`%`, `\%`, `&`, `\&`, `$`, `\$`, `#`, `\#`, `_`, `\_`,
+	`~`, `\textasciitilde{}`, `^`, `\textasciicircum{}`,
+	`<`, `\textless{}`, `>`, `\textgreater{}`, `|`, `\textbar{}`,
+	`"`, `\textquotedbl{}`,
+	`[`, `{[}`, `]`, `{]}`,
+)
+
+// EscapeLaTeX makes s safe to typeset as text: the special characters are
+// escaped, line breaks and runs of spaces become one space, and control,
+// formatting and emoji characters pdflatex cannot typeset are dropped.
+func EscapeLaTeX(s string) string {
+	return latexReplacer.Replace(strings.Join(strings.Fields(latexClean(s)), " "))
+}
+
+// EscapeLaTeXURL makes s safe as the URL argument of \href inside another
+// command's argument: spaces, non-ASCII and the characters hyperref cannot
+// take there are percent-encoded, and %, # and & are escaped.
+func EscapeLaTeXURL(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(latexClean(s)) {
+		switch {
+		case c == '%' || c == '#' || c == '&':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c <= ' ' || c >= utf8.RuneSelf || strings.IndexByte(`\{}^~"<>|`+"`", c) >= 0:
+			fmt.Fprintf(&b, `\%%%02X`, c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// latexClean drops the runes pdflatex cannot typeset: control and
+// formatting characters (zero-width joiners, bidi marks), variation
+// selectors, private-use and surrogate code points and emoji.
+// Whitespace is kept.
+func latexClean(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsSpace(r):
+			return ' '
+		case unicode.IsControl(r), unicode.In(r, unicode.Cf, unicode.Co, unicode.Cs),
+			r >= 0xFE00 && r <= 0xFE0F, // variation selectors
+			r >= 0x2600 && r <= 0x27BF, // miscellaneous symbols, dingbats
+			r >= 0x2B00 && r <= 0x2BFF, // arrows and symbols
+			r >= 0x1F000,               // emoji and other supplementary symbols
+			r == unicode.ReplacementChar:
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// splitName splits a full name into first names and the surname, the
+// last word; a single name is all first name.
+func splitName(name string) (first, last string) {
+	words := strings.Fields(name)
+	switch len(words) {
+	case 0:
+		return "", ""
+	case 1:
+		return words[0], ""
+	}
+	return strings.Join(words[:len(words)-1], " "), words[len(words)-1]
+}
@@ -0,0 +1,196 @@
+package export
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEscapeLaTeX(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"empty", "", ""},
+		{"plain", "São Paulo — Ünïcode", "São Paulo — Ünïcode"},
+		{"specials", "100% & $5_#1", `100\% \& \$5\_\#1`},
+		{"command", `\input{/etc/passwd}`, `\textbackslash{}input\{/etc/passwd\}`},
+		{"end document", `\end{document}`, `\textbackslash{}end\{document\}`},
+		{"write18", `\immediate\write18{rm -rf ~}`, `\textbackslash{}immediate\textbackslash{}write18\{rm -rf \textasciitilde{}\}`},
+		{"double backslash", `a\\b`, `a\textbackslash{}\textbackslash{}b`},
+		{"math", "x^2 ~ y", `x\textasciicircum{}2 \textasciitilde{} y`},
+		{"comparisons", "a < b > c | d", `a \textless{} b \textgreater{} c \textbar{} d`},
+		{"quotes", `"quoted"`, `\textquotedbl{}quoted\textquotedbl{}`},
+		{"item argument", "[1] Scaling Go", "{[}1{]} Scaling Go"},
+		{"unbalanced braces", "}}{", `\}\}\{`},
+		{"markdown", "**Go** _fast_", `**Go** \_fast\_`},
+		{"whitespace", "line1\n\n  line2\t end\r\n", "line1 line2 end"},
+		{"control characters", "nul\x00bell\x07", "nulbell"},
+		{"zero width", "a\u200bb\u200dc", "abc"},
+		{"bidi override", "\u202eevil\u202c", "evil"},
+		{"emoji", "Go 🚀 dev ☕\ufe0f", "Go dev"},
+		{"private use", "x\ue000y", "xy"},
+		{"replacement char", "bad \ufffd byte", "bad byte"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EscapeLaTeX(tt.in); got != tt.want {
+				t.Errorf("EscapeLaTeX(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEscapeLaTeXURL(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"https://ex.com/a-b_c.d?x=1", "https://ex.com/a-b_c.d?x=1"},
+		{"https://ex.com/a b?x=1&y=2#frag", `https://ex.com/a\%20b?x=1\&y=2\#frag`},
+		{"https://ex.com/100%", `https://ex.com/100\%`},
+		{`https://ex.com/{x}\`, `https://ex.com/\%7Bx\%7D\%5C`},
+		{`https://ex.com/"><`, `https://ex.com/\%22\%3E\%3C`},
+		{"https://ex.com/~u^`|", `https://ex.com/\%7Eu\%5E\%60\%7C`},
+		{"https://ex.com/ü", `https://ex.com/\%C3\%BC`},
+		{"https://ex.com/a\u200bb\nc", `https://ex.com/ab\%20c`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := EscapeLaTeXURL(tt.in); got != tt.want {
+				t.Errorf("EscapeLaTeXURL(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLaTeXLink(t *testing.T) {
+	tests := []struct {
+		in, url, text string
+	}{
+		{"https://github.com/ana_s/", "https://github.com/ana_s/", `github.com/ana\_s`},
+		{"http://ex.com", "http://ex.com", "ex.com"},
+		{"https://ex.com/a%20b", `https://ex.com/a\%20b`, `ex.com/a\%20b`},
+		{"javascript:alert(1)", "", ""},
+		{"ftp://ex.com/file", "", ""},
+		{"https:///path", "", ""},
+		{"ex.com", "", ""},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			u, text := latexLink(tt.in)
+			if u != tt.url || text != tt.text {
+				t.Errorf("latexLink(%q) = %q, %q; want %q, %q", tt.in, u, text, tt.url, tt.text)
+			}
+		})
+	}
+}
+
+func TestSplitName(t *testing.T) {
+	tests := []struct {
+		in, first, last string
+	}{
+		{"", "", ""},
+		{"  Ana ", "Ana", ""},
+		{"Ana Souza", "Ana", "Souza"},
+		{"Ana Maria  de Souza", "Ana Maria de", "Souza"},
+	}
+	for _, tt := range tests {
+		if first, last := splitName(tt.in); first != tt.first || last != tt.last {
+			t.Errorf("splitName(%q) = %q, %q; want %q, %q", tt.in, first, last, tt.first, tt.last)
+		}
+	}
+}
+
+// adversarial is AI output trying to break out of the LaTeX it is placed in.
+const adversarial = `}\end{document}\input{/etc/passwd}{ 100% & $x$ #1 a_b ^~ [opt] "q" 🚀`
+
+func TestRenderResumeLaTeXAdversarial(t *testing.T) {
+	resume := map[string]interface{}{
+		"meta": map[string]interface{}{
+			"name":     "Ana " + adversarial,
+			"headline": adversarial,
+			"contact": map[string]interface{}{
+				"email":    "ana_s@example.com",
+				"location": adversarial,
+				"website":  "https://ana.dev/a b}%",
+			},
+			"social_links": map[string]interface{}{
+				"github":   "https://github.com/ana-s",
+				"gitlab":   "https://gitlab.com/ana_s",
+				"linkedin": "https://www.linkedin.com/in/x}{/",
+			},
+		},
+		"summary": adversarial,
+		"snapshot": map[string]interface{}{
+			"tech":              adversarial,
+			"achievements":      []interface{}{adversarial, "[1] first", ""},
+			"selected_projects": []interface{}{adversarial},
+		},
+		"skills": []interface{}{
+			map[string]interface{}{"category": adversarial, "items": []interface{}{"C#", "F#", adversarial}},
+		},
+		"experience": []interface{}{
+			map[string]interface{}{"company": adversarial, "title": adversarial, "period": adversarial, "summary": adversarial, "bullets": []interface{}{adversarial}},
+		},
+		"education": []interface{}{
+			map[string]interface{}{"institution": adversarial, "degree": adversarial, "field": adversarial, "gpa": "9/10 (100%)"},
+		},
+		"projects": []interface{}{
+			map[string]interface{}{"title": adversarial, "url": "https://ex.com/}{\\input", "stack": adversarial, "description": adversarial},
+			map[string]interface{}{"title": "js", "url": "javascript:alert(1)"},
+		},
+		"publications":   []interface{}{adversarial},
+		"certifications": []interface{}{map[string]interface{}{"name": adversarial, "url": "https://ex.com/c#1"}, adversarial},
+		"extras":         []interface{}{map[string]interface{}{"category": adversarial, "text": adversarial}, adversarial},
+	}
+	labels := map[string]string{"experience": adversarial}
+
+	doc, err := RenderResumeLaTeX(resume, labels, LaTeXOptions{Paper: "Letter"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := strings.Count(doc, `\end{document}`); n != 1 {
+		t.Errorf(`\end{document} appears %d times, want 1`, n)
+	}
+	for _, raw := range []string{`\input{`, `\input`, `javascript:`, "🚀"} {
+		if strings.Contains(doc, raw) {
+			t.Errorf("document contains %q", raw)
+		}
+	}
+	if !strings.Contains(doc, `\documentclass[11pt,letterpaper,sans]{moderncv}`) {
+		t.Error("paper option not applied")
+	}
+	if !strings.Contains(doc, `\social[github]{ana-s}`) {
+		t.Error("GitHub account missing from \\social")
+	}
+	if !strings.Contains(doc, `https://gitlab.com/ana\_s`) {
+		t.Error("account with an underscore not moved to \\extrainfo")
+	}
+
+	// every special character is escaped and the braces balance
+	depth := 0
+	for i := 0; i < len(doc); i++ {
+		c := doc[i]
+		if c == '\\' {
+			i++ // skip the escaped character or the command's first letter
+			continue
+		}
+		switch c {
+		case '%', '&', '$', '#', '_', '^', '~':
+			t.Fatalf("unescaped %q at %d: %q", c, i, around(doc, i))
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth < 0 {
+				t.Fatalf("unbalanced } at %d: %q", i, around(doc, i))
+			}
+		}
+	}
+	if depth != 0 {
+		t.Errorf("%d braces left open", depth)
+	}
+}
+
+func around(s string, i int) string {
+	return s[max(0, i-40):min(len(s), i+40)]
+}
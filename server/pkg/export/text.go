@@ -0,0 +1,382 @@
+// Package export converts a validated resume map into alternative output
+// formats that do not go through the HTML template.
+package export
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"resume-generator/pkg/ai/formatters"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// DefaultTextWidth is the column at which text output wraps unless
+// TextOptions.Width says otherwise.
+const DefaultTextWidth = 80
+
+// TextOptions tune RenderResumeTextWithOptions.
+type TextOptions struct {
+	// Width wraps lines at this many characters: DefaultTextWidth when 0,
+	// no wrapping when negative. Words longer than a line, such as URLs,
+	// are never split.
+	Width int
+	// ASCII folds the text to ASCII, e.g. "José" to "Jose", for systems
+	// that cannot read UTF-8. Text is left untouched by default.
+	ASCII bool
+}
+
+// RenderResumeText renders the resume with the default TextOptions.
+func RenderResumeText(resume map[string]interface{}, labels map[string]string) (string, error) {
+	return RenderResumeTextWithOptions(resume, labels, TextOptions{})
+}
+
+// RenderResumeTextWithOptions renders the resume as clean single-column
+// UTF-8 text suitable for applicant tracking systems: contact details on
+// top, section headings in capitals and one bullet per item, wrapped with a
+// hanging indent. Sections follow the HTML template order and use the
+// translated labels; labels missing from the map fall back to the English
+// defaults. Optional sections that are absent or empty are skipped.
+func RenderResumeTextWithOptions(resume map[string]interface{}, labels map[string]string, opts TextOptions) (string, error) {
+	if resume == nil {
+		return "", errors.New("export: resume is nil")
+	}
+	label := func(key string) string {
+		if v := labels[key]; v != "" {
+			return v
+		}
+		return formatters.GetDefaultLabels()[key]
+	}
+
+	var sb strings.Builder
+	heading := func(title string) {
+		sb.WriteString("\n")
+		sb.WriteString(strings.ToUpper(title))
+		sb.WriteString("\n")
+	}
+	bullets := func(items []string) {
+		for _, it := range items {
+			sb.WriteString("- ")
+			sb.WriteString(it)
+			sb.WriteString("\n")
+		}
+	}
+	// section writes the heading and lines of a section built item by
+	// item, or nothing when no item had any text
+	section := func(title string, lines []string) {
+		if len(lines) == 0 {
+			return
+		}
+		heading(title)
+		for _, l := range lines {
+			sb.WriteString(l)
+			sb.WriteString("\n")
+		}
+	}
+
+	meta := asMap(resume["meta"])
+	if name := asString(meta["name"]); name != "" {
+		sb.WriteString(name)
+		sb.WriteString("\n")
+	}
+	if headline := asString(meta["headline"]); headline != "" {
+		sb.WriteString(headline)
+		sb.WriteString("\n")
+	}
+	var contact []string
+	c := asMap(meta["contact"])
+	for _, k := range []string{"email", "phone", "location", "website"} {
+		if v := asString(c[k]); v != "" {
+			contact = append(contact, v)
+		}
+	}
+	links := asMap(meta["social_links"])
+	for _, k := range []string{"github", "linkedin"} {
+		if v := asString(links[k]); v != "" {
+			contact = append(contact, v)
+		}
+	}
+	if len(contact) > 0 {
+		sb.WriteString(strings.Join(contact, " | "))
+		sb.WriteString("\n")
+	}
+
+	if summary := asString(resume["summary"]); summary != "" {
+		heading(label("professional_summary"))
+		sb.WriteString(summary)
+		sb.WriteString("\n")
+	}
+
+	if snap := asMap(resume["snapshot"]); snap != nil {
+		if tech := asString(snap["tech"]); tech != "" {
+			heading(label("tech_snapshot"))
+			sb.WriteString(tech)
+			sb.WriteString("\n")
+		}
+		if items := asStrings(snap["achievements"]); len(items) > 0 {
+			heading(label("top_achievements"))
+			bullets(items)
+		}
+		if items := asStrings(snap["selected_projects"]); len(items) > 0 {
+			heading(label("selected_projects"))
+			bullets(items)
+		}
+	}
+
+	var skills []string
+	for _, g := range asSlice(resume["skills"]) {
+		gm := asMap(g)
+		items := asStrings(gm["items"])
+		if len(items) == 0 {
+			continue
+		}
+		skills = append(skills, "- "+joinNonEmpty(": ", asString(gm["category"]), strings.Join(items, ", ")))
+	}
+	section(label("skills"), skills)
+
+	if roles := asSlice(resume["experience"]); len(roles) > 0 {
+		heading(label("experience"))
+		for i, r := range roles {
+			role := asMap(r)
+			if i > 0 {
+				sb.WriteString("\n")
+			}
+			line := strings.TrimSpace(asString(role["company"]) + " — " + asString(role["title"]))
+			if period := asString(role["period"]); period != "" {
+				line += " | " + period
+			}
+			sb.WriteString(line)
+			sb.WriteString("\n")
+			if s := asString(role["summary"]); s != "" {
+				sb.WriteString(s)
+				sb.WriteString("\n")
+			}
+			bullets(asStrings(role["bullets"]))
+		}
+	}
+
+	var education []string
+	for _, e := range asSlice(resume["education"]) {
+		em := asMap(e)
+		line := joinNonEmpty(", ", joinNonEmpty(" — ", asString(em["institution"]), asString(em["degree"])), asString(em["field"]))
+		period := strings.Trim(asString(em["start_date"])+" – "+asString(em["end_date"]), " –")
+		line = joinNonEmpty(" | ", line, period)
+		if g := asString(em["gpa"]); g != "" {
+			line = joinNonEmpty(" | ", line, "GPA "+g)
+		}
+		if line != "" {
+			education = append(education, "- "+line)
+		}
+	}
+	section(label("education"), education)
+
+	if projects := asSlice(resume["projects"]); len(projects) > 0 {
+		heading(label("projects_case_studies"))
+		for i, pr := range projects {
+			proj := asMap(pr)
+			if i > 0 {
+				sb.WriteString("\n")
+			}
+			line := asString(proj["title"])
+			if u := asString(proj["url"]); u != "" {
+				line += " — " + u
+			}
+			sb.WriteString(line)
+			sb.WriteString("\n")
+			if stack := asString(proj["stack"]); stack != "" {
+				sb.WriteString(stack)
+				sb.WriteString("\n")
+			}
+			if d := asString(proj["description"]); d != "" {
+				sb.WriteString(d)
+				sb.WriteString("\n")
+			}
+			bullets(asStrings(proj["bullets"]))
+		}
+	}
+
+	if pubs := asStrings(resume["publications"]); len(pubs) > 0 {
+		heading(label("publications"))
+		bullets(pubs)
+	}
+
+	var extras []string
+	for _, e := range asSlice(resume["extras"]) {
+		em := asMap(e)
+		if em == nil {
+			if s := asString(e); s != "" {
+				extras = append(extras, "- "+s)
+			}
+			continue
+		}
+		if text := asString(em["text"]); text != "" {
+			extras = append(extras, "- "+joinNonEmpty(": ", asString(em["category"]), text))
+		}
+	}
+	section(label("continuous_learning_community"), extras)
+
+	var certs []string
+	for _, ce := range asSlice(resume["certifications"]) {
+		cm := asMap(ce)
+		if cm == nil {
+			if s := asString(ce); s != "" {
+				certs = append(certs, "- "+s)
+			}
+			continue
+		}
+		line := joinNonEmpty(" — ", asString(cm["name"]), asString(cm["issuer"]))
+		if d := asString(cm["date"]); d != "" {
+			line = joinNonEmpty(" ", line, fmt.Sprintf("(%s)", d))
+		}
+		line = joinNonEmpty(" — ", line, asString(cm["url"]))
+		desc := asString(cm["description"])
+		if line == "" {
+			line, desc = desc, ""
+		}
+		if line == "" {
+			continue
+		}
+		certs = append(certs, "- "+line)
+		if desc != "" {
+			certs = append(certs, "  "+desc)
+		}
+	}
+	section(label("certifications"), certs)
+
+	text := strings.TrimLeft(sb.String(), "\n")
+	if opts.ASCII {
+		text = foldASCII(text)
+	}
+	width := opts.Width
+	if width == 0 {
+		width = DefaultTextWidth
+	}
+	if width > 0 {
+		text = wrapText(text, width)
+	}
+	return text, nil
+}
+
+// wrapText wraps every line of s at width characters on spaces. Continuation
+// lines keep the line's indentation, plus two spaces for "- " bullets.
+func wrapText(s string, width int) string {
+	lines := strings.Split(s, "\n")
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		out = append(out, wrapLine(line, width)...)
+	}
+	return strings.Join(out, "\n")
+}
+
+func wrapLine(line string, width int) []string {
+	if utf8.RuneCountInString(line) <= width {
+		return []string{line}
+	}
+	body := strings.TrimLeft(line, " ")
+	indent := line[:len(line)-len(body)]
+	hang := indent
+	if strings.HasPrefix(body, "- ") {
+		hang += "  "
+	}
+
+	var out []string
+	cur, curLen, empty := indent, len(indent), true
+	for _, w := range strings.Fields(body) {
+		n := utf8.RuneCountInString(w)
+		if !empty && curLen+1+n > width {
+			out = append(out, cur)
+			cur, curLen, empty = hang, len(hang), true
+		}
+		if !empty {
+			cur += " "
+			curLen++
+		}
+		cur += w
+		curLen += n
+		empty = false
+	}
+	return append(out, cur)
+}
+
+// asciiReplacer spells out letters and punctuation that do not decompose
+// into an ASCII letter and accents.
+var asciiReplacer = strings.NewReplacer(
+	"ß", "ss", "æ", "ae", "Æ", "AE", "œ", "oe", "Œ", "OE", "ø", "o", "Ø", "O",
+	"ł", "l", "Ł", "L", "đ", "d", "Đ", "D", "þ", "th", "Þ", "Th", "ı", "i",
+	"—", "-", "–", "-", "‘", "'", "’", "'", "“", "\"", "”", "\"", "…", "...",
+	"•", "-", "·", "-", "\u00a0", " ",
+)
+
+// foldASCII strips accents from s and replaces what is still not ASCII
+// with "?".
+func foldASCII(s string) string {
+	s = norm.NFD.String(asciiReplacer.Replace(s))
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+		case r < utf8.RuneSelf:
+			b.WriteRune(r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// joinNonEmpty joins the non-empty parts with sep, so a missing part leaves
+// no dangling separator.
+func joinNonEmpty(sep string, parts ...string) string {
+	var out []string
+	for _, p := range parts {
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return strings.Join(out, sep)
+}
+
+func asMap(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+func asSlice(v interface{}) []interface{} {
+	s, _ := v.([]interface{})
+	return s
+}
+
+func asString(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return strings.TrimSpace(t)
+	case nil:
+		return ""
+	default:
+		return strings.TrimSpace(fmt.Sprintf("%v", t))
+	}
+}
+
+// asStrings accepts []interface{} or []string and drops empty items.
+func asStrings(v interface{}) []string {
+	var out []string
+	switch t := v.(type) {
+	case []interface{}:
+		for _, it := range t {
+			if s := asString(it); s != "" {
+				out = append(out, s)
+			}
+		}
+	case []string:
+		for _, it := range t {
+			if s := strings.TrimSpace(it); s != "" {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
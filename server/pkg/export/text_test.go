@@ -0,0 +1,158 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	"resume-generator/pkg/ai/formatters"
+)
+
+// textResume returns a resume with every section the text export renders.
+func textResume() map[string]interface{} {
+	return map[string]interface{}{
+		"meta": map[string]interface{}{
+			"name":     "Ada Lovelace",
+			"headline": "Backend Engineer",
+			"contact":  map[string]interface{}{"email": "ada@example.com", "location": "London"},
+		},
+		"summary": "Builds reliable services.",
+		"skills": []interface{}{
+			map[string]interface{}{"category": "Languages", "items": []interface{}{"Go", "SQL"}},
+		},
+		"experience": []interface{}{
+			map[string]interface{}{"company": "Acme", "title": "Engineer", "period": "2020 – 2024", "bullets": []interface{}{"Cut latency by 40%"}},
+		},
+		"education": []interface{}{
+			map[string]interface{}{"institution": "University of London", "degree": "BSc", "field": "Mathematics", "start_date": "2014", "end_date": "2017"},
+		},
+		"publications": []interface{}{"Notes on the Analytical Engine"},
+		"certifications": []interface{}{
+			map[string]interface{}{"name": "CKA", "issuer": "Linux Foundation", "date": "2023"},
+		},
+	}
+}
+
+func TestRenderResumeTextMissingSections(t *testing.T) {
+	tests := []struct {
+		name string
+		// edit removes or empties sections of textResume
+		edit       func(r map[string]interface{})
+		wantAbsent []string
+		want       []string
+	}{
+		{
+			name:       "no education",
+			edit:       func(r map[string]interface{}) { delete(r, "education") },
+			wantAbsent: []string{"EDUCATION"},
+			want:       []string{"PUBLICATIONS", "CERTIFICATIONS", "SKILLS"},
+		},
+		{
+			name:       "no publications",
+			edit:       func(r map[string]interface{}) { r["publications"] = []interface{}{} },
+			wantAbsent: []string{"PUBLICATIONS"},
+			want:       []string{"EDUCATION", "CERTIFICATIONS"},
+		},
+		{
+			name:       "blank publications",
+			edit:       func(r map[string]interface{}) { r["publications"] = []interface{}{"", "  "} },
+			wantAbsent: []string{"PUBLICATIONS"},
+		},
+		{
+			name:       "no certifications",
+			edit:       func(r map[string]interface{}) { delete(r, "certifications") },
+			wantAbsent: []string{"CERTIFICATIONS"},
+			want:       []string{"PUBLICATIONS"},
+		},
+		{
+			name:       "empty certifications",
+			edit:       func(r map[string]interface{}) { r["certifications"] = []interface{}{map[string]interface{}{}, ""} },
+			wantAbsent: []string{"CERTIFICATIONS"},
+		},
+		{
+			name: "certification without a name",
+			edit: func(r map[string]interface{}) {
+				r["certifications"] = []interface{}{map[string]interface{}{"issuer": "Linux Foundation"}}
+			},
+			want: []string{"CERTIFICATIONS", "- Linux Foundation"},
+		},
+		{
+			name:       "no skills",
+			edit:       func(r map[string]interface{}) { delete(r, "skills") },
+			wantAbsent: []string{"SKILLS"},
+			want:       []string{"EXPERIENCE"},
+		},
+		{
+			name: "skill groups without items",
+			edit: func(r map[string]interface{}) {
+				r["skills"] = []interface{}{map[string]interface{}{"category": "Languages", "items": []interface{}{}}}
+			},
+			wantAbsent: []string{"SKILLS", "Languages"},
+		},
+		{
+			name:       "empty education entry",
+			edit:       func(r map[string]interface{}) { r["education"] = []interface{}{map[string]interface{}{}} },
+			wantAbsent: []string{"EDUCATION"},
+		},
+		{
+			name: "education without an institution",
+			edit: func(r map[string]interface{}) {
+				r["education"] = []interface{}{map[string]interface{}{"degree": "BSc", "end_date": "2017"}}
+			},
+			want: []string{"- BSc | 2017"},
+		},
+		{
+			name: "none of them",
+			edit: func(r map[string]interface{}) {
+				for _, k := range []string{"education", "publications", "certifications", "skills"} {
+					delete(r, k)
+				}
+			},
+			wantAbsent: []string{"EDUCATION", "PUBLICATIONS", "CERTIFICATIONS", "SKILLS"},
+			want:       []string{"Ada Lovelace", "EXPERIENCE"},
+		},
+	}
+
+	headings := map[string]bool{}
+	for _, v := range formatters.GetDefaultLabels() {
+		headings[strings.ToUpper(v)] = true
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resume := textResume()
+			tt.edit(resume)
+			got, err := RenderResumeTextWithOptions(resume, nil, TextOptions{Width: -1})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			lines := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+			for i, line := range lines {
+				if headings[line] && (i+1 == len(lines) || strings.TrimSpace(lines[i+1]) == "") {
+					t.Errorf("empty %q heading in:\n%s", line, got)
+				}
+				trimmed := strings.TrimSpace(line)
+				if trimmed == "-" {
+					t.Errorf("empty bullet in:\n%s", got)
+				}
+				for _, sep := range []string{"—", "|", ":", ","} {
+					if strings.HasSuffix(trimmed, sep) || strings.HasPrefix(strings.TrimPrefix(trimmed, "- "), sep) {
+						t.Errorf("stray %q in line %q", sep, line)
+					}
+				}
+			}
+			if strings.Contains(got, "\n\n\n") || strings.HasPrefix(got, "\n") {
+				t.Errorf("stray blank lines in:\n%s", got)
+			}
+			for _, s := range tt.wantAbsent {
+				if strings.Contains(got, s) {
+					t.Errorf("output contains %q:\n%s", s, got)
+				}
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(got, s) {
+					t.Errorf("output lacks %q:\n%s", s, got)
+				}
+			}
+		})
+	}
+}
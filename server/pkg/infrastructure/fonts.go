@@ -0,0 +1,127 @@
+package infrastructure
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// fontFormats maps supported font file extensions to their @font-face
+// format() hint and MIME type.
+var fontFormats = map[string][2]string{
+	".woff2": {"woff2", "font/woff2"},
+	".woff":  {"woff", "font/woff"},
+	".ttf":   {"truetype", "font/ttf"},
+	".otf":   {"opentype", "font/otf"},
+}
+
+// fontWeights maps weight names found in font file names to CSS weights.
+// Compound names come first so "semibold" is not read as "bold".
+var fontWeights = []struct {
+	name   string
+	weight string
+}{
+	{"extralight", "200"}, {"ultralight", "200"},
+	{"semibold", "600"}, {"demibold", "600"},
+	{"extrabold", "800"}, {"ultrabold", "800"},
+	{"thin", "100"}, {"light", "300"}, {"medium", "500"},
+	{"bold", "700"}, {"black", "900"}, {"heavy", "900"},
+}
+
+// FontSet embeds bundled fonts into rendered HTML so the PDF does not depend
+// on the fonts installed where Chrome runs. Fonts live in one directory per
+// family under Dir, e.g. fonts/Noto Sans/NotoSans-BoldItalic.woff2; weight
+// and style are read from the file name.
+type FontSet struct {
+	Dir string
+
+	mu    sync.Mutex
+	faces map[string]string
+}
+
+// NewFontSet serves the font families found under dir.
+func NewFontSet(dir string) *FontSet {
+	return &FontSet{Dir: dir, faces: map[string]string{}}
+}
+
+// FaceCSS returns @font-face rules embedding every file of the given
+// families as base64 data: URIs. Families without a directory under Dir are
+// skipped, so templates can name system fonts as fallbacks. The rules of a
+// family are built once and cached.
+func (s *FontSet) FaceCSS(families ...string) (string, error) {
+	var b strings.Builder
+	for _, family := range families {
+		css, err := s.familyCSS(family)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(css)
+	}
+	return b.String(), nil
+}
+
+func (s *FontSet) familyCSS(family string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if css, ok := s.faces[family]; ok {
+		return css, nil
+	}
+	if family == "" || !filepath.IsLocal(family) || strings.ContainsAny(family, `/\`) {
+		return "", nil
+	}
+
+	dir := filepath.Join(s.Dir, family)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			s.faces[family] = ""
+			return "", nil
+		}
+		return "", err
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if _, ok := fontFormats[strings.ToLower(filepath.Ext(e.Name()))]; ok && !e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return "", err
+		}
+		format := fontFormats[strings.ToLower(filepath.Ext(name))]
+		weight, style := fontFaceStyle(name)
+		fmt.Fprintf(&b, "@font-face{font-family:%q;font-weight:%s;font-style:%s;font-display:block;src:url(data:%s;base64,%s) format(%q);}\n",
+			family, weight, style, format[1], base64.StdEncoding.EncodeToString(data), format[0])
+	}
+	s.faces[family] = b.String()
+	return s.faces[family], nil
+}
+
+// fontFaceStyle derives the CSS weight and style of a font file from its
+// name. Variable fonts ("Variable" or "[wght]" in the name) cover the whole
+// weight range.
+func fontFaceStyle(name string) (weight, style string) {
+	n := strings.ToLower(strings.TrimSuffix(name, filepath.Ext(name)))
+	style = "normal"
+	if strings.Contains(n, "italic") || strings.Contains(n, "oblique") {
+		style = "italic"
+	}
+	if strings.Contains(n, "variable") || strings.Contains(n, "[wght") {
+		return "100 900", style
+	}
+	for _, w := range fontWeights {
+		if strings.Contains(n, w.name) {
+			return w.weight, style
+		}
+	}
+	return "400", style
+}
@@ -0,0 +1,71 @@
+package infrastructure
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFontFaceStyle(t *testing.T) {
+	tests := []struct {
+		file          string
+		weight, style string
+	}{
+		{"Inter-Regular.woff2", "400", "normal"},
+		{"Inter-Bold.woff2", "700", "normal"},
+		{"Inter-SemiBoldItalic.woff2", "600", "italic"},
+		{"Inter-ExtraLight.ttf", "200", "normal"},
+		{"NotoSans-BlackOblique.otf", "900", "italic"},
+		{"Inter-Variable.woff2", "100 900", "normal"},
+		{"Inter-Italic[wght].woff2", "100 900", "italic"},
+	}
+	for _, tt := range tests {
+		weight, style := fontFaceStyle(tt.file)
+		if weight != tt.weight || style != tt.style {
+			t.Errorf("fontFaceStyle(%q) = %s %s, want %s %s", tt.file, weight, style, tt.weight, tt.style)
+		}
+	}
+}
+
+func TestFontSetFaceCSS(t *testing.T) {
+	dir := t.TempDir()
+	font := []byte("wOF2 font bytes")
+	if err := os.MkdirAll(filepath.Join(dir, "Inter"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string][]byte{
+		"Inter-BoldItalic.woff2": font,
+		"README.txt":             []byte("not a font"),
+	} {
+		if err := os.WriteFile(filepath.Join(dir, "Inter", name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := NewFontSet(dir)
+
+	tests := []struct {
+		name     string
+		families []string
+		want     string
+	}{
+		{
+			name:     "bundled family",
+			families: []string{"Inter"},
+			want:     `@font-face{font-family:"Inter";font-weight:700;font-style:italic;font-display:block;src:url(data:font/woff2;base64,` + base64.StdEncoding.EncodeToString(font) + `) format("woff2");}` + "\n",
+		},
+		{name: "system font", families: []string{"Georgia"}},
+		{name: "escaping the dir", families: []string{"../Inter", "Inter/.."}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.FaceCSS(tt.families...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("FaceCSS(%q) = %q, want %q", tt.families, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// DefaultMaxImageBytes bounds the images fetched by ImageFetcher unless
+// MaxBytes is set.
+const DefaultMaxImageBytes = 2 << 20
+
+// ImageFetcher downloads remote images so they can be embedded in rendered
+// HTML, keeping the PDF self-contained.
+type ImageFetcher struct {
+	Client   *http.Client
+	MaxBytes int64
+}
+
+// FetchDataURI downloads the image at url and returns it as a base64
+// data: URI. Responses that are not 2xx, not an image or larger than
+// MaxBytes are rejected.
+func (f *ImageFetcher) FetchDataURI(ctx context.Context, url string) (string, error) {
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	limit := f.MaxBytes
+	if limit <= 0 {
+		limit = DefaultMaxImageBytes
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("fetch image: unexpected status %d", resp.StatusCode)
+	}
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return "", err
+	}
+	if int64(len(b)) > limit {
+		return "", fmt.Errorf("fetch image: larger than %d bytes", limit)
+	}
+
+	ctype, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !strings.HasPrefix(ctype, "image/") {
+		ctype, _, _ = mime.ParseMediaType(http.DetectContentType(b))
+	}
+	if !strings.HasPrefix(ctype, "image/") {
+		return "", fmt.Errorf("fetch image: unsupported content type %q", ctype)
+	}
+	return "data:" + ctype + ";base64," + base64.StdEncoding.EncodeToString(b), nil
+}
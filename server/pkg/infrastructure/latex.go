@@ -0,0 +1,71 @@
+package infrastructure
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// latexTimeout bounds a pdflatex run unless the context is shorter.
+const latexTimeout = 60 * time.Second
+
+// LaTeXCompiler compiles .tex sources to PDF with pdflatex. Shell escape is
+// disabled and each run works in its own temporary directory.
+type LaTeXCompiler struct {
+	path string
+}
+
+// NewLaTeXCompiler returns a compiler for the pdflatex found on PATH, or
+// nil when there is none.
+func NewLaTeXCompiler() *LaTeXCompiler {
+	path, err := exec.LookPath("pdflatex")
+	if err != nil {
+		return nil
+	}
+	return &LaTeXCompiler{path: path}
+}
+
+// CompileLaTeX runs pdflatex on src and returns the PDF. The error carries
+// the last lines of the log when compilation fails.
+func (l *LaTeXCompiler) CompileLaTeX(ctx context.Context, src string) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "resume-tex-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	if err := os.WriteFile(filepath.Join(dir, "resume.tex"), []byte(src), 0o600); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, latexTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, l.path,
+		"-interaction=nonstopmode", "-halt-on-error", "-no-shell-escape",
+		"-output-directory", dir, "resume.tex")
+	cmd.Dir = dir
+	var out bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &out
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("pdflatex: %w: %s", err, tail(out.String(), 10))
+	}
+	return os.ReadFile(filepath.Join(dir, "resume.pdf"))
+}
+
+// tail returns the last n non-empty lines of s.
+func tail(s string, n int) string {
+	var lines []string
+	for _, l := range strings.Split(s, "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, " | ")
+}
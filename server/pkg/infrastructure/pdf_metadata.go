@@ -0,0 +1,116 @@
+package infrastructure
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+	"unicode/utf16"
+)
+
+// PDFMetadata is written into the document information dictionary of a
+// rendered PDF, where viewers show it as the title, author and so on.
+type PDFMetadata struct {
+	Title    string
+	Author   string
+	Subject  string
+	Keywords string
+	// Created is the CreationDate; the ModDate is the time of rendering.
+	Created time.Time
+}
+
+// IsZero reports whether m sets nothing.
+func (m PDFMetadata) IsZero() bool {
+	return m.Title == "" && m.Author == "" && m.Subject == "" && m.Keywords == "" && m.Created.IsZero()
+}
+
+// pdfProducer is recorded as the Creator and Producer of PDFs with metadata.
+const pdfProducer = "resume-generator"
+
+var (
+	startXRefRe = regexp.MustCompile(`startxref\s+(\d+)\s+%%EOF\s*$`)
+	sizeRe      = regexp.MustCompile(`/Size\s+(\d+)`)
+	rootRe      = regexp.MustCompile(`/Root\s+(\d+\s+\d+\s+R)`)
+	idRe        = regexp.MustCompile(`/ID\s*\[[^\]]*\]`)
+)
+
+// SetPDFMetadata returns pdf with an incremental update whose information
+// dictionary holds m, replacing the one Chrome wrote. The original bytes are
+// kept as they are, so the update works for both xref tables and xref
+// streams.
+func SetPDFMetadata(pdf []byte, m PDFMetadata, now time.Time) ([]byte, error) {
+	loc := startXRefRe.FindSubmatchIndex(pdf)
+	if loc == nil {
+		return nil, errors.New("pdf: startxref not found")
+	}
+	prev, err := strconv.Atoi(string(pdf[loc[2]:loc[3]]))
+	if err != nil || prev >= len(pdf) {
+		return nil, fmt.Errorf("pdf: invalid startxref %q", pdf[loc[2]:loc[3]])
+	}
+
+	// the trailer, or the xref stream dictionary, follows the last xref
+	// section
+	trailer := pdf[prev:loc[0]]
+	sm := sizeRe.FindSubmatch(trailer)
+	rm := rootRe.FindSubmatch(trailer)
+	if sm == nil || rm == nil {
+		return nil, errors.New("pdf: trailer without /Size or /Root")
+	}
+	size, _ := strconv.Atoi(string(sm[1]))
+
+	var info bytes.Buffer
+	info.WriteString("<<")
+	for _, e := range []struct{ key, value string }{
+		{"Title", m.Title},
+		{"Author", m.Author},
+		{"Subject", m.Subject},
+		{"Keywords", m.Keywords},
+		{"Creator", pdfProducer},
+		{"Producer", pdfProducer},
+	} {
+		if e.value != "" {
+			fmt.Fprintf(&info, " /%s %s", e.key, pdfTextString(e.value))
+		}
+	}
+	if !m.Created.IsZero() {
+		fmt.Fprintf(&info, " /CreationDate %s", pdfDate(m.Created))
+	}
+	fmt.Fprintf(&info, " /ModDate %s >>", pdfDate(now))
+
+	var out bytes.Buffer
+	out.Grow(len(pdf) + info.Len() + 256)
+	out.Write(pdf)
+	if !bytes.HasSuffix(pdf, []byte("\n")) {
+		out.WriteByte('\n')
+	}
+	infoOffset := out.Len()
+	fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", size, info.Bytes())
+	xrefOffset := out.Len()
+	fmt.Fprintf(&out, "xref\n%d 1\n%010d 00000 n \n", size, infoOffset)
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root %s /Info %d 0 R /Prev %d", size+1, rm[1], size, prev)
+	if id := idRe.Find(trailer); id != nil {
+		out.WriteString(" ")
+		out.Write(id)
+	}
+	fmt.Fprintf(&out, " >>\nstartxref\n%d\n%%%%EOF\n", xrefOffset)
+	return out.Bytes(), nil
+}
+
+// pdfTextString encodes s as a UTF-16BE hex string, which needs no escaping
+// and covers names outside Latin-1.
+func pdfTextString(s string) string {
+	var b bytes.Buffer
+	b.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// pdfDate formats t as a PDF date string in UTC.
+func pdfDate(t time.Time) string {
+	return "(D:" + t.UTC().Format("20060102150405") + "Z)"
+}
@@ -0,0 +1,145 @@
+package infrastructure
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// minimalPDF returns a one-object PDF whose trailer is built from extra.
+func minimalPDF(trailer string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	obj := b.Len()
+	b.WriteString("1 0 obj\n<< /Type /Catalog >>\nendobj\n")
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 2\n0000000000 65535 f \n%010d 00000 n \n", obj)
+	fmt.Fprintf(&b, "trailer\n%s\nstartxref\n%d\n%%%%EOF\n", trailer, xref)
+	return b.Bytes()
+}
+
+func TestSetPDFMetadata(t *testing.T) {
+	created := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	now := time.Date(2024, 3, 2, 10, 0, 5, 0, time.FixedZone("BRT", -3*3600))
+	tests := []struct {
+		name    string
+		trailer string
+		meta    PDFMetadata
+		want    []string // substrings of the information dictionary
+		absent  []string
+		wantID  bool
+	}{
+		{
+			name:    "all fields",
+			trailer: "<< /Size 2 /Root 1 0 R >>",
+			meta:    PDFMetadata{Title: "Ada Resume", Author: "Ada", Subject: "Engineer", Keywords: "Go, SQL", Created: created},
+			want: []string{
+				"/Title <FEFF00410064006100200052006500730075006D0065>",
+				"/Author <FEFF004100640061>",
+				"/Subject <FEFF0045006E00670069006E006500650072>",
+				"/Keywords <FEFF0047006F002C002000530051004C>",
+				"/Producer <FEFF0072006500730075006D0065002D00670065006E0065007200610074006F0072>",
+				"/CreationDate (D:20240301093000Z)",
+				"/ModDate (D:20240302130005Z)",
+			},
+		},
+		{
+			name:    "empty fields are left out",
+			trailer: "<< /Size 2 /Root 1 0 R >>",
+			meta:    PDFMetadata{Title: "Ada"},
+			want:    []string{"/Title <FEFF004100640061>", "/ModDate (D:20240302130005Z)"},
+			absent:  []string{"/Author", "/Subject", "/Keywords", "/CreationDate"},
+		},
+		{
+			name:    "non-latin text",
+			trailer: "<< /Size 2 /Root 1 0 R >>",
+			meta:    PDFMetadata{Author: "José 李"},
+			want:    []string{"/Author <FEFF004A006F007300E90020674E>"},
+		},
+		{
+			name:    "keeps the document id",
+			trailer: "<< /Size 2 /Root 1 0 R /ID [<AB> <CD>] >>",
+			meta:    PDFMetadata{Title: "Ada"},
+			want:    []string{"/Title"},
+			wantID:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pdf := minimalPDF(tt.trailer)
+			out, err := SetPDFMetadata(pdf, tt.meta, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.HasPrefix(out, pdf) {
+				t.Fatal("original bytes were not kept")
+			}
+			update := string(out[len(pdf):])
+
+			info := regexp.MustCompile(`(?s)2 0 obj\n(<<.*?>>)\nendobj`).FindStringSubmatch(update)
+			if info == nil {
+				t.Fatalf("no information dictionary as object 2 in update:\n%s", update)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(info[1], w) {
+					t.Errorf("information dictionary %s lacks %s", info[1], w)
+				}
+			}
+			for _, a := range tt.absent {
+				if strings.Contains(info[1], a) {
+					t.Errorf("information dictionary %s has %s", info[1], a)
+				}
+			}
+
+			prev := bytes.Index(pdf, []byte("\nxref\n")) + 1
+			wantTrailer := fmt.Sprintf("<< /Size 3 /Root 1 0 R /Info 2 0 R /Prev %d", prev)
+			if !strings.Contains(update, wantTrailer) {
+				t.Errorf("update trailer lacks %q:\n%s", wantTrailer, update)
+			}
+			if got := strings.Contains(update, "/ID [<AB> <CD>]"); got != tt.wantID {
+				t.Errorf("update has /ID = %v, want %v", got, tt.wantID)
+			}
+
+			// the new xref entry and startxref point at the object and the
+			// section they name
+			m := regexp.MustCompile(`xref\n2 1\n(\d{10}) 00000 n \n`).FindStringSubmatch(update)
+			if m == nil {
+				t.Fatalf("no xref section for object 2:\n%s", update)
+			}
+			off, _ := strconv.Atoi(m[1])
+			if !bytes.HasPrefix(out[off:], []byte("2 0 obj")) {
+				t.Errorf("xref offset %d points at %q", off, out[off:min(off+10, len(out))])
+			}
+			sx := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindStringSubmatch(update)
+			if sx == nil {
+				t.Fatalf("update does not end with startxref:\n%s", update)
+			}
+			off, _ = strconv.Atoi(sx[1])
+			if !bytes.HasPrefix(out[off:], []byte("xref\n2 1")) {
+				t.Errorf("startxref %d points at %q", off, out[off:min(off+10, len(out))])
+			}
+		})
+	}
+}
+
+func TestSetPDFMetadataRejectsMalformedPDFs(t *testing.T) {
+	tests := []struct {
+		name string
+		pdf  []byte
+	}{
+		{"no startxref", []byte("%PDF-1.4\n1 0 obj\n<<>>\nendobj\n")},
+		{"startxref past the end", []byte("%PDF-1.4\nstartxref\n999\n%%EOF\n")},
+		{"trailer without root", minimalPDF("<< /Size 2 >>")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := SetPDFMetadata(tt.pdf, PDFMetadata{Title: "x"}, time.Now()); err == nil {
+				t.Error("SetPDFMetadata succeeded")
+			}
+		})
+	}
+}
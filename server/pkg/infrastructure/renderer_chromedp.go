@@ -2,19 +2,113 @@ package infrastructure
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/chromedp/cdproto/page"
 	"github.com/chromedp/chromedp"
+
+	"resume-generator/pkg/logctx"
+	"resume-generator/pkg/metrics"
+	"resume-generator/templates"
 )
 
+// RenderOptions controls page geometry for PDF output. Dimensions are in
+// inches, matching page.PrintToPDF. PageRanges limits the printed pages,
+// e.g. "1" or "1-2,4", and must pass ValidPageRanges; empty prints all.
+// Metadata, when set, is written into the PDF's information dictionary.
+type RenderOptions struct {
+	PaperWidth        float64
+	PaperHeight       float64
+	MarginTop         float64
+	MarginBottom      float64
+	MarginLeft        float64
+	MarginRight       float64
+	Landscape         bool
+	PreferCSSPageSize bool
+	PrintBackground   bool
+	PageRanges        string
+	Metadata          PDFMetadata
+}
+
+// paperSizes maps supported paper names to width/height in inches.
+var paperSizes = map[string][2]float64{
+	"a4":     {8.27, 11.69}, // 210mm x 297mm
+	"letter": {8.5, 11},
+	"legal":  {8.5, 14},
+}
+
+// pageRange matches one comma separated part of RenderOptions.PageRanges.
+var pageRange = regexp.MustCompile(`^\s*(\d+)\s*(?:-\s*(\d+)\s*)?$`)
+
+// ValidPageRanges reports whether s is a list of 1-based pages and
+// ascending ranges such as "1" or "1-2,4".
+func ValidPageRanges(s string) bool {
+	if strings.TrimSpace(s) == "" {
+		return false
+	}
+	for _, part := range strings.Split(s, ",") {
+		m := pageRange.FindStringSubmatch(part)
+		if m == nil {
+			return false
+		}
+		from, err := strconv.Atoi(m[1])
+		if err != nil || from < 1 {
+			return false
+		}
+		if m[2] != "" {
+			if to, err := strconv.Atoi(m[2]); err != nil || to < from {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// DefaultRenderOptions returns A4 with Chrome's default 0.4in margins and
+// backgrounds printed.
+func DefaultRenderOptions() RenderOptions {
+	o, _ := RenderOptionsForPaper("A4")
+	return o
+}
+
+// RenderOptionsForPaper returns default options for a named paper size
+// ("A4", "Letter", "Legal", case-insensitive). It reports false for
+// unknown names.
+func RenderOptionsForPaper(name string) (RenderOptions, bool) {
+	size, ok := paperSizes[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return RenderOptions{}, false
+	}
+	return RenderOptions{
+		PaperWidth:        size[0],
+		PaperHeight:       size[1],
+		MarginTop:         0.4,
+		MarginBottom:      0.4,
+		MarginLeft:        0.4,
+		MarginRight:       0.4,
+		PreferCSSPageSize: true,
+		PrintBackground:   true,
+	}, true
+}
+
 type ChromedpRenderer struct{}
 
 func NewChromedpRenderer() *ChromedpRenderer { return &ChromedpRenderer{} }
 
+// RenderHTMLToPDF renders html with DefaultRenderOptions.
 func (r *ChromedpRenderer) RenderHTMLToPDF(ctx context.Context, html string) ([]byte, error) {
+	return r.RenderHTMLToPDFWithOptions(ctx, html, DefaultRenderOptions())
+}
+
+// RenderHTMLToPDFWithOptions renders html to PDF using the given paper size,
+// margins and orientation.
+func (r *ChromedpRenderer) RenderHTMLToPDFWithOptions(ctx context.Context, html string, ro RenderOptions) ([]byte, error) {
 	// Create a temporary directory first (used for user-data-dir and files)
 	tmpDir, err := os.MkdirTemp("/tmp", "resume-")
 	if err != nil {
@@ -22,7 +116,45 @@ func (r *ChromedpRenderer) RenderHTMLToPDF(ctx context.Context, html string) ([]
 	}
 	defer os.RemoveAll(tmpDir)
 
-	// prepare exec allocator with optional CHROME_PATH and a dedicated user-data-dir
+	allocCtx, cancel := chromedp.NewExecAllocator(ctx, execAllocatorOptions(tmpDir)...)
+	defer cancel()
+
+	cctx, cancelCtx := chromedp.NewContext(allocCtx)
+	defer cancelCtx()
+
+	// ensure Chrome starts (give extra time for cold start); a shorter
+	// deadline on ctx, such as the job timeout, still wins
+	ctx2, cancel2 := context.WithTimeout(cctx, 120*time.Second)
+	defer cancel2()
+
+	return printHTMLToPDF(ctx2, tmpDir, html, ro)
+}
+
+// RenderHTMLToPNG captures the first page of html as a PNG of the given
+// width in CSS pixels, using an A4-proportioned viewport.
+func (r *ChromedpRenderer) RenderHTMLToPNG(ctx context.Context, html string, width int) ([]byte, error) {
+	tmpDir, err := os.MkdirTemp("/tmp", "resume-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	allocCtx, cancel := chromedp.NewExecAllocator(ctx, execAllocatorOptions(tmpDir)...)
+	defer cancel()
+
+	cctx, cancelCtx := chromedp.NewContext(allocCtx)
+	defer cancelCtx()
+
+	ctx2, cancel2 := context.WithTimeout(cctx, 120*time.Second)
+	defer cancel2()
+
+	return captureHTMLToPNG(ctx2, tmpDir, html, width)
+}
+
+// execAllocatorOptions builds headless Chrome flags with a dedicated
+// user-data-dir and CHROME_PATH (or a common install location) as the
+// executable.
+func execAllocatorOptions(userDataDir string) []chromedp.ExecAllocatorOption {
 	opts := append(chromedp.DefaultExecAllocatorOptions[:],
 		chromedp.Flag("headless", true),
 		chromedp.Flag("no-sandbox", true),
@@ -32,7 +164,7 @@ func (r *ChromedpRenderer) RenderHTMLToPDF(ctx context.Context, html string) ([]
 		// Avoid forcing a fixed remote-debugging port or single-process mode;
 		// let the allocator pick a free debugging port and default process model.
 		chromedp.Flag("disable-extensions", true),
-		chromedp.UserDataDir(tmpDir),
+		chromedp.UserDataDir(userDataDir),
 	)
 
 	// If CHROME_PATH isn't set, try common locations inside containers
@@ -54,45 +186,84 @@ func (r *ChromedpRenderer) RenderHTMLToPDF(ctx context.Context, html string) ([]
 			}
 		}
 	}
+	return opts
+}
 
-	allocCtx, cancel := chromedp.NewExecAllocator(ctx, opts...)
-	defer cancel()
+// writeRenderFiles writes html as index.html plus a copy of the template's
+// style.css into dir and returns the file:// URL to load.
+func writeRenderFiles(dir, html string) (string, error) {
+	// write HTML and copy style.css into the temp directory
+	htmlPath := filepath.Join(dir, "index.html")
+	if err := os.WriteFile(htmlPath, []byte(html), 0o644); err != nil {
+		return "", err
+	}
 
-	cctx, cancelCtx := chromedp.NewContext(allocCtx)
-	defer cancelCtx()
+	if b, err := templates.ReadFile("style.css"); err == nil {
+		_ = os.WriteFile(filepath.Join(dir, "style.css"), b, 0o644)
+	}
+	return "file://" + htmlPath, nil
+}
 
-	// ensure Chrome starts (give extra time for cold start)
-	ctx2, cancel2 := context.WithTimeout(cctx, 120*time.Second)
-	defer cancel2()
+// captureHTMLToPNG loads html in the chromedp context ctx with a viewport of
+// width x (width * A4 aspect ratio) and screenshots it.
+func captureHTMLToPNG(ctx context.Context, dir, html string, width int) ([]byte, error) {
+	htmlURL, err := writeRenderFiles(dir, html)
+	if err != nil {
+		return nil, err
+	}
 
-	// write HTML and copy style.css into the temp directory
-	htmlPath := filepath.Join(tmpDir, "index.html")
-	if err := os.WriteFile(htmlPath, []byte(html), 0o644); err != nil {
+	height := int64(float64(width) * paperSizes["a4"][1] / paperSizes["a4"][0])
+	var png []byte
+	err = chromedp.Run(ctx,
+		chromedp.EmulateViewport(int64(width), height),
+		chromedp.Navigate(htmlURL),
+		chromedp.WaitReady("body", chromedp.ByQuery),
+		chromedp.CaptureScreenshot(&png),
+	)
+	if err != nil {
 		return nil, err
 	}
+	return png, nil
+}
 
-	candidates := []string{"./templates/style.css", "templates/style.css", "/app/templates/style.css", "./style.css", "style.css"}
-	for _, c := range candidates {
-		if b, err := os.ReadFile(c); err == nil {
-			_ = os.WriteFile(filepath.Join(tmpDir, "style.css"), b, 0o644)
-			break
+// printHTMLToPDF writes html (plus style.css) into dir, loads it
+// in the chromedp context ctx and prints it to PDF.
+func printHTMLToPDF(ctx context.Context, dir, html string, ro RenderOptions) (_ []byte, err error) {
+	start := time.Now()
+	defer func() {
+		metrics.PDFRenderDuration.Observe(time.Since(start).Seconds())
+		if err != nil {
+			metrics.PDFRenderFailures.Inc()
 		}
+	}()
+
+	if ro.PageRanges != "" && !ValidPageRanges(ro.PageRanges) {
+		return nil, fmt.Errorf("invalid page ranges %q", ro.PageRanges)
+	}
+
+	htmlURL, err := writeRenderFiles(dir, html)
+	if err != nil {
+		return nil, err
 	}
 
 	var pdfBuf []byte
-	htmlURL := "file://" + htmlPath
 
 	// Try to run navigation + print; chromedp will start Chrome via the allocator
-	err = chromedp.Run(ctx2,
+	err = chromedp.Run(ctx,
 		chromedp.Navigate(htmlURL),
 		chromedp.WaitReady("body", chromedp.ByQuery),
 		chromedp.ActionFunc(func(ctx context.Context) error {
 			var err error
-			// A4: 210mm x 297mm -> inches: 8.27 x 11.69
-			pdfBuf, _, err = page.PrintToPDF().WithPrintBackground(true).
-				WithPaperWidth(8.27).
-				WithPaperHeight(11.69).
-				WithPreferCSSPageSize(true).
+			pdfBuf, _, err = page.PrintToPDF().WithPrintBackground(ro.PrintBackground).
+				WithPaperWidth(ro.PaperWidth).
+				WithPaperHeight(ro.PaperHeight).
+				WithMarginTop(ro.MarginTop).
+				WithMarginBottom(ro.MarginBottom).
+				WithMarginLeft(ro.MarginLeft).
+				WithMarginRight(ro.MarginRight).
+				WithLandscape(ro.Landscape).
+				WithPreferCSSPageSize(ro.PreferCSSPageSize).
+				WithPageRanges(ro.PageRanges).
 				Do(ctx)
 			return err
 		}),
@@ -100,5 +271,14 @@ func (r *ChromedpRenderer) RenderHTMLToPDF(ctx context.Context, html string) ([]
 	if err != nil {
 		return nil, err
 	}
+	if !ro.Metadata.IsZero() {
+		// Chrome only sets the title from <title>; a PDF whose metadata
+		// cannot be updated is still a usable resume
+		if withMeta, err := SetPDFMetadata(pdfBuf, ro.Metadata, time.Now()); err != nil {
+			logctx.Warnf(ctx, "renderer: set pdf metadata failed: %v", err)
+		} else {
+			pdfBuf = withMeta
+		}
+	}
 	return pdfBuf, nil
 }
@@ -0,0 +1,192 @@
+package infrastructure
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// DocxRenderer converts the generated resume HTML into a minimal Word
+// document. It keeps the reading order of the HTML and maps headings, list
+// items and text blocks to paragraphs; layout and CSS are not preserved.
+type DocxRenderer struct{}
+
+func NewDocxRenderer() *DocxRenderer { return &DocxRenderer{} }
+
+// docxParagraph is a single paragraph in the output document.
+type docxParagraph struct {
+	style  string // Word style id, e.g. "Heading1"; empty for body text
+	bullet bool
+	bold   bool
+	text   string
+}
+
+func (r *DocxRenderer) RenderHTMLToDOCX(ctx context.Context, htmlStr string) ([]byte, error) {
+	doc, err := html.Parse(strings.NewReader(htmlStr))
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var paras []docxParagraph
+	collectParagraphs(doc, &paras)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := []struct {
+		name string
+		body string
+	}{
+		{"[Content_Types].xml", docxContentTypes},
+		{"_rels/.rels", docxRootRels},
+		{"word/_rels/document.xml.rels", docxDocumentRels},
+		{"word/styles.xml", docxStyles},
+		{"word/document.xml", buildDocumentXML(paras)},
+	}
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write([]byte(f.body)); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// collectParagraphs walks the HTML tree and emits one paragraph per block
+// level element that directly contains text.
+func collectParagraphs(n *html.Node, out *[]docxParagraph) {
+	if n.Type == html.ElementNode {
+		if strings.Contains(attr(n, "class"), "visually-hidden") {
+			return
+		}
+		switch n.Data {
+		case "head", "style", "script", "title":
+			return
+		case "h1", "h2", "h3", "h4":
+			level := map[string]string{"h1": "Heading1", "h2": "Heading1", "h3": "Heading2", "h4": "Heading3"}[n.Data]
+			appendParagraph(out, docxParagraph{style: level, text: nodeText(n)})
+			return
+		case "li":
+			appendParagraph(out, docxParagraph{bullet: true, text: nodeText(n)})
+			return
+		case "p":
+			appendParagraph(out, docxParagraph{text: nodeText(n)})
+			return
+		case "div":
+			if !hasBlockChild(n) {
+				p := docxParagraph{text: nodeText(n)}
+				if cls := attr(n, "class"); cls == "name" {
+					p.style = "Title"
+				} else if cls == "role-head" || cls == "proj-title" {
+					p.bold = true
+				}
+				appendParagraph(out, p)
+				return
+			}
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		collectParagraphs(c, out)
+	}
+}
+
+func appendParagraph(out *[]docxParagraph, p docxParagraph) {
+	if p.text == "" {
+		return
+	}
+	*out = append(*out, p)
+}
+
+// hasBlockChild reports whether n contains nested block elements, in which
+// case its children are emitted as separate paragraphs.
+func hasBlockChild(n *html.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		switch c.Data {
+		case "div", "p", "ul", "ol", "li", "section", "header", "main", "h1", "h2", "h3", "h4":
+			return true
+		}
+		if hasBlockChild(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeText returns the whitespace-collapsed text content of n.
+func nodeText(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+			sb.WriteString(" ")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func buildDocumentXML(paras []docxParagraph) string {
+	var sb strings.Builder
+	sb.WriteString(xml.Header)
+	sb.WriteString(`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>`)
+	for _, p := range paras {
+		sb.WriteString("<w:p>")
+		if p.style != "" {
+			sb.WriteString(`<w:pPr><w:pStyle w:val="` + p.style + `"/></w:pPr>`)
+		}
+		text := p.text
+		if p.bullet {
+			text = "• " + text
+		}
+		sb.WriteString("<w:r>")
+		if p.bold {
+			sb.WriteString("<w:rPr><w:b/></w:rPr>")
+		}
+		sb.WriteString(`<w:t xml:space="preserve">`)
+		_ = xml.EscapeText(&sb, []byte(text))
+		sb.WriteString("</w:t></w:r></w:p>")
+	}
+	sb.WriteString(`<w:sectPr><w:pgSz w:w="11906" w:h="16838"/><w:pgMar w:top="1134" w:right="1134" w:bottom="1134" w:left="1134"/></w:sectPr>`)
+	sb.WriteString("</w:body></w:document>")
+	return sb.String()
+}
+
+const docxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/><Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/></Types>`
+
+const docxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/></Relationships>`
+
+const docxDocumentRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`
+
+const docxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:docDefaults><w:rPrDefault><w:rPr><w:rFonts w:ascii="Calibri" w:hAnsi="Calibri" w:cs="Calibri"/><w:sz w:val="21"/></w:rPr></w:rPrDefault><w:pPrDefault><w:pPr><w:spacing w:after="80"/></w:pPr></w:pPrDefault></w:docDefaults><w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/></w:style><w:style w:type="paragraph" w:styleId="Title"><w:name w:val="Title"/><w:basedOn w:val="Normal"/><w:rPr><w:b/><w:sz w:val="40"/></w:rPr></w:style><w:style w:type="paragraph" w:styleId="Heading1"><w:name w:val="heading 1"/><w:basedOn w:val="Normal"/><w:pPr><w:spacing w:before="240"/></w:pPr><w:rPr><w:b/><w:sz w:val="28"/></w:rPr></w:style><w:style w:type="paragraph" w:styleId="Heading2"><w:name w:val="heading 2"/><w:basedOn w:val="Normal"/><w:pPr><w:spacing w:before="160"/></w:pPr><w:rPr><w:b/><w:sz w:val="24"/></w:rPr></w:style><w:style w:type="paragraph" w:styleId="Heading3"><w:name w:val="heading 3"/><w:basedOn w:val="Normal"/><w:rPr><w:b/></w:rPr></w:style></w:styles>`
@@ -0,0 +1,222 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"resume-generator/pkg/logctx"
+
+	"github.com/chromedp/chromedp"
+)
+
+// ErrRendererClosed is returned when rendering on a closed pool.
+var ErrRendererClosed = errors.New("renderer pool closed")
+
+// pooledBrowser is a long-lived Chrome process with its own user-data-dir.
+type pooledBrowser struct {
+	allocCancel   context.CancelFunc
+	browserCtx    context.Context
+	browserCancel context.CancelFunc
+	dataDir       string
+}
+
+func (b *pooledBrowser) close() {
+	b.browserCancel()
+	b.allocCancel()
+	_ = os.RemoveAll(b.dataDir)
+}
+
+// PooledChromedpRenderer keeps up to size warm Chrome instances and renders
+// each request in a fresh tab of one of them, avoiding the multi-second cold
+// start of a new browser per render.
+type PooledChromedpRenderer struct {
+	slots chan struct{}
+	idle  chan *pooledBrowser
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewPooledChromedpRenderer creates a pool of size browsers and tries to
+// pre-warm them. Browsers that fail to start are launched lazily on the
+// next render instead.
+func NewPooledChromedpRenderer(size int) *PooledChromedpRenderer {
+	if size < 1 {
+		size = 1
+	}
+	r := &PooledChromedpRenderer{
+		slots: make(chan struct{}, size),
+		idle:  make(chan *pooledBrowser, size),
+	}
+	for i := 0; i < size; i++ {
+		b, err := launchBrowser()
+		if err != nil {
+			logctx.From(context.Background()).Warn("renderer: pre-warming chrome failed", "error", err)
+			break
+		}
+		r.idle <- b
+	}
+	return r
+}
+
+// launchBrowser starts a new Chrome process and waits until it responds.
+func launchBrowser() (*pooledBrowser, error) {
+	dir, err := os.MkdirTemp("/tmp", "resume-pool-")
+	if err != nil {
+		return nil, err
+	}
+	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), execAllocatorOptions(dir)...)
+	browserCtx, browserCancel := chromedp.NewContext(allocCtx)
+	b := &pooledBrowser{allocCancel: allocCancel, browserCtx: browserCtx, browserCancel: browserCancel, dataDir: dir}
+
+	// the first Run on a browser context starts Chrome
+	if err := chromedp.Run(browserCtx); err != nil {
+		b.close()
+		return nil, err
+	}
+	return b, nil
+}
+
+// healthy reports whether the browser process is still reachable.
+func (b *pooledBrowser) healthy() bool {
+	if b.browserCtx.Err() != nil {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(b.browserCtx, 5*time.Second)
+	defer cancel()
+	_, err := chromedp.Targets(ctx)
+	return err == nil
+}
+
+// acquire waits for a free slot and returns a healthy browser, launching or
+// replacing one as needed.
+func (r *PooledChromedpRenderer) acquire(ctx context.Context) (*pooledBrowser, error) {
+	select {
+	case r.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	r.mu.Lock()
+	closed := r.closed
+	r.mu.Unlock()
+	if closed {
+		<-r.slots
+		return nil, ErrRendererClosed
+	}
+
+	select {
+	case b := <-r.idle:
+		if b.healthy() {
+			return b, nil
+		}
+		logctx.Warnf(ctx, "renderer: pooled chrome is unhealthy, recreating")
+		b.close()
+	default:
+	}
+
+	b, err := launchBrowser()
+	if err != nil {
+		<-r.slots
+		return nil, err
+	}
+	return b, nil
+}
+
+// release returns b to the pool, or discards it when broken or the pool is
+// closed.
+func (r *PooledChromedpRenderer) release(b *pooledBrowser, broken bool) {
+	defer func() { <-r.slots }()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if broken || r.closed {
+		b.close()
+		return
+	}
+	select {
+	case r.idle <- b:
+	default:
+		b.close()
+	}
+}
+
+// RenderHTMLToPDF renders html with DefaultRenderOptions.
+func (r *PooledChromedpRenderer) RenderHTMLToPDF(ctx context.Context, html string) ([]byte, error) {
+	return r.RenderHTMLToPDFWithOptions(ctx, html, DefaultRenderOptions())
+}
+
+// RenderHTMLToPDFWithOptions renders html in a new tab of a pooled browser.
+func (r *PooledChromedpRenderer) RenderHTMLToPDFWithOptions(ctx context.Context, html string, ro RenderOptions) ([]byte, error) {
+	return r.inTab(ctx, func(tabCtx context.Context, dir string) ([]byte, error) {
+		return printHTMLToPDF(tabCtx, dir, html, ro)
+	})
+}
+
+// RenderHTMLToPNG captures a preview of html in a new tab of a pooled
+// browser.
+func (r *PooledChromedpRenderer) RenderHTMLToPNG(ctx context.Context, html string, width int) ([]byte, error) {
+	return r.inTab(ctx, func(tabCtx context.Context, dir string) ([]byte, error) {
+		return captureHTMLToPNG(tabCtx, dir, html, width)
+	})
+}
+
+// inTab runs fn in a fresh tab of a pooled browser with its own temp dir.
+func (r *PooledChromedpRenderer) inTab(ctx context.Context, fn func(tabCtx context.Context, dir string) ([]byte, error)) ([]byte, error) {
+	b, err := r.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpDir, err := os.MkdirTemp("/tmp", "resume-")
+	if err != nil {
+		r.release(b, false)
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	tabCtx, cancelTab := chromedp.NewContext(b.browserCtx)
+	defer cancelTab()
+	tabCtx, cancelTimeout := context.WithTimeout(tabCtx, 60*time.Second)
+	defer cancelTimeout()
+
+	// tab contexts derive from the browser, so tie them to the caller's ctx
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			cancelTab()
+		case <-done:
+		}
+	}()
+
+	out, err := fn(tabCtx, tmpDir)
+	r.release(b, err != nil && !b.healthy())
+	if err != nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return out, err
+}
+
+// Close shuts down all idle browsers. In-flight renders finish and their
+// browsers are discarded on release.
+func (r *PooledChromedpRenderer) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+	r.closed = true
+	for {
+		select {
+		case b := <-r.idle:
+			b.close()
+		default:
+			return
+		}
+	}
+}
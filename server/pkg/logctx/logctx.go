@@ -0,0 +1,92 @@
+// Package logctx carries a structured logger on a context so log lines
+// emitted deep in the pipeline keep the request and job they belong to.
+package logctx
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+type loggerKey struct{}
+
+// WithLogger returns a copy of ctx carrying l.
+func WithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// From returns the logger stored on ctx, or slog.Default().
+func From(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+			return l
+		}
+	}
+	return slog.Default()
+}
+
+// With returns a copy of ctx whose logger has args attached.
+func With(ctx context.Context, args ...any) context.Context {
+	return WithLogger(ctx, From(ctx).With(args...))
+}
+
+// Printf formats a message and logs it at info level with the attributes
+// of the logger on ctx. A trailing newline is dropped.
+func Printf(ctx context.Context, format string, args ...any) {
+	logf(ctx, slog.LevelInfo, format, args...)
+}
+
+// Debugf is Printf at debug level, for payload dumps and other output only
+// wanted while troubleshooting.
+func Debugf(ctx context.Context, format string, args ...any) {
+	logf(ctx, slog.LevelDebug, format, args...)
+}
+
+// Warnf is Printf at warn level, for recoverable failures.
+func Warnf(ctx context.Context, format string, args ...any) {
+	logf(ctx, slog.LevelWarn, format, args...)
+}
+
+// Errorf is Printf at error level.
+func Errorf(ctx context.Context, format string, args ...any) {
+	logf(ctx, slog.LevelError, format, args...)
+}
+
+// logf skips formatting when the logger would drop the message, so
+// disabled debug dumps of large payloads cost nothing.
+func logf(ctx context.Context, level slog.Level, format string, args ...any) {
+	l := From(ctx)
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !l.Enabled(ctx, level) {
+		return
+	}
+	l.Log(ctx, level, strings.TrimSuffix(fmt.Sprintf(format, args...), "\n"))
+}
+
+// NewLogger builds the process logger. level is debug, info, warn or error
+// (default info); format "json" selects the JSON handler for log
+// aggregation, anything else the text handler.
+func NewLogger(w io.Writer, level, format string) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: ParseLevel(level)}
+	if strings.EqualFold(format, "json") {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// ParseLevel maps a LOG_LEVEL value to a slog level, defaulting to info.
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
@@ -0,0 +1,32 @@
+package metrics
+
+// Job lifecycle counters, incremented by usecase.Processor.Process.
+var (
+	JobsStarted   = Default.NewCounter("resume_jobs_started_total", "Jobs picked up by the processor.")
+	JobsCompleted = Default.NewCounter("resume_jobs_completed_total", "Jobs that finished successfully.")
+	JobsFailed    = Default.NewCounter("resume_jobs_failed_total", "Jobs that ended with an error.")
+	JobsCancelled = Default.NewCounter("resume_jobs_cancelled_total", "Jobs cancelled while running.")
+	// JobFailures counts failed and timed out jobs by the stage recorded
+	// in metadata.failed_stage.
+	JobFailures = Default.NewCounterVec("resume_job_failures_total", "Jobs that failed, by pipeline stage.", "stage")
+)
+
+// AI service metrics.
+var (
+	// AIStageDuration is the time spent in the AI call of a pipeline stage.
+	AIStageDuration = Default.NewHistogramVec("resume_ai_stage_duration_seconds", "Duration of AI calls per pipeline stage.", nil, "stage")
+	// AIRetries counts POSTs to the ai-service that were retried.
+	AIRetries = Default.NewCounterVec("resume_ai_retries_total", "Retried requests to the ai-service.", "path")
+	// AIJSONRepairs counts responses sent back to the ai-service because
+	// they were not valid JSON, by whether the repair succeeded.
+	AIJSONRepairs = Default.NewCounterVec("resume_ai_json_repairs_total", "AI responses re-prompted to repair invalid JSON.", "result")
+)
+
+// PDF rendering metrics, recorded for both the one-shot and pooled renderers.
+var (
+	PDFRenderDuration = Default.NewHistogram("resume_pdf_render_duration_seconds", "Duration of HTML to PDF rendering.", nil)
+	PDFRenderFailures = Default.NewCounter("resume_pdf_render_failures_total", "PDF renders that returned an error.")
+)
+
+// ValidationFailures counts documents rejected by a JSON schema.
+var ValidationFailures = Default.NewCounterVec("resume_schema_validation_failures_total", "Documents that failed JSON schema validation.", "schema")
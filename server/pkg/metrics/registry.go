@@ -0,0 +1,295 @@
+// Package metrics collects counters and histograms and renders them in the
+// Prometheus text exposition format, so /metrics can be scraped without
+// pulling in the Prometheus client library.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefBuckets are histogram upper bounds in seconds suited to request and
+// render latencies.
+var DefBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120}
+
+// collector is a metric family that can write itself to a registry dump.
+type collector interface {
+	write(w io.Writer) error
+}
+
+// Registry holds metric families in registration order.
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Default is the registry the package-level metrics are registered in.
+var Default = NewRegistry()
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// Write writes every registered metric in the Prometheus text format.
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	cs := append([]collector(nil), r.collectors...)
+	r.mu.Unlock()
+	for _, c := range cs {
+		if err := c.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ContentType is the Content-Type of the output of Registry.Write.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// family holds the children of a labelled metric keyed by label values.
+type family[T any] struct {
+	name, help, kind string
+	labels           []string
+	newChild         func() *T
+
+	mu       sync.Mutex
+	children map[string]*T
+	values   map[string][]string
+}
+
+func newFamily[T any](name, help, kind string, labels []string, newChild func() *T) *family[T] {
+	return &family[T]{name: name, help: help, kind: kind, labels: labels, newChild: newChild, children: map[string]*T{}, values: map[string][]string{}}
+}
+
+func (f *family[T]) with(vals []string) *T {
+	if len(vals) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.name, len(f.labels), len(vals)))
+	}
+	key := strings.Join(vals, "\xff")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, ok := f.children[key]
+	if !ok {
+		c = f.newChild()
+		f.children[key] = c
+		f.values[key] = append([]string(nil), vals...)
+	}
+	return c
+}
+
+// each calls fn for every child in a stable order.
+func (f *family[T]) each(fn func(labels string, c *T) error) error {
+	f.mu.Lock()
+	keys := make([]string, 0, len(f.children))
+	for k := range f.children {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	type entry struct {
+		labels string
+		c      *T
+	}
+	entries := make([]entry, 0, len(keys))
+	for _, k := range keys {
+		entries = append(entries, entry{formatLabels(f.labels, f.values[k]), f.children[k]})
+	}
+	f.mu.Unlock()
+
+	for _, e := range entries {
+		if err := fn(e.labels, e.c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *family[T]) header(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, escapeHelp(f.help), f.name, f.kind)
+	return err
+}
+
+// Counter is a monotonically increasing value.
+type Counter struct {
+	mu sync.Mutex
+	v  float64
+}
+
+func (c *Counter) Inc() { c.Add(1) }
+
+// Add increases the counter by v; negative values are ignored.
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		return
+	}
+	c.mu.Lock()
+	c.v += v
+	c.mu.Unlock()
+}
+
+func (c *Counter) value() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.v
+}
+
+// CounterVec is a counter partitioned by label values.
+type CounterVec struct {
+	f *family[Counter]
+}
+
+// NewCounterVec registers a counter family in r.
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	v := &CounterVec{f: newFamily(name, help, "counter", labels, func() *Counter { return &Counter{} })}
+	r.register(v)
+	return v
+}
+
+// NewCounter registers an unlabelled counter in r.
+func (r *Registry) NewCounter(name, help string) *Counter {
+	return r.NewCounterVec(name, help).WithLabelValues()
+}
+
+// WithLabelValues returns the counter for vals, creating it on first use.
+func (v *CounterVec) WithLabelValues(vals ...string) *Counter {
+	return v.f.with(vals)
+}
+
+func (v *CounterVec) write(w io.Writer) error {
+	if err := v.f.header(w); err != nil {
+		return err
+	}
+	return v.f.each(func(labels string, c *Counter) error {
+		_, err := fmt.Fprintf(w, "%s%s %s\n", v.f.name, labels, formatFloat(c.value()))
+		return err
+	})
+}
+
+// Histogram counts observations in cumulative buckets.
+type Histogram struct {
+	buckets []float64
+
+	mu     sync.Mutex
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func newHistogram(buckets []float64) *Histogram {
+	return &Histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+// Observe records v.
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, b := range h.buckets {
+		if v <= b {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// HistogramVec is a histogram partitioned by label values.
+type HistogramVec struct {
+	f *family[Histogram]
+}
+
+// NewHistogramVec registers a histogram family in r. A nil buckets uses
+// DefBuckets.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	v := &HistogramVec{f: newFamily(name, help, "histogram", labels, func() *Histogram { return newHistogram(buckets) })}
+	r.register(v)
+	return v
+}
+
+// NewHistogram registers an unlabelled histogram in r.
+func (r *Registry) NewHistogram(name, help string, buckets []float64) *Histogram {
+	return r.NewHistogramVec(name, help, buckets).WithLabelValues()
+}
+
+// WithLabelValues returns the histogram for vals, creating it on first use.
+func (v *HistogramVec) WithLabelValues(vals ...string) *Histogram {
+	return v.f.with(vals)
+}
+
+func (v *HistogramVec) write(w io.Writer) error {
+	if err := v.f.header(w); err != nil {
+		return err
+	}
+	return v.f.each(func(labels string, h *Histogram) error {
+		h.mu.Lock()
+		counts := append([]uint64(nil), h.counts...)
+		sum, count := h.sum, h.count
+		h.mu.Unlock()
+
+		for i, b := range h.buckets {
+			if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", v.f.name, withLabel(labels, "le", formatFloat(b)), counts[i]); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", v.f.name, withLabel(labels, "le", "+Inf"), count); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintf(w, "%s_sum%s %s\n%s_count%s %d\n", v.f.name, labels, formatFloat(sum), v.f.name, labels, count)
+		return err
+	})
+}
+
+// formatLabels renders {name="value",...}, or "" without labels.
+func formatLabels(names, vals []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	parts := make([]string, len(names))
+	for i, n := range names {
+		parts[i] = n + `="` + escapeLabel(vals[i]) + `"`
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// withLabel appends name="value" to an already formatted label set.
+func withLabel(labels, name, value string) string {
+	l := name + `="` + value + `"`
+	if labels == "" {
+		return "{" + l + "}"
+	}
+	return labels[:len(labels)-1] + "," + l + "}"
+}
+
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
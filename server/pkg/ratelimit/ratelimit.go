@@ -0,0 +1,119 @@
+// Package ratelimit implements token-bucket rate limiting, in memory or
+// shared between replicas through Redis.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limiter takes tokens from the bucket of key. Allow takes one; AllowN
+// takes n at once, or none, and a request for more than the burst takes
+// a full bucket. When the bucket holds too few tokens, they return false
+// and how long until enough are available. Implementations must be safe
+// for concurrent use.
+type Limiter interface {
+	Allow(ctx context.Context, key string) (ok bool, retryAfter time.Duration, err error)
+	AllowN(ctx context.Context, key string, n int) (ok bool, retryAfter time.Duration, err error)
+}
+
+// Config sets the bucket size: each key may take Burst tokens at once and
+// gets Rate tokens back per second.
+type Config struct {
+	Rate  float64
+	Burst int
+}
+
+// normalized returns c with a Burst of at least 1.
+func (c Config) normalized() Config {
+	if c.Burst < 1 {
+		c.Burst = 1
+	}
+	return c
+}
+
+// cost returns the tokens AllowN(n) takes: n, at least 1 and at most the
+// burst.
+func (c Config) cost(n int) int {
+	return min(max(n, 1), c.Burst)
+}
+
+// waitFor returns how long a bucket holding tokens takes to hold n.
+func (c Config) waitFor(tokens float64, n int) time.Duration {
+	if tokens >= float64(n) {
+		return 0
+	}
+	if c.Rate <= 0 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(math.Ceil((float64(n) - tokens) / c.Rate * float64(time.Second)))
+}
+
+// MemoryLimiter keeps the buckets in process memory. It is the default; a
+// service running several replicas should use RedisLimiter so the limit
+// applies across them.
+type MemoryLimiter struct {
+	cfg Config
+	now func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	calls   int
+}
+
+type bucket struct {
+	tokens float64
+	at     time.Time
+}
+
+// sweepEvery is how many calls pass between removals of full buckets.
+const sweepEvery = 1024
+
+// NewMemoryLimiter returns an in-memory limiter for cfg.
+func NewMemoryLimiter(cfg Config) *MemoryLimiter {
+	return &MemoryLimiter{cfg: cfg.normalized(), now: time.Now, buckets: map[string]*bucket{}}
+}
+
+func (l *MemoryLimiter) Allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	return l.AllowN(ctx, key, 1)
+}
+
+func (l *MemoryLimiter) AllowN(_ context.Context, key string, n int) (bool, time.Duration, error) {
+	n = l.cfg.cost(n)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.cfg.Burst), at: now}
+		l.buckets[key] = b
+	}
+	b.tokens = l.refill(b, now)
+	b.at = now
+
+	if l.calls++; l.calls%sweepEvery == 0 {
+		l.sweep(now)
+	}
+	if b.tokens < float64(n) {
+		return false, l.cfg.waitFor(b.tokens, n), nil
+	}
+	b.tokens -= float64(n)
+	return true, 0, nil
+}
+
+// refill returns the tokens of b at now.
+func (l *MemoryLimiter) refill(b *bucket, now time.Time) float64 {
+	return math.Min(float64(l.cfg.Burst), b.tokens+now.Sub(b.at).Seconds()*l.cfg.Rate)
+}
+
+// sweep drops the buckets that have refilled, which behave like new ones,
+// so idle keys do not accumulate.
+func (l *MemoryLimiter) sweep(now time.Time) {
+	for k, b := range l.buckets {
+		if l.refill(b, now) >= float64(l.cfg.Burst) {
+			delete(l.buckets, k)
+		}
+	}
+}
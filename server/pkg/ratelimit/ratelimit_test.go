@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	type call struct {
+		after  time.Duration // advance the clock first
+		n      int
+		ok     bool
+		waitAt time.Duration // expected retryAfter when !ok
+	}
+	tests := []struct {
+		name  string
+		cfg   Config
+		calls []call
+	}{
+		{
+			name: "burst then refill",
+			cfg:  Config{Rate: 1, Burst: 2},
+			calls: []call{
+				{n: 1, ok: true},
+				{n: 1, ok: true},
+				{n: 1, ok: false, waitAt: time.Second},
+				{after: 500 * time.Millisecond, n: 1, ok: false, waitAt: 500 * time.Millisecond},
+				{after: 500 * time.Millisecond, n: 1, ok: true},
+			},
+		},
+		{
+			name: "refill caps at burst",
+			cfg:  Config{Rate: 1, Burst: 2},
+			calls: []call{
+				{n: 2, ok: true},
+				{after: time.Hour, n: 2, ok: true},
+				{n: 1, ok: false, waitAt: time.Second},
+			},
+		},
+		{
+			name: "n tokens at once or none",
+			cfg:  Config{Rate: 1, Burst: 5},
+			calls: []call{
+				{n: 3, ok: true},
+				{n: 3, ok: false, waitAt: time.Second},
+				{n: 2, ok: true},
+			},
+		},
+		{
+			name: "more than the burst takes a full bucket",
+			cfg:  Config{Rate: 2, Burst: 3},
+			calls: []call{
+				{n: 10, ok: true},
+				{n: 1, ok: false, waitAt: 500 * time.Millisecond},
+				{after: time.Second, n: 10, ok: false, waitAt: 500 * time.Millisecond},
+			},
+		},
+		{
+			name: "zero rate never refills",
+			cfg:  Config{Burst: 1},
+			calls: []call{
+				{n: 1, ok: true},
+				{after: time.Hour, n: 1, ok: false, waitAt: time.Duration(1<<63 - 1)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Unix(0, 0)
+			l := NewMemoryLimiter(tt.cfg)
+			l.now = func() time.Time { return now }
+			for i, c := range tt.calls {
+				now = now.Add(c.after)
+				ok, wait, err := l.AllowN(context.Background(), "k", c.n)
+				if err != nil {
+					t.Fatalf("call %d: %v", i, err)
+				}
+				if ok != c.ok {
+					t.Fatalf("call %d: AllowN(%d) = %v, want %v", i, c.n, ok, c.ok)
+				}
+				if !ok && wait != c.waitAt {
+					t.Errorf("call %d: retryAfter = %v, want %v", i, wait, c.waitAt)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryLimiterKeysAreIndependent(t *testing.T) {
+	l := NewMemoryLimiter(Config{Rate: 1, Burst: 1})
+	ctx := context.Background()
+	if ok, _, _ := l.Allow(ctx, "user:a"); !ok {
+		t.Fatal("first call for user:a rejected")
+	}
+	if ok, _, _ := l.Allow(ctx, "user:b"); !ok {
+		t.Fatal("user:b rejected after user:a emptied its own bucket")
+	}
+}
@@ -0,0 +1,267 @@
+package ratelimit
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redisDialTimeout bounds connecting to Redis; each call is further bounded
+// by its context, or redisCallTimeout without a deadline.
+const (
+	redisDialTimeout = 5 * time.Second
+	redisCallTimeout = 2 * time.Second
+)
+
+// tokenBucketScript refills and takes from the bucket hash at KEYS[1]
+// atomically, using the Redis clock so replicas agree on time. ARGV is the
+// rate per second, the burst and the tokens to take. It returns
+// {allowed, wait in ms}.
+const tokenBucketScript = `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local n = tonumber(ARGV[3])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local b = redis.call('HMGET', KEYS[1], 'tokens', 'at')
+local tokens = tonumber(b[1]) or burst
+local at = tonumber(b[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - at) / 1000 * rate)
+local allowed, wait = 0, 0
+if tokens >= n then
+  tokens = tokens - n
+  allowed = 1
+elseif rate > 0 then
+  wait = math.ceil((n - tokens) / rate * 1000)
+else
+  wait = -1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'at', tostring(now))
+if rate > 0 then
+  redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+end
+return {allowed, wait}
+`
+
+// RedisLimiter keeps the buckets in Redis so every replica shares them. It
+// speaks the Redis protocol over a single connection, redialed after
+// errors, and runs each check as one Lua script.
+type RedisLimiter struct {
+	cfg    Config
+	addr   string
+	tls    bool
+	user   string
+	pass   string
+	db     int
+	prefix string
+
+	mu   sync.Mutex
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+// NewRedisLimiter returns a limiter for cfg on the Redis server at rawURL,
+// redis://[user:password@]host[:port][/db] or rediss:// for TLS. Keys are
+// stored as prefix + key. No connection is made until the first call.
+func NewRedisLimiter(cfg Config, rawURL, prefix string) (*RedisLimiter, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("ratelimit: parse redis url: %w", err)
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return nil, fmt.Errorf("ratelimit: unsupported redis url scheme %q", u.Scheme)
+	}
+	l := &RedisLimiter{cfg: cfg.normalized(), addr: u.Host, tls: u.Scheme == "rediss", prefix: prefix}
+	if u.Port() == "" {
+		l.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		l.user = u.User.Username()
+		l.pass, _ = u.User.Password()
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if l.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("ratelimit: invalid redis db %q", db)
+		}
+	}
+	return l, nil
+}
+
+func (l *RedisLimiter) Allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	return l.AllowN(ctx, key, 1)
+}
+
+func (l *RedisLimiter) AllowN(ctx context.Context, key string, n int) (bool, time.Duration, error) {
+	n = l.cfg.cost(n)
+	reply, err := l.do(ctx, "EVAL", tokenBucketScript, "1", l.prefix+key,
+		strconv.FormatFloat(l.cfg.Rate, 'f', -1, 64), strconv.Itoa(l.cfg.Burst), strconv.Itoa(n))
+	if err != nil {
+		return false, 0, err
+	}
+	arr, ok := reply.([]interface{})
+	if !ok || len(arr) != 2 {
+		return false, 0, fmt.Errorf("ratelimit: unexpected redis reply %v", reply)
+	}
+	allowed, _ := arr[0].(int64)
+	wait, _ := arr[1].(int64)
+	if allowed == 1 {
+		return true, 0, nil
+	}
+	if wait < 0 {
+		return false, l.cfg.waitFor(0, n), nil
+	}
+	return false, time.Duration(wait) * time.Millisecond, nil
+}
+
+// Close closes the connection, if any.
+func (l *RedisLimiter) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.reset()
+}
+
+// do sends one command and reads its reply. A failed call drops the
+// connection, so the next one starts clean.
+func (l *RedisLimiter) do(ctx context.Context, args ...string) (interface{}, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conn == nil {
+		if err := l.dial(ctx); err != nil {
+			return nil, err
+		}
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(redisCallTimeout)
+	}
+	l.conn.SetDeadline(deadline)
+	reply, err := l.roundTrip(args)
+	if err != nil {
+		l.reset()
+		return nil, err
+	}
+	return reply, nil
+}
+
+func (l *RedisLimiter) dial(ctx context.Context) error {
+	d := &net.Dialer{Timeout: redisDialTimeout}
+	var (
+		conn net.Conn
+		err  error
+	)
+	if l.tls {
+		host, _, _ := net.SplitHostPort(l.addr)
+		td := &tls.Dialer{NetDialer: d, Config: &tls.Config{ServerName: host}}
+		conn, err = td.DialContext(ctx, "tcp", l.addr)
+	} else {
+		conn, err = d.DialContext(ctx, "tcp", l.addr)
+	}
+	if err != nil {
+		return fmt.Errorf("ratelimit: dial redis: %w", err)
+	}
+	l.conn, l.rd = conn, bufio.NewReader(conn)
+	conn.SetDeadline(time.Now().Add(redisDialTimeout))
+
+	if l.pass != "" {
+		args := []string{"AUTH", l.pass}
+		if l.user != "" {
+			args = []string{"AUTH", l.user, l.pass}
+		}
+		if _, err := l.roundTrip(args); err != nil {
+			l.reset()
+			return fmt.Errorf("ratelimit: redis auth: %w", err)
+		}
+	}
+	if l.db != 0 {
+		if _, err := l.roundTrip([]string{"SELECT", strconv.Itoa(l.db)}); err != nil {
+			l.reset()
+			return fmt.Errorf("ratelimit: redis select: %w", err)
+		}
+	}
+	return nil
+}
+
+func (l *RedisLimiter) reset() error {
+	if l.conn == nil {
+		return nil
+	}
+	err := l.conn.Close()
+	l.conn, l.rd = nil, nil
+	return err
+}
+
+// roundTrip writes args as a RESP array and reads the reply.
+func (l *RedisLimiter) roundTrip(args []string) (interface{}, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if _, err := l.conn.Write([]byte(b.String())); err != nil {
+		return nil, err
+	}
+	return readReply(l.rd)
+}
+
+// errRedisProtocol reports a reply that is not valid RESP.
+var errRedisProtocol = errors.New("ratelimit: malformed redis reply")
+
+// readReply reads one RESP reply: strings, integers, nil and arrays of
+// them. Error replies are returned as errors.
+func readReply(rd *bufio.Reader) (interface{}, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errRedisProtocol
+	}
+	body := line[1:]
+	switch line[0] {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, fmt.Errorf("ratelimit: redis: %s", body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, errRedisProtocol
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(rd, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, errRedisProtocol
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		arr := make([]interface{}, n)
+		for i := range arr {
+			if arr[i], err = readReply(rd); err != nil {
+				return nil, err
+			}
+		}
+		return arr, nil
+	}
+	return nil, errRedisProtocol
+}
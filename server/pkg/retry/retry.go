@@ -0,0 +1,131 @@
+// Package retry runs an operation again with exponential backoff. The PDF
+// renderer and the AI client share its Policy so both retry the same way.
+package retry
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Policy bounds the attempts at an operation and the waits between them.
+// The wait after the n-th failure is InitialBackoff doubled n-1 times,
+// capped at MaxBackoff, with up to Jitter of it (0 to 1) randomized away so
+// clients failing together do not retry in step.
+type Policy struct {
+	Attempts       int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Jitter         float64
+}
+
+// DefaultPolicy makes three attempts, waiting 1s and then 2s.
+func DefaultPolicy() Policy {
+	return Policy{Attempts: 3, InitialBackoff: time.Second, MaxBackoff: 30 * time.Second}
+}
+
+// FromEnv returns def with the fields set in <prefix>_ATTEMPTS,
+// <prefix>_INITIAL_BACKOFF, <prefix>_MAX_BACKOFF (durations such as
+// "500ms") and <prefix>_JITTER replaced. Invalid values are ignored.
+func FromEnv(prefix string, def Policy) Policy {
+	p := def
+	if n, err := strconv.Atoi(os.Getenv(prefix + "_ATTEMPTS")); err == nil && n > 0 {
+		p.Attempts = n
+	}
+	if d, err := time.ParseDuration(os.Getenv(prefix + "_INITIAL_BACKOFF")); err == nil && d >= 0 {
+		p.InitialBackoff = d
+	}
+	if d, err := time.ParseDuration(os.Getenv(prefix + "_MAX_BACKOFF")); err == nil && d > 0 {
+		p.MaxBackoff = d
+	}
+	if f, err := strconv.ParseFloat(os.Getenv(prefix+"_JITTER"), 64); err == nil && f >= 0 && f <= 1 {
+		p.Jitter = f
+	}
+	return p
+}
+
+// Backoff returns the wait after the attempt-th failed attempt, counted
+// from 1.
+func (p Policy) Backoff(attempt int) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < attempt && d > 0; i++ {
+		d *= 2
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if p.Jitter > 0 && d > 0 {
+		d -= time.Duration(rand.Float64() * p.Jitter * float64(d))
+	}
+	return d
+}
+
+// Stats reports how a Do call went: the attempts made and the time spent
+// waiting between them.
+type Stats struct {
+	Attempts int
+	Waited   time.Duration
+}
+
+// permanentError stops Do from retrying.
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so Do returns it without further attempts.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err}
+}
+
+// Do calls fn, with the attempt number from 1, until it returns nil, an
+// error wrapped by Permanent or p.Attempts calls were made, and returns
+// the last error unwrapped from Permanent. A policy with fewer than one
+// attempt makes one. ctx ending before an attempt or during a wait stops
+// Do with ctx.Err().
+func (p Policy) Do(ctx context.Context, fn func(attempt int) error) (Stats, error) {
+	attempts := p.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	var stats Stats
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return stats, ctxErr
+		}
+		stats.Attempts = attempt
+		if err = fn(attempt); err == nil {
+			return stats, nil
+		}
+		var perm permanentError
+		if errors.As(err, &perm) {
+			return stats, perm.err
+		}
+		if attempt == attempts {
+			break
+		}
+		wait := p.Backoff(attempt)
+		start := time.Now()
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+			stats.Waited += wait
+		case <-ctx.Done():
+			timer.Stop()
+			stats.Waited += time.Since(start)
+			return stats, ctx.Err()
+		}
+	}
+	return stats, err
+}
@@ -0,0 +1,161 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFromEnv(t *testing.T) {
+	def := DefaultPolicy()
+	tests := []struct {
+		name string
+		env  map[string]string
+		want Policy
+	}{
+		{"unset", nil, def},
+		{
+			name: "all set",
+			env:  map[string]string{"T_ATTEMPTS": "5", "T_INITIAL_BACKOFF": "250ms", "T_MAX_BACKOFF": "4s", "T_JITTER": "0.5"},
+			want: Policy{Attempts: 5, InitialBackoff: 250 * time.Millisecond, MaxBackoff: 4 * time.Second, Jitter: 0.5},
+		},
+		{
+			name: "zero backoff allowed",
+			env:  map[string]string{"T_INITIAL_BACKOFF": "0s"},
+			want: Policy{Attempts: 3, MaxBackoff: 30 * time.Second},
+		},
+		{
+			name: "invalid values ignored",
+			env:  map[string]string{"T_ATTEMPTS": "0", "T_INITIAL_BACKOFF": "soon", "T_MAX_BACKOFF": "-1s", "T_JITTER": "1.5"},
+			want: def,
+		},
+		{
+			name: "not numbers",
+			env:  map[string]string{"T_ATTEMPTS": "three", "T_JITTER": "some"},
+			want: def,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, k := range []string{"T_ATTEMPTS", "T_INITIAL_BACKOFF", "T_MAX_BACKOFF", "T_JITTER"} {
+				t.Setenv(k, tt.env[k])
+			}
+			if got := FromEnv("T", def); got != tt.want {
+				t.Errorf("FromEnv() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := Policy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	tests := []struct {
+		policy  Policy
+		attempt int
+		want    time.Duration
+	}{
+		{p, 1, time.Second},
+		{p, 2, 2 * time.Second},
+		{p, 3, 4 * time.Second},
+		{p, 4, 5 * time.Second},
+		{p, 60, 5 * time.Second},
+		{Policy{InitialBackoff: time.Second}, 4, 8 * time.Second},
+		{Policy{MaxBackoff: time.Second}, 3, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.Backoff(tt.attempt); got != tt.want {
+			t.Errorf("%+v.Backoff(%d) = %v, want %v", tt.policy, tt.attempt, got, tt.want)
+		}
+	}
+
+	jittered := Policy{InitialBackoff: time.Second, Jitter: 0.25}
+	for i := 0; i < 100; i++ {
+		if got := jittered.Backoff(1); got < 750*time.Millisecond || got > time.Second {
+			t.Fatalf("Backoff(1) with 25%% jitter = %v, want within [750ms, 1s]", got)
+		}
+	}
+}
+
+func TestDo(t *testing.T) {
+	errFlaky := errors.New("flaky")
+	errBad := errors.New("bad input")
+	policy := Policy{Attempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+	tests := []struct {
+		name     string
+		policy   Policy
+		failures int   // calls failing with errFlaky before success
+		last     error // returned after the failures instead of nil
+		want     error
+		attempts int
+		waited   time.Duration
+	}{
+		{name: "first try", policy: policy, attempts: 1},
+		{name: "succeeds on the last attempt", policy: policy, failures: 2, attempts: 3, waited: 3 * time.Millisecond},
+		{name: "attempts exhausted", policy: policy, failures: 5, want: errFlaky, attempts: 3, waited: 3 * time.Millisecond},
+		{name: "permanent", policy: policy, failures: 1, last: Permanent(errBad), want: errBad, attempts: 2, waited: time.Millisecond},
+		{name: "zero policy tries once", failures: 1, want: errFlaky, attempts: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			stats, err := tt.policy.Do(context.Background(), func(attempt int) error {
+				calls++
+				if attempt != calls {
+					t.Errorf("attempt %d passed on call %d", attempt, calls)
+				}
+				if calls <= tt.failures {
+					return errFlaky
+				}
+				return tt.last
+			})
+			if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+				t.Fatalf("Do() = %v, want %v", err, tt.want)
+			}
+			var perm permanentError
+			if errors.As(err, &perm) {
+				t.Error("Do() returned the Permanent wrapper")
+			}
+			if stats.Attempts != tt.attempts || calls != tt.attempts {
+				t.Errorf("stats.Attempts = %d after %d calls, want %d", stats.Attempts, calls, tt.attempts)
+			}
+			if stats.Waited != tt.waited {
+				t.Errorf("stats.Waited = %v, want %v", stats.Waited, tt.waited)
+			}
+		})
+	}
+
+	if Permanent(nil) != nil {
+		t.Error("Permanent(nil) != nil")
+	}
+}
+
+func TestDoCancelledDuringBackoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := Policy{Attempts: 3, InitialBackoff: time.Hour}
+	calls := 0
+	start := time.Now()
+	stats, err := p.Do(ctx, func(int) error {
+		calls++
+		time.AfterFunc(10*time.Millisecond, cancel)
+		return errors.New("chrome crashed")
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Do() = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Do() returned after %v, want the backoff interrupted", elapsed)
+	}
+	if calls != 1 || stats.Attempts != 1 {
+		t.Errorf("%d calls, stats.Attempts = %d, want 1", calls, stats.Attempts)
+	}
+	if stats.Waited <= 0 || stats.Waited > time.Second {
+		t.Errorf("stats.Waited = %v, want the time until cancellation", stats.Waited)
+	}
+
+	// a context already done makes no attempt
+	calls = 0
+	if _, err := p.Do(ctx, func(int) error { calls++; return nil }); !errors.Is(err, context.Canceled) || calls != 0 {
+		t.Errorf("Do() on a cancelled context = %v after %d calls", err, calls)
+	}
+}
@@ -0,0 +1,212 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// S3Config configures an S3-compatible bucket. Endpoint may point at AWS or
+// a MinIO deployment; PathStyle addresses the bucket as endpoint/bucket
+// instead of bucket.endpoint, which MinIO requires.
+type S3Config struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	PathStyle       bool
+	// PublicURL, when set, is used as the base of returned object URLs
+	// instead of the endpoint (e.g. a CDN in front of the bucket).
+	PublicURL string
+}
+
+// S3ConfigFromEnv reads S3_ENDPOINT, S3_REGION, S3_BUCKET,
+// S3_ACCESS_KEY_ID, S3_SECRET_ACCESS_KEY (falling back to the AWS_*
+// variables), S3_FORCE_PATH_STYLE and S3_PUBLIC_URL.
+func S3ConfigFromEnv() S3Config {
+	cfg := S3Config{
+		Endpoint:        os.Getenv("S3_ENDPOINT"),
+		Region:          os.Getenv("S3_REGION"),
+		Bucket:          os.Getenv("S3_BUCKET"),
+		AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		PathStyle:       os.Getenv("S3_FORCE_PATH_STYLE") != "false",
+		PublicURL:       os.Getenv("S3_PUBLIC_URL"),
+	}
+	if cfg.AccessKeyID == "" {
+		cfg.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+	}
+	if cfg.SecretAccessKey == "" {
+		cfg.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+	return cfg
+}
+
+// S3Storage uploads objects with SigV4-signed PUT requests.
+type S3Storage struct {
+	cfg      S3Config
+	endpoint *url.URL
+	http     *http.Client
+}
+
+// NewS3Storage validates cfg and returns a Storage backed by the bucket.
+func NewS3Storage(cfg S3Config) (*S3Storage, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("storage: S3 bucket is required")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, errors.New("storage: S3 credentials are required")
+	}
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("storage: invalid S3 endpoint %q", cfg.Endpoint)
+	}
+	return &S3Storage{cfg: cfg, endpoint: u, http: &http.Client{Timeout: 60 * time.Second}}, nil
+}
+
+// objectURL returns the request URL for key and the host to sign.
+func (s *S3Storage) objectURL(key string) (string, string) {
+	path := "/" + awsURIEscape(strings.TrimPrefix(key, "/"))
+	host := s.endpoint.Host
+	if s.cfg.PathStyle {
+		path = "/" + s.cfg.Bucket + path
+	} else {
+		host = s.cfg.Bucket + "." + host
+	}
+	return s.endpoint.Scheme + "://" + host + path, host
+}
+
+// Put uploads data to key and returns the object's URL.
+func (s *S3Storage) Put(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	objURL, host := s.objectURL(key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objURL, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Host = host
+	req.ContentLength = int64(len(data))
+	req.Header.Set("Content-Type", contentType)
+	s.sign(req, host, data, time.Now().UTC())
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("storage: S3 PUT %s returned %d: %s", key, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	if s.cfg.PublicURL != "" {
+		return strings.TrimRight(s.cfg.PublicURL, "/") + "/" + awsURIEscape(strings.TrimPrefix(key, "/")), nil
+	}
+	return objURL, nil
+}
+
+// Delete removes the object under key. S3 answers a missing key with 204
+// as well.
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	objURL, host := s.objectURL(key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, objURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Host = host
+	// content-type is among the signed headers
+	req.Header.Set("Content-Type", "application/octet-stream")
+	s.sign(req, host, nil, time.Now().UTC())
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 && resp.StatusCode != http.StatusNotFound {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("storage: S3 DELETE %s returned %d: %s", key, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// sign adds AWS Signature Version 4 headers for a single-chunk upload.
+func (s *S3Storage) sign(req *http.Request, host string, payload []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(payload)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "content-type;host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "content-type:" + req.Header.Get("Content-Type") + "\n" +
+		"host:" + host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		"",
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.cfg.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretAccessKey), date)
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.cfg.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// awsURIEscape escapes everything but RFC 3986 unreserved characters and
+// '/', as SigV4 canonical URIs require.
+func awsURIEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
@@ -0,0 +1,61 @@
+// Package storage persists generated artifacts either on local disk or in
+// S3-compatible object storage.
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// ErrInvalidKey is returned for keys that are absolute or escape the
+// storage root.
+var ErrInvalidKey = errors.New("storage: invalid key")
+
+// Storage stores an object under key and returns a URL (or local path) at
+// which it can be retrieved. Delete removes the object under key; deleting
+// a missing object is not an error.
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) (string, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// LocalStorage writes objects below BaseDir. It is the default when no
+// object storage is configured.
+type LocalStorage struct {
+	BaseDir string
+}
+
+func NewLocalStorage(baseDir string) *LocalStorage {
+	return &LocalStorage{BaseDir: baseDir}
+}
+
+// Put writes data to BaseDir/key and returns the file path. Keys that are
+// absolute or would escape BaseDir are rejected with ErrInvalidKey.
+func (s *LocalStorage) Put(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(key)) {
+		return "", ErrInvalidKey
+	}
+	path := filepath.Join(s.BaseDir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Delete removes BaseDir/key. Keys that are absolute or would escape
+// BaseDir are rejected with ErrInvalidKey.
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	if !filepath.IsLocal(filepath.FromSlash(key)) {
+		return ErrInvalidKey
+	}
+	err := os.Remove(filepath.Join(s.BaseDir, filepath.FromSlash(key)))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
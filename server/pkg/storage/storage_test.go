@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestLocalStorageDelete(t *testing.T) {
+	ctx := context.Background()
+	s := NewLocalStorage(t.TempDir())
+	path, err := s.Put(ctx, "user/a.pdf", []byte("%PDF"), "application/pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		key  string
+		want error
+	}{
+		{"existing", "user/a.pdf", nil},
+		{"already deleted", "user/a.pdf", nil},
+		{"escapes base dir", "../a.pdf", ErrInvalidKey},
+		{"absolute", "/etc/passwd", ErrInvalidKey},
+	}
+	for _, tt := range tests {
+		if err := s.Delete(ctx, tt.key); !errors.Is(err, tt.want) {
+			t.Errorf("%s: Delete(%q) = %v, want %v", tt.name, tt.key, err, tt.want)
+		}
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("%s still exists after Delete: %v", path, err)
+	}
+}
+
+func TestLocalStoragePutRejectsTraversal(t *testing.T) {
+	s := NewLocalStorage(t.TempDir())
+	for _, key := range []string{"../../etc/passwd", "/etc/passwd", "user/../../x.pdf", ""} {
+		if _, err := s.Put(context.Background(), key, []byte("x"), "text/plain"); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Put(%q) = %v, want ErrInvalidKey", key, err)
+		}
+	}
+}
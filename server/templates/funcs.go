@@ -0,0 +1,146 @@
+package templates
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// FuncMap returns the helpers available to template.html. Register it
+// before parsing:
+//
+//	template.New("template.html").Funcs(templates.FuncMap()).ParseFS(templates.FS(), "template.html")
+func FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"join":           join,
+		"truncateRunes":  truncateRunes,
+		"formatDate":     formatDate,
+		"lower":          strings.ToLower,
+		"upper":          strings.ToUpper,
+		"safeURL":        safeURL,
+		"nl2br":          nl2br,
+		"markdownInline": markdownInline,
+	}
+}
+
+// join concatenates the items of a list with sep; non-string items are
+// formatted with %v and empty ones skipped.
+//
+//	{{ join ", " .stack }}  // ["Go", "Postgres"] -> "Go, Postgres"
+func join(sep string, list interface{}) string {
+	var items []string
+	switch l := list.(type) {
+	case []string:
+		items = l
+	case []interface{}:
+		for _, it := range l {
+			if it == nil {
+				continue
+			}
+			items = append(items, fmt.Sprint(it))
+		}
+	case string:
+		return l
+	}
+	out := items[:0:0]
+	for _, s := range items {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return strings.Join(out, sep)
+}
+
+// truncateRunes shortens s to at most n characters, counted in runes so
+// multi-byte text is never cut mid-character, ending it with "…".
+//
+//	{{ truncateRunes 12 "Développeur backend senior" }}  // "Développeur…"
+func truncateRunes(n int, s string) string {
+	r := []rune(s)
+	if n <= 0 || len(r) <= n {
+		return s
+	}
+	return strings.TrimSpace(string(r[:n-1])) + "…"
+}
+
+// dateLayouts are the input forms formatDate understands, most specific
+// first.
+var dateLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02", "2006-01", "2006"}
+
+// formatDate reformats a date string with a Go layout. Values it cannot
+// parse, such as "Present", are returned unchanged.
+//
+//	{{ formatDate "Jan 2006" "2021-03-15" }}  // "Mar 2021"
+//	{{ formatDate "2006" .date }}            // "2021"
+func formatDate(layout string, v interface{}) string {
+	s, ok := v.(string)
+	if !ok {
+		if v == nil {
+			return ""
+		}
+		return fmt.Sprint(v)
+	}
+	s = strings.TrimSpace(s)
+	for _, in := range dateLayouts {
+		if t, err := time.Parse(in, s); err == nil {
+			return t.Format(layout)
+		}
+	}
+	return s
+}
+
+// safeURL marks http, https and mailto URLs as trusted for href and src
+// attributes; anything else becomes "#" so a javascript: URL from the AI
+// cannot reach the page.
+//
+//	<a href="{{ safeURL .url }}">
+func safeURL(s string) template.URL {
+	s = strings.TrimSpace(s)
+	lower := strings.ToLower(s)
+	for _, scheme := range []string{"http://", "https://", "mailto:"} {
+		if strings.HasPrefix(lower, scheme) {
+			return template.URL(s)
+		}
+	}
+	return "#"
+}
+
+// nl2br escapes s and turns its line breaks into <br>.
+//
+//	{{ nl2br .summary }}  // "a\nb" -> "a<br>b"
+func nl2br(s string) template.HTML {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return template.HTML(strings.ReplaceAll(html.EscapeString(s), "\n", "<br>"))
+}
+
+var (
+	mdCode   = regexp.MustCompile("`([^`]+)`")
+	mdLink   = regexp.MustCompile(`\[([^\]]+)\]\((https?://[^\s)]+)\)`)
+	mdBold   = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	mdItalic = regexp.MustCompile(`\*([^*\s][^*]*)\*|\b_([^_\s][^_]*)_\b`)
+)
+
+// markdownInline renders the inline markdown AI output tends to contain:
+// **bold**, *italic*, `code` and [text](https://link). The text is escaped
+// first, so any HTML in it is shown literally.
+//
+//	{{ markdownInline "Cut **p99 latency** by 40%" }}  // "Cut <strong>p99 latency</strong> by 40%"
+func markdownInline(s string) template.HTML {
+	out := html.EscapeString(s)
+	// code spans first, so their content is not read as emphasis
+	var codes []string
+	out = mdCode.ReplaceAllStringFunc(out, func(m string) string {
+		codes = append(codes, "<code>"+mdCode.FindStringSubmatch(m)[1]+"</code>")
+		return fmt.Sprintf("\x00%d\x00", len(codes)-1)
+	})
+	out = mdLink.ReplaceAllString(out, `<a href="$2">$1</a>`)
+	out = mdBold.ReplaceAllString(out, "<strong>$1$2</strong>")
+	out = mdItalic.ReplaceAllString(out, "<em>$1$2</em>")
+	for i, c := range codes {
+		out = strings.Replace(out, fmt.Sprintf("\x00%d\x00", i), c, 1)
+	}
+	return template.HTML(out)
+}
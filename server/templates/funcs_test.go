@@ -0,0 +1,144 @@
+package templates
+
+import (
+	"bytes"
+	"html/template"
+	"testing"
+)
+
+func TestJoin(t *testing.T) {
+	tests := []struct {
+		name string
+		list interface{}
+		want string
+	}{
+		{"strings", []string{"Go", " Postgres ", ""}, "Go, Postgres"},
+		{"profile list", []interface{}{"Go", nil, 3, "São Paulo"}, "Go, 3, São Paulo"},
+		{"string", "Go, SQL", "Go, SQL"},
+		{"missing", nil, ""},
+	}
+	for _, tt := range tests {
+		if got := join(", ", tt.list); got != tt.want {
+			t.Errorf("%s: join() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	tests := []struct {
+		n    int
+		in   string
+		want string
+	}{
+		{12, "Développeur backend senior", "Développeur…"},
+		{5, "東京都渋谷区", "東京都渋…"},
+		{30, "short", "short"},
+		{6, "Ação!!", "Ação!!"},
+		{0, "unlimited", "unlimited"},
+	}
+	for _, tt := range tests {
+		if got := truncateRunes(tt.n, tt.in); got != tt.want {
+			t.Errorf("truncateRunes(%d, %q) = %q, want %q", tt.n, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	tests := []struct {
+		layout string
+		in     interface{}
+		want   string
+	}{
+		{"Jan 2006", "2021-03-15", "Mar 2021"},
+		{"2006", "2021-03-15T10:00:00Z", "2021"},
+		{"01/2006", "2019-07", "07/2019"},
+		{"Jan 2006", " 2020 ", "Jan 2020"},
+		{"Jan 2006", "Present", "Present"},
+		{"Jan 2006", "atual", "atual"},
+		{"2006", 2021, "2021"},
+		{"2006", nil, ""},
+	}
+	for _, tt := range tests {
+		if got := formatDate(tt.layout, tt.in); got != tt.want {
+			t.Errorf("formatDate(%q, %v) = %q, want %q", tt.layout, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSafeURL(t *testing.T) {
+	tests := []struct {
+		in   string
+		want template.URL
+	}{
+		{"https://example.com/a?b=c", "https://example.com/a?b=c"},
+		{" HTTP://example.com ", "HTTP://example.com"},
+		{"mailto:ana@example.com", "mailto:ana@example.com"},
+		{"javascript:alert(1)", "#"},
+		{"example.com", "#"},
+		{"", "#"},
+	}
+	for _, tt := range tests {
+		if got := safeURL(tt.in); got != tt.want {
+			t.Errorf("safeURL(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNl2br(t *testing.T) {
+	tests := []struct {
+		in   string
+		want template.HTML
+	}{
+		{"a\nb", "a<br>b"},
+		{"a\r\nb", "a<br>b"},
+		{"<b>São</b>\nJosé", "&lt;b&gt;São&lt;/b&gt;<br>José"},
+	}
+	for _, tt := range tests {
+		if got := nl2br(tt.in); got != tt.want {
+			t.Errorf("nl2br(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMarkdownInline(t *testing.T) {
+	tests := []struct {
+		in   string
+		want template.HTML
+	}{
+		{"Cut **p99 latency** by 40%", "Cut <strong>p99 latency</strong> by 40%"},
+		{"__bold__ and *italic* and _also_", "<strong>bold</strong> and <em>italic</em> and <em>also</em>"},
+		{"Ran `go test ./...` **daily**", "Ran <code>go test ./...</code> <strong>daily</strong>"},
+		{"`**not bold**`", "<code>**not bold**</code>"},
+		{"See [the post](https://example.com/x)", `See <a href="https://example.com/x">the post</a>`},
+		{"[bad](javascript:alert(1))", "[bad](javascript:alert(1))"},
+		{"<script>x</script> **Olá**", "&lt;script&gt;x&lt;/script&gt; <strong>Olá</strong>"},
+		{"snake_case_name stays", "snake_case_name stays"},
+	}
+	for _, tt := range tests {
+		if got := markdownInline(tt.in); got != tt.want {
+			t.Errorf("markdownInline(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFuncMapRendersProfile(t *testing.T) {
+	profile := map[string]interface{}{
+		"summary": "Engenheiro **sênior**\nem São Paulo",
+		"projects": []interface{}{
+			map[string]interface{}{"title": "Plataforma de Pagamentos", "url": "https://example.com", "stack": []interface{}{"Go", "Postgres"}, "date": "2023-05-01"},
+			map[string]interface{}{"title": "日本語のプロジェクト名がとても長い", "url": "javascript:alert(1)", "stack": "Rust", "date": "Present"},
+		},
+	}
+	const src = `<p>{{ nl2br .summary }}</p>{{ range .projects }}<a href="{{ safeURL .url }}">{{ upper (truncateRunes 10 .title) }}</a> {{ join " · " .stack }} {{ formatDate "Jan 2006" .date }};{{ end }}`
+	tpl := template.Must(template.New("t").Funcs(FuncMap()).Parse(src))
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, profile); err != nil {
+		t.Fatal(err)
+	}
+	want := `<p>Engenheiro **sênior**<br>em São Paulo</p>` +
+		`<a href="https://example.com">PLATAFORM…</a> Go · Postgres May 2023;` +
+		`<a href="#">日本語のプロジェク…</a> Rust Present;`
+	if buf.String() != want {
+		t.Errorf("rendered\n%s\nwant\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,89 @@
+package templates
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// labelKeys are the section headings the layouts may show.
+var labelKeys = []string{
+	"professional_summary", "tech_snapshot", "top_achievements", "selected_projects",
+	"skills", "experience", "education", "projects_case_studies", "publications",
+	"certifications", "continuous_learning_community", "extras",
+	"page_2_projects_publications", "references_available",
+}
+
+// TestLayoutsGolden renders testdata/resume.json through every layout and
+// compares the result with testdata/<layout>.golden.html. Run
+// go test ./templates -update after changing a layout on purpose.
+func TestLayoutsGolden(t *testing.T) {
+	raw, err := os.ReadFile(filepath.Join("testdata", "resume.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var profile map[string]interface{}
+	if err := json.Unmarshal(raw, &profile); err != nil {
+		t.Fatal(err)
+	}
+	labels := map[string]string{}
+	for _, k := range labelKeys {
+		labels[k] = "Label " + k
+	}
+	// every layout must show these fixture values
+	required := []string{
+		"Ana Souza", "Senior Backend Engineer", "ana@example.com",
+		"Backend engineer with eight years", "Staff Engineer", "PayCo",
+		"Backend Engineer", "ShipFast", "Ledger Service", "PIX Client",
+		"Universidade de São Paulo", "Kubernetes",
+	}
+
+	for _, name := range Names() {
+		t.Run(name, func(t *testing.T) {
+			layout, _ := Lookup(name)
+			tpl, err := template.New(layout.HTML).Funcs(FuncMap()).ParseFS(FS(), layout.HTML)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ReadFile(layout.CSS); err != nil {
+				t.Errorf("stylesheet: %v", err)
+			}
+			var buf bytes.Buffer
+			data := map[string]interface{}{"Profile": profile, "Labels": labels, "Photo": "data:image/png;base64,AAAA"}
+			if err := tpl.Execute(&buf, data); err != nil {
+				t.Fatal(err)
+			}
+			out := buf.String()
+			if strings.Contains(out, "no value") {
+				t.Error("rendered a missing field as <no value>")
+			}
+			for _, s := range required {
+				if !strings.Contains(out, template.HTMLEscapeString(s)) {
+					t.Errorf("output lacks %q", s)
+				}
+			}
+
+			golden := filepath.Join("testdata", name+".golden.html")
+			if *update {
+				if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if out != string(want) {
+				t.Errorf("output differs from %s; run with -update if the change is intended", golden)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+// Package templates holds the resume layouts, their stylesheets, the LaTeX
+// export template and the JSON schemas the AI output is validated against. They are embedded in the
+// binary, so the server does not depend on its working directory; SetDir
+// serves customized copies from disk instead.
+package templates
+
+import (
+	"embed"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+//go:embed *.html *.css *.tmpl resume.schema.json schema/*.json
+var embedded embed.FS
+
+// DefaultDir is where templates live in a source checkout or the container;
+// it is only read for fonts unless SetDir is used.
+const DefaultDir = "templates"
+
+var (
+	mu   sync.RWMutex
+	dir  string
+	fsys fs.FS = embedded
+)
+
+// SetDir serves the templates from dir on disk, e.g. TEMPLATES_DIR. Files
+// dir does not have, such as a schema that was not customized, still come
+// from the embedded copies. An empty dir restores the embedded templates.
+func SetDir(d string) {
+	mu.Lock()
+	defer mu.Unlock()
+	dir = d
+	if d == "" {
+		fsys = embedded
+		return
+	}
+	fsys = overlayFS{disk: os.DirFS(d), fallback: embedded}
+}
+
+// Dir returns the directory set with SetDir, or DefaultDir.
+func Dir() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	if dir == "" {
+		return DefaultDir
+	}
+	return dir
+}
+
+// FS returns the templates in use. Names are slash separated and relative
+// to the templates directory, e.g. "schema/experience.schema.json".
+func FS() fs.FS {
+	mu.RLock()
+	defer mu.RUnlock()
+	return fsys
+}
+
+// ReadFile reads name from FS.
+func ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(FS(), filepath.ToSlash(name))
+}
+
+// Layout is a named resume template: an HTML template and the stylesheet
+// inlined into it. Every layout renders the same Profile, Labels and Photo
+// data.
+type Layout struct {
+	Name string
+	HTML string
+	CSS  string
+}
+
+// DefaultLayout is rendered when a job does not pick a layout.
+const DefaultLayout = "classic"
+
+var layouts = map[string]Layout{
+	"classic":    {Name: "classic", HTML: "template.html", CSS: "style.css"},
+	"compact":    {Name: "compact", HTML: "compact.html", CSS: "compact.css"},
+	"two-column": {Name: "two-column", HTML: "two-column.html", CSS: "two-column.css"},
+}
+
+// Lookup returns the layout called name; an empty name is DefaultLayout.
+func Lookup(name string) (Layout, bool) {
+	if name == "" {
+		name = DefaultLayout
+	}
+	l, ok := layouts[name]
+	return l, ok
+}
+
+// Names returns the names of all layouts, sorted.
+func Names() []string {
+	names := make([]string, 0, len(layouts))
+	for name := range layouts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// overlayFS opens files from disk, falling back to fallback for files disk
+// does not have.
+type overlayFS struct {
+	disk     fs.FS
+	fallback fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	f, err := o.disk.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return o.fallback.Open(name)
+	}
+	return f, err
+}
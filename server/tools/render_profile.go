@@ -7,6 +7,9 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+
+	"resume-generator/pkg/ai/formatters"
+	"resume-generator/templates"
 )
 
 func main() {
@@ -22,13 +25,20 @@ func main() {
 		os.Exit(2)
 	}
 	profile, _ := m["profile"].(map[string]interface{})
-	tplPath := filepath.Join("templates", "template.html")
-	tpl, err := template.ParseFiles(tplPath)
+	tpl, err := template.New("template.html").Funcs(templates.FuncMap()).ParseFS(templates.FS(), "template.html")
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "parse tpl: %v\n", err)
 		os.Exit(2)
 	}
-	data := map[string]interface{}{"Profile": profile}
+	labels := formatters.GetDefaultLabels()
+	if lm, ok := profile["labels"].(map[string]interface{}); ok {
+		for k, v := range lm {
+			if s, ok := v.(string); ok && s != "" {
+				labels[k] = s
+			}
+		}
+	}
+	data := map[string]interface{}{"Profile": profile, "Labels": labels}
 	var outFile = filepath.Join("resume-data", "generated", "resume_test_links.html")
 	f, err := os.Create(outFile)
 	if err != nil {